@@ -2,24 +2,49 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/http"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
 )
 
 func main() {
+	store := flag.String("store", "database", "where data is kept, database (DATABASE_URL) or memory")
+	promote := flag.String("promote", "", "email of a user to make an administrator, the server is not started")
+	flag.Parse()
+
+	apiServer, err := newAPIServer(*store)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *promote != "" {
+		if err := promoteUser(apiServer, *promote); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() { <-c; cancel() }()
 
 	m := &Main{
-		APIServer: http.NewPostgresAPIServer(),
+		APIServer: apiServer,
+		Store:     *store,
 	}
 
 	if err := m.Run(ctx); err != nil {
@@ -36,20 +61,166 @@ func main() {
 	}
 }
 
+const (
+	WebSubRenewInterval  = time.Hour
+	AccountPurgeInterval = time.Hour
+	ExportInterval       = 15 * time.Second
+	FeedSyncInterval     = time.Minute
+	FullContentInterval  = 30 * time.Second
+)
+
 type Main struct {
 	APIServer *http.APIServer
+	// Store is where the server keeps its data.
+	Store string
+}
+
+// newAPIServer returns a server keeping its data in store. The database is
+// SQLite when DATABASE_URL is a sqlite:// url and Postgres otherwise,
+// memory keeps nothing once the server stops and is meant for demos and
+// trying out clients.
+func newAPIServer(store string) (*http.APIServer, error) {
+	switch store {
+	case "database":
+		if path, ok := strings.CutPrefix(rf.Config.DatabaseURL, "sqlite://"); ok {
+			return http.NewSQLiteAPIServer(path), nil
+		}
+		return http.NewPostgresAPIServer(), nil
+	case "memory":
+		return http.NewMemoryAPIServer(), nil
+	default:
+		return nil, fmt.Errorf("unknown store %q, expected database or memory", store)
+	}
+}
+
+// promoteUser makes the user signed up with email an administrator, which
+// is how the first administrator of an install is made.
+func promoteUser(s *http.APIServer, email string) error {
+	if err := s.OpenDB(); err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err := s.AdminService.PromoteUser(context.Background(), email); err != nil {
+		return err
+	}
+
+	log.Printf("promoted: email=%q", email)
+	return nil
 }
 
 func (m *Main) Run(ctx context.Context) error {
+	keySet, err := jwt.LoadKeySet()
+	if err != nil {
+		return err
+	}
+	jwt.SetKeySet(keySet)
+
+	params, err := password.LoadParams()
+	if err != nil {
+		return err
+	}
+	password.SetParams(params)
+
 	if err := m.APIServer.Open(); err != nil {
 		return err
 	}
 
-	log.Printf("running: url=%q dsn=%q", m.APIServer.URL(), rf.Config.DatabaseURL)
+	if m.Store == "memory" {
+		log.Printf("running: url=%q store=%q", m.APIServer.URL(), m.Store)
+	} else {
+		log.Printf("running: url=%q dsn=%q", m.APIServer.URL(), rf.Config.DatabaseURL)
+	}
+
+	go m.renewWebSubLeases(ctx)
+	go m.purgeDeletedAccounts(ctx)
+	go m.processExports(ctx)
+	go m.syncFeeds(ctx)
+	go m.extractFullContent(ctx)
 
 	return nil
 }
 
+func (m *Main) renewWebSubLeases(ctx context.Context) {
+	ticker := time.NewTicker(WebSubRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.APIServer.WebSubService.RenewLeases(ctx); err != nil {
+				log.Printf("websub lease renewal: %v", err)
+			}
+		}
+	}
+}
+
+func (m *Main) purgeDeletedAccounts(ctx context.Context) {
+	ticker := time.NewTicker(AccountPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.APIServer.AuthService.PurgeDeletedAccounts(ctx); err != nil {
+				log.Printf("account purge: %v", err)
+			}
+		}
+	}
+}
+
+func (m *Main) processExports(ctx context.Context) {
+	ticker := time.NewTicker(ExportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.APIServer.ExportService.ProcessExports(ctx); err != nil {
+				log.Printf("data exports: %v", err)
+			}
+		}
+	}
+}
+
+func (m *Main) syncFeeds(ctx context.Context) {
+	ticker := time.NewTicker(FeedSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.APIServer.SyncService.SyncDueFeeds(ctx); err != nil {
+				log.Printf("feed sync: %v", err)
+			}
+		}
+	}
+}
+
+func (m *Main) extractFullContent(ctx context.Context) {
+	ticker := time.NewTicker(FullContentInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.APIServer.ItemService.ExtractPendingContent(ctx); err != nil {
+				log.Printf("full content: %v", err)
+			}
+		}
+	}
+}
+
 func (m *Main) Close() error {
 	if err := m.APIServer.Close(); err != nil {
 		return err
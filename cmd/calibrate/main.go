@@ -0,0 +1,41 @@
+// Command calibrate suggests argon2id parameters that hash a password in
+// about the target time on the machine it runs on, to be set with
+// ARGON2_MEMORY, ARGON2_ITERATIONS and ARGON2_PARALLELISM.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
+)
+
+func main() {
+	target := flag.Duration("target", 250*time.Millisecond, "how long hashing a password should take")
+	maxMemory := flag.Uint("max-memory", uint(argon2id.DefaultParams.Memory), "most memory a hash may use in KiB")
+	parallelism := flag.Uint("parallelism", uint(argon2id.DefaultParams.Parallelism), "threads a hash uses")
+	flag.Parse()
+
+	if *maxMemory > 1<<32-1 || *parallelism < 1 || *parallelism > 255 {
+		fmt.Fprintln(os.Stderr, "max-memory must fit in 32 bits and parallelism be between 1 and 255")
+		os.Exit(2)
+	}
+
+	params, took, err := password.Calibrate(*target, uint32(*maxMemory), uint8(*parallelism))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if took > *target {
+		fmt.Fprintf(os.Stderr, "the weakest parameters tried still take %s, more than the %s target\n", took.Round(time.Millisecond), *target)
+	}
+
+	fmt.Printf("# hashing takes about %s\n", took.Round(time.Millisecond))
+	fmt.Printf("ARGON2_MEMORY=%d\n", params.Memory)
+	fmt.Printf("ARGON2_ITERATIONS=%d\n", params.Iterations)
+	fmt.Printf("ARGON2_PARALLELISM=%d\n", params.Parallelism)
+}
@@ -0,0 +1,38 @@
+package rf
+
+import (
+	"time"
+)
+
+// AdminUser is a user as administrators see them, with the state of their
+// account.
+type AdminUser struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	Role            Role      `json:"role"`
+	Enabled         bool      `json:"enabled"`
+	Deleted         bool      `json:"deleted"`
+	EmailVerifiedAt time.Time `json:"emailVerifiedAt"`
+	LastSignedInAt  time.Time `json:"lastSignedInAt"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// UserSearchRequest matches Query against the email and name of users, an
+// empty query lists every user.
+type UserSearchRequest struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// FeedFetchError is the last failed fetch of a feed, Failures counts the
+// fetches that failed in a row.
+type FeedFetchError struct {
+	FeedID   int64     `json:"feedID"`
+	URL      string    `json:"url"`
+	Enabled  bool      `json:"enabled"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+	Failures int       `json:"failures"`
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+)
+
+type EventType string
+
+const (
+	EventSignUp               EventType = "sign_up"
+	EventSignInSucceeded      EventType = "sign_in.succeeded"
+	EventSignInFailed         EventType = "sign_in.failed"
+	EventSignInLockout        EventType = "sign_in.lockout"
+	EventSignInIPLockout      EventType = "sign_in.ip_lockout"
+	EventAccountUnlocked      EventType = "account.unlocked"
+	EventAccountDeleted       EventType = "account.deleted"
+	EventPasswordChanged      EventType = "password.changed"
+	EventPasswordReset        EventType = "password.reset"
+	EventEmailChangeRequested EventType = "email.change_requested"
+	EventEmailConfirmed       EventType = "email.confirmed"
+	EventTOTPEnabled          EventType = "totp.enabled"
+	EventTOTPDisabled         EventType = "totp.disabled"
+	EventOIDCIdentityLinked   EventType = "oidc.identity_linked"
+	EventSessionRevoked       EventType = "session.revoked"
+	EventAPIKeyCreated        EventType = "api_key.created"
+	EventAPIKeyRevoked        EventType = "api_key.revoked"
+	EventFeedSubscribed       EventType = "feed.subscribed"
+	EventFeedUnsubscribed     EventType = "feed.unsubscribed"
+
+	EventAdminUserDisabled EventType = "admin.user_disabled"
+	EventAdminUserEnabled  EventType = "admin.user_enabled"
+	EventAdminUserPromoted EventType = "admin.user_promoted"
+	EventAdminFeedResync   EventType = "admin.feed_resync"
+	EventAdminFeedDisabled EventType = "admin.feed_disabled"
+	EventAdminFeedEnabled  EventType = "admin.feed_enabled"
+)
+
+// TargetType names what an event was done to.
+type TargetType string
+
+const (
+	TargetUser    TargetType = "user"
+	TargetSession TargetType = "session"
+	TargetAPIKey  TargetType = "api_key"
+	TargetFeed    TargetType = "feed"
+)
+
+// Event is a security relevant or administrative thing that happened.
+// ActorID is the user who did it and is zero when nobody was signed in,
+// such as a failed sign in, whose target is the account it was against.
+type Event struct {
+	ID         int64      `json:"id"`
+	Type       EventType  `json:"type"`
+	ActorID    int64      `json:"actorID,omitempty"`
+	TargetType TargetType `json:"targetType,omitempty"`
+	TargetID   int64      `json:"targetID,omitempty"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"userAgent"`
+	RequestID  string     `json:"requestID"`
+	Detail     string     `json:"detail,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Query filters events, zero fields match every event. UserID matches the
+// events a user did and the ones done to their account. Results are
+// newest first, BeforeID pages past the last event of the previous page.
+type Query struct {
+	Types      []EventType
+	ActorID    int64
+	TargetType TargetType
+	TargetID   int64
+	UserID     int64
+	Since      time.Time
+	Until      time.Time
+	BeforeID   int64
+	Limit      int
+}
+
+type Recorder interface {
+	Record(ctx context.Context, event Event) error
+}
+
+// Record records event with the request it happened in and the signed in
+// user as actor unless they are set. Failing to record must not fail the
+// request, the error is logged.
+func Record(ctx context.Context, recorder Recorder, event Event) {
+	info := rfcontext.RequestInfoFromContext(ctx)
+
+	if event.ActorID == 0 {
+		event.ActorID = rfcontext.UserIDFromContext(ctx)
+	}
+	if event.IP == "" {
+		event.IP = info.IP
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = info.UserAgent
+	}
+	if event.RequestID == "" {
+		event.RequestID = info.ID
+	}
+
+	if err := recorder.Record(ctx, event); err != nil {
+		slog.Error("Audit record error", "err", err.Error(), "type", string(event.Type))
+	}
+}
+
+// LogRecorder writes events to the structured log.
+type LogRecorder struct {
+	Logger *slog.Logger
+}
+
+func NewLogRecorder() *LogRecorder {
+	return &LogRecorder{
+		Logger: slog.Default(),
+	}
+}
+
+func (r *LogRecorder) Record(ctx context.Context, event Event) error {
+	r.Logger.InfoContext(ctx, "audit",
+		"type", string(event.Type),
+		"actorID", event.ActorID,
+		"targetType", string(event.TargetType),
+		"targetID", event.TargetID,
+		"ip", event.IP,
+		"userAgent", event.UserAgent,
+		"requestID", event.RequestID,
+		"detail", event.Detail)
+	return nil
+}
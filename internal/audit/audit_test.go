@@ -0,0 +1,50 @@
+package audit_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/matryer/is"
+)
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r = rfcontext.SetRequestInfoToRequestContext(r, rfcontext.RequestInfo{ID: "req-1", IP: "192.0.2.1", UserAgent: "Gopher"})
+	r = rfcontext.SetUserIDToRequestContext(r, 7)
+
+	var got audit.Event
+	recorder := &mock.AuditRecorder{
+		RecordFn: func(ctx context.Context, event audit.Event) error {
+			got = event
+			return nil
+		},
+	}
+
+	audit.Record(r.Context(), recorder, audit.Event{Type: audit.EventPasswordChanged})
+
+	is.Equal(got.ActorID, int64(7))   // should be done by the signed in user
+	is.Equal(got.RequestID, "req-1")  // should name the request
+	is.Equal(got.IP, "192.0.2.1")     // should have the IP of the request
+	is.Equal(got.UserAgent, "Gopher") // should have the user agent of the request
+
+	audit.Record(r.Context(), recorder, audit.Event{Type: audit.EventSignUp, ActorID: 9, IP: "198.51.100.1"})
+
+	is.Equal(got.ActorID, int64(9))  // should keep the actor given
+	is.Equal(got.IP, "198.51.100.1") // should keep the IP given
+
+	recorder.RecordFn = func(ctx context.Context, event audit.Event) error {
+		return errors.InternalErrorf("database is down")
+	}
+
+	audit.Record(context.Background(), recorder, audit.Event{Type: audit.EventSignUp}) // should not panic when recording fails
+	is.True(recorder.RecordInvoked)                                                    // should have tried to record
+}
@@ -11,13 +11,28 @@ type Auth struct {
 	CreatedAt      time.Time `json:"createdAt"`
 	ModifiedAt     time.Time `json:"modifiedAt"`
 	LastSignedInAt time.Time `json:"lastSignedInAt"`
+	// EmailVerifiedAt is zero until the user follows a verification link.
+	EmailVerifiedAt time.Time `json:"emailVerifiedAt"`
+	// FailedSignInAttempts counts wrong passwords since the last sign in.
+	FailedSignInAttempts int       `json:"failedSignInAttempts"`
+	LastFailedSignInAt   time.Time `json:"lastFailedSignInAt"`
+	// TOTPEnabledAt is zero unless sign in needs a TOTP code.
+	TOTPEnabledAt time.Time `json:"totpEnabledAt"`
+	// DeletedAt is when the user deleted their account, signing in before
+	// PurgeAt restores it. PurgeAt is zero when it cannot be restored.
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
 
 	UserID int64 `json:"userID"`
-	User   *User `json:"user"`
+	// Role is the role of the user, it is put in their access tokens.
+	Role Role  `json:"role"`
+	User *User `json:"user"`
 
 	BasicAuth *BasicAuth `json:"basicAuth"`
 
-	Token string `json:"token"`
+	Token        string   `json:"token"`
+	RefreshToken string   `json:"-"`
+	Session      *Session `json:"-"`
 }
 
 type BasicAuth struct {
@@ -26,12 +41,246 @@ type BasicAuth struct {
 }
 
 type SignUpRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Name     string `json:"name"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	Name       string `json:"name"`
+	DeviceName string `json:"deviceName"`
+	IP         string `json:"-"`
+	UserAgent  string `json:"-"`
 }
 
 type SignInRequest struct {
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"deviceName"`
+	IP         string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+// Session is a signed in device, it is kept alive by rotating refresh
+// tokens and ends when revoked or expired.
+type Session struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"-"`
+	DeviceName string    `json:"deviceName"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	RevokedAt  time.Time `json:"-"`
+	Current    bool      `json:"current"`
+}
+
+// RefreshToken is a single use token of a session, only its hash is
+// stored.
+type RefreshToken struct {
+	TokenHash string
+	SessionID int64
+	CreatedAt time.Time
+	UsedAt    time.Time
+}
+
+type AuthTokens struct {
+	// SessionID is the session the tokens belong to.
+	SessionID             int64
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+
+	// MFAToken is set instead of the other tokens when the password was
+	// right but a second factor is still needed.
+	MFAToken          string
+	MFATokenExpiresAt time.Time
+
+	// LinkToken is set instead of the other tokens when an OpenID Connect
+	// sign in matched the email of an account that is not linked yet.
+	LinkToken          string
+	LinkTokenExpiresAt time.Time
+}
+
+type RefreshRequest struct {
+	RefreshToken string
+	IP           string
+	UserAgent    string
+}
+
+type APIKeyScope string
+
+const (
+	APIKeyScopeRead      APIKeyScope = "read"
+	APIKeyScopeReadWrite APIKeyScope = "read_write"
+)
+
+// APIKey is a long lived personal credential for scripts and clients, Key
+// is only set when the key is created as only its hash is stored.
+type APIKey struct {
+	ID         int64       `json:"id"`
+	UserID     int64       `json:"-"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Scope      APIKeyScope `json:"scope"`
+	Key        string      `json:"key,omitempty"`
+	KeyHash    string      `json:"-"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	LastUsedAt *time.Time  `json:"lastUsedAt"`
+	ExpiresAt  *time.Time  `json:"expiresAt"`
+	RevokedAt  *time.Time  `json:"-"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string      `json:"name"`
+	Scope     APIKeyScope `json:"scope"`
+	ExpiresAt *time.Time  `json:"expiresAt"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// PasswordResetToken is a single use token mailed to reset a password,
+// only its hash is stored.
+type PasswordResetToken struct {
+	TokenHash string
+	UserID    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// EmailVerificationToken is a single use token mailed to confirm Email
+// belongs to the user, either on sign up or when changing address.
+type EmailVerificationToken struct {
+	TokenHash string
+	UserID    int64
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ChangeEmailRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// AccountDeletion tells a user when their deleted account is purged,
+// signing in before then restores it.
+type AccountDeletion struct {
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// UnlockToken is a single use token mailed when an account is locked after
+// too many failed sign ins, only its hash is stored.
+type UnlockToken struct {
+	TokenHash string
+	UserID    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type UnlockAccountRequest struct {
+	Token string `json:"token"`
+}
+
+// TOTP is the authenticator app secret of a user, EnabledAt is zero until
+// enrolment is confirmed with a first code. LastUsedStep keeps a code
+// from being used twice.
+type TOTP struct {
+	UserID       int64
+	Secret       string
+	CreatedAt    time.Time
+	EnabledAt    time.Time
+	LastUsedStep int64
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TOTPRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MFAChallenge is the response of a sign in that needs a second factor.
+type MFAChallenge struct {
+	MFARequired bool      `json:"mfaRequired"`
+	MFAToken    string    `json:"mfaToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// MFAVerifyRequest completes a sign in with a TOTP or recovery code.
+type MFAVerifyRequest struct {
+	MFAToken   string `json:"mfaToken"`
+	Code       string `json:"code"`
+	DeviceName string `json:"deviceName"`
+	IP         string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+// OIDCLoginState is kept between sending a user to an OpenID Connect
+// provider and its callback, only the hash of the state is stored.
+type OIDCLoginState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// AuthIdentity links an account to a subject of an OpenID Connect issuer.
+type AuthIdentity struct {
+	ID        int64
+	UserID    int64
+	Issuer    string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// OIDCCallbackRequest is the redirect back from an OpenID Connect
+// provider, Error is set when the user did not sign in.
+type OIDCCallbackRequest struct {
+	Provider   string
+	Code       string
+	State      string
+	Error      string
+	DeviceName string
+	IP         string
+	UserAgent  string
+}
+
+// OIDCLinkChallenge is the response of an OpenID Connect sign in whose
+// email belongs to an account, the account password links the two.
+type OIDCLinkChallenge struct {
+	LinkRequired bool      `json:"linkRequired"`
+	LinkToken    string    `json:"linkToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+type OIDCLinkRequest struct {
+	LinkToken  string `json:"linkToken"`
+	Password   string `json:"password"`
+	DeviceName string `json:"deviceName"`
+	IP         string `json:"-"`
+	UserAgent  string `json:"-"`
+}
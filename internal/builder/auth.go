@@ -46,6 +46,11 @@ func (b *authBuilder) AsDeleted(deleted bool) *authBuilder {
 	return b
 }
 
+func (b *authBuilder) WithPurgeAt(purgeAt time.Time) *authBuilder {
+	b.auth.PurgeAt = purgeAt
+	return b
+}
+
 func (b *authBuilder) WithCreatedAt(createdAt time.Time) *authBuilder {
 	b.auth.CreatedAt = createdAt
 	return b
@@ -61,6 +66,11 @@ func (b *authBuilder) WithLastSignedInAt(lastSignedInAt time.Time) *authBuilder
 	return b
 }
 
+func (b *authBuilder) WithEmailVerifiedAt(emailVerifiedAt time.Time) *authBuilder {
+	b.auth.EmailVerifiedAt = emailVerifiedAt
+	return b
+}
+
 func (b *authBuilder) Build() *rf.Auth {
 	return b.auth
 }
@@ -61,6 +61,11 @@ func (b *feedBuilder) WithLastSyncedAt(lastSyncedAt time.Time) *feedBuilder {
 	return b
 }
 
+func (b *feedBuilder) WithItemIdentity(identity rf.ItemIdentity) *feedBuilder {
+	b.feed.ItemIdentity = identity
+	return b
+}
+
 func (b *feedBuilder) Build() *rf.Feed {
 	return b.feed
 }
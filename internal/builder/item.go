@@ -0,0 +1,96 @@
+package builder
+
+import (
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+type channelBuilder struct {
+	channel *rf.Channel
+}
+
+func NewChannelBuilder() *channelBuilder {
+	return &channelBuilder{
+		channel: &rf.Channel{},
+	}
+}
+
+func (b *channelBuilder) WithID(id int64) *channelBuilder {
+	b.channel.ID = id
+	return b
+}
+
+func (b *channelBuilder) WithFeedID(feedID int64) *channelBuilder {
+	b.channel.FeedID = feedID
+	return b
+}
+
+func (b *channelBuilder) WithTitle(title string) *channelBuilder {
+	b.channel.Title = title
+	return b
+}
+
+func (b *channelBuilder) WithLink(link string) *channelBuilder {
+	b.channel.Link = link
+	return b
+}
+
+func (b *channelBuilder) Build() *rf.Channel {
+	return b.channel
+}
+
+type itemBuilder struct {
+	item *rf.Item
+}
+
+func NewItemBuilder() *itemBuilder {
+	return &itemBuilder{
+		item: &rf.Item{},
+	}
+}
+
+func (b *itemBuilder) WithID(id int64) *itemBuilder {
+	b.item.ID = id
+	return b
+}
+
+func (b *itemBuilder) WithChannelID(channelID int64) *itemBuilder {
+	b.item.ChannelID = channelID
+	return b
+}
+
+func (b *itemBuilder) WithGUID(guid string, isPermaLink bool) *itemBuilder {
+	b.item.GUID = guid
+	b.item.GUIDIsPermaLink = isPermaLink
+	return b
+}
+
+func (b *itemBuilder) WithTitle(title string) *itemBuilder {
+	b.item.Title = title
+	return b
+}
+
+func (b *itemBuilder) WithDescription(description string) *itemBuilder {
+	b.item.Description = description
+	return b
+}
+
+func (b *itemBuilder) WithContent(content string) *itemBuilder {
+	b.item.Content = content
+	return b
+}
+
+func (b *itemBuilder) WithLink(link string) *itemBuilder {
+	b.item.Link = link
+	return b
+}
+
+func (b *itemBuilder) WithPublishedAt(publishedAt time.Time) *itemBuilder {
+	b.item.PublishedAt = publishedAt
+	return b
+}
+
+func (b *itemBuilder) Build() *rf.Item {
+	return b.item
+}
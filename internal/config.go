@@ -7,9 +7,74 @@ import (
 )
 
 type config struct {
+	// DatabaseURL is a Postgres url, or sqlite:///path/to/rf.db to keep
+	// everything in a single SQLite file.
 	DatabaseURL string
 	APIPort     string
 	JWTSecret   string
+	PublicURL   string
+
+	// JWTSigningKeyFile is a PEM Ed25519 or RSA private key to sign tokens
+	// with instead of JWTSecret, which is then only used to verify them.
+	JWTSigningKeyFile string
+	// JWTPreviousSecrets and JWTVerifyKeyFiles keep tokens signed before a
+	// key rotation valid until they expire.
+	JWTPreviousSecrets []string
+	JWTVerifyKeyFiles  []string
+	JWTIssuer          string
+	JWTAudience        string
+
+	// CookieSecure, CookieDomain and CookieSameSite set the attributes of
+	// the auth cookies, cookies are secure by default when PublicURL is
+	// https. CookieMaxAge in seconds overrides how long the auth cookies
+	// are kept, which is as long as their tokens by default, 0 keeps them
+	// until the browser closes.
+	CookieSecure   string
+	CookieDomain   string
+	CookieSameSite string
+	CookieMaxAge   string
+	// CSRFSecret signs CSRF tokens, a random key is used when it is not
+	// set.
+	CSRFSecret string
+	// AllowedOrigins may send cookie authenticated requests besides
+	// PublicURL.
+	AllowedOrigins []string
+	// URLSigningSecret signs download links, a random key is used when it
+	// is not set.
+	URLSigningSecret string
+	// ExportDir keeps finished data exports, a temporary directory is used
+	// when it is not set.
+	ExportDir string
+	// PasswordMinLength and PasswordMaxLength bound the length of new
+	// passwords. PasswordBreachDir is a breached password corpus sharded by
+	// SHA-1 prefix that new passwords are checked against when it is set.
+	PasswordMinLength string
+	PasswordMaxLength string
+	PasswordBreachDir string
+	// Argon2Memory in KiB, Argon2Iterations and Argon2Parallelism are the
+	// argon2id parameters passwords are hashed with, weaker hashes are
+	// upgraded on sign in.
+	Argon2Memory      string
+	Argon2Iterations  string
+	Argon2Parallelism string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	OIDCProviders []OIDCProviderConfig
+}
+
+// OIDCProviderConfig is an OpenID Connect provider users can sign in with,
+// configured by OIDC_PROVIDERS=name,... and OIDC_<NAME>_ISSUER,
+// OIDC_<NAME>_CLIENT_ID and OIDC_<NAME>_CLIENT_SECRET.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
 }
 
 var Config config
@@ -47,5 +112,68 @@ func init() {
 		DatabaseURL: os.Getenv("DATABASE_URL"),
 		APIPort:     os.Getenv("API_PORT"),
 		JWTSecret:   os.Getenv("JWT_SECRET"),
+		PublicURL:   os.Getenv("PUBLIC_URL"),
+
+		JWTSigningKeyFile:  os.Getenv("JWT_SIGNING_KEY_FILE"),
+		JWTPreviousSecrets: splitList(os.Getenv("JWT_PREVIOUS_SECRETS")),
+		JWTVerifyKeyFiles:  splitList(os.Getenv("JWT_VERIFY_KEY_FILES")),
+		JWTIssuer:          os.Getenv("JWT_ISSUER"),
+		JWTAudience:        os.Getenv("JWT_AUDIENCE"),
+
+		CookieSecure:   os.Getenv("COOKIE_SECURE"),
+		CookieDomain:   os.Getenv("COOKIE_DOMAIN"),
+		CookieSameSite: os.Getenv("COOKIE_SAMESITE"),
+		CookieMaxAge:   os.Getenv("COOKIE_MAX_AGE"),
+		CSRFSecret:     os.Getenv("CSRF_SECRET"),
+		AllowedOrigins: splitList(os.Getenv("ALLOWED_ORIGINS")),
+
+		URLSigningSecret: os.Getenv("URL_SIGNING_SECRET"),
+		ExportDir:        os.Getenv("EXPORT_DIR"),
+
+		PasswordMinLength: os.Getenv("PASSWORD_MIN_LENGTH"),
+		PasswordMaxLength: os.Getenv("PASSWORD_MAX_LENGTH"),
+		PasswordBreachDir: os.Getenv("PASSWORD_BREACH_DIR"),
+		Argon2Memory:      os.Getenv("ARGON2_MEMORY"),
+		Argon2Iterations:  os.Getenv("ARGON2_ITERATIONS"),
+		Argon2Parallelism: os.Getenv("ARGON2_PARALLELISM"),
+
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     os.Getenv("SMTP_PORT"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		MailFrom:     os.Getenv("MAIL_FROM"),
+
+		OIDCProviders: oidcProvidersFromEnv(),
 	}
 }
+
+func oidcProvidersFromEnv() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+
+	for _, name := range splitList(os.Getenv("OIDC_PROVIDERS")) {
+		name = strings.ToLower(name)
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+		})
+	}
+
+	return providers
+}
+
+// splitList returns the non empty values of a comma separated list.
+func splitList(value string) []string {
+	var values []string
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
@@ -3,14 +3,28 @@ package context
 import (
 	"context"
 	"net/http"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
 )
 
 type contextKey int
 
 const (
 	userIDContextKey = contextKey(iota + 1)
+	sessionIDContextKey
+	apiKeyContextKey
+	roleContextKey
+	requestInfoContextKey
 )
 
+// RequestInfo is who sent a request, kept so what it does can be traced
+// back to it.
+type RequestInfo struct {
+	ID        string
+	IP        string
+	UserAgent string
+}
+
 func SetUserIDToContext(ctx context.Context, userID int64) context.Context {
 	return context.WithValue(ctx, userIDContextKey, userID)
 }
@@ -27,3 +41,66 @@ func UserIDFromContext(ctx context.Context) int64 {
 	}
 	return user
 }
+
+func SetSessionIDToContext(ctx context.Context, sessionID int64) context.Context {
+	return context.WithValue(ctx, sessionIDContextKey, sessionID)
+}
+
+func SetSessionIDToRequestContext(r *http.Request, sessionID int64) *http.Request {
+	ctx := SetSessionIDToContext(r.Context(), sessionID)
+	return r.WithContext(ctx)
+}
+
+func SessionIDFromContext(ctx context.Context) int64 {
+	session, ok := ctx.Value(sessionIDContextKey).(int64)
+	if !ok {
+		return 0
+	}
+	return session
+}
+
+func SetRoleToContext(ctx context.Context, role rf.Role) context.Context {
+	return context.WithValue(ctx, roleContextKey, role)
+}
+
+func SetRoleToRequestContext(r *http.Request, role rf.Role) *http.Request {
+	ctx := SetRoleToContext(r.Context(), role)
+	return r.WithContext(ctx)
+}
+
+// RoleFromContext returns the role of the authenticated user, empty when
+// the request was not authenticated.
+func RoleFromContext(ctx context.Context) rf.Role {
+	role, ok := ctx.Value(roleContextKey).(rf.Role)
+	if !ok {
+		return ""
+	}
+	return role
+}
+
+// SetAPIKeyToRequestContext records that the request was authenticated with
+// an api key rather than a session.
+func SetAPIKeyToRequestContext(r *http.Request, key *rf.APIKey) *http.Request {
+	ctx := context.WithValue(r.Context(), apiKeyContextKey, key)
+	return r.WithContext(ctx)
+}
+
+func APIKeyFromContext(ctx context.Context) *rf.APIKey {
+	key, ok := ctx.Value(apiKeyContextKey).(*rf.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
+func SetRequestInfoToRequestContext(r *http.Request, info RequestInfo) *http.Request {
+	ctx := context.WithValue(r.Context(), requestInfoContextKey, info)
+	return r.WithContext(ctx)
+}
+
+// RequestInfoFromContext returns the info of the request, empty outside of
+// one.
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoContextKey).(RequestInfo)
+	return info
+}
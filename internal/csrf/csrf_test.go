@@ -0,0 +1,68 @@
+package csrf_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/csrf"
+	"github.com/matryer/is"
+)
+
+func TestProtector(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	p := csrf.NewProtector([]byte("secret"))
+
+	token, err := p.Token(1)
+	is.NoErr(err) // should issue a token
+
+	other, err := p.Token(1)
+	is.NoErr(err)           // should issue a token
+	is.True(token != other) // tokens should not repeat
+
+	is.True(p.Valid(token, 1))                                   // should accept the token of the session
+	is.True(!p.Valid(token, 2))                                  // should reject the token of another session
+	is.True(!csrf.NewProtector([]byte("other")).Valid(token, 1)) // should reject a token of another secret
+	is.True(!p.Valid("", 1))                                     // should reject no token
+	is.True(!p.Valid(token[:len(token)-2], 1))                   // should reject a tampered token
+}
+
+func TestSameOrigin(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	origins := []string{"https://app.example.com"}
+
+	for _, tc := range []struct {
+		Desc    string
+		Origin  string
+		Referer string
+		Want    bool
+	}{
+		{Desc: "without origin or referer", Want: true},
+		{Desc: "from the same host", Origin: "http://api.example.com", Want: true},
+		{Desc: "from an allowed origin", Origin: "https://app.example.com", Want: true},
+		{Desc: "from an allowed origin over http", Origin: "http://app.example.com", Want: false},
+		{Desc: "from another site", Origin: "https://evil.example.org", Want: false},
+		{Desc: "with a null origin", Origin: "null", Want: false},
+		{Desc: "with a referer from an allowed origin", Referer: "https://app.example.com/feeds", Want: true},
+		{Desc: "with a referer from another site", Referer: "https://evil.example.org/", Want: false},
+	} {
+		t.Run(tc.Desc, func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodPost, "http://api.example.com/api/v1/feeds", nil)
+			is.NoErr(err) // should be a request
+
+			if tc.Origin != "" {
+				r.Header.Set("Origin", tc.Origin)
+			}
+			if tc.Referer != "" {
+				r.Header.Set("Referer", tc.Referer)
+			}
+
+			is.Equal(csrf.SameOrigin(r, origins), tc.Want) // should judge the origin
+		})
+	}
+}
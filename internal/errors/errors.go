@@ -15,6 +15,9 @@ const (
 	InvalidData
 	Unauthorized
 	NotFound
+	Forbidden
+	AccountDisabled
+	TooManyRequests
 )
 
 const (
@@ -22,10 +25,93 @@ const (
 	ErrPasswordRequired = "password required."
 	ErrNameRequired     = "name required."
 	ErrURLRequired      = "url required."
+	ErrFeedRequired     = "feed required."
+	ErrInvalidID        = "invalid id."
+	ErrInvalidLimit     = "invalid limit."
+	ErrInvalidBefore    = "invalid before, expected an RFC 3339 timestamp."
+	ErrInvalidOffset    = "invalid offset."
+	ErrInvalidSince     = "invalid since, expected an RFC 3339 timestamp."
+	ErrInvalidUntil     = "invalid until, expected an RFC 3339 timestamp."
+	ErrNameTooLong      = "name must be at most 50 characters."
+	ErrTimezoneInvalid  = "timezone must be an IANA time zone such as Europe/London."
 
-	ErrCouldNotProcess    = "could not process request."
-	ErrInvalidCredentials = "invalid email and/or password was provided."
-	ErrUnauthorized       = "unauthorized to perform this action."
+	ErrCouldNotProcess       = "could not process request."
+	ErrInvalidCredentials    = "invalid email and/or password was provided."
+	ErrUnauthorized          = "unauthorized to perform this action."
+	ErrFeedNotFound          = "feed not found."
+	ErrFeedDisabled          = "feed has been disabled by an administrator."
+	ErrStoryNotFound         = "story not found."
+	ErrItemNotFound          = "item not found."
+	ErrEnclosureNotFound     = "enclosure not found."
+	ErrPositionInvalid       = "position must not be negative."
+	ErrSessionNotFound       = "session not found."
+	ErrSessionExpired        = "session expired."
+	ErrSessionRevoked        = "session revoked."
+	ErrRefreshTokenReused    = "refresh token reused, session revoked."
+	ErrRefreshTokenAbsent    = "refresh token required."
+	ErrAPIKeyNotFound        = "api key not found."
+	ErrAPIKeyExpired         = "api key expired."
+	ErrAPIKeyScopeInvalid    = "api key scope must be read or read_write."
+	ErrAPIKeyExpiryPast      = "api key expiry must be in the future."
+	ErrAPIKeyReadOnly        = "api key is read only."
+	ErrAPIKeyNotAllowed      = "api keys cannot perform this action."
+	ErrResetTokenRequired    = "reset token required."
+	ErrResetTokenInvalid     = "reset token is invalid or has expired."
+	ErrVerifyTokenRequired   = "verification token required."
+	ErrVerifyTokenInvalid    = "verification token is invalid or has expired."
+	ErrEmailUnchanged        = "email is already the address of the account."
+	ErrEmailAlreadyVerified  = "email is already verified."
+	ErrAccountDisabled       = "account is disabled."
+	ErrAccountDeleted        = "account has been deleted."
+	ErrAccountLocked         = "account locked after too many failed sign ins, use the mailed unlock link or try again later."
+	ErrTooManySignIns        = "too many failed sign ins, try again later."
+	ErrUnlockTokenRequired   = "unlock token required."
+	ErrUnlockTokenInvalid    = "unlock token is invalid or has expired."
+	ErrTOTPAlreadyEnabled    = "two-factor authentication is already enabled."
+	ErrTOTPNotEnrolled       = "two-factor authentication enrolment has not been started."
+	ErrTOTPNotEnabled        = "two-factor authentication is not enabled."
+	ErrTOTPCodeRequired      = "code required."
+	ErrTOTPCodeInvalid       = "code is invalid or was already used."
+	ErrMFATokenRequired      = "mfa token required."
+	ErrOIDCProviderNotFound  = "sign in provider not found."
+	ErrOIDCDenied            = "sign in with the provider was cancelled or denied."
+	ErrOIDCCodeRequired      = "code required."
+	ErrOIDCStateInvalid      = "sign in state is invalid or has expired, start the sign in again."
+	ErrOIDCEmailRequired     = "sign in provider did not share an email address."
+	ErrOIDCLinkTokenRequired = "link token required."
+	ErrOIDCIdentityLinked    = "sign in provider account is already linked."
+	ErrCSRFTokenInvalid      = "csrf token is missing or invalid."
+	ErrOriginNotAllowed      = "request origin is not allowed."
+	ErrUserNotFound          = "user not found."
+	ErrPreferencesTooLarge   = "preferences must be at most 16 KiB."
+	ErrPasswordUnchanged     = "new password must differ from the current password."
+	ErrExportNotFound        = "export not found."
+	ErrExportNotReady        = "export is not ready yet."
+	ErrDownloadLinkInvalid   = "download link is invalid or has expired."
+	ErrRoleRequired          = "your role does not allow this action."
+	ErrCannotDisableSelf     = "you cannot disable your own account."
+	ErrPasswordTooShort      = "password must be at least %d characters."
+	ErrPasswordTooLong       = "password must be at most %d characters."
+	ErrPasswordPersonal      = "password must not be your email address or name."
+	ErrPasswordBreached      = "password has appeared in a data breach, choose another."
+
+	ErrFeedParseFailed = "feed parse failed"
+
+	ErrExtractFailed = "extract failed"
+
+	ErrFetchFailed     = "fetch failed"
+	ErrFetchInvalidURL = "fetch invalid url"
+	ErrFetchRefused    = "fetch refused private network address"
+
+	ErrHubRejected             = "hub rejected request"
+	ErrHubSignatureInvalid     = "hub signature invalid"
+	ErrHubTopicMismatch        = "hub topic mismatch"
+	ErrHubSubscriptionNotFound = "hub subscription not found"
+	ErrHubNotAdvertised        = "feed does not advertise a hub"
+
+	ErrOIDCDiscoveryFailed = "oidc discovery failed"
+	ErrOIDCExchangeFailed  = "oidc code exchange failed"
+	ErrOIDCTokenInvalid    = "oidc id token invalid"
 
 	ErrTokenExpired                 = "token expired"
 	ErrTokenClaimsFailed            = "token claims failed"
@@ -97,6 +183,38 @@ func UnauthorizedError(err any) Error {
 	}
 }
 
+func NotFoundError(err any) Error {
+	return Error{
+		ReferenceCode: NotFound,
+		StatusCode:    http.StatusNotFound,
+		Err:           err,
+	}
+}
+
+func ForbiddenError(err any) Error {
+	return Error{
+		ReferenceCode: Forbidden,
+		StatusCode:    http.StatusForbidden,
+		Err:           err,
+	}
+}
+
+func AccountDisabledError(err any) Error {
+	return Error{
+		ReferenceCode: AccountDisabled,
+		StatusCode:    http.StatusForbidden,
+		Err:           err,
+	}
+}
+
+func TooManyRequestsError(err any) Error {
+	return Error{
+		ReferenceCode: TooManyRequests,
+		StatusCode:    http.StatusTooManyRequests,
+		Err:           err,
+	}
+}
+
 func InternalErrorf(format string, args ...any) Error {
 	return Errorf(Internal, format, args...)
 }
@@ -113,6 +231,22 @@ func Unauthorizedf(format string, args ...any) Error {
 	return Errorf(Unauthorized, format, args...)
 }
 
+func NotFoundf(format string, args ...any) Error {
+	return Errorf(NotFound, format, args...)
+}
+
+func Forbiddenf(format string, args ...any) Error {
+	return Errorf(Forbidden, format, args...)
+}
+
+func AccountDisabledf(format string, args ...any) Error {
+	return Errorf(AccountDisabled, format, args...)
+}
+
+func TooManyRequestsf(format string, args ...any) Error {
+	return Errorf(TooManyRequests, format, args...)
+}
+
 func ToAPIError(err error) error {
 	var e Error
 	if err == nil {
@@ -123,6 +257,14 @@ func ToAPIError(err error) error {
 			return BadRequestError(e.Err)
 		case Unauthorized:
 			return UnauthorizedError(e.Err)
+		case NotFound:
+			return NotFoundError(e.Err)
+		case Forbidden:
+			return ForbiddenError(e.Err)
+		case AccountDisabled:
+			return AccountDisabledError(e.Err)
+		case TooManyRequests:
+			return TooManyRequestsError(e.Err)
 		}
 	}
 	return err
@@ -0,0 +1,93 @@
+package rf
+
+import (
+	"time"
+)
+
+type DataExportState string
+
+const (
+	DataExportStatePending DataExportState = "pending"
+	DataExportStateRunning DataExportState = "running"
+	DataExportStateReady   DataExportState = "ready"
+	DataExportStateFailed  DataExportState = "failed"
+)
+
+// DataExport is a zip of everything held about a user, it is built in the
+// background and kept until ExpiresAt.
+type DataExport struct {
+	ID          int64           `json:"id"`
+	UserID      int64           `json:"-"`
+	State       DataExportState `json:"state"`
+	Size        int64           `json:"size"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	CompletedAt time.Time       `json:"completedAt"`
+	ExpiresAt   time.Time       `json:"expiresAt"`
+	// DownloadURL is a signed link to the zip once it is ready, it stops
+	// working before the export expires.
+	DownloadURL string `json:"downloadURL,omitempty"`
+}
+
+// ExportProfile is the account part of an export.
+type ExportProfile struct {
+	User            *User     `json:"user"`
+	Email           string    `json:"email"`
+	EmailVerifiedAt time.Time `json:"emailVerifiedAt"`
+	CreatedAt       time.Time `json:"createdAt"`
+	LastSignedInAt  time.Time `json:"lastSignedInAt"`
+}
+
+// ExportItemState is the read, starred and saved state of an item for a
+// user.
+type ExportItemState struct {
+	ItemID    int64     `json:"itemID"`
+	FeedID    int64     `json:"feedID"`
+	Title     string    `json:"title"`
+	Link      string    `json:"link"`
+	ReadAt    time.Time `json:"readAt"`
+	StarredAt time.Time `json:"starredAt"`
+	SavedAt   time.Time `json:"savedAt"`
+}
+
+// ExportPlaybackPosition is how far a user got through an enclosure.
+type ExportPlaybackPosition struct {
+	ItemID          int64     `json:"itemID"`
+	EnclosureURL    string    `json:"enclosureURL"`
+	PositionSeconds int       `json:"positionSeconds"`
+	Completed       bool      `json:"completed"`
+	ModifiedAt      time.Time `json:"modifiedAt"`
+}
+
+// ExportSession is a sign in of a user, including revoked and expired
+// ones.
+type ExportSession struct {
+	ID         int64     `json:"id"`
+	DeviceName string    `json:"deviceName"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	RevokedAt  time.Time `json:"revokedAt"`
+}
+
+// ExportAPIKey is an api key of a user without its secret, including
+// revoked and expired ones.
+type ExportAPIKey struct {
+	ID         int64       `json:"id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Scope      APIKeyScope `json:"scope"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	LastUsedAt time.Time   `json:"lastUsedAt"`
+	ExpiresAt  time.Time   `json:"expiresAt"`
+	RevokedAt  time.Time   `json:"revokedAt"`
+}
+
+// ExportIdentity is an OpenID Connect identity linked to a user.
+type ExportIdentity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,232 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/fetch"
+)
+
+// minParagraphChars is the shortest paragraph taken into account when
+// scoring candidates, shorter ones are usually captions or bylines.
+const minParagraphChars = 25
+
+var (
+	positiveHint = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text`)
+	negativeHint = regexp.MustCompile(`(?i)ad-|banner|combx|comment|disqus|footer|header|menu|meta|nav|promo|related|share|sidebar|social|sponsor|widget`)
+)
+
+type Extractor struct {
+	client *fetch.Client
+}
+
+func NewExtractor(client *fetch.Client) *Extractor {
+	return &Extractor{client: client}
+}
+
+// Extract downloads link and returns the sanitised main article body.
+func (e *Extractor) Extract(ctx context.Context, link string) (string, error) {
+	res, err := e.client.Get(ctx, link)
+	if err != nil {
+		return "", err
+	}
+
+	if ct := strings.ToLower(res.ContentType); ct != "" && !strings.Contains(ct, "html") {
+		return "", errors.InvalidDataf("%s: unsupported content type %q", errors.ErrExtractFailed, res.ContentType)
+	}
+
+	return Article(bytes.NewReader(res.Body), res.URL)
+}
+
+// Article isolates the main body of an HTML document and returns it
+// sanitised, relative links are resolved against baseURL.
+func Article(r io.Reader, baseURL string) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", errors.InvalidDataf("%s: %v", errors.ErrExtractFailed, err)
+	}
+
+	removeUnlikely(doc)
+
+	top := topCandidate(doc)
+	if top == nil {
+		return "", errors.InvalidDataf("%s: no article content found", errors.ErrExtractFailed)
+	}
+
+	var buf bytes.Buffer
+	for c := top.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return "", err
+		}
+	}
+
+	content := Sanitize(buf.String(), baseURL)
+	if strings.TrimSpace(content) == "" {
+		return "", errors.InvalidDataf("%s: no article content found", errors.ErrExtractFailed)
+	}
+
+	return content, nil
+}
+
+func removeUnlikely(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode {
+			switch c.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Nav, atom.Aside, atom.Footer, atom.Form, atom.Iframe:
+				n.RemoveChild(c)
+				c = next
+				continue
+			}
+			if hint := classAndID(c); hint != "" && negativeHint.MatchString(hint) &&
+				!positiveHint.MatchString(hint) && c.DataAtom != atom.Body && c.DataAtom != atom.Article {
+				n.RemoveChild(c)
+				c = next
+				continue
+			}
+		}
+		removeUnlikely(c)
+		c = next
+	}
+}
+
+func topCandidate(doc *html.Node) *html.Node {
+	scores := map[*html.Node]float64{}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+				scoreParagraph(n, scores)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var (
+		top      *html.Node
+		topScore float64
+	)
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if top == nil || score > topScore {
+			top, topScore = n, score
+		}
+	}
+
+	return top
+}
+
+func scoreParagraph(p *html.Node, scores map[*html.Node]float64) {
+	text := textContent(p)
+	if len(text) < minParagraphChars {
+		return
+	}
+
+	score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+
+	parent := p.Parent
+	if parent == nil || parent.Type != html.ElementNode {
+		return
+	}
+	if _, ok := scores[parent]; !ok {
+		scores[parent] = nodeWeight(parent)
+	}
+	scores[parent] += score
+
+	grandparent := parent.Parent
+	if grandparent == nil || grandparent.Type != html.ElementNode {
+		return
+	}
+	if _, ok := scores[grandparent]; !ok {
+		scores[grandparent] = nodeWeight(grandparent)
+	}
+	scores[grandparent] += score / 2
+}
+
+func nodeWeight(n *html.Node) float64 {
+	var weight float64
+
+	switch n.DataAtom {
+	case atom.Article:
+		weight += 10
+	case atom.Div:
+		weight += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		weight += 3
+	case atom.Form, atom.Ul, atom.Ol, atom.Dl:
+		weight -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		weight -= 5
+	}
+
+	hint := classAndID(n)
+	if positiveHint.MatchString(hint) {
+		weight += 25
+	}
+	if negativeHint.MatchString(hint) {
+		weight -= 25
+	}
+
+	return weight
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len(textContent(n))
+	if total == 0 {
+		return 0
+	}
+
+	var linked int
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			linked += len(textContent(c))
+			return
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+
+	return float64(linked) / float64(total)
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func classAndID(n *html.Node) string {
+	var parts []string
+	for _, attr := range n.Attr {
+		if attr.Key == "class" || attr.Key == "id" {
+			parts = append(parts, attr.Val)
+		}
+	}
+	return strings.Join(parts, " ")
+}
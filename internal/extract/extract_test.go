@@ -0,0 +1,79 @@
+package extract_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/extract"
+	"github.com/matryer/is"
+)
+
+const articlePage = `<!doctype html>
+<html>
+<head><title>Gophers</title><script>track()</script></head>
+<body>
+	<nav class="menu"><a href="/">Home</a><a href="/about">About</a></nav>
+	<div class="sidebar"><p>Subscribe to our newsletter, it is great, really, we promise you will like it.</p></div>
+	<div id="main-content" class="post">
+		<h1>Gophers everywhere</h1>
+		<p>Gophers are small burrowing rodents, they are found in North America, and they spend most of their time underground.</p>
+		<p>They dig extensive tunnel systems, which can damage gardens, lawns, and crops, much to the frustration of farmers.</p>
+		<p>Read <a href="/more">more about gophers</a> or see <a href="javascript:alert(1)">this</a>.</p>
+		<img src="/img/gopher.png" alt="A gopher" onerror="alert(1)">
+		<script>alert("x")</script>
+	</div>
+	<div class="comments"><p>First, great article, thanks for writing it, I learned a lot about gophers today.</p></div>
+	<footer><p>Copyright, all rights reserved, no part may be reproduced without permission.</p></footer>
+</body>
+</html>`
+
+func TestArticle(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	content, err := extract.Article(strings.NewReader(articlePage), "https://example.com/posts/gophers")
+
+	is.NoErr(err)                                                                                       // should extract the article
+	is.True(strings.Contains(content, "burrowing rodents"))                                             // should keep the article body
+	is.True(strings.Contains(content, "tunnel systems"))                                                // should keep every paragraph
+	is.True(!strings.Contains(content, "newsletter"))                                                   // should drop the sidebar
+	is.True(!strings.Contains(content, "great article"))                                                // should drop the comments
+	is.True(!strings.Contains(content, "Copyright"))                                                    // should drop the footer
+	is.True(!strings.Contains(content, "alert"))                                                        // should drop scripts and handlers
+	is.True(strings.Contains(content, `href="https://example.com/more"`))                               // should resolve relative links
+	is.True(strings.Contains(content, `<img src="https://example.com/img/gopher.png" alt="A gopher">`)) // should keep images
+	is.True(!strings.Contains(content, "javascript:"))                                                  // should drop unsafe links
+}
+
+func TestArticle_NoContent(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	_, err := extract.Article(strings.NewReader(`<html><body><nav>Home</nav></body></html>`), "https://example.com")
+
+	is.True(err != nil) // should fail without article content
+}
+
+func TestSanitize(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{"Should keep formatting", `<p>Go <strong>fast</strong></p>`, `<p>Go <strong>fast</strong></p>`},
+		{"Should unwrap unknown elements", `<section><span class="x">Go</span></section>`, `Go`},
+		{"Should drop scripts with their content", `<p>Go</p><script>alert(1)</script>`, `<p>Go</p>`},
+		{"Should drop event handlers", `<p onclick="alert(1)">Go</p>`, `<p>Go</p>`},
+		{"Should drop unsafe link schemes", `<a href="javascript:alert(1)">Go</a>`, `<a>Go</a>`},
+		{"Should mark links noopener", `<a href="https://go.dev">Go</a>`, `<a href="https://go.dev" rel="noopener noreferrer nofollow">Go</a>`},
+		{"Should drop images without a safe source", `<img src="data:image/png;base64,AAAA">`, ``},
+		{"Should escape text", `<p>1 &lt; 2</p>`, `<p>1 &lt; 2</p>`},
+	}
+
+	for _, test := range tests {
+		is.Equal(extract.Sanitize(test.fragment, "https://example.com"), test.want) // test.name
+	}
+}
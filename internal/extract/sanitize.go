@@ -0,0 +1,179 @@
+package extract
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags maps the elements kept by Sanitize to the attributes they may
+// keep, anything else is unwrapped so only its text survives.
+var allowedTags = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.B:          nil,
+	atom.Blockquote: nil,
+	atom.Br:         nil,
+	atom.Code:       nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title"},
+	atom.Li:         nil,
+	atom.Ol:         nil,
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Strong:     nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         nil,
+	atom.Th:         nil,
+	atom.Thead:      nil,
+	atom.Tr:         nil,
+	atom.Ul:         nil,
+}
+
+// droppedTags are removed together with their content.
+var droppedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Input:    true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Template: true,
+}
+
+// Sanitize reduces an HTML fragment to a small allow list of formatting
+// elements, links and images are only kept for http and https URLs.
+func Sanitize(fragment, baseURL string) string {
+	base, _ := url.Parse(baseURL)
+
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		sanitizeNode(&buf, n, base)
+	}
+
+	return strings.TrimSpace(buf.String())
+}
+
+func sanitizeNode(buf *bytes.Buffer, n *html.Node, base *url.URL) {
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if droppedTags[n.DataAtom] {
+		return
+	}
+
+	attrs, allowed := allowedTags[n.DataAtom]
+	if !allowed {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			sanitizeNode(buf, c, base)
+		}
+		return
+	}
+
+	kept := keptAttrs(n, attrs, base)
+	if n.DataAtom == atom.Img && kept["src"] == "" {
+		return
+	}
+
+	buf.WriteByte('<')
+	buf.WriteString(n.Data)
+	for _, key := range attrs {
+		if value, ok := kept[key]; ok {
+			buf.WriteByte(' ')
+			buf.WriteString(key)
+			buf.WriteString(`="`)
+			buf.WriteString(html.EscapeString(value))
+			buf.WriteByte('"')
+		}
+	}
+	if n.DataAtom == atom.A && kept["href"] != "" {
+		buf.WriteString(` rel="noopener noreferrer nofollow"`)
+	}
+	buf.WriteByte('>')
+
+	if n.DataAtom == atom.Br || n.DataAtom == atom.Hr || n.DataAtom == atom.Img {
+		return
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sanitizeNode(buf, c, base)
+	}
+
+	buf.WriteString("</")
+	buf.WriteString(n.Data)
+	buf.WriteByte('>')
+}
+
+func keptAttrs(n *html.Node, allowed []string, base *url.URL) map[string]string {
+	kept := map[string]string{}
+	for _, attr := range n.Attr {
+		if attr.Namespace != "" || !contains(allowed, attr.Key) {
+			continue
+		}
+
+		value := attr.Val
+		if attr.Key == "href" || attr.Key == "src" {
+			var ok bool
+			if value, ok = safeURL(value, base); !ok {
+				continue
+			}
+		}
+		kept[attr.Key] = value
+	}
+	return kept
+}
+
+func safeURL(raw string, base *url.URL) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	return u.String(), true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -5,14 +5,20 @@ import (
 )
 
 type Feed struct {
-	ID           int64     `db:"feed_id"`
-	Name         string    `db:"name"`
-	URL          string    `db:"url"`
-	Enabled      bool      `db:"-"`
-	Deleted      bool      `db:"-"`
-	CreatedAt    time.Time `db:"-"`
-	ModifiedAt   time.Time `db:"-"`
-	LastSyncedAt time.Time `db:"-"`
+	ID           int64         `db:"feed_id"`
+	Name         string        `db:"name"`
+	URL          string        `db:"url"`
+	Enabled      bool          `db:"-"`
+	Deleted      bool          `db:"-"`
+	CreatedAt    time.Time     `db:"-"`
+	ModifiedAt   time.Time     `db:"-"`
+	LastSyncedAt time.Time     `db:"-"`
+	ItemIdentity ItemIdentity  `db:"-"`
+	PollInterval time.Duration `db:"-"`
+
+	// FetchFullContent is a per subscription option to download and extract
+	// the full article of each item.
+	FetchFullContent bool `db:"fetch_full_content"`
 
 	UserID int64 `db:"user_id"`
 }
@@ -22,3 +28,9 @@ type AddFeedRequest struct {
 	URL    string `json:"url"`
 	UserID int64
 }
+
+type UpdateFeedRequest struct {
+	Name             *string `json:"name"`
+	FetchFullContent *bool   `json:"fetchFullContent"`
+	FeedID           int64   `json:"-"`
+}
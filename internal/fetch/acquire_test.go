@@ -0,0 +1,35 @@
+package fetch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestClient_Acquire_DropsIdleHosts(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	client := NewClient(Options{MaxPerHost: 1})
+
+	release, err := client.acquire(context.Background(), "feed.com")
+	is.NoErr(err)                  // should get a slot
+	is.Equal(len(client.hosts), 1) // should track the host while in use
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.acquire(ctx, "feed.com")
+	is.True(err != nil)            // should give up waiting when cancelled
+	is.Equal(len(client.hosts), 1) // should keep the host while a slot is held
+
+	release()
+	is.Equal(len(client.hosts), 0) // should drop the host once idle
+
+	for _, host := range []string{"a.com", "b.com", "c.com"} {
+		release, err := client.acquire(context.Background(), host)
+		is.NoErr(err) // should get a slot
+		release()
+	}
+	is.Equal(len(client.hosts), 0) // should not keep hosts that were fetched once
+}
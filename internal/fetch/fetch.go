@@ -0,0 +1,224 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+const (
+	DefaultTimeout       = 15 * time.Second
+	DefaultMaxBodyBytes  = 5 << 20
+	DefaultMaxConcurrent = 16
+	DefaultMaxPerHost    = 2
+	DefaultUserAgent     = "rss-feed-aggregator/1.0 (+https://github.com/dwaynedwards/rss-feed-aggregator-in-go)"
+	maxRedirects         = 5
+)
+
+// Client is the outbound HTTP client shared by everything that downloads
+// remote content on behalf of users, such as feeds and full articles, so
+// they are all held to the same limits.
+type Client struct {
+	httpClient   *http.Client
+	maxBodyBytes int64
+	userAgent    string
+
+	global chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+
+	maxPerHost int
+}
+
+type Options struct {
+	Timeout       time.Duration
+	MaxBodyBytes  int64
+	MaxConcurrent int
+	MaxPerHost    int
+	UserAgent     string
+
+	// AllowPrivateNetworks permits requests to loopback, private and link
+	// local addresses, which are refused by default.
+	AllowPrivateNetworks bool
+}
+
+type Response struct {
+	URL         string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+func NewClient(opts Options) *Client {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBodyBytes == 0 {
+		opts.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if opts.MaxConcurrent == 0 {
+		opts.MaxConcurrent = DefaultMaxConcurrent
+	}
+	if opts.MaxPerHost == 0 {
+		opts.MaxPerHost = DefaultMaxPerHost
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = refusePrivateNetworks
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return nil
+			},
+		},
+		maxBodyBytes: opts.MaxBodyBytes,
+		userAgent:    opts.UserAgent,
+		global:       make(chan struct{}, opts.MaxConcurrent),
+		hosts:        map[string]*hostSlots{},
+		maxPerHost:   opts.MaxPerHost,
+	}
+}
+
+func (c *Client) Get(ctx context.Context, rawURL string) (*Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.InvalidDataf("%s: %q", errors.ErrFetchInvalidURL, rawURL)
+	}
+
+	release, err := c.acquire(ctx, u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.InternalErrorf("%s: %v", errors.ErrFetchFailed, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.InternalErrorf("%s: %s returned %d", errors.ErrFetchFailed, u.Redacted(), res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, c.maxBodyBytes+1))
+	if err != nil {
+		return nil, errors.InternalErrorf("%s: %v", errors.ErrFetchFailed, err)
+	}
+	if int64(len(body)) > c.maxBodyBytes {
+		return nil, errors.InternalErrorf("%s: body larger than %d bytes", errors.ErrFetchFailed, c.maxBodyBytes)
+	}
+
+	return &Response{
+		URL:         res.Request.URL.String(),
+		StatusCode:  res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+		Body:        body,
+	}, nil
+}
+
+// HTTPClient returns the guarded client used by Get, for requests Get
+// cannot make such as posting to a WebSub hub. It refuses private networks
+// and limits redirects but does not share the concurrency limits.
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// hostSlots limits the requests in flight to one host, users counts the
+// requests holding or waiting for a slot so the entry can be dropped once
+// the host is idle.
+type hostSlots struct {
+	slots chan struct{}
+	users int
+}
+
+// acquire waits for a free slot both globally and for host, the returned
+// func releases both.
+func (c *Client) acquire(ctx context.Context, host string) (func(), error) {
+	c.mu.Lock()
+	h, ok := c.hosts[host]
+	if !ok {
+		h = &hostSlots{slots: make(chan struct{}, c.maxPerHost)}
+		c.hosts[host] = h
+	}
+	h.users++
+	c.mu.Unlock()
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		c.leave(host, h)
+		return nil, ctx.Err()
+	}
+
+	select {
+	case c.global <- struct{}{}:
+	case <-ctx.Done():
+		<-h.slots
+		c.leave(host, h)
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-c.global
+		<-h.slots
+		c.leave(host, h)
+	}, nil
+}
+
+// leave drops the entry for host once nothing holds or waits for one of
+// its slots, so the map only holds hosts in use.
+func (c *Client) leave(host string, h *hostSlots) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h.users--
+	if h.users == 0 {
+		delete(c.hosts, host)
+	}
+}
+
+func refusePrivateNetworks(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return errors.Unauthorizedf("%s: %s", errors.ErrFetchRefused, host)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package fetch_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/fetch"
+	"github.com/matryer/is"
+)
+
+func TestClient_Get(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Write([]byte(strings.Repeat("a", 64)))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(r.Header.Get("User-Agent")))
+		}
+	}))
+	defer server.Close()
+
+	t.Run("Should refuse private network addresses by default", func(t *testing.T) {
+		client := fetch.NewClient(fetch.Options{})
+
+		_, err := client.Get(context.Background(), server.URL)
+
+		is.True(err != nil)                                            // should fail
+		is.True(strings.Contains(err.Error(), errors.ErrFetchRefused)) // should be refused
+	})
+
+	t.Run("Should reject non http urls", func(t *testing.T) {
+		client := fetch.NewClient(fetch.Options{})
+
+		_, err := client.Get(context.Background(), "file:///etc/passwd")
+
+		is.True(err != nil) // should fail
+	})
+
+	t.Run("Should download with the configured user agent", func(t *testing.T) {
+		client := fetch.NewClient(fetch.Options{AllowPrivateNetworks: true, UserAgent: "gopher"})
+
+		res, err := client.Get(context.Background(), server.URL)
+
+		is.NoErr(err)                          // should download
+		is.Equal(string(res.Body), "gopher")   // should send the user agent
+		is.Equal(res.ContentType, "text/html") // should return the content type
+	})
+
+	t.Run("Should fail on bodies over the limit and error statuses", func(t *testing.T) {
+		client := fetch.NewClient(fetch.Options{AllowPrivateNetworks: true, MaxBodyBytes: 32})
+
+		_, err := client.Get(context.Background(), server.URL+"/large")
+		is.True(err != nil) // should fail over the limit
+
+		_, err = client.Get(context.Background(), server.URL+"/missing")
+		is.True(err != nil) // should fail on 404
+	})
+}
+
+func TestClient_Get_PerHostLimit(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := fetch.NewClient(fetch.Options{AllowPrivateNetworks: true, MaxPerHost: 2})
+
+	done := make(chan error)
+	for range 6 {
+		go func() {
+			_, err := client.Get(context.Background(), server.URL)
+			done <- err
+		}()
+	}
+	for range 6 {
+		is.NoErr(<-done) // should download
+	}
+
+	is.True(maxInFlight.Load() <= 2) // should not exceed the per host limit
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
+)
+
+func (s *APIServer) registerAdminRoutes(r *http.ServeMux) {
+	r.Handle("GET /api/v1/admin/users", makeHTTPHandlerFunc(s.handleAdminRequired(s.handleAdminUsers())))
+	r.Handle("POST /api/v1/admin/users/{userID}/disable", makeHTTPHandlerFunc(s.handleAdminRequired(s.handleAdminUserEnabled(false))))
+	r.Handle("POST /api/v1/admin/users/{userID}/enable", makeHTTPHandlerFunc(s.handleAdminRequired(s.handleAdminUserEnabled(true))))
+	r.Handle("POST /api/v1/admin/feeds/{feedID}/resync", makeHTTPHandlerFunc(s.handleAdminRequired(s.handleAdminFeedResync())))
+	r.Handle("POST /api/v1/admin/feeds/{feedID}/disable", makeHTTPHandlerFunc(s.handleAdminRequired(s.handleAdminFeedEnabled(false))))
+	r.Handle("POST /api/v1/admin/feeds/{feedID}/enable", makeHTTPHandlerFunc(s.handleAdminRequired(s.handleAdminFeedEnabled(true))))
+	r.Handle("GET /api/v1/admin/fetch-errors", makeHTTPHandlerFunc(s.handleAdminRequired(s.handleAdminFetchErrors())))
+}
+
+// handleAdminRequired lets administrators signed in with a session through,
+// api keys never carry the admin role.
+func (s *APIServer) handleAdminRequired(next APIFunc) APIFunc {
+	return s.handleSessionRequired(s.handleRoleRequired(rf.RoleAdmin, next))
+}
+
+func (s *APIServer) handleAdminUsers() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		req := &rf.UserSearchRequest{
+			Query: r.URL.Query().Get("q"),
+		}
+
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil {
+				return errors.BadRequestError(errors.ErrInvalidLimit)
+			}
+			req.Limit = n
+		}
+
+		if offset := r.URL.Query().Get("offset"); offset != "" {
+			n, err := strconv.Atoi(offset)
+			if err != nil {
+				return errors.BadRequestError(errors.ErrInvalidOffset)
+			}
+			req.Offset = n
+		}
+
+		users, err := s.AdminService.SearchUsers(r.Context(), req)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, users)
+	}
+}
+
+func (s *APIServer) handleAdminUserEnabled(enabled bool) APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		userID, err := strconv.ParseInt(r.PathValue("userID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		if enabled {
+			err = s.AdminService.EnableUser(r.Context(), userID)
+		} else {
+			err = s.AdminService.DisableUser(r.Context(), userID)
+		}
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleAdminFeedResync() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		feedID, err := strconv.ParseInt(r.PathValue("feedID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		if err := s.AdminService.ResyncFeed(r.Context(), feedID); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+}
+
+func (s *APIServer) handleAdminFeedEnabled(enabled bool) APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		feedID, err := strconv.ParseInt(r.PathValue("feedID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		if enabled {
+			err = s.AdminService.EnableFeed(r.Context(), feedID)
+		} else {
+			err = s.AdminService.DisableFeed(r.Context(), feedID)
+		}
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleAdminFetchErrors() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var limit int
+
+		if l := r.URL.Query().Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil {
+				return errors.BadRequestError(errors.ErrInvalidLimit)
+			}
+			limit = n
+		}
+
+		fetchErrors, err := s.AdminService.ListFetchErrors(r.Context(), limit)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, fetchErrors)
+	}
+}
@@ -0,0 +1,171 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/adminservice"
+	"github.com/matryer/is"
+)
+
+func TestAdminAPI(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	authStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindAPIKeyByHashFn: func(ctx context.Context, keyHash string) (*rf.APIKey, error) {
+				return &rf.APIKey{ID: 1, UserID: 1, Scope: rf.APIKeyScopeReadWrite}, nil
+			},
+			TouchAPIKeyFn: func(ctx context.Context, keyID int64) error {
+				return nil
+			},
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(userID).
+					AsEnabled(true).
+					Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+		}
+	}
+
+	newAdminStore := func() *mock.AdminStore {
+		return &mock.AdminStore{
+			SearchUsersFn: func(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error) {
+				return []rf.AdminUser{{ID: 2, Name: "Gopher", Email: "gopher@go.com", Role: rf.RoleUser}}, nil
+			},
+			SetAuthEnabledFn: func(ctx context.Context, userID int64, enabled bool) error {
+				return nil
+			},
+			RequestFeedResyncFn: func(ctx context.Context, feedID int64) error {
+				return nil
+			},
+		}
+	}
+
+	newServer := func(store *mock.AdminStore) (*APIServer, *[]audit.Event) {
+		events := &[]audit.Event{}
+
+		service := adminservice.NewAdminService(store)
+		service.Audit = &mock.AuditRecorder{
+			RecordFn: func(ctx context.Context, event audit.Event) error {
+				*events = append(*events, event)
+				return nil
+			},
+		}
+
+		s := makeAuthAPIServer(authStore())
+		s.AdminService = service
+		return s, events
+	}
+
+	newRequest := func(method, target string, role rf.Role) *http.Request {
+		token, err := jwt.GenerateAndSignUserSession(1, 1, role, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(method, target, nil)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		return request
+	}
+
+	t.Run("GET /api/v1/admin/users lists users for an admin", func(t *testing.T) {
+		t.Parallel()
+
+		store := newAdminStore()
+		s, _ := newServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodGet, "/api/v1/admin/users?q=gopher&limit=10", rf.RoleAdmin))
+
+		is.Equal(response.Code, http.StatusOK) // should list the users
+		is.True(store.SearchUsersInvoked)      // should search the users
+
+		var users []rf.AdminUser
+		is.NoErr(json.NewDecoder(response.Body).Decode(&users)) // should decode the users
+		is.Equal(len(users), 1)                                 // should have the matching user
+		is.Equal(users[0].Email, "gopher@go.com")               // should show the email
+	})
+
+	t.Run("Admin endpoints return 403 for a user", func(t *testing.T) {
+		t.Parallel()
+
+		store := newAdminStore()
+		s, events := newServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodPost, "/api/v1/admin/users/2/disable", rf.RoleUser))
+
+		is.Equal(response.Code, http.StatusForbidden) // should need the admin role
+		is.True(!store.SetAuthEnabledInvoked)         // should not disable the user
+		is.Equal(len(*events), 0)                     // should not audit anything
+	})
+
+	t.Run("Admin endpoints return 403 for an api key", func(t *testing.T) {
+		t.Parallel()
+
+		store := newAdminStore()
+		s, _ := newServer(store)
+
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer rfk_key")
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusForbidden) // api keys should not reach admin endpoints
+		is.True(!store.SearchUsersInvoked)            // should not search the users
+	})
+
+	t.Run("POST /api/v1/admin/users/{userID}/disable disables the user and audits it", func(t *testing.T) {
+		t.Parallel()
+
+		store := newAdminStore()
+		s, events := newServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodPost, "/api/v1/admin/users/2/disable", rf.RoleAdmin))
+
+		is.Equal(response.Code, http.StatusNoContent)             // should disable the user
+		is.True(store.SetAuthEnabledInvoked)                      // should store the change
+		is.Equal(len(*events), 1)                                 // should audit the change
+		is.Equal((*events)[0].Type, audit.EventAdminUserDisabled) // should record what was done
+		is.Equal((*events)[0].ActorID, int64(1))                  // should record who did it
+		is.Equal((*events)[0].TargetID, int64(2))                 // should record who it was done to
+		is.True((*events)[0].RequestID != "")                     // should record the request
+	})
+
+	t.Run("POST /api/v1/admin/feeds/{feedID}/resync queues a resync", func(t *testing.T) {
+		t.Parallel()
+
+		store := newAdminStore()
+		s, events := newServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodPost, "/api/v1/admin/feeds/3/resync", rf.RoleAdmin))
+
+		is.Equal(response.Code, http.StatusAccepted)            // should accept the resync
+		is.True(store.RequestFeedResyncInvoked)                 // should mark the feed
+		is.Equal((*events)[0].Type, audit.EventAdminFeedResync) // should audit the resync
+	})
+}
@@ -2,27 +2,103 @@ package http
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
 	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/cookie"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/csrf"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/extract"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/fetch"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/limiter"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/adminservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/auditservice"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/authservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/exportservice"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/feedservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/itemservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/syncservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/userservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/websubservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/signedurl"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/memstore"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/postgresstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlitestore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/websub"
 )
 
 const ShutdownTimeout = 1 * time.Second
 
 type AuthService interface {
-	SignUp(ctx context.Context, req *rf.SignUpRequest) (string, error)
-	SignIn(ctx context.Context, req *rf.SignInRequest) (string, error)
+	SignUp(ctx context.Context, req *rf.SignUpRequest) (*rf.AuthTokens, error)
+	SignIn(ctx context.Context, req *rf.SignInRequest) (*rf.AuthTokens, error)
+	Refresh(ctx context.Context, req *rf.RefreshRequest) (*rf.AuthTokens, error)
+	SignOut(ctx context.Context, refreshToken string) error
+	VerifyAPIKey(ctx context.Context, key string) (*rf.APIKey, error)
+	CreateAPIKey(ctx context.Context, req *rf.CreateAPIKeyRequest) (*rf.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]rf.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int64) error
+	VerifySession(ctx context.Context, userID, sessionID int64) error
+	ForgotPassword(ctx context.Context, req *rf.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *rf.ResetPasswordRequest) error
+	ConfirmEmail(ctx context.Context, req *rf.ConfirmEmailRequest) error
+	ResendVerification(ctx context.Context) error
+	ChangeEmail(ctx context.Context, req *rf.ChangeEmailRequest) error
+	UnlockAccount(ctx context.Context, req *rf.UnlockAccountRequest) error
+	VerifyMFA(ctx context.Context, req *rf.MFAVerifyRequest) (*rf.AuthTokens, error)
+	EnrollTOTP(ctx context.Context) (*rf.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, req *rf.TOTPCodeRequest) (*rf.TOTPRecoveryCodes, error)
+	DisableTOTP(ctx context.Context, req *rf.TOTPCodeRequest) error
+	StartOIDC(ctx context.Context, providerName string) (string, string, error)
+	CompleteOIDC(ctx context.Context, req *rf.OIDCCallbackRequest) (*rf.AuthTokens, error)
+	LinkOIDC(ctx context.Context, req *rf.OIDCLinkRequest) (*rf.AuthTokens, error)
+	ListSessions(ctx context.Context) ([]rf.Session, error)
+	RevokeSession(ctx context.Context, sessionID int64) error
+	RevokeOtherSessions(ctx context.Context) error
+	ChangePassword(ctx context.Context, req *rf.ChangePasswordRequest) error
+	DeleteAccount(ctx context.Context) (*rf.AccountDeletion, error)
+	PurgeDeletedAccounts(ctx context.Context) error
+}
+
+type UserService interface {
+	GetProfile(ctx context.Context) (*rf.User, error)
+	UpdateProfile(ctx context.Context, req *rf.UpdateProfileRequest) (*rf.User, error)
+}
+
+type ExportService interface {
+	RequestExport(ctx context.Context) (*rf.DataExport, error)
+	ListExports(ctx context.Context) ([]rf.DataExport, error)
+	GetExport(ctx context.Context, exportID int64) (*rf.DataExport, error)
+	OpenExport(ctx context.Context, exportID int64) (*os.File, *rf.DataExport, error)
+	ProcessExports(ctx context.Context) error
+}
+
+type AdminService interface {
+	SearchUsers(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error)
+	DisableUser(ctx context.Context, userID int64) error
+	EnableUser(ctx context.Context, userID int64) error
+	PromoteUser(ctx context.Context, email string) error
+	ResyncFeed(ctx context.Context, feedID int64) error
+	DisableFeed(ctx context.Context, feedID int64) error
+	EnableFeed(ctx context.Context, feedID int64) error
+	ListFetchErrors(ctx context.Context, limit int) ([]rf.FeedFetchError, error)
+}
+
+type AuditService interface {
+	ListUserEvents(ctx context.Context, query *audit.Query) ([]audit.Event, error)
+	ListEvents(ctx context.Context, query *audit.Query) ([]audit.Event, error)
 }
 
 type FeedService interface {
@@ -30,6 +106,27 @@ type FeedService interface {
 	RemoveFeed(ctx context.Context, feedID int64) error
 	GetFeeds(ctx context.Context) ([]rf.Feed, error)
 	GetFeed(ctx context.Context, feedID int64) (*rf.Feed, error)
+	UpdateFeed(ctx context.Context, req *rf.UpdateFeedRequest) (*rf.Feed, error)
+}
+
+type ItemService interface {
+	GetTimeline(ctx context.Context, req *rf.TimelineRequest) ([]rf.TimelineEntry, error)
+	MarkStoryRead(ctx context.Context, storyID int64) error
+	StarItem(ctx context.Context, itemID int64, starred bool) error
+	SaveItem(ctx context.Context, itemID int64, saved bool) error
+	GetItem(ctx context.Context, itemID int64) (*rf.Item, error)
+	SavePlaybackPosition(ctx context.Context, req *rf.PlaybackPositionRequest) error
+	ExtractPendingContent(ctx context.Context) error
+}
+
+type WebSubService interface {
+	VerifyIntent(ctx context.Context, v *rf.WebSubVerification) (string, error)
+	Ingest(ctx context.Context, feedID int64, signature string, body []byte) error
+	RenewLeases(ctx context.Context) error
+}
+
+type SyncService interface {
+	SyncDueFeeds(ctx context.Context) error
 }
 
 type DB interface {
@@ -45,8 +142,27 @@ type APIServer struct {
 
 	Domain string
 
-	AuthService AuthService
-	FeedService FeedService
+	// Cookies are the attributes of the auth cookies.
+	Cookies CookieConfig
+	// CSRF issues and checks the tokens cookie authenticated requests that
+	// change state must echo.
+	CSRF *csrf.Protector
+	// AllowedOrigins may send cookie authenticated requests besides the
+	// server itself.
+	AllowedOrigins []string
+	// URLSigner signs links that work without signing in, such as export
+	// downloads.
+	URLSigner *signedurl.Signer
+
+	AuthService   AuthService
+	UserService   UserService
+	ExportService ExportService
+	AdminService  AdminService
+	AuditService  AuditService
+	FeedService   FeedService
+	ItemService   ItemService
+	WebSubService WebSubService
+	SyncService   SyncService
 }
 
 func NewAPIServer(db DB) *APIServer {
@@ -54,52 +170,300 @@ func NewAPIServer(db DB) *APIServer {
 		server: &http.Server{},
 		router: http.NewServeMux(),
 		db:     db,
+
+		Cookies:        newCookieConfig(),
+		CSRF:           newCSRFProtector(),
+		AllowedOrigins: newAllowedOrigins(),
+		URLSigner:      newURLSigner(),
 	}
 
 	s.server.Handler = http.HandlerFunc(s.ServeHTTP)
 	s.server.Handler = reportPanic(http.HandlerFunc(s.ServeHTTP))
 
 	s.registerAuthRoutes(s.router)
+	s.registerUserRoutes(s.router)
+	s.registerExportRoutes(s.router)
+	s.registerAdminRoutes(s.router)
+	s.registerAuditRoutes(s.router)
 	s.registerFeedRoutes(s.router)
+	s.registerTimelineRoutes(s.router)
+	s.registerItemRoutes(s.router)
+	s.registerWebSubRoutes(s.router)
 
 	return s
 }
 
+// stores are the stores an APIServer's services are built on, every store
+// package provides all of them.
+type stores struct {
+	auth    authservice.AuthStore
+	user    userservice.UserStore
+	export  exportservice.ExportStore
+	admin   adminservice.AdminStore
+	audit   auditStore
+	feed    feedservice.FeedStore
+	sync    syncservice.SyncStore
+	item    itemservice.ItemStore
+	webSub  websubservice.WebSubStore
+	limiter limiter.Limiter
+}
+
+type auditStore interface {
+	audit.Recorder
+	auditservice.AuditStore
+}
+
 func NewPostgresAPIServer() *APIServer {
 	db := postgresstore.NewDB(rf.Config.DatabaseURL)
 
+	return newStoreAPIServer(db, sqlStores(db))
+}
+
+// NewSQLiteAPIServer returns a server keeping everything in the SQLite file
+// at path, which is created when missing.
+func NewSQLiteAPIServer(path string) *APIServer {
+	db := sqlitestore.NewDB(path)
+
+	return newStoreAPIServer(db, sqlStores(db))
+}
+
+// sqlStores returns the stores keeping everything in db, which either
+// postgresstore or sqlitestore opens.
+func sqlStores(db sqlstore.DB) stores {
+	return stores{
+		auth:    sqlstore.NewAuthStore(db),
+		user:    sqlstore.NewUserStore(db),
+		export:  sqlstore.NewExportStore(db),
+		admin:   sqlstore.NewAdminStore(db),
+		audit:   sqlstore.NewAuditStore(db),
+		feed:    sqlstore.NewFeedStore(db),
+		sync:    sqlstore.NewFeedStore(db),
+		item:    sqlstore.NewItemStore(db),
+		webSub:  sqlstore.NewWebSubStore(db),
+		limiter: sqlstore.NewLimiter(db),
+	}
+}
+
+// NewMemoryAPIServer returns a server keeping everything in memory, it
+// needs no database and loses its data when it stops.
+func NewMemoryAPIServer() *APIServer {
+	db := memstore.NewDB()
+
+	return newStoreAPIServer(db, stores{
+		auth:    memstore.NewAuthStore(db),
+		user:    memstore.NewUserStore(db),
+		export:  memstore.NewExportStore(db),
+		admin:   memstore.NewAdminStore(db),
+		audit:   memstore.NewAuditStore(db),
+		feed:    memstore.NewFeedStore(db),
+		sync:    memstore.NewFeedStore(db),
+		item:    memstore.NewItemStore(db),
+		webSub:  memstore.NewWebSubStore(db),
+		limiter: limiter.NewMemoryLimiter(),
+	})
+}
+
+func newStoreAPIServer(db DB, st stores) *APIServer {
 	s := NewAPIServer(db)
 
-	authStore := postgresstore.NewAuthStore(db)
-	feedStore := postgresstore.NewFeedStore(db)
-	s.AuthService = authservice.NewAuthService(authStore)
-	s.FeedService = feedservice.NewFeedService(feedStore)
+	fetchClient := fetch.NewClient(fetch.Options{})
+
+	itemService := itemservice.NewItemService(st.item)
+	itemService.Extractor = extract.NewExtractor(fetchClient)
+	webSubService := websubservice.NewWebSubService(st.webSub, websub.NewClient(fetchClient.HTTPClient()), itemService)
+	webSubService.CallbackURL = s.webSubCallbackURL
+	syncService := syncservice.NewSyncService(st.sync, fetchClient, itemService)
+	syncService.WebSub = webSubService
+
+	authService := authservice.NewAuthService(st.auth)
+	authService.Mailer = newMailer()
+	authService.PasswordResetURL = s.passwordResetURL
+	authService.EmailVerificationURL = s.emailVerificationURL
+	authService.UnlockURL = s.unlockURL
+	authService.Limiter = st.limiter
+	authService.OIDCProviders = s.oidcProviders()
+	authService.PasswordPolicy = newPasswordPolicy()
+	authService.Audit = st.audit
+
+	s.AuthService = authService
+	s.UserService = userservice.NewUserService(st.user)
+
+	exportService := exportservice.NewExportService(st.export)
+	exportService.DownloadURL = s.exportDownloadURL
+	if rf.Config.ExportDir != "" {
+		exportService.Dir = rf.Config.ExportDir
+	}
+	s.ExportService = exportService
+	adminService := adminservice.NewAdminService(st.admin)
+	adminService.Audit = st.audit
+	s.AdminService = adminService
+	s.AuditService = auditservice.NewAuditService(st.audit)
+
+	feedService := feedservice.NewFeedService(st.feed)
+	feedService.Audit = st.audit
+	feedService.WebSub = webSubService
+	s.FeedService = feedService
+	s.ItemService = itemService
+	s.WebSubService = webSubService
+	s.SyncService = syncService
 
 	return s
 }
 
+// handleAuthRequired authenticates a request with a bearer access token or
+// api key, falling back to the token cookie. Read only api keys are limited
+// to safe methods here so handlers don't need to check scopes.
 func (s *APIServer) handleAuthRequired(next APIFunc) APIFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		token, err := cookie.Read(r, "token")
-		if err != nil {
-			return err
+		token, ok := bearerToken(r)
+		if !ok {
+			return errors.UnauthorizedError(errors.ErrUnauthorized)
 		}
 
-		userID, err := jwt.ParseAndVerifyUserID(token)
+		// Browsers attach cookies to requests from any site, bearer tokens
+		// and api keys have to be added by the client.
+		fromCookie := token == ""
+		if fromCookie {
+			cookieToken, err := cookie.Read(r, tokenCookieName)
+			if err != nil {
+				return errors.UnauthorizedError(errors.ErrUnauthorized)
+			}
+			token = cookieToken
+		}
+
+		if strings.HasPrefix(token, authservice.APIKeyPrefix) {
+			if fromCookie {
+				return errors.UnauthorizedError(errors.ErrUnauthorized)
+			}
+
+			key, err := s.AuthService.VerifyAPIKey(r.Context(), token)
+			if err != nil {
+				return errors.ToAPIError(err)
+			}
+
+			if key.Scope != rf.APIKeyScopeReadWrite && !isSafeMethod(r.Method) {
+				return errors.ForbiddenError(errors.ErrAPIKeyReadOnly)
+			}
+
+			// Api keys only ever act with the user role, so a leaked key
+			// never reaches the admin endpoints.
+			r = rfcontext.SetUserIDToRequestContext(r, key.UserID)
+			r = rfcontext.SetRoleToRequestContext(r, rf.RoleUser)
+			r = rfcontext.SetAPIKeyToRequestContext(r, key)
+
+			return next(w, r)
+		}
+
+		access, err := jwt.ParseAndVerifyAccess(token)
 		if err != nil {
-			return err
+			return errors.ToAPIError(err)
 		}
 
-		if userID == 0 {
+		if access.UserID == 0 {
 			return errors.Unauthorizedf(errors.ErrUnauthorized)
 		}
 
-		r = rfcontext.SetUserIDToRequestContext(r, userID)
+		if err := s.AuthService.VerifySession(r.Context(), access.UserID, access.SessionID); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		if fromCookie {
+			if err := s.checkCSRF(r, access.SessionID); err != nil {
+				return err
+			}
+		}
+
+		r = rfcontext.SetUserIDToRequestContext(r, access.UserID)
+		r = rfcontext.SetSessionIDToRequestContext(r, access.SessionID)
+		r = rfcontext.SetRoleToRequestContext(r, access.Role)
 
 		return next(w, r)
 	}
 }
 
+// handleSessionRequired is handleAuthRequired for account management
+// endpoints that api keys must not reach.
+func (s *APIServer) handleSessionRequired(next APIFunc) APIFunc {
+	return s.handleAuthRequired(func(w http.ResponseWriter, r *http.Request) error {
+		if rfcontext.APIKeyFromContext(r.Context()) != nil {
+			return errors.ForbiddenError(errors.ErrAPIKeyNotAllowed)
+		}
+
+		return next(w, r)
+	})
+}
+
+// handleRoleRequired lets through requests of users with role, it goes
+// inside handleAuthRequired or handleSessionRequired which set the role. The
+// role comes from the access token, a change of role applies once the token
+// is refreshed.
+func (s *APIServer) handleRoleRequired(role rf.Role, next APIFunc) APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if rfcontext.RoleFromContext(r.Context()) != role {
+			return errors.ForbiddenError(errors.ErrRoleRequired)
+		}
+
+		return next(w, r)
+	}
+}
+
+// bearerToken returns the token of an Authorization header, ok is false
+// when the header is present but not a bearer token.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", true
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// requestIDHeader carries the id of a request, one sent by a proxy in front
+// of the server is kept so both logs can be matched up.
+const requestIDHeader = "X-Request-ID"
+
+// withRequestInfo puts who sent r and an id for it into its context and
+// echoes the id back.
+func withRequestInfo(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(requestIDHeader)
+	if !validRequestID(id) {
+		b := make([]byte, 16)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+
+	w.Header().Set(requestIDHeader, id)
+
+	return rfcontext.SetRequestInfoToRequestContext(r, rfcontext.RequestInfo{
+		ID:        id,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
 func reportPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -114,7 +478,7 @@ func reportPanic(next http.Handler) http.Handler {
 }
 
 func (s *APIServer) Open() (err error) {
-	if err := s.db.Open(); err != nil {
+	if err := s.OpenDB(); err != nil {
 		return err
 	}
 
@@ -127,6 +491,12 @@ func (s *APIServer) Open() (err error) {
 	return nil
 }
 
+// OpenDB opens the database without serving requests, for commands that
+// only change data.
+func (s *APIServer) OpenDB() error {
+	return s.db.Open()
+}
+
 func (s *APIServer) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
 	defer cancel()
@@ -139,7 +509,7 @@ func (s *APIServer) Close() error {
 }
 
 func (s *APIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.router.ServeHTTP(w, r)
+	s.router.ServeHTTP(w, withRequestInfo(w, r))
 }
 
 func (s *APIServer) Scheme() string {
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
+)
+
+func (s *APIServer) registerAuditRoutes(r *http.ServeMux) {
+	r.Handle("GET /api/v1/me/security-events", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleSecurityEvents())))
+	r.Handle("GET /api/v1/admin/audit-events", makeHTTPHandlerFunc(s.handleAdminRequired(s.handleAdminAuditEvents())))
+}
+
+func (s *APIServer) handleSecurityEvents() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		query, err := parseAuditQuery(r.URL.Query())
+		if err != nil {
+			return err
+		}
+
+		events, err := s.AuditService.ListUserEvents(r.Context(), query)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, events)
+	}
+}
+
+func (s *APIServer) handleAdminAuditEvents() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		query, err := parseAuditQuery(r.URL.Query())
+		if err != nil {
+			return err
+		}
+
+		events, err := s.AuditService.ListEvents(r.Context(), query)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, events)
+	}
+}
+
+// parseAuditQuery reads the filters of an audit event listing, type may be
+// repeated or comma separated.
+func parseAuditQuery(values url.Values) (*audit.Query, error) {
+	query := &audit.Query{
+		TargetType: audit.TargetType(values.Get("targetType")),
+	}
+
+	for _, v := range values["type"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				query.Types = append(query.Types, audit.EventType(t))
+			}
+		}
+	}
+
+	ids := map[string]*int64{
+		"actorID":  &query.ActorID,
+		"userID":   &query.UserID,
+		"targetID": &query.TargetID,
+		"beforeID": &query.BeforeID,
+	}
+	for name, id := range ids {
+		if v := values.Get(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, errors.BadRequestError(errors.ErrInvalidID)
+			}
+			*id = n
+		}
+	}
+
+	if v := values.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.BadRequestError(errors.ErrInvalidSince)
+		}
+		query.Since = t
+	}
+
+	if v := values.Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.BadRequestError(errors.ErrInvalidUntil)
+		}
+		query.Until = t
+	}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.BadRequestError(errors.ErrInvalidLimit)
+		}
+		query.Limit = n
+	}
+
+	return query, nil
+}
@@ -0,0 +1,153 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/auditservice"
+	"github.com/matryer/is"
+)
+
+func TestAuditAPI(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	authStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(userID).
+					AsEnabled(true).
+					Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+		}
+	}
+
+	newAuditStore := func(got *audit.Query) *mock.AuditStore {
+		return &mock.AuditStore{
+			ListAuditEventsFn: func(ctx context.Context, query *audit.Query) ([]audit.Event, error) {
+				*got = *query
+				return []audit.Event{{ID: 7, Type: audit.EventSignInFailed, TargetType: audit.TargetUser, TargetID: 1}}, nil
+			},
+		}
+	}
+
+	newRequest := func(target string, role rf.Role) *http.Request {
+		token, err := jwt.GenerateAndSignUserSession(1, 1, role, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(http.MethodGet, target, nil)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		return request
+	}
+
+	t.Run("GET /api/v1/me/security-events lists only the user's events", func(t *testing.T) {
+		t.Parallel()
+
+		var got audit.Query
+		s := makeAuthAPIServer(authStore())
+		s.AuditService = auditservice.NewAuditService(newAuditStore(&got))
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest("/api/v1/me/security-events?type=sign_in.failed,sign_in.succeeded&userID=2&actorID=2", rf.RoleUser))
+
+		is.Equal(response.Code, http.StatusOK)                                                      // should list the events
+		is.Equal(got.UserID, int64(1))                                                              // should only ask for the user's events
+		is.Equal(got.ActorID, int64(0))                                                             // should ignore filters for other users
+		is.Equal(got.Types, []audit.EventType{audit.EventSignInFailed, audit.EventSignInSucceeded}) // should filter by type
+		is.Equal(got.Limit, 50)                                                                     // should use the default limit
+
+		var events []audit.Event
+		is.NoErr(json.NewDecoder(response.Body).Decode(&events)) // should decode the events
+		is.Equal(len(events), 1)                                 // should have the event
+	})
+
+	t.Run("GET /api/v1/admin/audit-events filters every event for an admin", func(t *testing.T) {
+		t.Parallel()
+
+		var got audit.Query
+		s := makeAuthAPIServer(authStore())
+		s.AuditService = auditservice.NewAuditService(newAuditStore(&got))
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest("/api/v1/admin/audit-events?actorID=2&targetType=feed&since=2024-10-01T00:00:00Z&beforeID=100", rf.RoleAdmin))
+
+		is.Equal(response.Code, http.StatusOK)                            // should list the events
+		is.Equal(got.ActorID, int64(2))                                   // should filter by actor
+		is.Equal(got.TargetType, audit.TargetFeed)                        // should filter by target
+		is.Equal(got.Since, time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)) // should filter by time
+		is.Equal(got.BeforeID, int64(100))                                // should page
+	})
+
+	t.Run("GET /api/v1/admin/audit-events returns 403 for a user", func(t *testing.T) {
+		t.Parallel()
+
+		var got audit.Query
+		store := newAuditStore(&got)
+		s := makeAuthAPIServer(authStore())
+		s.AuditService = auditservice.NewAuditService(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest("/api/v1/admin/audit-events", rf.RoleUser))
+
+		is.Equal(response.Code, http.StatusForbidden) // should need the admin role
+		is.True(!store.ListAuditEventsInvoked)        // should not list events
+	})
+
+	t.Run("GET /api/v1/admin/audit-events with a bad since returns 400", func(t *testing.T) {
+		t.Parallel()
+
+		var got audit.Query
+		s := makeAuthAPIServer(authStore())
+		s.AuditService = auditservice.NewAuditService(newAuditStore(&got))
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest("/api/v1/admin/audit-events?since=yesterday", rf.RoleAdmin))
+
+		is.Equal(response.Code, http.StatusBadRequest) // should reject the filter
+	})
+
+	t.Run("Requests get an id that is echoed back", func(t *testing.T) {
+		t.Parallel()
+
+		var got audit.Query
+		s := makeAuthAPIServer(authStore())
+		s.AuditService = auditservice.NewAuditService(newAuditStore(&got))
+
+		request := newRequest("/api/v1/me/security-events", rf.RoleUser)
+		request.Header.Set("X-Request-ID", "proxy-123")
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Header().Get("X-Request-ID"), "proxy-123") // should keep the id of a proxy
+
+		request = newRequest("/api/v1/me/security-events", rf.RoleUser)
+		request.Header.Set("X-Request-ID", "bad id\n")
+		response = httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		id := response.Header().Get("X-Request-ID")
+		is.True(id != "" && id != "bad id\n") // should replace an unusable id
+	})
+}
@@ -1,18 +1,77 @@
 package http
 
 import (
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/cookie"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/csrf"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/request"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/authservice"
+)
+
+const (
+	tokenCookieName        = "token"
+	refreshTokenCookieName = "refresh_token"
+
+	// refreshTokenCookiePath keeps the refresh token from being sent with
+	// anything but the auth endpoints.
+	refreshTokenCookiePath = "/api/v1/auths"
+
+	// oidcStateCookieName binds an OpenID Connect sign in to the browser
+	// that started it so a callback can't be replayed in another.
+	oidcStateCookieName = "oidc_state"
+	oidcCookiePath      = "/api/v1/auths/oidc"
 )
 
 func (s *APIServer) registerAuthRoutes(r *http.ServeMux) {
-	r.Handle("POST /api/v1/auths/signup", makeHTTPHandlerFunc(s.handleSignUp()))
-	r.Handle("POST /api/v1/auths/signin", makeHTTPHandlerFunc(s.handleSignIn()))
+	r.Handle("GET /.well-known/jwks.json", makeHTTPHandlerFunc(s.handleJWKS()))
+	r.Handle("POST /api/v1/auths/signup", makeHTTPHandlerFunc(s.handleSameOrigin(s.handleSignUp())))
+	r.Handle("POST /api/v1/auths/signin", makeHTTPHandlerFunc(s.handleSameOrigin(s.handleSignIn())))
+	r.Handle("POST /api/v1/auths/refresh", makeHTTPHandlerFunc(s.handleSameOrigin(s.handleRefresh())))
+	r.Handle("POST /api/v1/auths/signout", makeHTTPHandlerFunc(s.handleSameOrigin(s.handleSignOut())))
+	r.Handle("POST /api/v1/auths/password/forgot", makeHTTPHandlerFunc(s.handleForgotPassword()))
+	r.Handle("POST /api/v1/auths/password/reset", makeHTTPHandlerFunc(s.handleResetPassword()))
+	r.Handle("POST /api/v1/auths/mfa/verify", makeHTTPHandlerFunc(s.handleSameOrigin(s.handleVerifyMFA())))
+	r.Handle("POST /api/v1/auths/mfa/totp", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleEnrollTOTP())))
+	r.Handle("POST /api/v1/auths/mfa/totp/confirm", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleConfirmTOTP())))
+	r.Handle("DELETE /api/v1/auths/mfa/totp", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleDisableTOTP())))
+	r.Handle("GET /api/v1/auths/oidc/{provider}/login", makeHTTPHandlerFunc(s.handleOIDCLogin()))
+	r.Handle("GET /api/v1/auths/oidc/{provider}/callback", makeHTTPHandlerFunc(s.handleOIDCCallback()))
+	r.Handle("POST /api/v1/auths/oidc/link", makeHTTPHandlerFunc(s.handleSameOrigin(s.handleOIDCLink())))
+	r.Handle("POST /api/v1/auths/unlock", makeHTTPHandlerFunc(s.handleUnlockAccount()))
+	r.Handle("POST /api/v1/auths/email/confirm", makeHTTPHandlerFunc(s.handleConfirmEmail()))
+	r.Handle("POST /api/v1/auths/email/verify", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleResendVerification())))
+	r.Handle("POST /api/v1/auths/email/change", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleChangeEmail())))
+	r.Handle("GET /api/v1/auths/sessions", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleSessions())))
+	r.Handle("DELETE /api/v1/auths/sessions", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleRevokeOtherSessions())))
+	r.Handle("DELETE /api/v1/auths/sessions/{sessionID}", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleRevokeSession())))
+	r.Handle("GET /api/v1/auths/keys", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleAPIKeys())))
+	r.Handle("POST /api/v1/auths/keys", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleAPIKeyNew())))
+	r.Handle("DELETE /api/v1/auths/keys/{keyID}", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleRevokeAPIKey())))
+}
+
+// handleJWKS publishes the public keys access tokens are signed with so
+// other services can verify them.
+func (s *APIServer) handleJWKS() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+
+		return response.WriteJSON(w, http.StatusOK, jwt.CurrentKeySet().JWKS())
+	}
 }
 
 func (s *APIServer) handleSignUp() APIFunc {
@@ -23,23 +82,18 @@ func (s *APIServer) handleSignUp() APIFunc {
 			return errors.MalformedDataError(err)
 		}
 
-		token, err := s.AuthService.SignUp(r.Context(), req)
+		req.IP = clientIP(r)
+		req.UserAgent = r.UserAgent()
+
+		tokens, err := s.AuthService.SignUp(r.Context(), req)
 		if err != nil {
 			return errors.ToAPIError(err)
 		}
 
-		c := http.Cookie{
-			Name:     "token",
-			Value:    token,
-			Path:     "/",
-			MaxAge:   3600,
-			HttpOnly: true,
-			Secure:   false,
-			SameSite: http.SameSiteLaxMode,
+		if err := s.writeAuthCookies(w, tokens); err != nil {
+			return err
 		}
 
-		cookie.Write(w, c)
-
 		err = response.WriteJSON(w, http.StatusCreated, nil)
 		if err != nil {
 			return err
@@ -56,27 +110,613 @@ func (s *APIServer) handleSignIn() APIFunc {
 			return errors.MalformedDataError(err)
 		}
 
-		token, err := s.AuthService.SignIn(r.Context(), req)
+		req.IP = clientIP(r)
+		req.UserAgent = r.UserAgent()
+
+		tokens, err := s.AuthService.SignIn(r.Context(), req)
 		if err != nil {
 			return errors.ToAPIError(err)
 		}
 
-		c := http.Cookie{
-			Name:     "token",
-			Value:    token,
-			Path:     "/",
-			MaxAge:   3600,
-			HttpOnly: true,
-			Secure:   false,
-			SameSite: http.SameSiteLaxMode,
+		return s.writeSignIn(w, tokens)
+	}
+}
+
+// writeSignIn sets the auth cookies of a sign in, or writes the challenge
+// to complete first without setting any.
+func (s *APIServer) writeSignIn(w http.ResponseWriter, tokens *rf.AuthTokens) error {
+	if tokens.MFAToken != "" {
+		return response.WriteJSON(w, http.StatusOK, rf.MFAChallenge{
+			MFARequired: true,
+			MFAToken:    tokens.MFAToken,
+			ExpiresAt:   tokens.MFATokenExpiresAt,
+		})
+	}
+
+	if tokens.LinkToken != "" {
+		return response.WriteJSON(w, http.StatusOK, rf.OIDCLinkChallenge{
+			LinkRequired: true,
+			LinkToken:    tokens.LinkToken,
+			ExpiresAt:    tokens.LinkTokenExpiresAt,
+		})
+	}
+
+	if err := s.writeAuthCookies(w, tokens); err != nil {
+		return err
+	}
+
+	return response.WriteJSON(w, http.StatusOK, nil)
+}
+
+func (s *APIServer) handleRefresh() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		refreshToken, err := cookie.Read(r, refreshTokenCookieName)
+		if err != nil {
+			return errors.UnauthorizedError(errors.ErrRefreshTokenAbsent)
+		}
+
+		req := &rf.RefreshRequest{
+			RefreshToken: refreshToken,
+			IP:           clientIP(r),
+			UserAgent:    r.UserAgent(),
+		}
+
+		tokens, err := s.AuthService.Refresh(r.Context(), req)
+		if err != nil {
+			// Only a session that is gone for good signs the client out, the
+			// cookies are kept to retry after any other failure.
+			if errors.ToReferenceCode(err) == errors.Unauthorized {
+				s.clearAuthCookies(w)
+			}
+			return errors.ToAPIError(err)
+		}
+
+		if err := s.writeAuthCookies(w, tokens); err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleSignOut() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		// The cookies are cleared even when the session is already gone so a
+		// client can always sign out.
+		s.clearAuthCookies(w)
+
+		refreshToken, err := cookie.Read(r, refreshTokenCookieName)
+		if err == nil {
+			if err := s.AuthService.SignOut(r.Context(), refreshToken); err != nil {
+				return errors.ToAPIError(err)
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleForgotPassword() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.ForgotPasswordRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		if err := s.AuthService.ForgotPassword(r.Context(), req); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+}
+
+func (s *APIServer) handleResetPassword() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.ResetPasswordRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		if err := s.AuthService.ResetPassword(r.Context(), req); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		s.clearAuthCookies(w)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleVerifyMFA() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.MFAVerifyRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
 		}
 
-		cookie.Write(w, c)
+		req.IP = clientIP(r)
+		req.UserAgent = r.UserAgent()
 
-		err = response.WriteJSON(w, http.StatusOK, nil)
+		tokens, err := s.AuthService.VerifyMFA(r.Context(), req)
 		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		if err := s.writeAuthCookies(w, tokens); err != nil {
 			return err
 		}
+
+		return response.WriteJSON(w, http.StatusOK, nil)
+	}
+}
+
+func (s *APIServer) handleOIDCLogin() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		authURL, state, err := s.AuthService.StartOIDC(r.Context(), r.PathValue("provider"))
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		cookie.Write(w, http.Cookie{
+			Name:     oidcStateCookieName,
+			Value:    state,
+			Path:     oidcCookiePath,
+			Domain:   s.Cookies.Domain,
+			MaxAge:   int(authservice.OIDCLoginTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   s.Cookies.Secure,
+			SameSite: s.Cookies.callbackSameSite(),
+		})
+
+		http.Redirect(w, r, authURL, http.StatusFound)
+		return nil
+	}
+}
+
+func (s *APIServer) handleOIDCCallback() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		query := r.URL.Query()
+
+		state, err := cookie.Read(r, oidcStateCookieName)
+		if err != nil || state != query.Get("state") {
+			return errors.ToAPIError(errors.InvalidDataf(errors.ErrOIDCStateInvalid))
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookieName,
+			Path:     oidcCookiePath,
+			Domain:   s.Cookies.Domain,
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   s.Cookies.Secure,
+			SameSite: s.Cookies.callbackSameSite(),
+		})
+
+		req := &rf.OIDCCallbackRequest{
+			Provider:  r.PathValue("provider"),
+			Code:      query.Get("code"),
+			State:     state,
+			Error:     query.Get("error"),
+			IP:        clientIP(r),
+			UserAgent: r.UserAgent(),
+		}
+
+		tokens, err := s.AuthService.CompleteOIDC(r.Context(), req)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return s.writeSignIn(w, tokens)
+	}
+}
+
+func (s *APIServer) handleOIDCLink() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.OIDCLinkRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		req.IP = clientIP(r)
+		req.UserAgent = r.UserAgent()
+
+		tokens, err := s.AuthService.LinkOIDC(r.Context(), req)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return s.writeSignIn(w, tokens)
+	}
+}
+
+func (s *APIServer) handleEnrollTOTP() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		enrollment, err := s.AuthService.EnrollTOTP(r.Context())
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusCreated, enrollment)
+	}
+}
+
+func (s *APIServer) handleConfirmTOTP() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.TOTPCodeRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		codes, err := s.AuthService.ConfirmTOTP(r.Context(), req)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, codes)
+	}
+}
+
+func (s *APIServer) handleDisableTOTP() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.TOTPCodeRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		if err := s.AuthService.DisableTOTP(r.Context(), req); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleUnlockAccount() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.UnlockAccountRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		if err := s.AuthService.UnlockAccount(r.Context(), req); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleConfirmEmail() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.ConfirmEmailRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		if err := s.AuthService.ConfirmEmail(r.Context(), req); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleResendVerification() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := s.AuthService.ResendVerification(r.Context()); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+}
+
+func (s *APIServer) handleChangeEmail() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.ChangeEmailRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		if err := s.AuthService.ChangeEmail(r.Context(), req); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+}
+
+func (s *APIServer) passwordResetURL(token string) string {
+	return s.publicURL("/reset-password", token)
+}
+
+func (s *APIServer) emailVerificationURL(token string) string {
+	return s.publicURL("/verify-email", token)
+}
+
+func (s *APIServer) unlockURL(token string) string {
+	return s.publicURL("/unlock-account", token)
+}
+
+// oidcProviders returns the configured OpenID Connect providers, their
+// callbacks are under the public url.
+func (s *APIServer) oidcProviders() map[string]authservice.OIDCProvider {
+	providers := map[string]authservice.OIDCProvider{}
+
+	if len(rf.Config.OIDCProviders) > 0 && rf.Config.PublicURL == "" {
+		slog.Warn("PUBLIC_URL is not set, OpenID Connect callbacks will not reach this server")
+	}
+
+	for _, p := range rf.Config.OIDCProviders {
+		providers[p.Name] = oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  s.oidcCallbackURL(p.Name),
+		})
+	}
+
+	return providers
+}
+
+func (s *APIServer) oidcCallbackURL(provider string) string {
+	base := rf.Config.PublicURL
+	if base == "" {
+		base = s.URL()
+	}
+	return strings.TrimSuffix(base, "/") + "/api/v1/auths/oidc/" + url.PathEscape(provider) + "/callback"
+}
+
+func (s *APIServer) publicURL(path, token string) string {
+	base := rf.Config.PublicURL
+	if base == "" {
+		base = s.URL()
+	}
+	return strings.TrimSuffix(base, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// newPasswordPolicy returns the configured password policy, settings that
+// can't be used fall back to the defaults.
+func newPasswordPolicy() *password.Policy {
+	policy := password.DefaultPolicy()
+
+	if rf.Config.PasswordMinLength != "" {
+		n, err := strconv.Atoi(rf.Config.PasswordMinLength)
+		if err != nil || n < 1 {
+			slog.Warn("PASSWORD_MIN_LENGTH is not a positive number, using the default", "value", rf.Config.PasswordMinLength)
+		} else {
+			policy.MinLength = n
+		}
+	}
+
+	if rf.Config.PasswordMaxLength != "" {
+		n, err := strconv.Atoi(rf.Config.PasswordMaxLength)
+		if err != nil || n < policy.MinLength {
+			slog.Warn("PASSWORD_MAX_LENGTH is not a number of at least PASSWORD_MIN_LENGTH, using the default", "value", rf.Config.PasswordMaxLength)
+		} else {
+			policy.MaxLength = n
+		}
+	}
+
+	if rf.Config.PasswordBreachDir == "" {
+		slog.Warn("PASSWORD_BREACH_DIR is not set, new passwords are not checked against breached passwords")
+		return policy
+	}
+
+	corpus, err := password.NewBreachCorpus(rf.Config.PasswordBreachDir)
+	if err != nil {
+		slog.Error("Breached password corpus error, new passwords are not checked against breached passwords", "err", err.Error())
+		return policy
+	}
+	policy.Breached = corpus
+
+	return policy
+}
+
+func newMailer() mail.Mailer {
+	if rf.Config.SMTPHost == "" {
+		slog.Warn("SMTP_HOST is not set, account mail is kept in memory and not delivered")
+		return mail.NewMemoryMailer()
+	}
+
+	port := rf.Config.SMTPPort
+	if port == "" {
+		port = "587"
+	}
+
+	return mail.NewSMTPMailer(rf.Config.SMTPHost, port, rf.Config.SMTPUsername, rf.Config.SMTPPassword, rf.Config.MailFrom)
+}
+
+func (s *APIServer) handleSessions() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		sessions, err := s.AuthService.ListSessions(r.Context())
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, sessions)
+	}
+}
+
+func (s *APIServer) handleRevokeSession() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		sessionID, err := strconv.ParseInt(r.PathValue("sessionID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		if err := s.AuthService.RevokeSession(r.Context(), sessionID); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		if sessionID == rfcontext.SessionIDFromContext(r.Context()) {
+			s.clearAuthCookies(w)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleRevokeOtherSessions() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := s.AuthService.RevokeOtherSessions(r.Context()); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleAPIKeys() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		keys, err := s.AuthService.ListAPIKeys(r.Context())
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, keys)
+	}
+}
+
+func (s *APIServer) handleAPIKeyNew() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.CreateAPIKeyRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		key, err := s.AuthService.CreateAPIKey(r.Context(), req)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		// The key itself is only ever part of this response.
+		return response.WriteJSON(w, http.StatusCreated, key)
+	}
+}
+
+func (s *APIServer) handleRevokeAPIKey() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		keyID, err := strconv.ParseInt(r.PathValue("keyID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		if err := s.AuthService.RevokeAPIKey(r.Context(), keyID); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
 		return nil
 	}
 }
+
+// writeAuthCookies sets the tokens of a session along with a CSRF token
+// for it, which is also sent in the csrf.HeaderName header for clients on
+// another domain that can't read the cookie.
+func (s *APIServer) writeAuthCookies(w http.ResponseWriter, tokens *rf.AuthTokens) error {
+	csrfToken, err := s.CSRF.Token(tokens.SessionID)
+	if err != nil {
+		return err
+	}
+
+	// The refresh and CSRF cookies last as long as the session does.
+	sessionMaxAge := s.Cookies.maxAge(time.Until(tokens.RefreshTokenExpiresAt))
+
+	cookie.Write(w, http.Cookie{
+		Name:     tokenCookieName,
+		Value:    tokens.AccessToken,
+		Path:     "/",
+		Domain:   s.Cookies.Domain,
+		MaxAge:   s.Cookies.maxAge(authservice.AccessTokenTTL),
+		HttpOnly: true,
+		Secure:   s.Cookies.Secure,
+		SameSite: s.Cookies.SameSite,
+	})
+
+	cookie.Write(w, http.Cookie{
+		Name:     refreshTokenCookieName,
+		Value:    tokens.RefreshToken,
+		Path:     refreshTokenCookiePath,
+		Domain:   s.Cookies.Domain,
+		MaxAge:   sessionMaxAge,
+		HttpOnly: true,
+		Secure:   s.Cookies.Secure,
+		SameSite: s.Cookies.refreshSameSite(),
+	})
+
+	// Not encoded like the other cookies so scripts can echo it as is.
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    csrfToken,
+		Path:     "/",
+		Domain:   s.Cookies.Domain,
+		MaxAge:   sessionMaxAge,
+		Secure:   s.Cookies.Secure,
+		SameSite: s.Cookies.SameSite,
+	})
+	w.Header().Set(csrf.HeaderName, csrfToken)
+
+	return nil
+}
+
+func (s *APIServer) clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookieName,
+		Path:     "/",
+		Domain:   s.Cookies.Domain,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.Cookies.Secure,
+		SameSite: s.Cookies.SameSite,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookieName,
+		Path:     refreshTokenCookiePath,
+		Domain:   s.Cookies.Domain,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.Cookies.Secure,
+		SameSite: s.Cookies.refreshSameSite(),
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Path:     "/",
+		Domain:   s.Cookies.Domain,
+		MaxAge:   -1,
+		Secure:   s.Cookies.Secure,
+		SameSite: s.Cookies.SameSite,
+	})
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
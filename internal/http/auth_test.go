@@ -3,6 +3,7 @@ package http_test
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,7 +17,14 @@ import (
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	rfhttp "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/http"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwk"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/limiter"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc/oidctest"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/authservice"
 	"github.com/matryer/is"
 )
 
@@ -37,6 +45,13 @@ func TestAuthAPI_SignUp_Success(t *testing.T) {
 				auth.LastSignedInAt = time.Now()
 				return nil
 			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+			CreateEmailVerificationTokenFn: func(ctx context.Context, token *rf.EmailVerificationToken) error {
+				return nil
+			},
 			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
 				return nil, nil
 			},
@@ -116,9 +131,17 @@ func TestAuthAPI_SignIn_Success(t *testing.T) {
 		is.NoErr(err) // Should hash password
 
 		store := &mock.AuthStore{
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
 			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
 				auth := builder.NewAuthBuilder().
 					WithUserID(1).
+					AsEnabled(true).
 					WithBasicAuth(builder.NewBasicAuthBuilder().
 						WithPassword(hashedPassword)).
 					Build()
@@ -192,3 +215,1032 @@ func structToJSONReader(is *is.I, data any) io.Reader {
 
 	return bytes.NewBuffer(bodyBytes)
 }
+
+func TestAuthAPI_Sessions(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	t.Run("POST /api/v1/auths/signin sets a short lived token and a refresh token", func(t *testing.T) {
+		t.Parallel()
+
+		hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+		is.NoErr(err) // Should hash password
+
+		store := &mock.AuthStore{
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(1).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithPassword(hashedPassword)).
+					Build(), nil
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher@go.com").
+			WithPassword("gogopher1").
+			Build()
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/signin", structToJSONReader(is, req))
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusOK) // should sign in
+
+		cookies := map[string]*http.Cookie{}
+		for _, c := range response.Result().Cookies() {
+			cookies[c.Name] = c
+		}
+
+		is.Equal(cookies["token"].MaxAge, 900)                             // access token should last 15 minutes
+		is.Equal(cookies["refresh_token"].Path, "/api/v1/auths")           // refresh token should only be sent to auth endpoints
+		is.True(cookies["refresh_token"].HttpOnly)                         // refresh token should not be readable by scripts
+		is.True(cookies["refresh_token"].MaxAge > cookies["token"].MaxAge) // refresh token should outlive the access token
+		is.True(store.CreateSessionInvoked)                                // should create a session
+	})
+
+	t.Run("POST /api/v1/auths/signin keeps the cookies for the configured max age", func(t *testing.T) {
+		t.Parallel()
+
+		hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+		is.NoErr(err) // Should hash password
+
+		store := &mock.AuthStore{
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(1).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithPassword(hashedPassword)).
+					Build(), nil
+			},
+		}
+		s := makeAuthAPIServer(store)
+		maxAge := 3600
+		s.Cookies.MaxAge = &maxAge
+
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher@go.com").
+			WithPassword("gogopher1").
+			Build()
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/signin", structToJSONReader(is, req))
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusOK) // should sign in
+
+		cookies := map[string]*http.Cookie{}
+		for _, c := range response.Result().Cookies() {
+			cookies[c.Name] = c
+		}
+
+		is.Equal(cookies["token"].MaxAge, 3600)         // access token cookie should last the max age
+		is.Equal(cookies["refresh_token"].MaxAge, 3600) // refresh token cookie should last the max age
+		is.Equal(cookies["csrf_token"].MaxAge, 3600)    // csrf cookie should last the max age
+	})
+
+	t.Run("POST /api/v1/auths/refresh without a refresh token returns 401", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{}
+		s := makeAuthAPIServer(store)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/refresh", nil)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusUnauthorized) // should not refresh
+		is.True(!store.FindRefreshTokenInvoked)          // should not look up a token
+	})
+
+	newRefreshRequest := func() *http.Request {
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/refresh", nil)
+		is.NoErr(err) // should be a successful request
+
+		request.AddCookie(&http.Cookie{Name: "refresh_token", Value: base64.URLEncoding.EncodeToString([]byte("refresh-token"))})
+		return request
+	}
+
+	clearedCookies := func(response *httptest.ResponseRecorder) int {
+		cleared := 0
+		for _, c := range response.Result().Cookies() {
+			if c.MaxAge < 0 {
+				cleared++
+			}
+		}
+		return cleared
+	}
+
+	t.Run("POST /api/v1/auths/refresh sets cookies lasting as long as the session", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{
+			FindRefreshTokenFn: func(ctx context.Context, tokenHash string) (*rf.RefreshToken, error) {
+				return &rf.RefreshToken{TokenHash: tokenHash, SessionID: 1}, nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().WithUserID(userID).AsEnabled(true).Build(), nil
+			},
+			RotateRefreshTokenFn: func(ctx context.Context, session *rf.Session, used, next *rf.RefreshToken) error {
+				session.LastUsedAt = time.Now()
+				return nil
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRefreshRequest())
+
+		is.Equal(response.Code, http.StatusNoContent) // should refresh
+
+		cookies := map[string]*http.Cookie{}
+		for _, c := range response.Result().Cookies() {
+			cookies[c.Name] = c
+		}
+
+		ttl := int(authservice.RefreshTokenTTL.Seconds())
+		is.True(cookies["refresh_token"].MaxAge > ttl-60)                       // refresh token should last as long as the extended session
+		is.True(cookies["refresh_token"].MaxAge <= ttl)                         // refresh token should not outlive the session
+		is.Equal(cookies["csrf_token"].MaxAge, cookies["refresh_token"].MaxAge) // csrf token should last as long as the refresh token
+	})
+
+	t.Run("POST /api/v1/auths/refresh with a revoked session clears the cookies", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{
+			FindRefreshTokenFn: func(ctx context.Context, tokenHash string) (*rf.RefreshToken, error) {
+				return nil, nil
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRefreshRequest())
+
+		is.Equal(response.Code, http.StatusUnauthorized) // should not refresh
+		is.Equal(clearedCookies(response), 3)            // should sign the client out
+	})
+
+	t.Run("POST /api/v1/auths/refresh keeps the cookies when refreshing fails for another reason", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{
+			FindRefreshTokenFn: func(ctx context.Context, tokenHash string) (*rf.RefreshToken, error) {
+				return nil, errors.InternalErrorf("database unavailable")
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRefreshRequest())
+
+		is.Equal(response.Code, http.StatusInternalServerError) // should fail
+		is.Equal(clearedCookies(response), 0)                   // should keep the client signed in to retry
+	})
+
+	t.Run("POST /api/v1/auths/signout clears the cookies and returns 204", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{}
+		s := makeAuthAPIServer(store)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/signout", nil)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusNoContent) // should sign out
+
+		cleared := 0
+		for _, c := range response.Result().Cookies() {
+			if c.MaxAge < 0 {
+				cleared++
+			}
+		}
+		is.Equal(cleared, 3) // should clear the auth and csrf cookies
+	})
+}
+
+func TestAuthAPI_AuthRequired(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	newRequest := func(sessionID int64) *http.Request {
+		token, err := jwt.GenerateAndSignUserSession(1, sessionID, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/auths/sessions", nil)
+		is.NoErr(err) // should be a successful request
+
+		request.AddCookie(&http.Cookie{Name: "token", Value: base64.URLEncoding.EncodeToString([]byte(token))})
+		return request
+	}
+
+	newStore := func(revokedAt time.Time) *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().WithUserID(userID).AsEnabled(true).Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: revokedAt}, nil
+			},
+			ListUserSessionsFn: func(ctx context.Context, userID int64) ([]rf.Session, error) {
+				return []rf.Session{{ID: 1, UserID: userID}, {ID: 2, UserID: userID}}, nil
+			},
+		}
+	}
+
+	t.Run("GET /api/v1/auths/sessions lists sessions and marks the current one", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newStore(time.Time{}))
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(2))
+
+		is.Equal(response.Code, http.StatusOK) // should list sessions
+
+		var got []rf.Session
+		err := json.NewDecoder(response.Body).Decode(&got)
+
+		is.NoErr(err)           // should have a response
+		is.Equal(len(got), 2)   // should list every session
+		is.True(got[1].Current) // should mark the current session
+	})
+
+	t.Run("Should reject tokens of revoked sessions with 401", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newStore(time.Now()))
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(1))
+
+		is.Equal(response.Code, http.StatusUnauthorized) // revoked sessions should be rejected
+	})
+
+	t.Run("Should reject tokens of accounts disabled after issuance with 403", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(time.Time{})
+		store.FindByUserIDFn = func(ctx context.Context, userID int64) (*rf.Auth, error) {
+			return builder.NewAuthBuilder().WithUserID(userID).AsEnabled(false).Build(), nil
+		}
+		s := makeAuthAPIServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(1))
+
+		is.Equal(response.Code, http.StatusForbidden) // disabled accounts should be rejected
+		is.True(!store.ListUserSessionsInvoked)       // should not reach the handler
+	})
+
+	t.Run("Should reject requests without a token with 401", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(&mock.AuthStore{})
+
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/auths/sessions", nil)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusUnauthorized) // missing tokens should be rejected
+	})
+}
+
+func TestAuthAPI_BearerAndAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	newStore := func(scope rf.APIKeyScope) *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().WithUserID(userID).AsEnabled(true).Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+			ListUserSessionsFn: func(ctx context.Context, userID int64) ([]rf.Session, error) {
+				return []rf.Session{{ID: 1, UserID: userID}}, nil
+			},
+			FindAPIKeyByHashFn: func(ctx context.Context, keyHash string) (*rf.APIKey, error) {
+				return &rf.APIKey{ID: 1, UserID: 1, Scope: scope}, nil
+			},
+			TouchAPIKeyFn: func(ctx context.Context, keyID int64) error {
+				return nil
+			},
+			CreateAPIKeyFn: func(ctx context.Context, key *rf.APIKey) error {
+				key.ID = 1
+				return nil
+			},
+		}
+	}
+
+	t.Run("Should accept a bearer access token", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newStore(rf.APIKeyScopeRead))
+
+		token, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/auths/sessions", nil)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusOK) // should be authorized
+	})
+
+	t.Run("Should reject read only api keys on writes with 403", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(rf.APIKeyScopeRead)
+		s := makeAuthAPIServer(store)
+
+		request, err := http.NewRequest(http.MethodPut, "/api/v1/items/1/enclosures/1/position", strings.NewReader(`{}`))
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer rfk_key")
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusForbidden) // read only keys should not write
+		is.True(store.FindAPIKeyByHashInvoked)        // should look up the key
+	})
+
+	t.Run("Should reject api keys on account endpoints with 403", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newStore(rf.APIKeyScopeReadWrite))
+
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/auths/keys", nil)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer rfk_key")
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusForbidden) // api keys should not manage keys
+	})
+
+	t.Run("Should reject malformed authorization headers with 401", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newStore(rf.APIKeyScopeReadWrite))
+
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/auths/sessions", nil)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Basic Z286Z28=")
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusUnauthorized) // should not be authorized
+	})
+
+	t.Run("POST /api/v1/auths/keys returns the key once with 201", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newStore(rf.APIKeyScopeRead))
+
+		token, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		body := structToJSONReader(is, rf.CreateAPIKeyRequest{Name: "cli", Scope: rf.APIKeyScopeRead})
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/keys", body)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusCreated) // should create the key
+
+		var got rf.APIKey
+		err = json.NewDecoder(response.Body).Decode(&got)
+
+		is.NoErr(err)                               // should have a response
+		is.True(strings.HasPrefix(got.Key, "rfk_")) // should return the key
+		is.Equal(got.KeyHash, "")                   // should not return the hash
+	})
+}
+
+func TestAuthAPI_PasswordReset(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	t.Run("POST /api/v1/auths/password/forgot returns 202 for unknown accounts", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return nil, nil
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		body := structToJSONReader(is, rf.ForgotPasswordRequest{Email: "nobody@go.com"})
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/password/forgot", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusAccepted) // should not reveal the account does not exist
+	})
+
+	t.Run("POST /api/v1/auths/password/reset with a bad token returns 400", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{
+			ResetPasswordFn: func(ctx context.Context, tokenHash, hashedPassword string) (int64, error) {
+				return 0, errors.InvalidDataf(errors.ErrResetTokenInvalid)
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		body := structToJSONReader(is, rf.ResetPasswordRequest{Token: "bad", Password: "gogopher2"})
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/password/reset", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusBadRequest) // should reject the token
+	})
+}
+
+func TestAuthAPI_SignInThrottle(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	t.Run("POST /api/v1/auths/signin of a locked account returns 429", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{}
+		s := makeAuthAPIServer(store)
+
+		l := limiter.NewMemoryLimiter()
+		for range authservice.AccountLockoutFailures {
+			is.NoErr(l.Fail(context.Background(), "sign_in:email:gopher@go.com")) // should record a failure
+		}
+		s.AuthService.(*authservice.AuthService).Limiter = l
+
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher@go.com").
+			WithPassword("password1").
+			Build()
+		body := structToJSONReader(is, req)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/signin", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusTooManyRequests) // should be locked out
+		is.True(!store.FindByEmailInvoked)                  // should not look up the account
+	})
+
+	t.Run("POST /api/v1/auths/unlock with a bad token returns 400", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{
+			UseUnlockTokenFn: func(ctx context.Context, tokenHash string) (*rf.Auth, error) {
+				return nil, errors.InvalidDataf(errors.ErrUnlockTokenInvalid)
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		body := structToJSONReader(is, rf.UnlockAccountRequest{Token: "bad"})
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/unlock", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusBadRequest) // should reject the token
+	})
+}
+
+func TestAuthAPI_MFA(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	t.Run("POST /api/v1/auths/signin with two-factor enabled returns a challenge without cookies", func(t *testing.T) {
+		t.Parallel()
+
+		hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+		is.NoErr(err) // Should hash password
+
+		store := &mock.AuthStore{
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				auth := builder.NewAuthBuilder().
+					WithUserID(1).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithPassword(hashedPassword)).
+					Build()
+				auth.TOTPEnabledAt = time.Now()
+				return auth, nil
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher@go.com").
+			WithPassword("gogopher1").
+			Build()
+		body := structToJSONReader(is, req)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/signin", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusOK)        // should accept the password
+		is.Equal(len(response.Result().Cookies()), 0) // should not set auth cookies
+		is.True(!store.CreateSessionInvoked)          // should not create a session
+
+		var got rf.MFAChallenge
+		err = json.NewDecoder(response.Body).Decode(&got)
+
+		is.NoErr(err)               // should have a response
+		is.True(got.MFARequired)    // should ask for a second factor
+		is.True(got.MFAToken != "") // should return the mfa token
+	})
+
+	t.Run("POST /api/v1/auths/mfa/verify with a bad token returns 401", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(&mock.AuthStore{})
+
+		body := structToJSONReader(is, rf.MFAVerifyRequest{MFAToken: "bad", Code: "123456"})
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/mfa/verify", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusUnauthorized) // should reject the token
+	})
+}
+
+func TestAuthAPI_ConfirmEmail(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	t.Run("POST /api/v1/auths/email/confirm with a bad token returns 400", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{
+			ConfirmEmailFn: func(ctx context.Context, tokenHash string) (int64, error) {
+				return 0, errors.InvalidDataf(errors.ErrVerifyTokenInvalid)
+			},
+		}
+		s := makeAuthAPIServer(store)
+
+		body := structToJSONReader(is, rf.ConfirmEmailRequest{Token: "bad"})
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/email/confirm", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusBadRequest) // should reject the token
+		is.True(store.ConfirmEmailInvoked)             // should confirm through the store
+	})
+
+	t.Run("POST /api/v1/auths/email/change without a session returns 401", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(&mock.AuthStore{})
+
+		body := structToJSONReader(is, rf.ChangeEmailRequest{Email: "gopher2@go.com", Password: "gogopher1"})
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/email/change", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusUnauthorized) // should require a session
+	})
+}
+
+func TestAuthAPI_OIDC(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	server := oidctest.NewProvider()
+	t.Cleanup(server.Close)
+
+	makeOIDCServer := func(store *mock.AuthStore) *APIServer {
+		s := makeAuthAPIServer(store)
+		s.AuthService.(*authservice.AuthService).OIDCProviders = map[string]authservice.OIDCProvider{
+			"test": oidc.NewProvider(server.Config("http://localhost/api/v1/auths/oidc/test/callback")),
+		}
+		return s
+	}
+
+	t.Run("GET /api/v1/auths/oidc/{provider}/login and callback signs up with the provider", func(t *testing.T) {
+		t.Parallel()
+
+		states := map[string]*rf.OIDCLoginState{}
+		store := &mock.AuthStore{
+			CreateOIDCLoginStateFn: func(ctx context.Context, state *rf.OIDCLoginState) error {
+				states[state.StateHash] = state
+				return nil
+			},
+			UseOIDCLoginStateFn: func(ctx context.Context, provider, stateHash string) (*rf.OIDCLoginState, error) {
+				state, ok := states[stateHash]
+				if !ok {
+					return nil, errors.InvalidDataf(errors.ErrOIDCStateInvalid)
+				}
+				return state, nil
+			},
+			FindIdentityFn: func(ctx context.Context, issuer, subject string) (*rf.AuthIdentity, error) {
+				return nil, nil
+			},
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return nil, nil
+			},
+			CreateAuthAndUserWithIdentityFn: func(ctx context.Context, auth *rf.Auth, identity *rf.AuthIdentity) error {
+				auth.UserID = 1
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+		}
+		s := makeOIDCServer(store)
+
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/auths/oidc/test/login", nil)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusFound)                                              // should redirect to the provider
+		is.True(strings.HasPrefix(response.Header().Get("Location"), server.URL+"/authorize")) // should redirect to the authorization endpoint
+
+		stateCookies := response.Result().Cookies()
+		is.Equal(len(stateCookies), 1) // should bind the state to the browser
+
+		code, state, err := server.Authorize(response.Header().Get("Location"), oidctest.User{
+			Subject:       "gopher",
+			Email:         "gopher@go.com",
+			EmailVerified: true,
+		})
+		is.NoErr(err) // should authorize at the provider
+
+		callbackURL := "/api/v1/auths/oidc/test/callback?code=" + code + "&state=" + state
+
+		request, err = http.NewRequest(http.MethodGet, callbackURL, nil)
+		is.NoErr(err) // should be a successful request
+
+		response = httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusBadRequest) // should require the state cookie
+		is.True(!store.UseOIDCLoginStateInvoked)       // should not spend the state
+
+		request, err = http.NewRequest(http.MethodGet, callbackURL, nil)
+		is.NoErr(err) // should be a successful request
+		request.AddCookie(stateCookies[0])
+
+		response = httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusOK)              // should sign in
+		is.True(store.CreateAuthAndUserWithIdentityInvoked) // should sign up the user
+
+		var tokenCookie bool
+		for _, c := range response.Result().Cookies() {
+			if c.Name == "token" && c.Value != "" {
+				tokenCookie = true
+			}
+		}
+		is.True(tokenCookie) // should set the auth cookies
+	})
+
+	t.Run("GET /api/v1/auths/oidc/{provider}/login with an unknown provider returns 404", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeOIDCServer(&mock.AuthStore{})
+
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/auths/oidc/other/login", nil)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusNotFound) // should not know the provider
+	})
+
+	t.Run("POST /api/v1/auths/oidc/link with a bad token returns 401", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeOIDCServer(&mock.AuthStore{})
+
+		body := structToJSONReader(is, rf.OIDCLinkRequest{LinkToken: "bad", Password: "gogopher1"})
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/oidc/link", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusUnauthorized) // should reject the token
+	})
+}
+
+func TestAuthAPI_JWKS(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	s := makeAuthAPIServer(&mock.AuthStore{})
+
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	is.NoErr(err) // should be a successful request
+
+	response := httptest.NewRecorder()
+
+	s.ServeHTTP(response, request)
+
+	is.Equal(response.Code, http.StatusOK) // should serve the key set
+
+	var got jwk.Set
+	err = json.NewDecoder(response.Body).Decode(&got)
+
+	is.NoErr(err)                                                 // should be a key set
+	is.Equal(len(got.Keys), len(jwt.CurrentKeySet().JWKS().Keys)) // should publish the public keys
+	for _, key := range got.Keys {
+		is.True(key.Kty != "oct") // should never publish secrets
+	}
+}
+
+func TestAuthAPI_CSRF(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	newStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().WithUserID(userID).AsEnabled(true).Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+			RevokeOtherSessionsFn: func(ctx context.Context, userID, keepSessionID int64) error {
+				return nil
+			},
+		}
+	}
+
+	// newRequest revokes the other sessions of session 1 with its token in
+	// a cookie.
+	newRequest := func() *http.Request {
+		token, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(http.MethodDelete, "/api/v1/auths/sessions", nil)
+		is.NoErr(err) // should be a successful request
+
+		request.AddCookie(&http.Cookie{Name: "token", Value: base64.URLEncoding.EncodeToString([]byte(token))})
+		return request
+	}
+
+	t.Run("Should accept a cookie authenticated request with the token of its session", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore()
+		s := makeAuthAPIServer(store)
+
+		csrfToken, err := s.CSRF.Token(1)
+		is.NoErr(err) // should issue a token
+
+		request := newRequest()
+		request.Header.Set("X-CSRF-Token", csrfToken)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusNoContent) // should revoke the sessions
+		is.True(store.RevokeOtherSessionsInvoked)     // should reach the handler
+	})
+
+	t.Run("Should reject a cookie authenticated request without a valid token with 403", func(t *testing.T) {
+		t.Parallel()
+
+		otherSession, err := makeAuthAPIServer(newStore()).CSRF.Token(2)
+		is.NoErr(err) // should issue a token
+
+		for _, csrfToken := range []string{"", "bad", otherSession} {
+			store := newStore()
+			s := makeAuthAPIServer(store)
+
+			request := newRequest()
+			request.Header.Set("X-CSRF-Token", csrfToken)
+
+			response := httptest.NewRecorder()
+
+			s.ServeHTTP(response, request)
+
+			is.Equal(response.Code, http.StatusForbidden) // should reject the request
+			is.True(!store.RevokeOtherSessionsInvoked)    // should not reach the handler
+		}
+	})
+
+	t.Run("Should reject a cookie authenticated request from another site with 403", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore()
+		s := makeAuthAPIServer(store)
+
+		csrfToken, err := s.CSRF.Token(1)
+		is.NoErr(err) // should issue a token
+
+		request := newRequest()
+		request.Header.Set("X-CSRF-Token", csrfToken)
+		request.Header.Set("Origin", "https://evil.example.org")
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusForbidden) // should reject the origin
+		is.True(!store.RevokeOtherSessionsInvoked)    // should not reach the handler
+	})
+
+	t.Run("Should not need a token with a bearer token", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore()
+		s := makeAuthAPIServer(store)
+
+		token, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(http.MethodDelete, "/api/v1/auths/sessions", nil)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusNoContent) // should revoke the sessions
+	})
+
+	t.Run("POST /api/v1/auths/signin from another site returns 403", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{}
+		s := makeAuthAPIServer(store)
+
+		body := structToJSONReader(is, builder.NewSignInRequestBuilder().
+			WithEmail("gopher@go.com").
+			WithPassword("gogopher1").
+			Build())
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/signin", body)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Origin", "https://evil.example.org")
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusForbidden) // should reject the origin
+		is.True(!store.FindByEmailInvoked)            // should not try the password
+	})
+
+	t.Run("POST /api/v1/auths/signin sets cookies with the configured attributes and a csrf token", func(t *testing.T) {
+		t.Parallel()
+
+		hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+		is.NoErr(err) // Should hash password
+
+		store := &mock.AuthStore{
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 7
+				return nil
+			},
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(1).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithPassword(hashedPassword)).
+					Build(), nil
+			},
+		}
+		s := makeAuthAPIServer(store)
+		s.Cookies = rfhttp.CookieConfig{Secure: true, Domain: "example.com", SameSite: http.SameSiteNoneMode}
+
+		body := structToJSONReader(is, builder.NewSignInRequestBuilder().
+			WithEmail("gopher@go.com").
+			WithPassword("gogopher1").
+			Build())
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/auths/signin", body)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusOK) // should sign in
+
+		cookies := map[string]*http.Cookie{}
+		for _, c := range response.Result().Cookies() {
+			cookies[c.Name] = c
+		}
+
+		for _, name := range []string{"token", "refresh_token", "csrf_token"} {
+			is.True(cookies[name].Secure)                           // should be secure
+			is.Equal(cookies[name].Domain, "example.com")           // should set the domain
+			is.Equal(cookies[name].SameSite, http.SameSiteNoneMode) // should use the configured same site
+		}
+
+		is.True(!cookies["csrf_token"].HttpOnly)                                     // scripts should read the csrf token
+		is.Equal(response.Header().Get("X-CSRF-Token"), cookies["csrf_token"].Value) // should send the csrf token for other domains
+		is.True(s.CSRF.Valid(cookies["csrf_token"].Value, 7))                        // should bind the csrf token to the session
+	})
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/csrf"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// csrfCookieName holds the CSRF token for scripts of the site to echo in
+// the csrf.HeaderName header, it is the only cookie they can read.
+const csrfCookieName = "csrf_token"
+
+// CookieConfig are the attributes of the cookies the server sets.
+type CookieConfig struct {
+	Secure   bool
+	Domain   string
+	SameSite http.SameSite
+	// MaxAge in seconds overrides how long the auth cookies are kept when
+	// it is set, 0 keeps them until the browser closes.
+	MaxAge *int
+}
+
+// maxAge returns how long an auth cookie for a token that lasts ttl is
+// kept, as long as the token unless MaxAge overrides it.
+func (c CookieConfig) maxAge(ttl time.Duration) int {
+	if c.MaxAge != nil {
+		return *c.MaxAge
+	}
+	return int(ttl.Seconds())
+}
+
+// refreshSameSite keeps the refresh token strict unless the site is on
+// another domain than the api.
+func (c CookieConfig) refreshSameSite() http.SameSite {
+	if c.SameSite == http.SameSiteNoneMode {
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteStrictMode
+}
+
+// callbackSameSite lets the state cookie of an OpenID Connect sign in
+// come back with the provider's redirect.
+func (c CookieConfig) callbackSameSite() http.SameSite {
+	if c.SameSite == http.SameSiteNoneMode {
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteLaxMode
+}
+
+func newCookieConfig() CookieConfig {
+	config := CookieConfig{
+		Secure:   strings.HasPrefix(rf.Config.PublicURL, "https://"),
+		Domain:   rf.Config.CookieDomain,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if rf.Config.CookieSecure != "" {
+		secure, err := strconv.ParseBool(rf.Config.CookieSecure)
+		if err != nil {
+			slog.Warn("COOKIE_SECURE is not a boolean, using the default", "value", rf.Config.CookieSecure)
+		} else {
+			config.Secure = secure
+		}
+	}
+
+	if rf.Config.CookieMaxAge != "" {
+		maxAge, err := strconv.Atoi(rf.Config.CookieMaxAge)
+		if err != nil || maxAge < 0 {
+			slog.Warn("COOKIE_MAX_AGE is not a number of seconds, using the token lifetimes", "value", rf.Config.CookieMaxAge)
+		} else {
+			config.MaxAge = &maxAge
+		}
+	}
+
+	switch strings.ToLower(rf.Config.CookieSameSite) {
+	case "", "lax":
+	case "strict":
+		config.SameSite = http.SameSiteStrictMode
+	case "none":
+		config.SameSite = http.SameSiteNoneMode
+	default:
+		slog.Warn("COOKIE_SAMESITE is not lax, strict or none, using lax", "value", rf.Config.CookieSameSite)
+	}
+
+	// Browsers drop SameSite=None cookies that are not secure.
+	if config.SameSite == http.SameSiteNoneMode && !config.Secure {
+		slog.Warn("COOKIE_SAMESITE=none needs secure cookies, setting them secure")
+		config.Secure = true
+	}
+
+	return config
+}
+
+func newCSRFProtector() *csrf.Protector {
+	return csrf.NewProtector(secretOrRandom("CSRF_SECRET", rf.Config.CSRFSecret))
+}
+
+// secretOrRandom returns value as a key, or a random key when it is not
+// set. Keys are never shared with JWT_SECRET, a random key does not
+// survive a restart so name is logged to have it set.
+func secretOrRandom(name, value string) []byte {
+	if value != "" {
+		return []byte(value)
+	}
+
+	slog.Warn(name + " is not set, using a random key until the server restarts")
+
+	b := make([]byte, 32)
+	rand.Read(b)
+	return b
+}
+
+func newAllowedOrigins() []string {
+	origins := append([]string{}, rf.Config.AllowedOrigins...)
+	if rf.Config.PublicURL != "" {
+		origins = append(origins, rf.Config.PublicURL)
+	}
+	return origins
+}
+
+// handleSameOrigin rejects requests that carry cookies from pages of
+// other sites.
+func (s *APIServer) handleSameOrigin(next APIFunc) APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if !isSafeMethod(r.Method) && !csrf.SameOrigin(r, s.AllowedOrigins) {
+			return errors.ForbiddenError(errors.ErrOriginNotAllowed)
+		}
+
+		return next(w, r)
+	}
+}
+
+// checkCSRF guards a cookie authenticated request that changes state, it
+// must come from an allowed origin and echo the token of its session.
+func (s *APIServer) checkCSRF(r *http.Request, sessionID int64) error {
+	if isSafeMethod(r.Method) {
+		return nil
+	}
+
+	if !csrf.SameOrigin(r, s.AllowedOrigins) {
+		return errors.ForbiddenError(errors.ErrOriginNotAllowed)
+	}
+
+	if !s.CSRF.Valid(r.Header.Get(csrf.HeaderName), sessionID) {
+		return errors.ForbiddenError(errors.ErrCSRFTokenInvalid)
+	}
+
+	return nil
+}
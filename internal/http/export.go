@@ -0,0 +1,103 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/signedurl"
+)
+
+func (s *APIServer) registerExportRoutes(r *http.ServeMux) {
+	r.Handle("POST /api/v1/me/exports", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleExportNew())))
+	r.Handle("GET /api/v1/me/exports", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleExports())))
+	r.Handle("GET /api/v1/me/exports/{exportID}", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleExport())))
+	r.Handle("GET /api/v1/exports/{exportID}/download", makeHTTPHandlerFunc(s.handleExportDownload()))
+}
+
+func (s *APIServer) handleExportNew() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		export, err := s.ExportService.RequestExport(r.Context())
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusAccepted, export)
+	}
+}
+
+func (s *APIServer) handleExports() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		exports, err := s.ExportService.ListExports(r.Context())
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, exports)
+	}
+}
+
+func (s *APIServer) handleExport() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		exportID, err := strconv.ParseInt(r.PathValue("exportID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		export, err := s.ExportService.GetExport(r.Context(), exportID)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, export)
+	}
+}
+
+// handleExportDownload serves an export to whoever holds a signed link, so
+// it can be handed to a browser or download manager without cookies.
+func (s *APIServer) handleExportDownload() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if !s.URLSigner.Valid(r.URL.Path, r.URL.Query()) {
+			return errors.ForbiddenError(errors.ErrDownloadLinkInvalid)
+		}
+
+		exportID, err := strconv.ParseInt(r.PathValue("exportID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		file, export, err := s.ExportService.OpenExport(r.Context(), exportID)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+		defer file.Close()
+
+		name := fmt.Sprintf("rss-feed-aggregator-export-%s.zip", export.CompletedAt.Format("2006-01-02"))
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		w.Header().Set("Cache-Control", "private, no-store")
+
+		http.ServeContent(w, r, name, export.CompletedAt, file)
+		return nil
+	}
+}
+
+// exportDownloadURL returns a signed link to download an export under the
+// public url.
+func (s *APIServer) exportDownloadURL(exportID int64, expiresAt time.Time) string {
+	base := rf.Config.PublicURL
+	if base == "" {
+		base = s.URL()
+	}
+	return strings.TrimSuffix(base, "/") + s.URLSigner.Sign(fmt.Sprintf("/api/v1/exports/%d/download", exportID), expiresAt)
+}
+
+func newURLSigner() *signedurl.Signer {
+	return signedurl.NewSigner(secretOrRandom("URL_SIGNING_SECRET", rf.Config.URLSigningSecret))
+}
@@ -0,0 +1,128 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/exportservice"
+	"github.com/matryer/is"
+)
+
+func TestExportAPI_Download(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	var stored *rf.DataExport
+	store := &mock.ExportStore{
+		CreateExportFn: func(ctx context.Context, export *rf.DataExport) error {
+			export.ID = 1
+			export.State = rf.DataExportStatePending
+			stored = export
+			return nil
+		},
+		FindExportFn: func(ctx context.Context, exportID int64) (*rf.DataExport, error) {
+			found := *stored
+			return &found, nil
+		},
+		ClaimPendingExportFn: func(ctx context.Context) (*rf.DataExport, error) {
+			if stored.State != rf.DataExportStatePending {
+				return nil, nil
+			}
+			stored.State = rf.DataExportStateRunning
+			claimed := *stored
+			return &claimed, nil
+		},
+		FinishExportFn: func(ctx context.Context, exportID int64, state rf.DataExportState, size int64) error {
+			stored.State = state
+			stored.Size = size
+			stored.CompletedAt = time.Now()
+			return nil
+		},
+		DeleteExpiredExportsFn: func(ctx context.Context) ([]int64, error) {
+			return nil, nil
+		},
+		FindExportProfileFn: func(ctx context.Context, userID int64) (*rf.ExportProfile, error) {
+			return &rf.ExportProfile{User: &rf.User{ID: userID, Name: "Gopher"}}, nil
+		},
+		EachSubscriptionFn: func(ctx context.Context, userID int64, fn func(rf.Feed) error) error {
+			return nil
+		},
+		EachItemStateFn: func(ctx context.Context, userID int64, fn func(rf.ExportItemState) error) error {
+			return nil
+		},
+		EachPlaybackPositionFn: func(ctx context.Context, userID int64, fn func(rf.ExportPlaybackPosition) error) error {
+			return nil
+		},
+		EachSessionFn: func(ctx context.Context, userID int64, fn func(rf.ExportSession) error) error {
+			return nil
+		},
+		EachAPIKeyFn: func(ctx context.Context, userID int64, fn func(rf.ExportAPIKey) error) error {
+			return nil
+		},
+		EachIdentityFn: func(ctx context.Context, userID int64, fn func(rf.ExportIdentity) error) error {
+			return nil
+		},
+	}
+
+	s := makeAuthAPIServer(&mock.AuthStore{})
+
+	exportService := exportservice.NewExportService(store)
+	exportService.Dir = t.TempDir()
+	exportService.DownloadURL = func(exportID int64, expiresAt time.Time) string {
+		return s.URLSigner.Sign("/api/v1/exports/1/download", expiresAt)
+	}
+	s.ExportService = exportService
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+	_, err := exportService.RequestExport(ctx)
+	is.NoErr(err) // should queue the export
+
+	is.NoErr(exportService.ProcessExports(context.Background())) // should build the export
+
+	export, err := exportService.GetExport(ctx, 1)
+	is.NoErr(err)                     // should find the export
+	is.True(export.DownloadURL != "") // should have a download link
+
+	t.Run("GET a signed download link returns the zip", func(t *testing.T) {
+		request, err := http.NewRequest(http.MethodGet, export.DownloadURL, nil)
+		is.NoErr(err) // should be a successful request
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusOK)                                // should serve the export
+		is.Equal(response.Header().Get("Content-Type"), "application/zip")    // should be a zip
+		is.Equal(int64(response.Body.Len()), export.Size)                     // should be the whole export
+		is.Equal(response.Header().Get("Cache-Control"), "private, no-store") // should not be cached
+	})
+
+	t.Run("GET a download link with a bad signature returns 403", func(t *testing.T) {
+		u, err := url.Parse(export.DownloadURL)
+		is.NoErr(err) // should be a url
+
+		query := u.Query()
+		query.Set("expires", "9999999999")
+		u.RawQuery = query.Encode()
+
+		for _, link := range []string{"/api/v1/exports/1/download", u.String()} {
+			request, err := http.NewRequest(http.MethodGet, link, nil)
+			is.NoErr(err) // should be a successful request
+
+			response := httptest.NewRecorder()
+
+			s.ServeHTTP(response, request)
+
+			is.Equal(response.Code, http.StatusForbidden) // should reject the link
+		}
+	})
+}
@@ -2,10 +2,17 @@ package http
 
 import (
 	"net/http"
+	"strconv"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/request"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
 )
 
 func (s *APIServer) registerFeedRoutes(r *http.ServeMux) {
 	r.Handle("POST /api/v1/feeds/new", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleFeedNew())))
+	r.Handle("PATCH /api/v1/feeds/{feedID}", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleFeedUpdate())))
 }
 
 func (s *APIServer) handleFeedNew() APIFunc {
@@ -13,3 +20,27 @@ func (s *APIServer) handleFeedNew() APIFunc {
 		return nil
 	}
 }
+
+func (s *APIServer) handleFeedUpdate() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		feedID, err := strconv.ParseInt(r.PathValue("feedID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		req := &rf.UpdateFeedRequest{}
+
+		if err := request.ReadJSON(w, r, req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		req.FeedID = feedID
+
+		feed, err := s.FeedService.UpdateFeed(r.Context(), req)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, feed)
+	}
+}
@@ -0,0 +1,94 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/feedservice"
+	"github.com/matryer/is"
+)
+
+func TestFeedAPI_Update(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	newAuthStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(userID).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithEmail("gopher@go.com")).
+					Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+		}
+	}
+
+	newFeedStore := func() *mock.FeedStore {
+		return &mock.FeedStore{
+			FindUserFeedByIDFn: func(ctx context.Context, userID, feedID int64) (*rf.Feed, error) {
+				return &rf.Feed{ID: feedID, Name: "Gopher"}, nil
+			},
+			UpdateUserFeedFn: func(ctx context.Context, feed *rf.Feed) error {
+				return nil
+			},
+		}
+	}
+
+	newRequest := func(body string) *http.Request {
+		token, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(http.MethodPatch, "/api/v1/feeds/2", strings.NewReader(body))
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		return request
+	}
+
+	t.Run("PATCH a feed turns on full content", func(t *testing.T) {
+		t.Parallel()
+
+		feedStore := newFeedStore()
+		s := makeAuthAPIServer(newAuthStore())
+		s.FeedService = feedservice.NewFeedService(feedStore)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(`{"fetchFullContent":true}`))
+
+		is.Equal(response.Code, http.StatusOK)   // should update the feed
+		is.True(feedStore.UpdateUserFeedInvoked) // should store the feed
+
+		var feed rf.Feed
+		is.NoErr(json.NewDecoder(response.Body).Decode(&feed)) // should decode the feed
+		is.True(feed.FetchFullContent)                         // should fetch full content
+	})
+
+	t.Run("PATCH a null body does not panic", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newAuthStore())
+		s.FeedService = feedservice.NewFeedService(newFeedStore())
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(`null`))
+
+		is.True(response.Code != http.StatusInternalServerError) // should not fail on a null body
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
 	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
 )
@@ -16,17 +17,20 @@ func makeHTTPHandlerFunc(h APIFunc) http.HandlerFunc {
 		if err := h(w, r); err != nil {
 			var werr error
 			var e rferrors.Error
-			if errors.As(err, &e) {
+			// Errors from below the handlers, such as store errors, have no
+			// status and are not shown to clients.
+			if errors.As(err, &e) && e.StatusCode != 0 {
 				werr = response.WriteJSON(w, e.StatusCode, e)
 			} else {
 				errRes := rferrors.InternalServerError("internal server error")
 				werr = response.WriteJSON(w, errRes.StatusCode, errRes)
 			}
-			slog.Error("HTTP API error", "err", err.Error(), "path", r.URL.Path)
+			requestID := rfcontext.RequestInfoFromContext(r.Context()).ID
+			slog.Error("HTTP API error", "err", err.Error(), "path", r.URL.Path, "requestID", requestID)
 			if werr == nil {
 				return
 			}
-			slog.Error("HTTP API error", "werr", werr.Error(), "path", r.URL.Path)
+			slog.Error("HTTP API error", "werr", werr.Error(), "path", r.URL.Path, "requestID", requestID)
 		}
 	}
 }
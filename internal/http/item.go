@@ -0,0 +1,98 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/request"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
+)
+
+func (s *APIServer) registerItemRoutes(r *http.ServeMux) {
+	r.Handle("GET /api/v1/items/{itemID}", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleItem())))
+	r.Handle("PUT /api/v1/items/{itemID}/star", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleStarItem(true))))
+	r.Handle("DELETE /api/v1/items/{itemID}/star", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleStarItem(false))))
+	r.Handle("PUT /api/v1/items/{itemID}/save", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleSaveItem(true))))
+	r.Handle("DELETE /api/v1/items/{itemID}/save", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleSaveItem(false))))
+	r.Handle("PUT /api/v1/items/{itemID}/enclosures/{enclosureID}/position", makeHTTPHandlerFunc(s.handleAuthRequired(s.handlePlaybackPosition())))
+}
+
+func (s *APIServer) handleItem() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		itemID, err := strconv.ParseInt(r.PathValue("itemID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		item, err := s.ItemService.GetItem(r.Context(), itemID)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, item)
+	}
+}
+
+func (s *APIServer) handleStarItem(starred bool) APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		itemID, err := strconv.ParseInt(r.PathValue("itemID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		if err := s.ItemService.StarItem(r.Context(), itemID, starred); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handleSaveItem(saved bool) APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		itemID, err := strconv.ParseInt(r.PathValue("itemID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		if err := s.ItemService.SaveItem(r.Context(), itemID, saved); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func (s *APIServer) handlePlaybackPosition() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		itemID, err := strconv.ParseInt(r.PathValue("itemID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		enclosureID, err := strconv.ParseInt(r.PathValue("enclosureID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		req := &rf.PlaybackPositionRequest{}
+
+		if err := request.ReadJSON(w, r, req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		req.ItemID = itemID
+		req.EnclosureID = enclosureID
+
+		if err := s.ItemService.SavePlaybackPosition(r.Context(), req); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
@@ -0,0 +1,164 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/itemservice"
+	"github.com/matryer/is"
+)
+
+func TestItemAPI_PlaybackPosition(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	newAuthStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(userID).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithEmail("gopher@go.com")).
+					Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+		}
+	}
+
+	newItemStore := func() *mock.ItemStore {
+		return &mock.ItemStore{
+			SavePlaybackPositionFn: func(ctx context.Context, position *rf.PlaybackPosition) error {
+				return nil
+			},
+		}
+	}
+
+	newRequest := func(body string) *http.Request {
+		token, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(http.MethodPut, "/api/v1/items/2/enclosures/3/position", strings.NewReader(body))
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		return request
+	}
+
+	t.Run("PUT a position saves it", func(t *testing.T) {
+		t.Parallel()
+
+		itemStore := newItemStore()
+		s := makeAuthAPIServer(newAuthStore())
+		s.ItemService = itemservice.NewItemService(itemStore)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(`{"positionSeconds":42}`))
+
+		is.Equal(response.Code, http.StatusNoContent)  // should save the position
+		is.True(itemStore.SavePlaybackPositionInvoked) // should store the position
+	})
+
+	t.Run("PUT a null body does not panic", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newAuthStore())
+		s.ItemService = itemservice.NewItemService(newItemStore())
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(`null`))
+
+		is.True(response.Code != http.StatusInternalServerError) // should not fail on a null body
+	})
+}
+
+func TestItemAPI_StarAndSave(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	newAuthStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(userID).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithEmail("gopher@go.com")).
+					Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+		}
+	}
+
+	newRequest := func(method, path string) *http.Request {
+		token, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(method, path, nil)
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		return request
+	}
+
+	t.Run("PUT star stars the item", func(t *testing.T) {
+		t.Parallel()
+
+		var starredID int64
+		var starred bool
+		itemStore := &mock.ItemStore{
+			StarItemFn: func(ctx context.Context, userID, itemID int64, on bool) error {
+				starredID, starred = itemID, on
+				return nil
+			},
+		}
+		s := makeAuthAPIServer(newAuthStore())
+		s.ItemService = itemservice.NewItemService(itemStore)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodPut, "/api/v1/items/2/star"))
+
+		is.Equal(response.Code, http.StatusNoContent) // should star the item
+		is.Equal(starredID, int64(2))                 // should star the item of the path
+		is.True(starred)                              // should star rather than unstar
+	})
+
+	t.Run("DELETE save stops saving the item", func(t *testing.T) {
+		t.Parallel()
+
+		saved := true
+		itemStore := &mock.ItemStore{
+			SaveItemFn: func(ctx context.Context, userID, itemID int64, on bool) error {
+				saved = on
+				return nil
+			},
+		}
+		s := makeAuthAPIServer(newAuthStore())
+		s.ItemService = itemservice.NewItemService(itemStore)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodDelete, "/api/v1/items/2/save"))
+
+		is.Equal(response.Code, http.StatusNoContent) // should stop saving the item
+		is.True(itemStore.SaveItemInvoked)            // should store the change
+		is.True(!saved)                               // should unsave rather than save
+	})
+}
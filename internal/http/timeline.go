@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
+)
+
+func (s *APIServer) registerTimelineRoutes(r *http.ServeMux) {
+	r.Handle("GET /api/v1/timeline", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleTimeline())))
+	r.Handle("POST /api/v1/timeline/{storyID}/read", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleTimelineRead())))
+}
+
+func (s *APIServer) handleTimeline() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		req := &rf.TimelineRequest{}
+
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil {
+				return errors.BadRequestError(errors.ErrInvalidLimit)
+			}
+			req.Limit = n
+		}
+
+		if before := r.URL.Query().Get("before"); before != "" {
+			t, err := time.Parse(time.RFC3339, before)
+			if err != nil {
+				return errors.BadRequestError(errors.ErrInvalidBefore)
+			}
+			req.Before = t
+		}
+
+		entries, err := s.ItemService.GetTimeline(r.Context(), req)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, entries)
+	}
+}
+
+func (s *APIServer) handleTimelineRead() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		storyID, err := strconv.ParseInt(r.PathValue("storyID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		if err := s.ItemService.MarkStoryRead(r.Context(), storyID); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
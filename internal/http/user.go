@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/request"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/response"
+)
+
+func (s *APIServer) registerUserRoutes(r *http.ServeMux) {
+	r.Handle("GET /api/v1/me", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleProfile())))
+	r.Handle("PATCH /api/v1/me", makeHTTPHandlerFunc(s.handleAuthRequired(s.handleProfileUpdate())))
+	r.Handle("POST /api/v1/me/password", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleChangePassword())))
+	r.Handle("DELETE /api/v1/me", makeHTTPHandlerFunc(s.handleSessionRequired(s.handleDeleteAccount())))
+}
+
+func (s *APIServer) handleProfile() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		user, err := s.UserService.GetProfile(r.Context())
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, user)
+	}
+}
+
+func (s *APIServer) handleProfileUpdate() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.UpdateProfileRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		user, err := s.UserService.UpdateProfile(r.Context(), req)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		return response.WriteJSON(w, http.StatusOK, user)
+	}
+}
+
+func (s *APIServer) handleChangePassword() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req *rf.ChangePasswordRequest
+
+		if err := request.ReadJSON(w, r, &req); err != nil {
+			return errors.MalformedDataError(err)
+		}
+
+		if err := s.AuthService.ChangePassword(r.Context(), req); err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// handleDeleteAccount deletes the account and signs this device out, the
+// response says until when signing in restores it.
+func (s *APIServer) handleDeleteAccount() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		deletion, err := s.AuthService.DeleteAccount(r.Context())
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		s.clearAuthCookies(w)
+
+		return response.WriteJSON(w, http.StatusAccepted, deletion)
+	}
+}
@@ -0,0 +1,161 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/userservice"
+	"github.com/matryer/is"
+)
+
+func TestUserAPI_Me(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	newAuthStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(userID).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithEmail("gopher@go.com")).
+					Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+		}
+	}
+
+	newUserStore := func() *mock.UserStore {
+		return &mock.UserStore{
+			FindUserFn: func(ctx context.Context, userID int64) (*rf.User, error) {
+				return &rf.User{ID: userID, Name: "Gopher", Timezone: "UTC", Preferences: map[string]any{}}, nil
+			},
+			UpdateUserFn: func(ctx context.Context, user *rf.User) error {
+				return nil
+			},
+		}
+	}
+
+	newRequest := func(method, body string) *http.Request {
+		token, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+		is.NoErr(err) // should sign a token
+
+		request, err := http.NewRequest(method, "/api/v1/me", strings.NewReader(body))
+		is.NoErr(err) // should be a successful request
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		return request
+	}
+
+	t.Run("GET /api/v1/me returns the profile", func(t *testing.T) {
+		t.Parallel()
+
+		s := makeAuthAPIServer(newAuthStore())
+		s.UserService = userservice.NewUserService(newUserStore())
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodGet, ""))
+
+		is.Equal(response.Code, http.StatusOK) // should return the profile
+
+		var user rf.User
+		is.NoErr(json.NewDecoder(response.Body).Decode(&user)) // should decode the profile
+		is.Equal(user.Name, "Gopher")                          // should be the user's name
+		is.Equal(user.Timezone, "UTC")                         // should be the user's timezone
+	})
+
+	t.Run("PATCH /api/v1/me updates the profile", func(t *testing.T) {
+		t.Parallel()
+
+		userStore := newUserStore()
+		s := makeAuthAPIServer(newAuthStore())
+		s.UserService = userservice.NewUserService(userStore)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodPatch, `{"name":"Go Gopher","preferences":{"theme":"dark"}}`))
+
+		is.Equal(response.Code, http.StatusOK) // should update the profile
+		is.True(userStore.UpdateUserInvoked)   // should store the profile
+
+		var user rf.User
+		is.NoErr(json.NewDecoder(response.Body).Decode(&user))      // should decode the profile
+		is.Equal(user.Name, "Go Gopher")                            // should change the name
+		is.Equal(user.Preferences, map[string]any{"theme": "dark"}) // should set the preferences
+	})
+
+	t.Run("PATCH /api/v1/me with an invalid timezone returns 400", func(t *testing.T) {
+		t.Parallel()
+
+		userStore := newUserStore()
+		s := makeAuthAPIServer(newAuthStore())
+		s.UserService = userservice.NewUserService(userStore)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodPatch, `{"timezone":"Nowhere/Else"}`))
+
+		is.Equal(response.Code, http.StatusBadRequest) // should reject the timezone
+		is.True(!userStore.UpdateUserInvoked)          // should not store the profile
+	})
+
+	t.Run("DELETE /api/v1/me deletes the account and clears the cookies", func(t *testing.T) {
+		t.Parallel()
+
+		store := newAuthStore()
+		store.DeleteAccountFn = func(ctx context.Context, userID int64, purgeAt time.Time) error {
+			return nil
+		}
+		s := makeAuthAPIServer(store)
+
+		response := httptest.NewRecorder()
+
+		s.ServeHTTP(response, newRequest(http.MethodDelete, ""))
+
+		is.Equal(response.Code, http.StatusAccepted) // should accept the deletion
+		is.True(store.DeleteAccountInvoked)          // should delete the account
+
+		var deletion rf.AccountDeletion
+		is.NoErr(json.NewDecoder(response.Body).Decode(&deletion)) // should decode the deletion
+		is.True(deletion.PurgeAt.After(time.Now()))                // should say when the account is purged
+
+		cleared := 0
+		for _, c := range response.Result().Cookies() {
+			if c.MaxAge < 0 {
+				cleared++
+			}
+		}
+		is.Equal(cleared, 3) // should clear the auth and csrf cookies
+	})
+
+	t.Run("POST /api/v1/me/password with a wrong current password returns 401", func(t *testing.T) {
+		t.Parallel()
+
+		store := newAuthStore()
+		s := makeAuthAPIServer(store)
+
+		response := httptest.NewRecorder()
+
+		request := newRequest(http.MethodPost, `{"currentPassword":"wrong","newPassword":"gogopher2"}`)
+		request.URL.Path = "/api/v1/me/password"
+
+		s.ServeHTTP(response, request)
+
+		is.Equal(response.Code, http.StatusUnauthorized) // should check the current password
+		is.True(!store.ChangePasswordInvoked)            // should not change the password
+	})
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/websub"
+)
+
+// maxWebSubBodyBytes bounds the size of content distributed by a hub.
+const maxWebSubBodyBytes = 10 << 20
+
+func (s *APIServer) registerWebSubRoutes(r *http.ServeMux) {
+	r.Handle("GET /api/v1/websub/{feedID}", makeHTTPHandlerFunc(s.handleWebSubVerify()))
+	r.Handle("POST /api/v1/websub/{feedID}", makeHTTPHandlerFunc(s.handleWebSubContent()))
+}
+
+func (s *APIServer) webSubCallbackURL(feedID int64) string {
+	base := rf.Config.PublicURL
+	if base == "" {
+		base = s.URL()
+	}
+	return fmt.Sprintf("%s/api/v1/websub/%d", strings.TrimSuffix(base, "/"), feedID)
+}
+
+func (s *APIServer) handleWebSubVerify() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		feedID, err := strconv.ParseInt(r.PathValue("feedID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		query := r.URL.Query()
+		leaseSeconds, _ := strconv.Atoi(query.Get("hub.lease_seconds"))
+
+		v := &rf.WebSubVerification{
+			FeedID:       feedID,
+			Mode:         query.Get("hub.mode"),
+			Topic:        query.Get("hub.topic"),
+			Challenge:    query.Get("hub.challenge"),
+			LeaseSeconds: leaseSeconds,
+		}
+
+		challenge, err := s.WebSubService.VerifyIntent(r.Context(), v)
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		if v.Mode == websub.ModeDenied {
+			w.WriteHeader(http.StatusOK)
+			return nil
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		_, err = io.WriteString(w, challenge)
+		return err
+	}
+}
+
+func (s *APIServer) handleWebSubContent() APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		feedID, err := strconv.ParseInt(r.PathValue("feedID"), 10, 64)
+		if err != nil {
+			return errors.BadRequestError(errors.ErrInvalidID)
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebSubBodyBytes))
+		if err != nil {
+			return errors.MalformedDataError(err.Error())
+		}
+
+		err = s.WebSubService.Ingest(r.Context(), feedID, r.Header.Get("X-Hub-Signature"), body)
+		if errors.ToReferenceCode(err) == errors.Unauthorized {
+			// Content with an invalid signature is acknowledged but ignored
+			// so the hub cannot be used to probe for valid secrets.
+			slog.Warn("WebSub content ignored", "err", err.Error(), "feedID", feedID)
+			w.WriteHeader(http.StatusAccepted)
+			return nil
+		}
+		if err != nil {
+			return errors.ToAPIError(err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+}
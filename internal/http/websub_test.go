@@ -0,0 +1,271 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/fetch"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/parser"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/feedservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/itemservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/syncservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/websubservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/memstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/websub"
+	"github.com/matryer/is"
+)
+
+// hubStandIn is a minimal WebSub hub that verifies intent synchronously and
+// can publish signed content to its subscriber.
+type hubStandIn struct {
+	mu        sync.Mutex
+	callback  string
+	secret    string
+	verified  bool
+	challenge string
+}
+
+func (h *hubStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("hub.mode") != websub.ModeSubscribe {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.callback = r.Form.Get("hub.callback")
+	h.secret = r.Form.Get("hub.secret")
+	h.challenge = "challenge-123"
+
+	query := url.Values{
+		"hub.mode":          {websub.ModeSubscribe},
+		"hub.topic":         {r.Form.Get("hub.topic")},
+		"hub.challenge":     {h.challenge},
+		"hub.lease_seconds": {"86400"},
+	}
+	res, err := http.Get(h.callback + "?" + query.Encode())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	h.verified = res.StatusCode == http.StatusOK && string(body) == h.challenge
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *hubStandIn) publish(body []byte, signature string) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, h.callback, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/rss+xml")
+	req.Header.Set("X-Hub-Signature", signature)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	return res.StatusCode, nil
+}
+
+func TestWebSubAPI_SubscribeVerifyAndIngest(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	var mu sync.Mutex
+	var saved *rf.WebSubSubscription
+	var pollInterval time.Duration
+
+	store := &mock.WebSubStore{
+		FindSubscriptionByFeedIDFn: func(ctx context.Context, feedID int64) (*rf.WebSubSubscription, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if saved == nil {
+				return nil, nil
+			}
+			sub := *saved
+			return &sub, nil
+		},
+		SaveSubscriptionFn: func(ctx context.Context, sub *rf.WebSubSubscription) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sub.ID = 1
+			copied := *sub
+			saved = &copied
+			return nil
+		},
+		SetFeedPollIntervalFn: func(ctx context.Context, feedID int64, interval time.Duration) error {
+			mu.Lock()
+			defer mu.Unlock()
+			pollInterval = interval
+			return nil
+		},
+	}
+
+	var synced []*rf.Item
+	syncer := &mock.ItemSyncer{
+		SyncItemsFn: func(ctx context.Context, channel *rf.Channel, items []*rf.Item) error {
+			synced = items
+			return nil
+		},
+	}
+
+	s := makeAuthAPIServer(&mock.AuthStore{})
+	service := websubservice.NewWebSubService(store, websub.NewClient(fetch.NewClient(fetch.Options{AllowPrivateNetworks: true}).HTTPClient()), syncer)
+	s.WebSubService = service
+
+	api := httptest.NewServer(s)
+	t.Cleanup(api.Close)
+
+	service.CallbackURL = func(feedID int64) string {
+		return fmt.Sprintf("%s/api/v1/websub/%d", api.URL, feedID)
+	}
+
+	hub := &hubStandIn{}
+	hubServer := httptest.NewServer(hub)
+	t.Cleanup(hubServer.Close)
+
+	feed := &parser.Feed{
+		HubURL:  hubServer.URL,
+		SelfURL: "http://feed.com/rss",
+	}
+
+	err := service.SubscribeFeed(context.Background(), 1, feed)
+
+	is.NoErr(err)                                               // should subscribe to the hub
+	is.True(hub.verified)                                       // should echo the hub challenge
+	is.Equal(saved.State, rf.WebSubStateActive)                 // should activate the subscription
+	is.Equal(saved.LeaseSeconds, 86400)                         // should use the lease granted by the hub
+	is.Equal(pollInterval, websubservice.SafetyNetPollInterval) // should drop to the safety net poll interval
+
+	body := []byte(`<rss version="2.0"><channel><title>Go</title>` +
+		`<item><title>Pushed</title><link>http://feed.com/pushed</link></item></channel></rss>`)
+
+	code, err := hub.publish(body, websub.Sign(hub.secret, body))
+
+	is.NoErr(err)                       // should publish content
+	is.Equal(code, http.StatusAccepted) // should accept content
+	is.True(syncer.SyncItemsInvoked)    // should ingest pushed content
+	is.Equal(len(synced), 1)            // should ingest every item
+	is.Equal(synced[0].Title, "Pushed") // should parse pushed items
+
+	syncer.SyncItemsInvoked = false
+	code, err = hub.publish(body, websub.Sign("wrong secret", body))
+
+	is.NoErr(err)                       // should publish content
+	is.Equal(code, http.StatusAccepted) // should acknowledge content with a bad signature
+	is.True(!syncer.SyncItemsInvoked)   // should ignore content with a bad signature
+
+	query := url.Values{
+		"hub.mode":      {websub.ModeSubscribe},
+		"hub.topic":     {"http://other.com/rss"},
+		"hub.challenge": {"nope"},
+	}
+	res, err := http.Get(service.CallbackURL(1) + "?" + query.Encode())
+	is.NoErr(err)
+	res.Body.Close()
+
+	is.Equal(res.StatusCode, http.StatusNotFound) // should refuse to verify an unknown topic
+}
+
+func TestWebSubAPI_PollSubscribeVerifyAndIngest(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	hub := &hubStandIn{}
+	hubServer := httptest.NewServer(hub)
+	t.Cleanup(hubServer.Close)
+
+	var feedURL string
+	feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Go</title>`+
+			`<atom:link rel="hub" href="%s"/><atom:link rel="self" href="%s"/>`+
+			`<item><title>Polled</title><link>http://feed.com/polled</link></item></channel></rss>`, hubServer.URL, feedURL)
+	}))
+	t.Cleanup(feedServer.Close)
+	feedURL = feedServer.URL + "/rss"
+
+	db := memstore.NewDB()
+	auth := builder.NewAuthBuilder().
+		WithUser(builder.NewUserBuilder().WithName("Gopher")).
+		WithBasicAuth(builder.NewBasicAuthBuilder().WithEmail("gopher@go.com").WithPassword("password-hash")).
+		Build()
+	err := memstore.NewAuthStore(db).CreateAuthAndUser(context.Background(), auth)
+	is.NoErr(err) // should create the user
+
+	fetchClient := fetch.NewClient(fetch.Options{AllowPrivateNetworks: true})
+	feedStore := memstore.NewFeedStore(db)
+	itemService := itemservice.NewItemService(memstore.NewItemStore(db))
+	webSubService := websubservice.NewWebSubService(memstore.NewWebSubStore(db), websub.NewClient(fetchClient.HTTPClient()), itemService)
+	syncService := syncservice.NewSyncService(feedStore, fetchClient, itemService)
+	syncService.WebSub = webSubService
+
+	s := makeAuthAPIServer(&mock.AuthStore{})
+	s.WebSubService = webSubService
+
+	api := httptest.NewServer(s)
+	t.Cleanup(api.Close)
+
+	webSubService.CallbackURL = func(feedID int64) string {
+		return fmt.Sprintf("%s/api/v1/websub/%d", api.URL, feedID)
+	}
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), auth.UserID)
+	feedID, err := feedservice.NewFeedService(feedStore).AddFeed(ctx, &rf.AddFeedRequest{Name: "Go", URL: feedURL})
+	is.NoErr(err) // should add the feed
+
+	err = syncService.SyncDueFeeds(context.Background())
+
+	is.NoErr(err)         // should sync the new feed
+	is.True(hub.verified) // should subscribe to the hub the feed advertises
+
+	feed, err := feedStore.FindByURL(context.Background(), feedURL)
+	is.NoErr(err)
+	is.Equal(feed.PollInterval, websubservice.SafetyNetPollInterval) // should drop to the safety net poll interval
+
+	due, err := feedStore.ListDueFeeds(context.Background(), syncservice.BatchSize)
+	is.NoErr(err)
+	is.Equal(len(due), 0) // should not poll the feed again until its interval passes
+
+	body := []byte(`<rss version="2.0"><channel><title>Go</title>` +
+		`<item><title>Pushed</title><link>http://feed.com/pushed</link></item></channel></rss>`)
+
+	code, err := hub.publish(body, websub.Sign(hub.secret, body))
+
+	is.NoErr(err)                       // should publish content
+	is.Equal(code, http.StatusAccepted) // should accept content
+
+	entries, err := itemService.GetTimeline(ctx, &rf.TimelineRequest{})
+	is.NoErr(err)
+
+	titles := []string{}
+	for _, entry := range entries {
+		is.Equal(entry.Sources[0].FeedID, feedID) // should keep items of the feed
+		titles = append(titles, entry.Title)
+	}
+	slices.Sort(titles)
+
+	is.Equal(titles, []string{"Polled", "Pushed"}) // should have polled and pushed items
+}
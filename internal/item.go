@@ -0,0 +1,118 @@
+package rf
+
+import (
+	"time"
+)
+
+type ItemIdentity string
+
+const (
+	ItemIdentityGUID ItemIdentity = "guid"
+	ItemIdentityLink ItemIdentity = "link"
+	ItemIdentityHash ItemIdentity = "hash"
+)
+
+type Channel struct {
+	ID          int64     `json:"id"`
+	FeedID      int64     `json:"feedID"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Link        string    `json:"link"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ModifiedAt  time.Time `json:"modifiedAt"`
+}
+
+type Item struct {
+	ID              int64     `json:"id"`
+	ChannelID       int64     `json:"channelID"`
+	GUID            string    `json:"guid"`
+	GUIDIsPermaLink bool      `json:"-"`
+	IdentityKey     string    `json:"-"`
+	CanonicalLink   string    `json:"-"`
+	SimHash         uint64    `json:"-"`
+	StoryID         int64     `json:"storyID"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	Content         string    `json:"content"`
+	FullContent     string    `json:"fullContent,omitempty"`
+	Link            string    `json:"link"`
+	PublishedAt     time.Time `json:"publishedAt"`
+	CreatedAt       time.Time `json:"createdAt"`
+	ModifiedAt      time.Time `json:"modifiedAt"`
+
+	Enclosures []Enclosure `json:"enclosures"`
+
+	// FetchFullContent is set when the requesting user's subscription asks
+	// for the full article.
+	FetchFullContent bool `json:"-"`
+}
+
+// ItemContent is the cached full article of an item, Error is set instead
+// of Content when the extraction failed.
+type ItemContent struct {
+	ItemID    int64
+	Content   string
+	Error     string
+	FetchedAt time.Time
+}
+
+type Enclosure struct {
+	ID              int64     `json:"id"`
+	ItemID          int64     `json:"itemID"`
+	URL             string    `json:"url"`
+	MIMEType        string    `json:"mimeType"`
+	Length          int64     `json:"length"`
+	Medium          string    `json:"medium"`
+	Width           int       `json:"width,omitempty"`
+	Height          int       `json:"height,omitempty"`
+	DurationSeconds int       `json:"durationSeconds,omitempty"`
+	ThumbnailURL    string    `json:"thumbnailURL,omitempty"`
+	ImageURL        string    `json:"imageURL,omitempty"`
+	Episode         int       `json:"episode,omitempty"`
+	Explicit        bool      `json:"explicit"`
+	CreatedAt       time.Time `json:"createdAt"`
+	ModifiedAt      time.Time `json:"modifiedAt"`
+
+	Playback *PlaybackPosition `json:"playback,omitempty"`
+}
+
+type PlaybackPosition struct {
+	UserID          int64     `json:"-"`
+	ItemID          int64     `json:"itemID"`
+	EnclosureID     int64     `json:"enclosureID"`
+	PositionSeconds int       `json:"positionSeconds"`
+	Completed       bool      `json:"completed"`
+	ModifiedAt      time.Time `json:"modifiedAt"`
+}
+
+type PlaybackPositionRequest struct {
+	PositionSeconds int   `json:"positionSeconds"`
+	Completed       bool  `json:"completed"`
+	ItemID          int64 `json:"-"`
+	EnclosureID     int64 `json:"-"`
+}
+
+type TimelineEntry struct {
+	StoryID     int64            `json:"storyID"`
+	Title       string           `json:"title"`
+	Link        string           `json:"link"`
+	PublishedAt time.Time        `json:"publishedAt"`
+	Read        bool             `json:"read"`
+	Sources     []TimelineSource `json:"sources"`
+}
+
+type TimelineSource struct {
+	FeedID      int64     `json:"feedID"`
+	FeedName    string    `json:"feedName"`
+	ItemID      int64     `json:"itemID"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	PublishedAt time.Time `json:"publishedAt"`
+	Read        bool      `json:"read"`
+}
+
+type TimelineRequest struct {
+	Limit  int
+	Before time.Time
+	UserID int64
+}
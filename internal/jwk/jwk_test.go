@@ -0,0 +1,54 @@
+package jwk_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwk"
+	"github.com/matryer/is"
+)
+
+func TestKey_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	is.NoErr(err) // should generate an RSA key
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	is.NoErr(err) // should generate an EC key
+
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	is.NoErr(err) // should generate an Ed25519 key
+
+	for alg, key := range map[string]crypto.PublicKey{
+		"RS256": &rsaKey.PublicKey,
+		"ES256": &ecKey.PublicKey,
+		"EdDSA": edKey,
+	} {
+		k, err := jwk.New("kid", key)
+		is.NoErr(err)          // should encode the key
+		is.Equal(k.Alg, alg)   // should name the algorithm
+		is.Equal(k.Kid, "kid") // should keep the key id
+
+		b, err := json.Marshal(jwk.Set{Keys: []jwk.Key{k}})
+		is.NoErr(err) // should marshal
+
+		var set jwk.Set
+		is.NoErr(json.Unmarshal(b, &set)) // should unmarshal
+
+		decoded, err := set.Keys[0].PublicKey()
+		is.NoErr(err) // should decode the key
+
+		equal, ok := decoded.(interface{ Equal(crypto.PublicKey) bool })
+		is.True(ok)               // should be a public key
+		is.True(equal.Equal(key)) // should be the same key
+	}
+}
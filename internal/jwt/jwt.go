@@ -1,50 +1,236 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
 	"time"
 
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
-	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Leeway allows for clock skew between the services verifying tokens.
+const Leeway = 30 * time.Second
+
+// Claims are the claims of every token, sub is the user id. Tokens with a
+// purpose are not access tokens.
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID int64   `json:"sid,omitempty"`
+	Role      rf.Role `json:"role,omitempty"`
+	Purpose   string  `json:"purpose,omitempty"`
+
+	// LinkIssuer, LinkSubject and LinkEmail name the identity an
+	// oidc_link token links.
+	LinkIssuer  string `json:"link_iss,omitempty"`
+	LinkSubject string `json:"link_sub,omitempty"`
+	LinkEmail   string `json:"link_email,omitempty"`
+}
+
+func (c *Claims) userID() (int64, error) {
+	userID, err := strconv.ParseInt(c.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, rferrors.Unauthorizedf(rferrors.ErrTokenClaimsFailed)
+	}
+	return userID, nil
+}
+
+// Access is who an access token was issued to.
+type Access struct {
+	UserID    int64
+	SessionID int64
+	Role      rf.Role
+}
+
 func GenerateAndSignUserID(userID int64, ttl time.Time) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": userID,
-		"ttl":    ttl.Unix(),
+	return GenerateAndSignUserSession(userID, 0, rf.RoleUser, ttl)
+}
+
+// GenerateAndSignUserSession signs an access token for userID with role
+// bound to the server side session it was issued for.
+func GenerateAndSignUserSession(userID, sessionID int64, role rf.Role, ttl time.Time) (string, error) {
+	if role == "" {
+		role = rf.RoleUser
+	}
+	return sign(userID, ttl, &Claims{SessionID: sessionID, Role: role})
+}
+
+// mfaPendingPurpose marks a token that only proves the password was right,
+// it must be exchanged with a second factor for an access token.
+const mfaPendingPurpose = "mfa_pending"
+
+// GenerateAndSignMFAPending signs a short lived token for userID to
+// complete a sign in with a second factor.
+func GenerateAndSignMFAPending(userID int64, ttl time.Time) (string, error) {
+	return sign(userID, ttl, &Claims{Purpose: mfaPendingPurpose})
+}
+
+func ParseAndVerifyMFAPending(tokenString string) (int64, error) {
+	claims, err := parseAndVerify(tokenString)
+	if err != nil {
+		return 0, err
+	}
+
+	if claims.Purpose != mfaPendingPurpose {
+		return 0, rferrors.Unauthorizedf(rferrors.ErrTokenClaimsFailed)
+	}
+
+	return claims.userID()
+}
+
+// oidcLinkPurpose marks a token that proves a sign in with an OpenID
+// Connect provider whose email belongs to an existing account.
+const oidcLinkPurpose = "oidc_link"
+
+// GenerateAndSignOIDCLink signs a short lived token to link subject at
+// issuer to the account of userID once its password is given.
+func GenerateAndSignOIDCLink(userID int64, issuer, subject, email string, ttl time.Time) (string, error) {
+	return sign(userID, ttl, &Claims{
+		Purpose:     oidcLinkPurpose,
+		LinkIssuer:  issuer,
+		LinkSubject: subject,
+		LinkEmail:   email,
 	})
+}
 
-	tokenString, err := token.SignedString([]byte(rf.Config.JWTSecret))
+func ParseAndVerifyOIDCLink(tokenString string) (*rf.AuthIdentity, error) {
+	claims, err := parseAndVerify(tokenString)
 	if err != nil {
-		return "", errors.InternalErrorf("%s: %v", errors.ErrTokenGenerationFailed, err)
+		return nil, err
 	}
 
-	return tokenString, nil
+	if claims.Purpose != oidcLinkPurpose || claims.LinkIssuer == "" || claims.LinkSubject == "" {
+		return nil, rferrors.Unauthorizedf(rferrors.ErrTokenClaimsFailed)
+	}
+
+	userID, err := claims.userID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rf.AuthIdentity{
+		UserID:  userID,
+		Issuer:  claims.LinkIssuer,
+		Subject: claims.LinkSubject,
+		Email:   claims.LinkEmail,
+	}, nil
 }
 
 func ParseAndVerifyUserID(tokenString string) (int64, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.InternalErrorf("%s: %v", errors.ErrTokenUnexpactedSigningMethod, token.Header["alg"])
-		}
+	userID, _, err := ParseAndVerifyUserSession(tokenString)
+	return userID, err
+}
 
-		return []byte(rf.Config.JWTSecret), nil
-	})
+func ParseAndVerifyUserSession(tokenString string) (int64, int64, error) {
+	access, err := ParseAndVerifyAccess(tokenString)
 	if err != nil {
-		return 0, errors.Unauthorizedf("%s: %v", errors.ErrTokenParseFailed, err)
+		return 0, 0, err
 	}
+	return access.UserID, access.SessionID, nil
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, errors.Unauthorizedf(errors.ErrTokenClaimsFailed)
+// ParseAndVerifyAccess verifies an access token, tokens issued before roles
+// were added carry the user role.
+func ParseAndVerifyAccess(tokenString string) (*Access, error) {
+	claims, err := parseAndVerify(tokenString)
+	if err != nil {
+		return nil, err
 	}
 
-	ttl := int64(claims["ttl"].(float64))
-	userID := int64(claims["userID"].(float64))
+	if claims.Purpose != "" {
+		return nil, rferrors.Unauthorizedf(rferrors.ErrTokenClaimsFailed)
+	}
 
-	if ttl < time.Now().Unix() {
-		return 0, errors.Unauthorizedf(errors.ErrTokenExpired)
+	userID, err := claims.userID()
+	if err != nil {
+		return nil, err
 	}
 
-	return userID, nil
+	role := claims.Role
+	if role == "" {
+		role = rf.RoleUser
+	}
+
+	return &Access{
+		UserID:    userID,
+		SessionID: claims.SessionID,
+		Role:      role,
+	}, nil
+}
+
+func sign(userID int64, expiresAt time.Time, claims *Claims) (string, error) {
+	claims.Subject = strconv.FormatInt(userID, 10)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+
+	return CurrentKeySet().Sign(claims)
+}
+
+func parseAndVerify(tokenString string) (*Claims, error) {
+	return CurrentKeySet().Verify(tokenString)
+}
+
+// Sign fills in the issuer, audience, issue time and id of claims and signs
+// them with the signing key, the subject and expiry are up to the caller.
+func (ks *KeySet) Sign(claims *Claims) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", rferrors.InternalErrorf("%s: %v", rferrors.ErrTokenGenerationFailed, err)
+	}
+
+	now := jwt.NewNumericDate(time.Now())
+	claims.Issuer = ks.Issuer
+	claims.Audience = jwt.ClaimStrings{ks.Audience}
+	claims.NotBefore = now
+	claims.IssuedAt = now
+	claims.ID = base64.RawURLEncoding.EncodeToString(id)
+
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+
+	tokenString, err := token.SignedString(ks.signing.Private)
+	if err != nil {
+		return "", rferrors.InternalErrorf("%s: %v", rferrors.ErrTokenGenerationFailed, err)
+	}
+
+	return tokenString, nil
+}
+
+// Verify checks the signature, issuer, audience and lifetime of
+// tokenString against the key named by its kid header.
+func (ks *KeySet) Verify(tokenString string) (*Claims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(ks.methods()),
+		jwt.WithIssuer(ks.Issuer),
+		jwt.WithAudience(ks.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(Leeway),
+	)
+
+	claims := &Claims{}
+	_, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, rferrors.Unauthorizedf("%s: unknown key id %q", rferrors.ErrTokenParseFailed, kid)
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, rferrors.InternalErrorf("%s: %v", rferrors.ErrTokenUnexpactedSigningMethod, token.Header["alg"])
+		}
+
+		return key.Public, nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, rferrors.Unauthorizedf(rferrors.ErrTokenExpired)
+		}
+		return nil, rferrors.Unauthorizedf("%s: %v", rferrors.ErrTokenParseFailed, err)
+	}
+
+	return claims, nil
 }
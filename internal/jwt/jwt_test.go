@@ -1,11 +1,16 @@
 package jwt_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"testing"
 	"time"
 
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	gojwt "github.com/golang-jwt/jwt/v5"
 	"github.com/matryer/is"
 )
 
@@ -31,3 +36,183 @@ func TestJWT(t *testing.T) {
 	is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // shoud have error code
 	is.Equal(errors.ToErr(err), errors.ErrTokenExpired)        // shoud have token expired error
 }
+
+func TestJWT_MFAPending(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	token, err := jwt.GenerateAndSignMFAPending(1, time.Now().Add(time.Minute))
+	is.NoErr(err) // should sign a token
+
+	userID, err := jwt.ParseAndVerifyMFAPending(token)
+	is.NoErr(err)              // should verify
+	is.Equal(userID, int64(1)) // should carry the user id
+
+	_, _, err = jwt.ParseAndVerifyUserSession(token)
+	is.Equal(errors.ToErr(err), errors.ErrTokenClaimsFailed) // should not be accepted as an access token
+
+	accessToken, err := jwt.GenerateAndSignUserSession(1, 1, rf.RoleUser, time.Now().Add(time.Minute))
+	is.NoErr(err) // should sign a token
+
+	_, err = jwt.ParseAndVerifyMFAPending(accessToken)
+	is.Equal(errors.ToErr(err), errors.ErrTokenClaimsFailed) // access tokens should not complete a sign in
+}
+
+func TestJWT_RegisteredClaims(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	token, err := jwt.GenerateAndSignUserSession(1, 2, rf.RoleUser, time.Now().Add(time.Minute))
+	is.NoErr(err) // should sign a token
+
+	claims, err := jwt.CurrentKeySet().Verify(token)
+	is.NoErr(err)                                                               // should verify
+	is.Equal(claims.Subject, "1")                                               // should carry the user id as subject
+	is.Equal(claims.SessionID, int64(2))                                        // should carry the session
+	is.Equal(claims.Issuer, jwt.CurrentKeySet().Issuer)                         // should name the issuer
+	is.Equal([]string(claims.Audience), []string{jwt.CurrentKeySet().Audience}) // should name the audience
+	is.True(claims.ID != "")                                                    // should have a token id
+	is.True(claims.IssuedAt != nil && claims.NotBefore != nil)                  // should have issue times
+
+	noSubject, err := jwt.CurrentKeySet().Sign(&jwt.Claims{
+		RegisteredClaims: gojwt.RegisteredClaims{ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute))},
+	})
+	is.NoErr(err) // should sign a token
+
+	_, _, err = jwt.ParseAndVerifyUserSession(noSubject)
+	is.Equal(errors.ToErr(err), errors.ErrTokenClaimsFailed) // should reject a token without a subject
+
+	noExpiry, err := jwt.CurrentKeySet().Sign(&jwt.Claims{
+		RegisteredClaims: gojwt.RegisteredClaims{Subject: "1"},
+	})
+	is.NoErr(err) // should sign a token
+
+	_, _, err = jwt.ParseAndVerifyUserSession(noExpiry)
+	is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // should reject a token without an expiry
+}
+
+func TestJWT_Role(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	token, err := jwt.GenerateAndSignUserSession(1, 2, rf.RoleAdmin, time.Now().Add(time.Minute))
+	is.NoErr(err) // should sign a token
+
+	access, err := jwt.ParseAndVerifyAccess(token)
+	is.NoErr(err)                        // should verify
+	is.Equal(access.UserID, int64(1))    // should carry the user id
+	is.Equal(access.SessionID, int64(2)) // should carry the session
+	is.Equal(access.Role, rf.RoleAdmin)  // should carry the role
+
+	noRole, err := jwt.CurrentKeySet().Sign(&jwt.Claims{
+		RegisteredClaims: gojwt.RegisteredClaims{Subject: "1", ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute))},
+		SessionID:        2,
+	})
+	is.NoErr(err) // should sign a token
+
+	access, err = jwt.ParseAndVerifyAccess(noRole)
+	is.NoErr(err)                      // should verify
+	is.Equal(access.Role, rf.RoleUser) // tokens without a role should be user tokens
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	sign := func(ks *jwt.KeySet) string {
+		token, err := ks.Sign(&jwt.Claims{
+			RegisteredClaims: gojwt.RegisteredClaims{
+				Subject:   "1",
+				ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		is.NoErr(err) // should sign a token
+		return token
+	}
+
+	oldKey := jwt.NewHMACKey([]byte("old secret"))
+	newKey := jwt.NewHMACKey([]byte("new secret"))
+	is.True(oldKey.ID != newKey.ID)                              // key ids should differ per secret
+	is.Equal(oldKey.ID, jwt.NewHMACKey([]byte("old secret")).ID) // key ids should be stable
+
+	before := jwt.NewKeySet("issuer", "audience")
+	is.NoErr(before.AddSigningKey(oldKey)) // should sign with the old key
+	oldToken := sign(before)
+
+	rotated := jwt.NewKeySet("issuer", "audience")
+	is.NoErr(rotated.AddSigningKey(newKey)) // should sign with the new key
+	rotated.AddVerificationKey(oldKey)
+
+	_, err := rotated.Verify(oldToken)
+	is.NoErr(err) // should accept tokens signed before the rotation
+
+	_, err = before.Verify(sign(rotated))
+	is.True(err != nil) // should not accept keys it doesn't know
+
+	retired := jwt.NewKeySet("issuer", "audience")
+	is.NoErr(retired.AddSigningKey(newKey)) // should sign with the new key
+
+	_, err = retired.Verify(oldToken)
+	is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // should reject tokens of a retired key
+
+	other := jwt.NewKeySet("other", "audience")
+	is.NoErr(other.AddSigningKey(newKey)) // should sign with the new key
+
+	_, err = retired.Verify(sign(other))
+	is.True(err != nil) // should reject another issuer
+
+	verifyOnly := jwt.NewKeySet("issuer", "audience")
+	verifyOnly.AddVerificationKey(oldKey)
+	is.True(verifyOnly.AddSigningKey(&jwt.Key{ID: "public", Public: oldKey.Public}) != nil) // should not sign without a private key
+}
+
+func TestKeySet_Asymmetric(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	is.NoErr(err) // should generate an Ed25519 key
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	is.NoErr(err) // should generate an RSA key
+
+	for alg, private := range map[string]any{"EdDSA": edPrivate, "RS256": rsaPrivate} {
+		key, err := jwt.NewKey(private)
+		is.NoErr(err)                   // should make a key
+		is.Equal(key.Method.Alg(), alg) // should pick the algorithm of the key
+
+		ks := jwt.NewKeySet("issuer", "audience")
+		is.NoErr(ks.AddSigningKey(key))                         // should sign with the key
+		ks.AddVerificationKey(jwt.NewHMACKey([]byte("secret"))) // should keep an old secret
+
+		token, err := ks.Sign(&jwt.Claims{
+			RegisteredClaims: gojwt.RegisteredClaims{
+				Subject:   "1",
+				ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		is.NoErr(err) // should sign a token
+
+		_, err = ks.Verify(token)
+		is.NoErr(err) // should verify its own token
+
+		set := ks.JWKS()
+		is.Equal(len(set.Keys), 1)        // should only publish the public key
+		is.Equal(set.Keys[0].Kid, key.ID) // should publish the key id
+		is.Equal(set.Keys[0].Alg, alg)    // should publish the algorithm
+
+		// Another service only has the published key.
+		published, err := set.Keys[0].PublicKey()
+		is.NoErr(err) // should decode the published key
+
+		_, err = gojwt.Parse(token, func(token *gojwt.Token) (any, error) {
+			return published, nil
+		}, gojwt.WithValidMethods([]string{alg}))
+		is.NoErr(err) // should verify with the published key
+	}
+}
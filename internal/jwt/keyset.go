@@ -0,0 +1,309 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwk"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultIssuer is the issuer of tokens when neither JWT_ISSUER nor
+// PUBLIC_URL is set.
+const DefaultIssuer = "rss-feed-aggregator"
+
+// Key signs and verifies tokens, its ID is sent as the kid header so a
+// token finds its key after a rotation.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// Private signs tokens, it is nil for keys kept to verify tokens
+	// signed before a rotation.
+	Private any
+	Public  any
+}
+
+// NewHMACKey returns an HS256 key, its id is derived from the secret so it
+// stays the same across restarts.
+func NewHMACKey(secret []byte) *Key {
+	sum := sha256.Sum256(secret)
+
+	return &Key{
+		ID:      "hs256-" + hex.EncodeToString(sum[:6]),
+		Method:  jwt.SigningMethodHS256,
+		Private: secret,
+		Public:  secret,
+	}
+}
+
+// NewKey returns the key of an Ed25519, RSA or P-256 private or public
+// key, public keys only verify tokens.
+func NewKey(key any) (*Key, error) {
+	var public crypto.PublicKey
+	var private any
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		private, public = k, k.Public()
+	case *rsa.PrivateKey:
+		private, public = k, k.Public()
+	case *ecdsa.PrivateKey:
+		private, public = k, k.Public()
+	case ed25519.PublicKey, *rsa.PublicKey, *ecdsa.PublicKey:
+		public = k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+
+	var method jwt.SigningMethod
+	switch k := public.(type) {
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported curve %s", k.Curve.Params().Name)
+		}
+		method = jwt.SigningMethodES256
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+
+	return &Key{
+		ID:      base64.RawURLEncoding.EncodeToString(sum[:12]),
+		Method:  method,
+		Private: private,
+		Public:  public,
+	}, nil
+}
+
+// LoadKeyFile reads a PEM encoded PKCS #8 or PKIX key.
+func LoadKeyFile(path string) (*Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return NewKey(key)
+}
+
+// KeySet signs tokens with its signing key and verifies them with any of
+// its keys, old keys are kept to verify tokens issued before a rotation.
+type KeySet struct {
+	Issuer   string
+	Audience string
+
+	signing *Key
+	keys    map[string]*Key
+}
+
+func NewKeySet(issuer, audience string) *KeySet {
+	return &KeySet{
+		Issuer:   issuer,
+		Audience: audience,
+		keys:     map[string]*Key{},
+	}
+}
+
+// AddSigningKey makes key the key new tokens are signed with.
+func (ks *KeySet) AddSigningKey(key *Key) error {
+	if key.Private == nil {
+		return fmt.Errorf("key %s cannot sign", key.ID)
+	}
+
+	ks.signing = key
+	ks.keys[key.ID] = key
+	return nil
+}
+
+// AddVerificationKey accepts tokens signed with key.
+func (ks *KeySet) AddVerificationKey(key *Key) {
+	if _, ok := ks.keys[key.ID]; ok {
+		return
+	}
+
+	ks.keys[key.ID] = &Key{
+		ID:     key.ID,
+		Method: key.Method,
+		Public: key.Public,
+	}
+}
+
+// JWKS returns the public keys of the set, HMAC secrets are never shared.
+func (ks *KeySet) JWKS() jwk.Set {
+	set := jwk.Set{Keys: []jwk.Key{}}
+
+	for _, key := range ks.keys {
+		if _, ok := key.Method.(*jwt.SigningMethodHMAC); ok {
+			continue
+		}
+
+		k, err := jwk.New(key.ID, key.Public)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, k)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+func (ks *KeySet) methods() []string {
+	seen := map[string]bool{}
+	var methods []string
+
+	for _, key := range ks.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	return methods
+}
+
+// LoadKeySet builds the key set from the config. Tokens are signed with
+// JWT_SIGNING_KEY_FILE when set and JWT_SECRET otherwise, without either a
+// random key is used and tokens don't survive a restart.
+func LoadKeySet() (*KeySet, error) {
+	issuer := rf.Config.JWTIssuer
+	if issuer == "" {
+		issuer = rf.Config.PublicURL
+	}
+	if issuer == "" {
+		issuer = DefaultIssuer
+	}
+
+	audience := rf.Config.JWTAudience
+	if audience == "" {
+		audience = issuer
+	}
+
+	ks := NewKeySet(issuer, audience)
+
+	if rf.Config.JWTSigningKeyFile != "" {
+		key, err := LoadKeyFile(rf.Config.JWTSigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := ks.AddSigningKey(key); err != nil {
+			return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE: %w", err)
+		}
+	}
+
+	if rf.Config.JWTSecret != "" {
+		key := NewHMACKey([]byte(rf.Config.JWTSecret))
+		if ks.signing == nil {
+			ks.AddSigningKey(key)
+		} else {
+			ks.AddVerificationKey(key)
+		}
+	}
+
+	for _, secret := range rf.Config.JWTPreviousSecrets {
+		ks.AddVerificationKey(NewHMACKey([]byte(secret)))
+	}
+
+	for _, path := range rf.Config.JWTVerifyKeyFiles {
+		key, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		ks.AddVerificationKey(key)
+	}
+
+	if ks.signing == nil {
+		slog.Warn("JWT_SECRET and JWT_SIGNING_KEY_FILE are not set, tokens are signed with a random key")
+
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		ks.AddSigningKey(NewHMACKey(secret))
+	}
+
+	return ks, nil
+}
+
+var (
+	keySetMu   sync.RWMutex
+	keySetOnce sync.Once
+	keySet     *KeySet
+)
+
+// SetKeySet replaces the key set tokens are signed and verified with.
+func SetKeySet(ks *KeySet) {
+	keySetOnce.Do(func() {})
+
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+
+	keySet = ks
+}
+
+// CurrentKeySet returns the key set tokens are signed and verified with,
+// it is loaded from the config on first use unless set before.
+func CurrentKeySet() *KeySet {
+	keySetOnce.Do(func() {
+		ks, err := LoadKeySet()
+		if err != nil {
+			panic(fmt.Sprintf("jwt: load key set: %v", err))
+		}
+
+		keySetMu.Lock()
+		keySet = ks
+		keySetMu.Unlock()
+	})
+
+	keySetMu.RLock()
+	defer keySetMu.RUnlock()
+
+	return keySet
+}
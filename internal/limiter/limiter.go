@@ -0,0 +1,76 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter counts failures per key, callers decide how long a window to
+// look back over so the same counts serve a sliding window of any size.
+type Limiter interface {
+	// Fail records a failure of key.
+	Fail(ctx context.Context, key string) error
+	// Failures returns how often key failed since, and when it last did.
+	Failures(ctx context.Context, key string, since time.Time) (int, time.Time, error)
+	// Reset forgets every failure of key.
+	Reset(ctx context.Context, key string) error
+}
+
+// Retention is how long failures are kept, windows longer than it see
+// fewer failures than happened.
+const Retention = 24 * time.Hour
+
+// MemoryLimiter keeps failures in memory, it is used by tests and when a
+// single API instance runs.
+type MemoryLimiter struct {
+	mu       sync.Mutex
+	failures map[string][]time.Time
+	now      func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		failures: map[string][]time.Time{},
+		now:      time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Fail(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.failures[key] = append(prune(l.failures[key], now.Add(-Retention)), now)
+	return nil
+}
+
+func (l *MemoryLimiter) Failures(ctx context.Context, key string, since time.Time) (int, time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	failures := prune(l.failures[key], since)
+	if len(failures) == 0 {
+		return 0, time.Time{}, nil
+	}
+
+	return len(failures), failures[len(failures)-1], nil
+}
+
+func (l *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.failures, key)
+	return nil
+}
+
+// prune drops the failures before since, failures are kept in order.
+func prune(failures []time.Time, since time.Time) []time.Time {
+	for i, failedAt := range failures {
+		if !failedAt.Before(since) {
+			return failures[i:]
+		}
+	}
+	return nil
+}
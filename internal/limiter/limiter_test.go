@@ -0,0 +1,49 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2024, 9, 12, 9, 0, 0, 0, time.UTC)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+
+	for range 3 {
+		is.NoErr(l.Fail(ctx, "email:gopher@go.com")) // should record a failure
+		now = now.Add(time.Minute)
+	}
+
+	count, last, err := l.Failures(ctx, "email:gopher@go.com", now.Add(-time.Hour))
+
+	is.NoErr(err)                         // should count failures
+	is.Equal(count, 3)                    // should count every failure in the window
+	is.Equal(last, now.Add(-time.Minute)) // should return the latest failure
+
+	count, _, err = l.Failures(ctx, "email:gopher@go.com", now.Add(-90*time.Second))
+
+	is.NoErr(err)      // should count failures
+	is.Equal(count, 1) // should slide the window
+
+	count, _, err = l.Failures(ctx, "ip:127.0.0.1", now.Add(-time.Hour))
+
+	is.NoErr(err)      // should count failures
+	is.Equal(count, 0) // keys should not share failures
+
+	is.NoErr(l.Reset(ctx, "email:gopher@go.com")) // should reset
+
+	count, last, err = l.Failures(ctx, "email:gopher@go.com", now.Add(-time.Hour))
+
+	is.NoErr(err)          // should count failures
+	is.Equal(count, 0)     // should forget failures on reset
+	is.True(last.IsZero()) // should have no latest failure
+}
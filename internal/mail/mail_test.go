@@ -0,0 +1,97 @@
+package mail_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
+	"github.com/matryer/is"
+)
+
+func TestMemoryMailer(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	mailer := mail.NewMemoryMailer()
+
+	err := mailer.Send(context.Background(), mail.Message{To: "gopher@go.com", Subject: "Hi", Body: "Hello"})
+	is.NoErr(err) // should send
+
+	err = mailer.Send(context.Background(), mail.Message{To: "gopher@go.com\r\nBcc: x@go.com", Subject: "Hi"})
+	is.True(err != nil) // should refuse header injection
+
+	is.Equal(len(mailer.Messages()), 1)                // should keep sent messages
+	is.Equal(mailer.Messages()[0].To, "gopher@go.com") // should keep the recipient
+}
+
+func TestSMTPMailer(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err) // should listen
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go serveSMTP(listener, received)
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	is.NoErr(err) // should have an address
+
+	mailer := mail.NewSMTPMailer(host, port, "", "", "noreply@go.com")
+
+	err = mailer.Send(context.Background(), mail.Message{To: "gopher@go.com", Subject: "Reset", Body: "Follow the link\n"})
+	is.NoErr(err) // should send
+
+	data := <-received
+	is.True(strings.Contains(data, "To: gopher@go.com"))   // should address the recipient
+	is.True(strings.Contains(data, "Subject: Reset"))      // should set the subject
+	is.True(strings.Contains(data, "Follow the link\r\n")) // should send the body
+}
+
+// serveSMTP is a minimal SMTP server accepting a single message.
+func serveSMTP(listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	write("220 localhost ESMTP")
+	var data strings.Builder
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if inData {
+			if line == ".\r\n" {
+				inData = false
+				received <- data.String()
+				write("250 OK")
+				continue
+			}
+			data.WriteString(line)
+			continue
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			write("250 localhost")
+		case cmd == "DATA":
+			inData = true
+			write("354 End data with <CR><LF>.<CR><LF>")
+		case cmd == "QUIT":
+			write("221 Bye")
+			return
+		default:
+			write("250 OK")
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package mock
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+type AdminStore struct {
+	SearchUsersFn              func(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error)
+	SearchUsersInvoked         bool
+	SetAuthEnabledFn           func(ctx context.Context, userID int64, enabled bool) error
+	SetAuthEnabledInvoked      bool
+	SetUserRoleFn              func(ctx context.Context, email string, role rf.Role) (int64, error)
+	SetUserRoleInvoked         bool
+	RequestFeedResyncFn        func(ctx context.Context, feedID int64) error
+	RequestFeedResyncInvoked   bool
+	SetFeedEnabledFn           func(ctx context.Context, feedID int64, enabled bool) error
+	SetFeedEnabledInvoked      bool
+	ListFeedFetchErrorsFn      func(ctx context.Context, limit int) ([]rf.FeedFetchError, error)
+	ListFeedFetchErrorsInvoked bool
+}
+
+func (as *AdminStore) SearchUsers(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error) {
+	as.SearchUsersInvoked = true
+	return as.SearchUsersFn(ctx, req)
+}
+
+func (as *AdminStore) SetAuthEnabled(ctx context.Context, userID int64, enabled bool) error {
+	as.SetAuthEnabledInvoked = true
+	return as.SetAuthEnabledFn(ctx, userID, enabled)
+}
+
+func (as *AdminStore) SetUserRole(ctx context.Context, email string, role rf.Role) (int64, error) {
+	as.SetUserRoleInvoked = true
+	return as.SetUserRoleFn(ctx, email, role)
+}
+
+func (as *AdminStore) RequestFeedResync(ctx context.Context, feedID int64) error {
+	as.RequestFeedResyncInvoked = true
+	return as.RequestFeedResyncFn(ctx, feedID)
+}
+
+func (as *AdminStore) SetFeedEnabled(ctx context.Context, feedID int64, enabled bool) error {
+	as.SetFeedEnabledInvoked = true
+	return as.SetFeedEnabledFn(ctx, feedID, enabled)
+}
+
+func (as *AdminStore) ListFeedFetchErrors(ctx context.Context, limit int) ([]rf.FeedFetchError, error) {
+	as.ListFeedFetchErrorsInvoked = true
+	return as.ListFeedFetchErrorsFn(ctx, limit)
+}
@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+)
+
+type AuditStore struct {
+	ListAuditEventsFn      func(ctx context.Context, query *audit.Query) ([]audit.Event, error)
+	ListAuditEventsInvoked bool
+}
+
+func (as *AuditStore) ListAuditEvents(ctx context.Context, query *audit.Query) ([]audit.Event, error) {
+	as.ListAuditEventsInvoked = true
+	return as.ListAuditEventsFn(ctx, query)
+}
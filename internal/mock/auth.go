@@ -2,6 +2,7 @@ package mock
 
 import (
 	"context"
+	"time"
 
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
@@ -76,10 +77,80 @@ var SignInAuthAPIWithMissingPassword = builder.NewSignInRequestBuilder().
 	Build()
 
 type AuthStore struct {
-	CreateAuthAndUserFn func(ctx context.Context, auth *rf.Auth) error
-	CreateInvoked       bool
-	FindByEmailFn       func(ctx context.Context, email string) (*rf.Auth, error)
-	FindByEmailInvoked  bool
+	CreateAuthAndUserFn                  func(ctx context.Context, auth *rf.Auth) error
+	CreateInvoked                        bool
+	FindByEmailFn                        func(ctx context.Context, email string) (*rf.Auth, error)
+	FindByEmailInvoked                   bool
+	CreateSessionFn                      func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error
+	CreateSessionInvoked                 bool
+	FindRefreshTokenFn                   func(ctx context.Context, tokenHash string) (*rf.RefreshToken, error)
+	FindRefreshTokenInvoked              bool
+	FindSessionByIDFn                    func(ctx context.Context, sessionID int64) (*rf.Session, error)
+	FindSessionByIDInvoked               bool
+	RotateRefreshTokenFn                 func(ctx context.Context, session *rf.Session, used, next *rf.RefreshToken) error
+	RotateRefreshTokenInvoked            bool
+	RevokeSessionFn                      func(ctx context.Context, userID, sessionID int64) error
+	RevokeSessionInvoked                 bool
+	ListUserSessionsFn                   func(ctx context.Context, userID int64) ([]rf.Session, error)
+	ListUserSessionsInvoked              bool
+	RevokeOtherSessionsFn                func(ctx context.Context, userID, keepSessionID int64) error
+	RevokeOtherSessionsInvoked           bool
+	CreateAPIKeyFn                       func(ctx context.Context, key *rf.APIKey) error
+	CreateAPIKeyInvoked                  bool
+	ListUserAPIKeysFn                    func(ctx context.Context, userID int64) ([]rf.APIKey, error)
+	ListUserAPIKeysInvoked               bool
+	FindAPIKeyByHashFn                   func(ctx context.Context, keyHash string) (*rf.APIKey, error)
+	FindAPIKeyByHashInvoked              bool
+	TouchAPIKeyFn                        func(ctx context.Context, keyID int64) error
+	TouchAPIKeyInvoked                   bool
+	RevokeAPIKeyFn                       func(ctx context.Context, userID, keyID int64) error
+	RevokeAPIKeyInvoked                  bool
+	CreatePasswordResetTokenFn           func(ctx context.Context, token *rf.PasswordResetToken) error
+	CreatePasswordResetTokenInvoked      bool
+	ResetPasswordFn                      func(ctx context.Context, tokenHash, hashedPassword string) (int64, error)
+	ResetPasswordInvoked                 bool
+	FindByUserIDFn                       func(ctx context.Context, userID int64) (*rf.Auth, error)
+	FindByUserIDInvoked                  bool
+	CreateEmailVerificationTokenFn       func(ctx context.Context, token *rf.EmailVerificationToken) error
+	CreateEmailVerificationTokenInvoked  bool
+	ConfirmEmailFn                       func(ctx context.Context, tokenHash string) (int64, error)
+	ConfirmEmailInvoked                  bool
+	RecordSignInAttemptFn                func(ctx context.Context, auth *rf.Auth, succeeded bool) error
+	RecordSignInAttemptInvoked           bool
+	CreateUnlockTokenFn                  func(ctx context.Context, token *rf.UnlockToken) error
+	CreateUnlockTokenInvoked             bool
+	UseUnlockTokenFn                     func(ctx context.Context, tokenHash string) (*rf.Auth, error)
+	UseUnlockTokenInvoked                bool
+	SaveTOTPSecretFn                     func(ctx context.Context, totp *rf.TOTP) error
+	SaveTOTPSecretInvoked                bool
+	FindTOTPFn                           func(ctx context.Context, userID int64) (*rf.TOTP, error)
+	FindTOTPInvoked                      bool
+	EnableTOTPFn                         func(ctx context.Context, userID, step int64, recoveryCodeHashes []string) error
+	EnableTOTPInvoked                    bool
+	UseTOTPStepFn                        func(ctx context.Context, userID, step int64) error
+	UseTOTPStepInvoked                   bool
+	UseRecoveryCodeFn                    func(ctx context.Context, userID int64, codeHash string) error
+	UseRecoveryCodeInvoked               bool
+	DisableTOTPFn                        func(ctx context.Context, userID, step int64) error
+	DisableTOTPInvoked                   bool
+	CreateOIDCLoginStateFn               func(ctx context.Context, state *rf.OIDCLoginState) error
+	CreateOIDCLoginStateInvoked          bool
+	UseOIDCLoginStateFn                  func(ctx context.Context, provider, stateHash string) (*rf.OIDCLoginState, error)
+	UseOIDCLoginStateInvoked             bool
+	FindIdentityFn                       func(ctx context.Context, issuer, subject string) (*rf.AuthIdentity, error)
+	FindIdentityInvoked                  bool
+	CreateIdentityFn                     func(ctx context.Context, identity *rf.AuthIdentity) error
+	CreateIdentityInvoked                bool
+	CreateAuthAndUserWithIdentityFn      func(ctx context.Context, auth *rf.Auth, identity *rf.AuthIdentity) error
+	CreateAuthAndUserWithIdentityInvoked bool
+	ChangePasswordFn                     func(ctx context.Context, userID, keepSessionID int64, hashedPassword string) error
+	ChangePasswordInvoked                bool
+	RehashPasswordFn                     func(ctx context.Context, userID int64, oldHash, newHash string) error
+	RehashPasswordInvoked                bool
+	DeleteAccountFn                      func(ctx context.Context, userID int64, purgeAt time.Time) error
+	DeleteAccountInvoked                 bool
+	PurgeDeletedAccountsFn               func(ctx context.Context) (int64, error)
+	PurgeDeletedAccountsInvoked          bool
 }
 
 func (as *AuthStore) CreateAuthAndUser(ctx context.Context, auth *rf.Auth) error {
@@ -91,3 +162,178 @@ func (as *AuthStore) FindByEmail(ctx context.Context, email string) (*rf.Auth, e
 	as.FindByEmailInvoked = true
 	return as.FindByEmailFn(ctx, email)
 }
+
+func (as *AuthStore) CreateSession(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+	as.CreateSessionInvoked = true
+	return as.CreateSessionFn(ctx, session, token)
+}
+
+func (as *AuthStore) FindRefreshToken(ctx context.Context, tokenHash string) (*rf.RefreshToken, error) {
+	as.FindRefreshTokenInvoked = true
+	return as.FindRefreshTokenFn(ctx, tokenHash)
+}
+
+func (as *AuthStore) FindSessionByID(ctx context.Context, sessionID int64) (*rf.Session, error) {
+	as.FindSessionByIDInvoked = true
+	return as.FindSessionByIDFn(ctx, sessionID)
+}
+
+func (as *AuthStore) RotateRefreshToken(ctx context.Context, session *rf.Session, used, next *rf.RefreshToken) error {
+	as.RotateRefreshTokenInvoked = true
+	return as.RotateRefreshTokenFn(ctx, session, used, next)
+}
+
+func (as *AuthStore) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	as.RevokeSessionInvoked = true
+	return as.RevokeSessionFn(ctx, userID, sessionID)
+}
+
+func (as *AuthStore) ListUserSessions(ctx context.Context, userID int64) ([]rf.Session, error) {
+	as.ListUserSessionsInvoked = true
+	return as.ListUserSessionsFn(ctx, userID)
+}
+
+func (as *AuthStore) RevokeOtherSessions(ctx context.Context, userID, keepSessionID int64) error {
+	as.RevokeOtherSessionsInvoked = true
+	return as.RevokeOtherSessionsFn(ctx, userID, keepSessionID)
+}
+
+func (as *AuthStore) CreateAPIKey(ctx context.Context, key *rf.APIKey) error {
+	as.CreateAPIKeyInvoked = true
+	return as.CreateAPIKeyFn(ctx, key)
+}
+
+func (as *AuthStore) ListUserAPIKeys(ctx context.Context, userID int64) ([]rf.APIKey, error) {
+	as.ListUserAPIKeysInvoked = true
+	return as.ListUserAPIKeysFn(ctx, userID)
+}
+
+func (as *AuthStore) FindAPIKeyByHash(ctx context.Context, keyHash string) (*rf.APIKey, error) {
+	as.FindAPIKeyByHashInvoked = true
+	return as.FindAPIKeyByHashFn(ctx, keyHash)
+}
+
+func (as *AuthStore) TouchAPIKey(ctx context.Context, keyID int64) error {
+	as.TouchAPIKeyInvoked = true
+	return as.TouchAPIKeyFn(ctx, keyID)
+}
+
+func (as *AuthStore) RevokeAPIKey(ctx context.Context, userID, keyID int64) error {
+	as.RevokeAPIKeyInvoked = true
+	return as.RevokeAPIKeyFn(ctx, userID, keyID)
+}
+
+func (as *AuthStore) CreatePasswordResetToken(ctx context.Context, token *rf.PasswordResetToken) error {
+	as.CreatePasswordResetTokenInvoked = true
+	return as.CreatePasswordResetTokenFn(ctx, token)
+}
+
+func (as *AuthStore) ResetPassword(ctx context.Context, tokenHash, hashedPassword string) (int64, error) {
+	as.ResetPasswordInvoked = true
+	return as.ResetPasswordFn(ctx, tokenHash, hashedPassword)
+}
+
+func (as *AuthStore) FindByUserID(ctx context.Context, userID int64) (*rf.Auth, error) {
+	as.FindByUserIDInvoked = true
+	return as.FindByUserIDFn(ctx, userID)
+}
+
+func (as *AuthStore) CreateEmailVerificationToken(ctx context.Context, token *rf.EmailVerificationToken) error {
+	as.CreateEmailVerificationTokenInvoked = true
+	return as.CreateEmailVerificationTokenFn(ctx, token)
+}
+
+func (as *AuthStore) ConfirmEmail(ctx context.Context, tokenHash string) (int64, error) {
+	as.ConfirmEmailInvoked = true
+	return as.ConfirmEmailFn(ctx, tokenHash)
+}
+
+func (as *AuthStore) RecordSignInAttempt(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+	as.RecordSignInAttemptInvoked = true
+	return as.RecordSignInAttemptFn(ctx, auth, succeeded)
+}
+
+func (as *AuthStore) CreateUnlockToken(ctx context.Context, token *rf.UnlockToken) error {
+	as.CreateUnlockTokenInvoked = true
+	return as.CreateUnlockTokenFn(ctx, token)
+}
+
+func (as *AuthStore) UseUnlockToken(ctx context.Context, tokenHash string) (*rf.Auth, error) {
+	as.UseUnlockTokenInvoked = true
+	return as.UseUnlockTokenFn(ctx, tokenHash)
+}
+
+func (as *AuthStore) SaveTOTPSecret(ctx context.Context, totp *rf.TOTP) error {
+	as.SaveTOTPSecretInvoked = true
+	return as.SaveTOTPSecretFn(ctx, totp)
+}
+
+func (as *AuthStore) FindTOTP(ctx context.Context, userID int64) (*rf.TOTP, error) {
+	as.FindTOTPInvoked = true
+	return as.FindTOTPFn(ctx, userID)
+}
+
+func (as *AuthStore) EnableTOTP(ctx context.Context, userID, step int64, recoveryCodeHashes []string) error {
+	as.EnableTOTPInvoked = true
+	return as.EnableTOTPFn(ctx, userID, step, recoveryCodeHashes)
+}
+
+func (as *AuthStore) UseTOTPStep(ctx context.Context, userID, step int64) error {
+	as.UseTOTPStepInvoked = true
+	return as.UseTOTPStepFn(ctx, userID, step)
+}
+
+func (as *AuthStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	as.UseRecoveryCodeInvoked = true
+	return as.UseRecoveryCodeFn(ctx, userID, codeHash)
+}
+
+func (as *AuthStore) DisableTOTP(ctx context.Context, userID, step int64) error {
+	as.DisableTOTPInvoked = true
+	return as.DisableTOTPFn(ctx, userID, step)
+}
+
+func (as *AuthStore) CreateOIDCLoginState(ctx context.Context, state *rf.OIDCLoginState) error {
+	as.CreateOIDCLoginStateInvoked = true
+	return as.CreateOIDCLoginStateFn(ctx, state)
+}
+
+func (as *AuthStore) UseOIDCLoginState(ctx context.Context, provider, stateHash string) (*rf.OIDCLoginState, error) {
+	as.UseOIDCLoginStateInvoked = true
+	return as.UseOIDCLoginStateFn(ctx, provider, stateHash)
+}
+
+func (as *AuthStore) FindIdentity(ctx context.Context, issuer, subject string) (*rf.AuthIdentity, error) {
+	as.FindIdentityInvoked = true
+	return as.FindIdentityFn(ctx, issuer, subject)
+}
+
+func (as *AuthStore) CreateIdentity(ctx context.Context, identity *rf.AuthIdentity) error {
+	as.CreateIdentityInvoked = true
+	return as.CreateIdentityFn(ctx, identity)
+}
+
+func (as *AuthStore) CreateAuthAndUserWithIdentity(ctx context.Context, auth *rf.Auth, identity *rf.AuthIdentity) error {
+	as.CreateAuthAndUserWithIdentityInvoked = true
+	return as.CreateAuthAndUserWithIdentityFn(ctx, auth, identity)
+}
+
+func (as *AuthStore) ChangePassword(ctx context.Context, userID, keepSessionID int64, hashedPassword string) error {
+	as.ChangePasswordInvoked = true
+	return as.ChangePasswordFn(ctx, userID, keepSessionID, hashedPassword)
+}
+
+func (as *AuthStore) RehashPassword(ctx context.Context, userID int64, oldHash, newHash string) error {
+	as.RehashPasswordInvoked = true
+	return as.RehashPasswordFn(ctx, userID, oldHash, newHash)
+}
+
+func (as *AuthStore) DeleteAccount(ctx context.Context, userID int64, purgeAt time.Time) error {
+	as.DeleteAccountInvoked = true
+	return as.DeleteAccountFn(ctx, userID, purgeAt)
+}
+
+func (as *AuthStore) PurgeDeletedAccounts(ctx context.Context) (int64, error) {
+	as.PurgeDeletedAccountsInvoked = true
+	return as.PurgeDeletedAccountsFn(ctx)
+}
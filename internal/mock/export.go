@@ -0,0 +1,101 @@
+package mock
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+type ExportStore struct {
+	CreateExportFn              func(ctx context.Context, export *rf.DataExport) error
+	CreateExportInvoked         bool
+	FindExportFn                func(ctx context.Context, exportID int64) (*rf.DataExport, error)
+	FindExportInvoked           bool
+	ListUserExportsFn           func(ctx context.Context, userID int64) ([]rf.DataExport, error)
+	ListUserExportsInvoked      bool
+	ClaimPendingExportFn        func(ctx context.Context) (*rf.DataExport, error)
+	ClaimPendingExportInvoked   bool
+	FinishExportFn              func(ctx context.Context, exportID int64, state rf.DataExportState, size int64) error
+	FinishExportInvoked         bool
+	DeleteExpiredExportsFn      func(ctx context.Context) ([]int64, error)
+	DeleteExpiredExportsInvoked bool
+	FindExportProfileFn         func(ctx context.Context, userID int64) (*rf.ExportProfile, error)
+	FindExportProfileInvoked    bool
+	EachSubscriptionFn          func(ctx context.Context, userID int64, fn func(rf.Feed) error) error
+	EachSubscriptionInvoked     bool
+	EachItemStateFn             func(ctx context.Context, userID int64, fn func(rf.ExportItemState) error) error
+	EachItemStateInvoked        bool
+	EachPlaybackPositionFn      func(ctx context.Context, userID int64, fn func(rf.ExportPlaybackPosition) error) error
+	EachPlaybackPositionInvoked bool
+	EachSessionFn               func(ctx context.Context, userID int64, fn func(rf.ExportSession) error) error
+	EachSessionInvoked          bool
+	EachAPIKeyFn                func(ctx context.Context, userID int64, fn func(rf.ExportAPIKey) error) error
+	EachAPIKeyInvoked           bool
+	EachIdentityFn              func(ctx context.Context, userID int64, fn func(rf.ExportIdentity) error) error
+	EachIdentityInvoked         bool
+}
+
+func (es *ExportStore) CreateExport(ctx context.Context, export *rf.DataExport) error {
+	es.CreateExportInvoked = true
+	return es.CreateExportFn(ctx, export)
+}
+
+func (es *ExportStore) FindExport(ctx context.Context, exportID int64) (*rf.DataExport, error) {
+	es.FindExportInvoked = true
+	return es.FindExportFn(ctx, exportID)
+}
+
+func (es *ExportStore) ListUserExports(ctx context.Context, userID int64) ([]rf.DataExport, error) {
+	es.ListUserExportsInvoked = true
+	return es.ListUserExportsFn(ctx, userID)
+}
+
+func (es *ExportStore) ClaimPendingExport(ctx context.Context) (*rf.DataExport, error) {
+	es.ClaimPendingExportInvoked = true
+	return es.ClaimPendingExportFn(ctx)
+}
+
+func (es *ExportStore) FinishExport(ctx context.Context, exportID int64, state rf.DataExportState, size int64) error {
+	es.FinishExportInvoked = true
+	return es.FinishExportFn(ctx, exportID, state, size)
+}
+
+func (es *ExportStore) DeleteExpiredExports(ctx context.Context) ([]int64, error) {
+	es.DeleteExpiredExportsInvoked = true
+	return es.DeleteExpiredExportsFn(ctx)
+}
+
+func (es *ExportStore) FindExportProfile(ctx context.Context, userID int64) (*rf.ExportProfile, error) {
+	es.FindExportProfileInvoked = true
+	return es.FindExportProfileFn(ctx, userID)
+}
+
+func (es *ExportStore) EachSubscription(ctx context.Context, userID int64, fn func(rf.Feed) error) error {
+	es.EachSubscriptionInvoked = true
+	return es.EachSubscriptionFn(ctx, userID, fn)
+}
+
+func (es *ExportStore) EachItemState(ctx context.Context, userID int64, fn func(rf.ExportItemState) error) error {
+	es.EachItemStateInvoked = true
+	return es.EachItemStateFn(ctx, userID, fn)
+}
+
+func (es *ExportStore) EachPlaybackPosition(ctx context.Context, userID int64, fn func(rf.ExportPlaybackPosition) error) error {
+	es.EachPlaybackPositionInvoked = true
+	return es.EachPlaybackPositionFn(ctx, userID, fn)
+}
+
+func (es *ExportStore) EachSession(ctx context.Context, userID int64, fn func(rf.ExportSession) error) error {
+	es.EachSessionInvoked = true
+	return es.EachSessionFn(ctx, userID, fn)
+}
+
+func (es *ExportStore) EachAPIKey(ctx context.Context, userID int64, fn func(rf.ExportAPIKey) error) error {
+	es.EachAPIKeyInvoked = true
+	return es.EachAPIKeyFn(ctx, userID, fn)
+}
+
+func (es *ExportStore) EachIdentity(ctx context.Context, userID int64, fn func(rf.ExportIdentity) error) error {
+	es.EachIdentityInvoked = true
+	return es.EachIdentityFn(ctx, userID, fn)
+}
@@ -0,0 +1,92 @@
+package mock
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+type FeedStore struct {
+	BeginTxFn                   func(ctx context.Context) (rf.Tx, error)
+	BeginTxInvoked              bool
+	CreateFeedFn                func(ctx context.Context, tx rf.Tx, feed *rf.Feed) error
+	CreateFeedInvoked           bool
+	CreateUserFeedFn            func(ctx context.Context, tx rf.Tx, feed *rf.Feed) error
+	CreateUserFeedInvoked       bool
+	ListUserFeedsFn             func(ctx context.Context, userID int64) ([]rf.Feed, error)
+	ListUserFeedsInvoked        bool
+	FindUserFeedByIDFn          func(ctx context.Context, userID, feedID int64) (*rf.Feed, error)
+	FindUserFeedByIDInvoked     bool
+	UpdateUserFeedFn            func(ctx context.Context, feed *rf.Feed) error
+	UpdateUserFeedInvoked       bool
+	FindByURLFn                 func(ctx context.Context, url string) (*rf.Feed, error)
+	FindByURLInvoked            bool
+	DeleteFeedFn                func(ctx context.Context, userID, feedID int64) error
+	DeleteFeedInvoked           bool
+	CountFeedSubscribersFn      func(ctx context.Context, feedID int64) (int, error)
+	CountFeedSubscribersInvoked bool
+}
+
+func (fs *FeedStore) BeginTx(ctx context.Context) (rf.Tx, error) {
+	fs.BeginTxInvoked = true
+	return fs.BeginTxFn(ctx)
+}
+
+func (fs *FeedStore) CreateFeed(ctx context.Context, tx rf.Tx, feed *rf.Feed) error {
+	fs.CreateFeedInvoked = true
+	return fs.CreateFeedFn(ctx, tx, feed)
+}
+
+func (fs *FeedStore) CreateUserFeed(ctx context.Context, tx rf.Tx, feed *rf.Feed) error {
+	fs.CreateUserFeedInvoked = true
+	return fs.CreateUserFeedFn(ctx, tx, feed)
+}
+
+func (fs *FeedStore) ListUserFeeds(ctx context.Context, userID int64) ([]rf.Feed, error) {
+	fs.ListUserFeedsInvoked = true
+	return fs.ListUserFeedsFn(ctx, userID)
+}
+
+func (fs *FeedStore) FindUserFeedByID(ctx context.Context, userID, feedID int64) (*rf.Feed, error) {
+	fs.FindUserFeedByIDInvoked = true
+	return fs.FindUserFeedByIDFn(ctx, userID, feedID)
+}
+
+func (fs *FeedStore) UpdateUserFeed(ctx context.Context, feed *rf.Feed) error {
+	fs.UpdateUserFeedInvoked = true
+	return fs.UpdateUserFeedFn(ctx, feed)
+}
+
+func (fs *FeedStore) FindByURL(ctx context.Context, url string) (*rf.Feed, error) {
+	fs.FindByURLInvoked = true
+	return fs.FindByURLFn(ctx, url)
+}
+
+func (fs *FeedStore) DeleteFeed(ctx context.Context, userID, feedID int64) error {
+	fs.DeleteFeedInvoked = true
+	return fs.DeleteFeedFn(ctx, userID, feedID)
+}
+
+func (fs *FeedStore) CountFeedSubscribers(ctx context.Context, feedID int64) (int, error) {
+	fs.CountFeedSubscribersInvoked = true
+	return fs.CountFeedSubscribersFn(ctx, feedID)
+}
+
+// Tx records whether it was committed and rolled back, Rollback after
+// Commit is not counted as a rollback.
+type Tx struct {
+	CommitInvoked   bool
+	RollbackInvoked bool
+}
+
+func (tx *Tx) Commit(ctx context.Context) error {
+	tx.CommitInvoked = true
+	return nil
+}
+
+func (tx *Tx) Rollback(ctx context.Context) error {
+	if !tx.CommitInvoked {
+		tx.RollbackInvoked = true
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+type ItemStore struct {
+	FindFeedByIDFn                 func(ctx context.Context, feedID int64) (*rf.Feed, error)
+	FindFeedByIDInvoked            bool
+	UpsertChannelFn                func(ctx context.Context, channel *rf.Channel) error
+	UpsertChannelInvoked           bool
+	ListChannelItemsFn             func(ctx context.Context, channelID int64, limit int) ([]rf.Item, error)
+	ListChannelItemsInvoked        bool
+	UpsertItemsFn                  func(ctx context.Context, items []*rf.Item) error
+	UpsertItemsInvoked             bool
+	SetFeedItemIdentityFn          func(ctx context.Context, feedID, channelID int64, identity rf.ItemIdentity) error
+	SetFeedItemIdentityInvoked     bool
+	GroupItemStoriesFn             func(ctx context.Context, items []*rf.Item, maxDistance int) error
+	GroupItemStoriesInvoked        bool
+	ListTimelineFn                 func(ctx context.Context, req *rf.TimelineRequest) ([]rf.TimelineEntry, error)
+	ListTimelineInvoked            bool
+	MarkStoryReadFn                func(ctx context.Context, userID, storyID int64) error
+	MarkStoryReadInvoked           bool
+	StarItemFn                     func(ctx context.Context, userID, itemID int64, starred bool) error
+	StarItemInvoked                bool
+	SaveItemFn                     func(ctx context.Context, userID, itemID int64, saved bool) error
+	SaveItemInvoked                bool
+	FindUserItemByIDFn             func(ctx context.Context, userID, itemID int64) (*rf.Item, error)
+	FindUserItemByIDInvoked        bool
+	SavePlaybackPositionFn         func(ctx context.Context, position *rf.PlaybackPosition) error
+	SavePlaybackPositionInvoked    bool
+	ListItemsWantingContentFn      func(ctx context.Context, retryBefore time.Time, limit int) ([]rf.Item, error)
+	ListItemsWantingContentInvoked bool
+	FindItemContentFn              func(ctx context.Context, itemID int64) (*rf.ItemContent, error)
+	FindItemContentInvoked         bool
+	SaveItemContentFn              func(ctx context.Context, content *rf.ItemContent) error
+	SaveItemContentInvoked         bool
+}
+
+func (is *ItemStore) FindFeedByID(ctx context.Context, feedID int64) (*rf.Feed, error) {
+	is.FindFeedByIDInvoked = true
+	return is.FindFeedByIDFn(ctx, feedID)
+}
+
+func (is *ItemStore) UpsertChannel(ctx context.Context, channel *rf.Channel) error {
+	is.UpsertChannelInvoked = true
+	return is.UpsertChannelFn(ctx, channel)
+}
+
+func (is *ItemStore) ListChannelItems(ctx context.Context, channelID int64, limit int) ([]rf.Item, error) {
+	is.ListChannelItemsInvoked = true
+	return is.ListChannelItemsFn(ctx, channelID, limit)
+}
+
+func (is *ItemStore) UpsertItems(ctx context.Context, items []*rf.Item) error {
+	is.UpsertItemsInvoked = true
+	return is.UpsertItemsFn(ctx, items)
+}
+
+func (is *ItemStore) SetFeedItemIdentity(ctx context.Context, feedID, channelID int64, identity rf.ItemIdentity) error {
+	is.SetFeedItemIdentityInvoked = true
+	return is.SetFeedItemIdentityFn(ctx, feedID, channelID, identity)
+}
+
+func (is *ItemStore) GroupItemStories(ctx context.Context, items []*rf.Item, maxDistance int) error {
+	is.GroupItemStoriesInvoked = true
+	return is.GroupItemStoriesFn(ctx, items, maxDistance)
+}
+
+func (is *ItemStore) ListTimeline(ctx context.Context, req *rf.TimelineRequest) ([]rf.TimelineEntry, error) {
+	is.ListTimelineInvoked = true
+	return is.ListTimelineFn(ctx, req)
+}
+
+func (is *ItemStore) MarkStoryRead(ctx context.Context, userID, storyID int64) error {
+	is.MarkStoryReadInvoked = true
+	return is.MarkStoryReadFn(ctx, userID, storyID)
+}
+
+func (is *ItemStore) StarItem(ctx context.Context, userID, itemID int64, starred bool) error {
+	is.StarItemInvoked = true
+	return is.StarItemFn(ctx, userID, itemID, starred)
+}
+
+func (is *ItemStore) SaveItem(ctx context.Context, userID, itemID int64, saved bool) error {
+	is.SaveItemInvoked = true
+	return is.SaveItemFn(ctx, userID, itemID, saved)
+}
+
+func (is *ItemStore) FindUserItemByID(ctx context.Context, userID, itemID int64) (*rf.Item, error) {
+	is.FindUserItemByIDInvoked = true
+	return is.FindUserItemByIDFn(ctx, userID, itemID)
+}
+
+func (is *ItemStore) SavePlaybackPosition(ctx context.Context, position *rf.PlaybackPosition) error {
+	is.SavePlaybackPositionInvoked = true
+	return is.SavePlaybackPositionFn(ctx, position)
+}
+
+func (is *ItemStore) ListItemsWantingContent(ctx context.Context, retryBefore time.Time, limit int) ([]rf.Item, error) {
+	is.ListItemsWantingContentInvoked = true
+	return is.ListItemsWantingContentFn(ctx, retryBefore, limit)
+}
+
+func (is *ItemStore) FindItemContent(ctx context.Context, itemID int64) (*rf.ItemContent, error) {
+	is.FindItemContentInvoked = true
+	return is.FindItemContentFn(ctx, itemID)
+}
+
+func (is *ItemStore) SaveItemContent(ctx context.Context, content *rf.ItemContent) error {
+	is.SaveItemContentInvoked = true
+	return is.SaveItemContentFn(ctx, content)
+}
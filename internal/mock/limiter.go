@@ -0,0 +1,42 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+)
+
+type Limiter struct {
+	FailFn          func(ctx context.Context, key string) error
+	FailInvoked     bool
+	FailuresFn      func(ctx context.Context, key string, since time.Time) (int, time.Time, error)
+	FailuresInvoked bool
+	ResetFn         func(ctx context.Context, key string) error
+	ResetInvoked    bool
+}
+
+func (l *Limiter) Fail(ctx context.Context, key string) error {
+	l.FailInvoked = true
+	return l.FailFn(ctx, key)
+}
+
+func (l *Limiter) Failures(ctx context.Context, key string, since time.Time) (int, time.Time, error) {
+	l.FailuresInvoked = true
+	return l.FailuresFn(ctx, key, since)
+}
+
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	l.ResetInvoked = true
+	return l.ResetFn(ctx, key)
+}
+
+type AuditRecorder struct {
+	RecordFn      func(ctx context.Context, event audit.Event) error
+	RecordInvoked bool
+}
+
+func (ar *AuditRecorder) Record(ctx context.Context, event audit.Event) error {
+	ar.RecordInvoked = true
+	return ar.RecordFn(ctx, event)
+}
@@ -0,0 +1,11 @@
+package mock
+
+type BreachChecker struct {
+	BreachedFn      func(plaintextPassword string) (bool, error)
+	BreachedInvoked bool
+}
+
+func (bc *BreachChecker) Breached(plaintextPassword string) (bool, error) {
+	bc.BreachedInvoked = true
+	return bc.BreachedFn(plaintextPassword)
+}
@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/fetch"
+)
+
+type SyncStore struct {
+	ListDueFeedsFn         func(ctx context.Context, limit int) ([]rf.Feed, error)
+	ListDueFeedsInvoked    bool
+	RecordFeedFetchFn      func(ctx context.Context, feedID int64, fetchErr error) error
+	RecordFeedFetchInvoked bool
+}
+
+func (ss *SyncStore) ListDueFeeds(ctx context.Context, limit int) ([]rf.Feed, error) {
+	ss.ListDueFeedsInvoked = true
+	return ss.ListDueFeedsFn(ctx, limit)
+}
+
+func (ss *SyncStore) RecordFeedFetch(ctx context.Context, feedID int64, fetchErr error) error {
+	ss.RecordFeedFetchInvoked = true
+	return ss.RecordFeedFetchFn(ctx, feedID, fetchErr)
+}
+
+type Fetcher struct {
+	GetFn      func(ctx context.Context, url string) (*fetch.Response, error)
+	GetInvoked bool
+}
+
+func (f *Fetcher) Get(ctx context.Context, url string) (*fetch.Response, error) {
+	f.GetInvoked = true
+	return f.GetFn(ctx, url)
+}
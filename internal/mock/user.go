@@ -0,0 +1,24 @@
+package mock
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+type UserStore struct {
+	FindUserFn        func(ctx context.Context, userID int64) (*rf.User, error)
+	FindUserInvoked   bool
+	UpdateUserFn      func(ctx context.Context, user *rf.User) error
+	UpdateUserInvoked bool
+}
+
+func (us *UserStore) FindUser(ctx context.Context, userID int64) (*rf.User, error) {
+	us.FindUserInvoked = true
+	return us.FindUserFn(ctx, userID)
+}
+
+func (us *UserStore) UpdateUser(ctx context.Context, user *rf.User) error {
+	us.UpdateUserInvoked = true
+	return us.UpdateUserFn(ctx, user)
+}
@@ -0,0 +1,67 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/parser"
+)
+
+type WebSubStore struct {
+	FindSubscriptionByFeedIDFn       func(ctx context.Context, feedID int64) (*rf.WebSubSubscription, error)
+	FindSubscriptionByFeedIDInvoked  bool
+	SaveSubscriptionFn               func(ctx context.Context, sub *rf.WebSubSubscription) error
+	SaveSubscriptionInvoked          bool
+	ListExpiringSubscriptionsFn      func(ctx context.Context, before time.Time) ([]rf.WebSubSubscription, error)
+	ListExpiringSubscriptionsInvoked bool
+	SetFeedPollIntervalFn            func(ctx context.Context, feedID int64, interval time.Duration) error
+	SetFeedPollIntervalInvoked       bool
+}
+
+func (ws *WebSubStore) FindSubscriptionByFeedID(ctx context.Context, feedID int64) (*rf.WebSubSubscription, error) {
+	ws.FindSubscriptionByFeedIDInvoked = true
+	return ws.FindSubscriptionByFeedIDFn(ctx, feedID)
+}
+
+func (ws *WebSubStore) SaveSubscription(ctx context.Context, sub *rf.WebSubSubscription) error {
+	ws.SaveSubscriptionInvoked = true
+	return ws.SaveSubscriptionFn(ctx, sub)
+}
+
+func (ws *WebSubStore) ListExpiringSubscriptions(ctx context.Context, before time.Time) ([]rf.WebSubSubscription, error) {
+	ws.ListExpiringSubscriptionsInvoked = true
+	return ws.ListExpiringSubscriptionsFn(ctx, before)
+}
+
+func (ws *WebSubStore) SetFeedPollInterval(ctx context.Context, feedID int64, interval time.Duration) error {
+	ws.SetFeedPollIntervalInvoked = true
+	return ws.SetFeedPollIntervalFn(ctx, feedID, interval)
+}
+
+type ItemSyncer struct {
+	SyncItemsFn      func(ctx context.Context, channel *rf.Channel, items []*rf.Item) error
+	SyncItemsInvoked bool
+}
+
+func (is *ItemSyncer) SyncItems(ctx context.Context, channel *rf.Channel, items []*rf.Item) error {
+	is.SyncItemsInvoked = true
+	return is.SyncItemsFn(ctx, channel, items)
+}
+
+type WebSubSubscriber struct {
+	SubscribeFeedFn        func(ctx context.Context, feedID int64, feed *parser.Feed) error
+	SubscribeFeedInvoked   bool
+	UnsubscribeFeedFn      func(ctx context.Context, feedID int64) error
+	UnsubscribeFeedInvoked bool
+}
+
+func (ws *WebSubSubscriber) SubscribeFeed(ctx context.Context, feedID int64, feed *parser.Feed) error {
+	ws.SubscribeFeedInvoked = true
+	return ws.SubscribeFeedFn(ctx, feedID, feed)
+}
+
+func (ws *WebSubSubscriber) UnsubscribeFeed(ctx context.Context, feedID int64) error {
+	ws.UnsubscribeFeedInvoked = true
+	return ws.UnsubscribeFeedFn(ctx, feedID)
+}
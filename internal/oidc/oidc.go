@@ -0,0 +1,314 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwk"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	maxBodyBytes   = 1 << 20
+
+	// jwksRefreshInterval limits refetching the keys for unknown key ids,
+	// providers rotate keys rarely but tokens with bogus ids are free.
+	jwksRefreshInterval = time.Minute
+)
+
+// Config is a provider registered with an OpenID Connect issuer.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Claims are the verified claims of an ID token used to sign in.
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider signs users in with the authorization code flow and PKCE
+// against one issuer, discovery and keys are fetched on first use.
+type Provider struct {
+	Config     Config
+	HTTPClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *discovery
+	keys          map[string]any
+	keysFetchedAt time.Time
+}
+
+func NewProvider(config Config) *Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &Provider{
+		Config:     config,
+		HTTPClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (p *Provider) Issuer() string {
+	return p.Config.Issuer
+}
+
+// AuthCodeURL returns where to send the user to sign in with the issuer.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.Config.ClientID},
+		"redirect_uri":          {p.Config.RedirectURL},
+		"scope":                 {strings.Join(p.Config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return d.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange redeems code for an ID token and returns its verified claims.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.Config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.doJSON(req, &token); err != nil {
+		return nil, err
+	}
+
+	if token.IDToken == "" {
+		return nil, errors.Unauthorizedf("%s: no id token", errors.ErrOIDCExchangeFailed)
+	}
+
+	return p.verify(ctx, d, token.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, d *discovery, idToken, nonce string) (*Claims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.Config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+
+	token, err := parser.Parse(idToken, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, d, kid)
+	})
+	if err != nil {
+		return nil, errors.Unauthorizedf("%s: %v", errors.ErrOIDCTokenInvalid, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.Unauthorizedf(errors.ErrOIDCTokenInvalid)
+	}
+
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.Unauthorizedf("%s: nonce mismatch", errors.ErrOIDCTokenInvalid)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, errors.Unauthorizedf("%s: no subject", errors.ErrOIDCTokenInvalid)
+	}
+
+	result := &Claims{
+		Issuer:  d.Issuer,
+		Subject: subject,
+	}
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+
+	// Some issuers send email_verified as a string.
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+
+	return result, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &discovery{}
+	if err := p.doJSON(req, d); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(d.Issuer, "/") != strings.TrimSuffix(p.Config.Issuer, "/") {
+		return nil, errors.InternalErrorf("%s: issuer %q does not match %q", errors.ErrOIDCDiscoveryFailed, d.Issuer, p.Config.Issuer)
+	}
+
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.InternalErrorf("%s: missing endpoints", errors.ErrOIDCDiscoveryFailed)
+	}
+
+	p.discovery = d
+	return d, nil
+}
+
+// key returns the verification key kid, refetching the key set once in a
+// while so rotated keys are picked up.
+func (p *Provider) key(ctx context.Context, d *discovery, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwk.Set
+	if err := p.doJSON(req, &set); err != nil {
+		return nil, err
+	}
+
+	p.keys = map[string]any{}
+	p.keysFetchedAt = time.Now()
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		p.keys[k.Kid] = key
+	}
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (p *Provider) doJSON(req *http.Request, v any) error {
+	res, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return errors.InternalErrorf("%s: %v", errors.ErrOIDCExchangeFailed, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxBodyBytes))
+	if err != nil {
+		return errors.InternalErrorf("%s: %v", errors.ErrOIDCExchangeFailed, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Unauthorizedf("%s: %s returned %d", errors.ErrOIDCExchangeFailed, req.URL.Path, res.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.InternalErrorf("%s: %v", errors.ErrOIDCExchangeFailed, err)
+	}
+
+	return nil
+}
+
+// GenerateVerifier returns a random PKCE code verifier.
+func GenerateVerifier() (string, error) {
+	return randomString(32)
+}
+
+// Challenge returns the S256 PKCE code challenge of verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// GenerateState returns a random value for the state or nonce parameter.
+func GenerateState() (string, error) {
+	return randomString(24)
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,103 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc/oidctest"
+	"github.com/matryer/is"
+)
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	server := oidctest.NewProvider()
+	defer server.Close()
+
+	user := oidctest.User{Subject: "sub-1", Email: "test@test.com", EmailVerified: true, Name: "Test"}
+
+	authorize := func(is *is.I, provider *oidc.Provider, verifier, nonce string) string {
+		authURL, err := provider.AuthCodeURL(context.Background(), "state", nonce, oidc.Challenge(verifier))
+		is.NoErr(err) // should build the authorization url
+
+		parsed, err := url.Parse(authURL)
+		is.NoErr(err)                                                 // should be a valid url
+		is.Equal(parsed.Query().Get("code_challenge_method"), "S256") // should use S256 PKCE
+		is.Equal(parsed.Query().Get("client_id"), server.ClientID)    // should send the client id
+
+		code, state, err := server.Authorize(authURL, user)
+		is.NoErr(err)            // should authorize
+		is.Equal(state, "state") // should pass state through
+		return code
+	}
+
+	t.Run("exchanges a code for verified claims", func(t *testing.T) {
+		is := is.New(t)
+
+		provider := oidc.NewProvider(server.Config("http://localhost/callback"))
+		verifier, err := oidc.GenerateVerifier()
+		is.NoErr(err) // should generate a verifier
+
+		code := authorize(is, provider, verifier, "nonce")
+
+		claims, err := provider.Exchange(context.Background(), code, verifier, "nonce")
+		is.NoErr(err)                       // should exchange the code
+		is.Equal(claims.Issuer, server.URL) // should be the issuer
+		is.Equal(claims.Subject, "sub-1")   // should be the subject
+		is.Equal(claims.Email, user.Email)  // should be the email
+		is.True(claims.EmailVerified)       // should be verified
+		is.Equal(claims.Name, user.Name)    // should be the name
+	})
+
+	t.Run("rejects a wrong code verifier", func(t *testing.T) {
+		is := is.New(t)
+
+		provider := oidc.NewProvider(server.Config("http://localhost/callback"))
+		code := authorize(is, provider, "verifier", "nonce")
+
+		_, err := provider.Exchange(context.Background(), code, "other", "nonce")
+		is.True(err != nil)                                                        // should fail
+		is.Equal(errors.ToReferenceCode(err), errors.Unauthorized)                 // should be unauthorized
+		is.True(strings.Contains(errors.ToErr(err), errors.ErrOIDCExchangeFailed)) // should fail the exchange
+	})
+
+	t.Run("rejects a wrong nonce", func(t *testing.T) {
+		is := is.New(t)
+
+		provider := oidc.NewProvider(server.Config("http://localhost/callback"))
+		code := authorize(is, provider, "verifier", "nonce")
+
+		_, err := provider.Exchange(context.Background(), code, "verifier", "other")
+		is.True(err != nil)                                        // should fail
+		is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // should be unauthorized
+	})
+
+	t.Run("rejects tokens for another client", func(t *testing.T) {
+		is := is.New(t)
+
+		config := server.Config("http://localhost/callback")
+		provider := oidc.NewProvider(config)
+		code := authorize(is, provider, "verifier", "nonce")
+
+		config.ClientID = "other"
+		_, err := oidc.NewProvider(config).Exchange(context.Background(), code, "verifier", "nonce")
+		is.True(err != nil) // should fail
+	})
+
+	t.Run("rejects an issuer that does not match discovery", func(t *testing.T) {
+		is := is.New(t)
+
+		config := server.Config("http://localhost/callback")
+		config.Issuer = server.URL + "/"
+		_, err := oidc.NewProvider(config).AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+		is.NoErr(err) // should ignore a trailing slash
+
+		config.Issuer = server.URL + "/other"
+		_, err = oidc.NewProvider(config).AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+		is.True(err != nil) // should fail discovery
+	})
+}
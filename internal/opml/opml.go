@@ -0,0 +1,98 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Document is an OPML 2.0 subscription list.
+type Document struct {
+	XMLName xml.Name  `xml:"opml"`
+	Version string    `xml:"version,attr"`
+	Title   string    `xml:"head>title"`
+	Created string    `xml:"head>dateCreated,omitempty"`
+	Body    []Outline `xml:"body>outline"`
+}
+
+// Outline is a subscription, or a folder of them when it has children.
+type Outline struct {
+	XMLName  xml.Name  `xml:"outline"`
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Writer streams a document one outline at a time so a long subscription
+// list is never held in memory.
+type Writer struct {
+	enc *xml.Encoder
+}
+
+var (
+	opmlStart = xml.StartElement{
+		Name: xml.Name{Local: "opml"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "version"}, Value: "2.0"}},
+	}
+	headStart = xml.StartElement{Name: xml.Name{Local: "head"}}
+	bodyStart = xml.StartElement{Name: xml.Name{Local: "body"}}
+)
+
+// NewWriter writes the head of a document titled title, outlines follow
+// with WriteOutline and Close ends it.
+func NewWriter(w io.Writer, title string) (*Writer, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.EncodeToken(opmlStart); err != nil {
+		return nil, err
+	}
+
+	if err := enc.EncodeToken(headStart); err != nil {
+		return nil, err
+	}
+
+	err := enc.EncodeElement(title, xml.StartElement{Name: xml.Name{Local: "title"}})
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.EncodeElement(time.Now().UTC().Format(time.RFC1123Z), xml.StartElement{Name: xml.Name{Local: "dateCreated"}})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := enc.EncodeToken(headStart.End()); err != nil {
+		return nil, err
+	}
+
+	if err := enc.EncodeToken(bodyStart); err != nil {
+		return nil, err
+	}
+
+	return &Writer{enc: enc}, nil
+}
+
+func (w *Writer) WriteOutline(outline Outline) error {
+	return w.enc.Encode(outline)
+}
+
+// Close ends the document, it does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.enc.EncodeToken(bodyStart.End()); err != nil {
+		return err
+	}
+
+	if err := w.enc.EncodeToken(opmlStart.End()); err != nil {
+		return err
+	}
+
+	return w.enc.Close()
+}
@@ -0,0 +1,35 @@
+package opml_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/opml"
+	"github.com/matryer/is"
+)
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	var buf bytes.Buffer
+
+	w, err := opml.NewWriter(&buf, "Subscriptions & more")
+	is.NoErr(err) // should write the head
+
+	is.NoErr(w.WriteOutline(opml.Outline{Text: "Go Blog", Type: "rss", XMLURL: "https://go.dev/blog/feed.atom"}))  // should write an outline
+	is.NoErr(w.WriteOutline(opml.Outline{Text: "<Gophers>", Type: "rss", XMLURL: "https://example.com/?a=1&b=2"})) // should escape attributes
+	is.NoErr(w.Close())                                                                                            // should end the document
+
+	var doc opml.Document
+	is.NoErr(xml.Unmarshal(buf.Bytes(), &doc)) // should be valid xml
+
+	is.Equal(doc.Version, "2.0")                                  // should be opml 2.0
+	is.Equal(doc.Title, "Subscriptions & more")                   // should keep the title
+	is.True(doc.Created != "")                                    // should say when it was created
+	is.Equal(len(doc.Body), 2)                                    // should have both outlines
+	is.Equal(doc.Body[0].XMLURL, "https://go.dev/blog/feed.atom") // should keep the feed url
+	is.Equal(doc.Body[1].Text, "<Gophers>")                       // should round trip escaped text
+	is.Equal(doc.Body[1].XMLURL, "https://example.com/?a=1&b=2")  // should round trip escaped urls
+}
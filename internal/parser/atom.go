@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"html"
+	"strings"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",innerxml"`
+}
+
+type atomFeed struct {
+	Title    atomText    `xml:"title"`
+	Subtitle atomText    `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	mediaElements
+	itunesElements
+	ID        string     `xml:"id"`
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var doc atomFeed
+	if err := newDecoder(data).Decode(&doc); err != nil {
+		return nil, errors.InvalidDataf("%s: %v", errors.ErrFeedParseFailed, err)
+	}
+
+	feed := &Feed{
+		Channel: &rf.Channel{
+			Title:       doc.Title.String(),
+			Link:        alternateLink(doc.Links),
+			Description: doc.Subtitle.String(),
+		},
+		HubURL:  relLink(doc.Links, "hub"),
+		SelfURL: relLink(doc.Links, "self"),
+	}
+
+	for _, entry := range doc.Entries {
+		feed.Items = append(feed.Items, entry.toItem())
+	}
+
+	return feed, nil
+}
+
+func (ae atomEntry) toItem() *rf.Item {
+	item := &rf.Item{
+		GUID:        strings.TrimSpace(ae.ID),
+		Title:       firstNonEmpty(ae.Title.String(), ae.title()),
+		Description: firstNonEmpty(ae.Summary.String(), ae.description()),
+		Content:     ae.Content.String(),
+		Link:        alternateLink(ae.Links),
+		PublishedAt: parseTime(firstNonEmpty(ae.Published, ae.Updated)),
+	}
+
+	enclosures := newEnclosureBuilder()
+	for _, link := range ae.Links {
+		if link.Rel == "enclosure" {
+			enclosures.add(rf.Enclosure{
+				URL:      link.Href,
+				MIMEType: strings.TrimSpace(link.Type),
+				Length:   parseInt64(link.Length),
+			})
+		}
+	}
+	enclosures.addMedia(ae.mediaElements)
+	item.Enclosures = enclosures.build(ae.itunesElements)
+
+	return item
+}
+
+// String returns the text construct as HTML, xhtml content is returned as is
+// while text and html content is unescaped.
+func (at atomText) String() string {
+	body := strings.TrimSpace(at.Body)
+	if strings.HasPrefix(body, "<![CDATA[") && strings.HasSuffix(body, "]]>") {
+		return strings.TrimSpace(body[len("<![CDATA[") : len(body)-len("]]>")])
+	}
+	if at.Type == "xhtml" {
+		return body
+	}
+	return strings.TrimSpace(html.UnescapeString(body))
+}
+
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+func relLink(links []atomLink, rel string) string {
+	for _, link := range links {
+		if strings.EqualFold(link.Rel, rel) {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+type mediaContent struct {
+	URL        string           `xml:"url,attr"`
+	Type       string           `xml:"type,attr"`
+	Medium     string           `xml:"medium,attr"`
+	FileSize   string           `xml:"fileSize,attr"`
+	Duration   string           `xml:"duration,attr"`
+	Width      string           `xml:"width,attr"`
+	Height     string           `xml:"height,attr"`
+	Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type mediaGroup struct {
+	Title       string           `xml:"http://search.yahoo.com/mrss/ title"`
+	Description string           `xml:"http://search.yahoo.com/mrss/ description"`
+	Contents    []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails  []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+// mediaElements holds the Media RSS elements of an item or entry. It is
+// embedded ahead of the plain fields so namespaced elements such as
+// media:title or media:content do not overwrite their RSS/Atom namesakes.
+type mediaElements struct {
+	MediaTitle       string           `xml:"http://search.yahoo.com/mrss/ title"`
+	MediaDescription string           `xml:"http://search.yahoo.com/mrss/ description"`
+	MediaGroups      []mediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+	MediaContents    []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnails  []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+func (me mediaElements) title() string {
+	for _, group := range me.MediaGroups {
+		if title := firstNonEmpty(group.Title); title != "" {
+			return title
+		}
+	}
+	return strings.TrimSpace(me.MediaTitle)
+}
+
+func (me mediaElements) description() string {
+	for _, group := range me.MediaGroups {
+		if description := firstNonEmpty(group.Description); description != "" {
+			return description
+		}
+	}
+	return strings.TrimSpace(me.MediaDescription)
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type itunesElements struct {
+	ITunesTitle    string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ITunesSummary  string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ITunesDuration string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesImage    itunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	ITunesEpisode  string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesExplicit string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+}
+
+type enclosureBuilder struct {
+	enclosures []rf.Enclosure
+	byURL      map[string]int
+}
+
+func newEnclosureBuilder() *enclosureBuilder {
+	return &enclosureBuilder{
+		byURL: map[string]int{},
+	}
+}
+
+// add merges enc into any enclosure already seen with the same url, as
+// podcasts commonly repeat the <enclosure> as a media:content element.
+func (b *enclosureBuilder) add(enc rf.Enclosure) {
+	enc.URL = strings.TrimSpace(enc.URL)
+	if enc.URL == "" {
+		return
+	}
+
+	i, ok := b.byURL[enc.URL]
+	if !ok {
+		b.byURL[enc.URL] = len(b.enclosures)
+		b.enclosures = append(b.enclosures, enc)
+		return
+	}
+
+	existing := &b.enclosures[i]
+	existing.MIMEType = firstNonEmpty(existing.MIMEType, enc.MIMEType)
+	existing.Medium = firstNonEmpty(existing.Medium, enc.Medium)
+	existing.ThumbnailURL = firstNonEmpty(existing.ThumbnailURL, enc.ThumbnailURL)
+	existing.Length = max(existing.Length, enc.Length)
+	existing.DurationSeconds = max(existing.DurationSeconds, enc.DurationSeconds)
+	existing.Width = max(existing.Width, enc.Width)
+	existing.Height = max(existing.Height, enc.Height)
+}
+
+func (b *enclosureBuilder) addMedia(media mediaElements) {
+	thumbnail := firstThumbnail(media.MediaThumbnails)
+
+	addContents := func(contents []mediaContent, groupThumbnail string) {
+		for _, content := range contents {
+			b.add(rf.Enclosure{
+				URL:             content.URL,
+				MIMEType:        content.Type,
+				Medium:          content.Medium,
+				Length:          parseInt64(content.FileSize),
+				DurationSeconds: parseDuration(content.Duration),
+				Width:           int(parseInt64(content.Width)),
+				Height:          int(parseInt64(content.Height)),
+				ThumbnailURL:    firstNonEmpty(firstThumbnail(content.Thumbnails), groupThumbnail, thumbnail),
+			})
+		}
+	}
+
+	for _, group := range media.MediaGroups {
+		addContents(group.Contents, firstThumbnail(group.Thumbnails))
+	}
+	addContents(media.MediaContents, "")
+}
+
+func (b *enclosureBuilder) build(itunes itunesElements) []rf.Enclosure {
+	duration := parseDuration(itunes.ITunesDuration)
+	episode := int(parseInt64(itunes.ITunesEpisode))
+	explicit := parseExplicit(itunes.ITunesExplicit)
+
+	for i := range b.enclosures {
+		enc := &b.enclosures[i]
+		if enc.Medium == "" {
+			enc.Medium = mediumFromMIMEType(enc.MIMEType)
+		}
+		if enc.DurationSeconds == 0 {
+			enc.DurationSeconds = duration
+		}
+		enc.ImageURL = strings.TrimSpace(itunes.ITunesImage.Href)
+		enc.Episode = episode
+		enc.Explicit = explicit
+	}
+
+	return b.enclosures
+}
+
+func firstThumbnail(thumbnails []mediaThumbnail) string {
+	for _, thumbnail := range thumbnails {
+		if url := strings.TrimSpace(thumbnail.URL); url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+func mediumFromMIMEType(mimeType string) string {
+	kind, _, _ := strings.Cut(strings.ToLower(mimeType), "/")
+	switch kind {
+	case "audio", "video", "image":
+		return kind
+	}
+	return ""
+}
+
+// parseDuration parses iTunes and Media RSS durations which are either a
+// number of seconds or a [[HH:]MM:]SS clock value.
+func parseDuration(value string) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	seconds := 0
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + int(n)
+	}
+
+	return seconds
+}
+
+func parseExplicit(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "true", "explicit":
+		return true
+	}
+	return false
+}
+
+func parseInt64(value string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
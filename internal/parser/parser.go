@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// maxFeedBytes bounds how much of a feed document is read.
+const maxFeedBytes = 10 << 20
+
+type Feed struct {
+	Channel *rf.Channel
+	Items   []*rf.Item
+
+	// HubURL and SelfURL are the WebSub hub and topic advertised by the feed.
+	HubURL  string
+	SelfURL string
+}
+
+func Parse(r io.Reader) (*Feed, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxFeedBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, errors.InvalidDataf("%s: %v", errors.ErrFeedParseFailed, err)
+	}
+
+	switch root {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, errors.InvalidDataf("%s: unsupported root element %q", errors.ErrFeedParseFailed, root)
+	}
+}
+
+func newDecoder(data []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Feeds commonly declare latin-1 or windows-1252 while only using
+		// its ASCII subset, the bytes are passed through as is.
+		return input, nil
+	}
+	return dec
+}
+
+func rootElement(data []byte) (string, error) {
+	dec := newDecoder(data)
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func parseTime(value string) time.Time {
+	layouts := []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC3339,
+		time.RFC3339Nano,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		"2 Jan 2006 15:04:05 -0700",
+		"2006-01-02T15:04:05",
+		"2006-01-02",
+	}
+
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package parser_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/parser"
+	"github.com/matryer/is"
+)
+
+func TestParse_RSSPodcast(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	file, err := os.Open("testdata/podcast.xml")
+	is.NoErr(err)
+	defer file.Close()
+
+	feed, err := parser.Parse(file)
+	is.NoErr(err) // should parse the feed
+
+	is.Equal(feed.Channel.Title, "The Gopher Podcast")   // should have channel title
+	is.Equal(feed.Channel.Link, "https://gopher.fm")     // should not mistake atom:link for link
+	is.Equal(feed.SelfURL, "https://gopher.fm/feed.xml") // should have atom:link self
+	is.Equal(feed.HubURL, "")                            // should have no hub
+	is.Equal(len(feed.Items), 2)                         // should have every item
+
+	item := feed.Items[0]
+	is.Equal(item.Title, "Episode 42: Generics")                              // media:title should not replace title
+	is.Equal(item.GUID, "gopher-fm-42")                                       // should have guid
+	is.True(!item.GUIDIsPermaLink)                                            // should honour isPermaLink
+	is.Equal(item.Content, "<p>We talk <b>generics</b>.</p>")                 // should have content:encoded
+	is.Equal(item.PublishedAt, time.Date(2024, 8, 12, 10, 0, 0, 0, time.UTC)) // should parse pubDate
+	is.Equal(len(item.Enclosures), 1)                                         // should merge enclosure and media:content
+	is.Equal(item.Enclosures[0].URL, "https://cdn.gopher.fm/42.mp3")          // should have url
+	is.Equal(item.Enclosures[0].MIMEType, "audio/mpeg")                       // should have type
+	is.Equal(item.Enclosures[0].Length, int64(52428800))                      // should have length
+	is.Equal(item.Enclosures[0].Medium, "audio")                              // should have medium
+	is.Equal(item.Enclosures[0].DurationSeconds, 3725)                        // should have duration
+	is.Equal(item.Enclosures[0].ThumbnailURL, "https://gopher.fm/42.jpg")     // should have thumbnail
+	is.Equal(item.Enclosures[0].ImageURL, "https://gopher.fm/42-cover.jpg")   // should have itunes:image
+	is.Equal(item.Enclosures[0].Episode, 42)                                  // should have itunes:episode
+	is.True(item.Enclosures[0].Explicit)                                      // should have itunes:explicit
+
+	is.True(feed.Items[1].GUIDIsPermaLink)     // guids should default to perma links
+	is.Equal(len(feed.Items[1].Enclosures), 0) // should have no enclosures
+}
+
+func TestParse_AtomMediaGroup(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	file, err := os.Open("testdata/youtube.xml")
+	is.NoErr(err)
+	defer file.Close()
+
+	feed, err := parser.Parse(file)
+	is.NoErr(err) // should parse the feed
+
+	is.Equal(feed.Channel.Title, "Gopher TV")                            // should have channel title
+	is.Equal(feed.Channel.Link, "https://www.youtube.com/channel/UC123") // should use the alternate link
+	is.Equal(len(feed.Items), 1)                                         // should have every entry
+
+	item := feed.Items[0]
+	is.Equal(item.Title, "Concurrency & You")                                              // should unescape title
+	is.Equal(item.Description, "Channels and goroutines.")                                 // should fall back to media:description
+	is.Equal(item.Link, "https://www.youtube.com/watch?v=abc")                             // should have link
+	is.Equal(len(item.Enclosures), 1)                                                      // should have media:group content
+	is.Equal(item.Enclosures[0].Medium, "video")                                           // should derive medium from type
+	is.Equal(item.Enclosures[0].Width, 640)                                                // should have width
+	is.Equal(item.Enclosures[0].ThumbnailURL, "https://i1.ytimg.com/vi/abc/hqdefault.jpg") // should have group thumbnail
+}
+
+func TestParse_Failure(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	_, err := parser.Parse(strings.NewReader("<html><body>not a feed</body></html>"))
+
+	is.True(err != nil)                                                     // should be an error
+	is.Equal(errors.ToReferenceCode(err), errors.InvalidData)               // should have error code
+	is.True(strings.Contains(errors.ToErr(err), errors.ErrFeedParseFailed)) // should have error message
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"strings"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type rssFeed struct {
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	AtomLinks   []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+	ITunesTitle string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description"`
+	Items       []rssItem  `xml:"item"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type rssItem struct {
+	mediaElements
+	itunesElements
+	AtomLinks      []atomLink     `xml:"http://www.w3.org/2005/Atom link"`
+	ContentEncoded string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	DCDate         string         `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Title          string         `xml:"title"`
+	Link           string         `xml:"link"`
+	Description    string         `xml:"description"`
+	GUID           rssGUID        `xml:"guid"`
+	PubDate        string         `xml:"pubDate"`
+	Enclosures     []rssEnclosure `xml:"enclosure"`
+}
+
+func parseRSS(data []byte) (*Feed, error) {
+	var doc rssFeed
+	if err := newDecoder(data).Decode(&doc); err != nil {
+		return nil, errors.InvalidDataf("%s: %v", errors.ErrFeedParseFailed, err)
+	}
+
+	feed := &Feed{
+		Channel: &rf.Channel{
+			Title:       firstNonEmpty(doc.Channel.Title, doc.Channel.ITunesTitle),
+			Link:        strings.TrimSpace(doc.Channel.Link),
+			Description: strings.TrimSpace(doc.Channel.Description),
+		},
+		HubURL:  relLink(doc.Channel.AtomLinks, "hub"),
+		SelfURL: relLink(doc.Channel.AtomLinks, "self"),
+	}
+
+	for _, item := range doc.Channel.Items {
+		feed.Items = append(feed.Items, item.toItem())
+	}
+
+	return feed, nil
+}
+
+func (ri rssItem) toItem() *rf.Item {
+	// RSS 2.0 treats a guid as a permalink unless it says otherwise.
+	isPermaLink := !strings.EqualFold(strings.TrimSpace(ri.GUID.IsPermaLink), "false")
+
+	item := &rf.Item{
+		GUID:            strings.TrimSpace(ri.GUID.Value),
+		GUIDIsPermaLink: isPermaLink,
+		Title:           firstNonEmpty(ri.Title, ri.ITunesTitle, ri.title()),
+		Description:     firstNonEmpty(ri.Description, ri.ITunesSummary, ri.description()),
+		Content:         strings.TrimSpace(ri.ContentEncoded),
+		Link:            firstNonEmpty(ri.Link, alternateLink(ri.AtomLinks)),
+		PublishedAt:     parseTime(firstNonEmpty(ri.PubDate, ri.DCDate)),
+	}
+	if item.GUID == "" {
+		item.GUIDIsPermaLink = false
+	}
+
+	enclosures := newEnclosureBuilder()
+	for _, enc := range ri.Enclosures {
+		enclosures.add(rf.Enclosure{
+			URL:      enc.URL,
+			MIMEType: strings.TrimSpace(enc.Type),
+			Length:   parseInt64(enc.Length),
+		})
+	}
+	enclosures.addMedia(ri.mediaElements)
+	item.Enclosures = enclosures.build(ri.itunesElements)
+
+	return item
+}
@@ -0,0 +1,68 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachPrefixLength is how many hex characters of a SHA-1 name the shard
+// it is kept in.
+const BreachPrefixLength = 5
+
+// BreachCorpus checks passwords against a local copy of a breached password
+// corpus sharded by SHA-1 prefix, the layout of the Pwned Passwords range
+// API. Each shard is a file named by the first five upper case hex
+// characters of the hash, such as 5BAA6.txt, with a SUFFIX:COUNT line for
+// every breached hash starting with them. Only the shard a password falls
+// in is read, so no network call is made and the corpus is not kept in
+// memory.
+type BreachCorpus struct {
+	dir string
+}
+
+func NewBreachCorpus(dir string) (*BreachCorpus, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached password corpus %q is not a directory", dir)
+	}
+
+	return &BreachCorpus{
+		dir: dir,
+	}, nil
+}
+
+func (bc *BreachCorpus) Breached(plaintextPassword string) (bool, error) {
+	sum := sha1.Sum([]byte(plaintextPassword))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:BreachPrefixLength], hash[BreachPrefixLength:]
+
+	file, err := os.Open(filepath.Join(bc.dir, prefix+".txt"))
+	if err != nil {
+		// A missing shard has no breached hashes.
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), ":")
+		if strings.EqualFold(strings.TrimSpace(line), suffix) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
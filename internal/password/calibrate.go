@@ -0,0 +1,74 @@
+package password
+
+import (
+	"time"
+
+	"github.com/alexedwards/argon2id"
+)
+
+const (
+	// CalibrationRuns is how many hashes each candidate is timed over.
+	CalibrationRuns = 3
+	// MaxCalibrationIterations stops calibration for targets the machine
+	// can't reach with sensible parameters.
+	MaxCalibrationIterations = 64
+)
+
+// Calibrate returns the strongest parameters hashing within target on this
+// machine and how long they take. It uses as much of maxMemory KiB as it
+// can, halving it when a single pass is already too slow, then adds
+// iterations until the next one would go over target.
+func Calibrate(target time.Duration, maxMemory uint32, parallelism uint8) (*Params, time.Duration, error) {
+	p := &Params{
+		Memory:      maxMemory,
+		Iterations:  1,
+		Parallelism: parallelism,
+		SaltLength:  argon2id.DefaultParams.SaltLength,
+		KeyLength:   argon2id.DefaultParams.KeyLength,
+	}
+	if err := validateParams(p); err != nil {
+		return nil, 0, err
+	}
+
+	took, err := timeHash(p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for took > target && p.Memory/2 >= 8*uint32(p.Parallelism) {
+		p.Memory /= 2
+		if took, err = timeHash(p); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	for p.Iterations < MaxCalibrationIterations {
+		next := *p
+		next.Iterations++
+
+		nextTook, err := timeHash(&next)
+		if err != nil {
+			return nil, 0, err
+		}
+		if nextTook > target {
+			break
+		}
+
+		p, took = &next, nextTook
+	}
+
+	return p, took, nil
+}
+
+// timeHash returns the mean time hashing with p takes.
+func timeHash(p *Params) (time.Duration, error) {
+	start := time.Now()
+
+	for range CalibrationRuns {
+		if _, err := argon2id.CreateHash("calibration", p); err != nil {
+			return 0, err
+		}
+	}
+
+	return time.Since(start) / CalibrationRuns, nil
+}
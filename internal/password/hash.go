@@ -6,7 +6,7 @@ import (
 )
 
 func Hash(plaintextPassword string) (string, error) {
-	hashedPassword, err := argon2id.CreateHash(plaintextPassword, argon2id.DefaultParams)
+	hashedPassword, err := argon2id.CreateHash(plaintextPassword, CurrentParams())
 	if err != nil {
 		return "", err
 	}
@@ -14,6 +14,11 @@ func Hash(plaintextPassword string) (string, error) {
 }
 
 func Matches(plaintextPassword, hashedPassword string) (bool, error) {
+	// Accounts signed up with an OpenID Connect provider have no password.
+	if hashedPassword == "" {
+		return false, errors.Unauthorizedf(errors.ErrInvalidCredentials)
+	}
+
 	match, err := argon2id.ComparePasswordAndHash(plaintextPassword, hashedPassword)
 	if err != nil {
 		return false, err
@@ -0,0 +1,108 @@
+package password
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/alexedwards/argon2id"
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+// Params are the argon2id parameters passwords are hashed with, Memory is
+// in KiB.
+type Params = argon2id.Params
+
+var (
+	paramsMu sync.RWMutex
+	params   = *argon2id.DefaultParams
+)
+
+// SetParams replaces the parameters new hashes are made with, hashes made
+// with weaker ones are upgraded on sign in.
+func SetParams(p *Params) {
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+
+	params = *p
+}
+
+func CurrentParams() *Params {
+	paramsMu.RLock()
+	defer paramsMu.RUnlock()
+
+	p := params
+	return &p
+}
+
+// LoadParams builds the parameters from the config, ARGON2_MEMORY,
+// ARGON2_ITERATIONS and ARGON2_PARALLELISM that are not set keep their
+// defaults.
+func LoadParams() (*Params, error) {
+	p := *argon2id.DefaultParams
+
+	if rf.Config.Argon2Memory != "" {
+		n, err := strconv.ParseUint(rf.Config.Argon2Memory, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("ARGON2_MEMORY: %w", err)
+		}
+		p.Memory = uint32(n)
+	}
+
+	if rf.Config.Argon2Iterations != "" {
+		n, err := strconv.ParseUint(rf.Config.Argon2Iterations, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("ARGON2_ITERATIONS: %w", err)
+		}
+		p.Iterations = uint32(n)
+	}
+
+	if rf.Config.Argon2Parallelism != "" {
+		n, err := strconv.ParseUint(rf.Config.Argon2Parallelism, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("ARGON2_PARALLELISM: %w", err)
+		}
+		p.Parallelism = uint8(n)
+	}
+
+	if err := validateParams(&p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func validateParams(p *Params) error {
+	if p.Iterations < 1 {
+		return fmt.Errorf("ARGON2_ITERATIONS must be at least 1")
+	}
+	if p.Parallelism < 1 {
+		return fmt.Errorf("ARGON2_PARALLELISM must be at least 1")
+	}
+	// argon2 needs 8 KiB for every lane.
+	if p.Memory < 8*uint32(p.Parallelism) {
+		return fmt.Errorf("ARGON2_MEMORY must be at least 8 KiB for every ARGON2_PARALLELISM lane")
+	}
+	return nil
+}
+
+// NeedsRehash reports whether hashedPassword was made with parameters
+// weaker than the current ones. Hashes that can't be decoded, such as the
+// empty password of accounts signed up with a provider, are left alone.
+func NeedsRehash(hashedPassword string) bool {
+	if hashedPassword == "" {
+		return false
+	}
+
+	stored, _, _, err := argon2id.DecodeHash(hashedPassword)
+	if err != nil {
+		return false
+	}
+
+	current := CurrentParams()
+	return stored.Memory < current.Memory ||
+		stored.Iterations < current.Iterations ||
+		stored.Parallelism < current.Parallelism ||
+		stored.SaltLength < current.SaltLength ||
+		stored.KeyLength < current.KeyLength
+}
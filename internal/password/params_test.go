@@ -0,0 +1,72 @@
+package password_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
+	"github.com/matryer/is"
+)
+
+func TestNeedsRehash(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	hash := func(p *password.Params) string {
+		hashedPassword, err := argon2id.CreateHash("gogopher1", p)
+		is.NoErr(err) // should hash the password
+		return hashedPassword
+	}
+
+	current := password.CurrentParams()
+	weaker := *current
+	weaker.Iterations = 1
+	weaker.Memory = current.Memory / 2
+	stronger := *current
+	stronger.Iterations = current.Iterations + 1
+
+	currentHash, err := password.Hash("gogopher1")
+	is.NoErr(err) // should hash the password
+
+	is.True(password.NeedsRehash(hash(&weaker)))    // weaker hashes should be upgraded
+	is.True(!password.NeedsRehash(currentHash))     // current hashes should be kept
+	is.True(!password.NeedsRehash(hash(&stronger))) // stronger hashes should be kept
+	is.True(!password.NeedsRehash(""))              // missing passwords should be left alone
+	is.True(!password.NeedsRehash("not a hash"))    // other hashes should be left alone
+}
+
+func TestCalibrate(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should fall back to the weakest parameters for an unreachable target", func(t *testing.T) {
+		t.Parallel()
+
+		params, took, err := password.Calibrate(time.Nanosecond, 64, 1)
+
+		is.NoErr(err)                          // should calibrate
+		is.Equal(params.Memory, uint32(8))     // should halve the memory down to the minimum
+		is.Equal(params.Iterations, uint32(1)) // should not add iterations
+		is.True(took > time.Nanosecond)        // should report the target was missed
+	})
+
+	t.Run("Should add iterations up to the target", func(t *testing.T) {
+		t.Parallel()
+
+		params, took, err := password.Calibrate(20*time.Millisecond, 1024, 1)
+
+		is.NoErr(err)                         // should calibrate
+		is.Equal(params.Memory, uint32(1024)) // should keep the memory
+		is.True(params.Iterations > 1)        // should add iterations
+		is.True(took <= 20*time.Millisecond)  // should hash within the target
+	})
+
+	t.Run("Should fail with invalid parameters", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := password.Calibrate(time.Second, 8, 0)
+
+		is.True(err != nil) // should be an error
+	})
+}
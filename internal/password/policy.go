@@ -0,0 +1,92 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+const (
+	DefaultMinLength = 8
+	// DefaultMaxLength bounds the work hashing a password takes.
+	DefaultMaxLength = 128
+)
+
+// BreachChecker reports whether a password is known from a data breach.
+type BreachChecker interface {
+	Breached(plaintextPassword string) (bool, error)
+}
+
+// Policy is what a new password has to satisfy. Lengths are counted in
+// characters, a zero MaxLength allows any length and a nil Breached skips
+// the breach check.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	Breached  BreachChecker
+}
+
+func DefaultPolicy() *Policy {
+	return &Policy{
+		MinLength: DefaultMinLength,
+		MaxLength: DefaultMaxLength,
+	}
+}
+
+// Check returns why plaintextPassword is not allowed, or an empty string
+// when it is. identifiers are the email address and name of the account,
+// which a password must not be.
+func (p *Policy) Check(plaintextPassword string, identifiers ...string) (string, error) {
+	length := utf8.RuneCountInString(plaintextPassword)
+
+	if length < p.MinLength {
+		return fmt.Sprintf(errors.ErrPasswordTooShort, p.MinLength), nil
+	}
+
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Sprintf(errors.ErrPasswordTooLong, p.MaxLength), nil
+	}
+
+	if isIdentifier(plaintextPassword, identifiers) {
+		return errors.ErrPasswordPersonal, nil
+	}
+
+	if p.Breached == nil {
+		return "", nil
+	}
+
+	breached, err := p.Breached.Breached(plaintextPassword)
+	if err != nil {
+		return "", err
+	}
+	if breached {
+		return errors.ErrPasswordBreached, nil
+	}
+
+	return "", nil
+}
+
+// isIdentifier reports whether plaintextPassword is one of identifiers or
+// the local part of an email address among them, ignoring case.
+func isIdentifier(plaintextPassword string, identifiers []string) bool {
+	plaintextPassword = strings.TrimSpace(plaintextPassword)
+
+	for _, identifier := range identifiers {
+		identifier = strings.TrimSpace(identifier)
+		if identifier == "" {
+			continue
+		}
+
+		if strings.EqualFold(plaintextPassword, identifier) {
+			return true
+		}
+
+		if local, _, found := strings.Cut(identifier, "@"); found && strings.EqualFold(plaintextPassword, local) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package password_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
+	"github.com/matryer/is"
+)
+
+// writeBreachCorpus writes a corpus of breached passwords sharded like the
+// Pwned Passwords range API and returns its directory.
+func writeBreachCorpus(t *testing.T, breached ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	shards := map[string][]string{}
+	for _, p := range breached {
+		sum := sha1.Sum([]byte(p))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+		prefix := hash[:password.BreachPrefixLength]
+		shards[prefix] = append(shards[prefix], hash[password.BreachPrefixLength:]+":42")
+	}
+
+	for prefix, lines := range shards {
+		err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(strings.Join(lines, "\r\n")), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should bound the length", func(t *testing.T) {
+		t.Parallel()
+
+		policy := &password.Policy{MinLength: 8, MaxLength: 12}
+
+		reason, err := policy.Check("gopher")
+		is.NoErr(err)                                                // should check
+		is.Equal(reason, fmt.Sprintf(errors.ErrPasswordTooShort, 8)) // should be too short
+		reason, err = policy.Check("gogophergogopher")
+		is.NoErr(err)                                                // should check
+		is.Equal(reason, fmt.Sprintf(errors.ErrPasswordTooLong, 12)) // should be too long
+		reason, err = policy.Check("gögöphér")
+		is.NoErr(err)        // should check
+		is.Equal(reason, "") // should count characters rather than bytes
+	})
+
+	t.Run("Should not allow the email address or name", func(t *testing.T) {
+		t.Parallel()
+
+		policy := password.DefaultPolicy()
+
+		for _, p := range []string{"Gophers1@go.com", "gophers1", "The Gopher"} {
+			reason, err := policy.Check(p, "gophers1@go.com", "the gopher")
+			is.NoErr(err)                                // should check
+			is.Equal(reason, errors.ErrPasswordPersonal) // should be personal
+		}
+
+		reason, err := policy.Check("gogopher1", "gopher1@go.com", "")
+		is.NoErr(err)        // should check
+		is.Equal(reason, "") // should allow other passwords
+	})
+
+	t.Run("Should not allow breached passwords", func(t *testing.T) {
+		t.Parallel()
+
+		corpus, err := password.NewBreachCorpus(writeBreachCorpus(t, "password1", "letmein123"))
+		is.NoErr(err) // should load the corpus
+
+		policy := password.DefaultPolicy()
+		policy.Breached = corpus
+
+		reason, err := policy.Check("letmein123")
+		is.NoErr(err)                                // should check
+		is.Equal(reason, errors.ErrPasswordBreached) // should be breached
+		reason, err = policy.Check("gogopher1")
+		is.NoErr(err)        // should check
+		is.Equal(reason, "") // should allow passwords missing from the corpus
+	})
+
+	t.Run("Should fail to load a missing corpus", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := password.NewBreachCorpus(filepath.Join(t.TempDir(), "missing"))
+
+		is.True(err != nil) // should be an error
+	})
+}
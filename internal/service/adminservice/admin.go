@@ -0,0 +1,137 @@
+package adminservice
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type AdminStore interface {
+	SearchUsers(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error)
+	SetAuthEnabled(ctx context.Context, userID int64, enabled bool) error
+	// SetUserRole gives the user signed up with email the role and returns
+	// their id.
+	SetUserRole(ctx context.Context, email string, role rf.Role) (int64, error)
+	RequestFeedResync(ctx context.Context, feedID int64) error
+	SetFeedEnabled(ctx context.Context, feedID int64, enabled bool) error
+	ListFeedFetchErrors(ctx context.Context, limit int) ([]rf.FeedFetchError, error)
+}
+
+// AdminService is what administrators can do to other users and to feeds.
+// Callers must have checked the role of the user, every change is written
+// to the audit log as done by the user in the context.
+type AdminService struct {
+	store AdminStore
+
+	// Audit records every change made by an administrator.
+	Audit audit.Recorder
+}
+
+func NewAdminService(store AdminStore) *AdminService {
+	return &AdminService{
+		store: store,
+		Audit: audit.NewLogRecorder(),
+	}
+}
+
+func (as *AdminService) SearchUsers(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error) {
+	req.Limit = listLimit(req.Limit)
+	req.Offset = max(req.Offset, 0)
+
+	return as.store.SearchUsers(ctx, req)
+}
+
+// DisableUser stops the user signing in, revokes their sessions and
+// refuses their api keys until they are enabled again. Administrators
+// cannot lock themselves out.
+func (as *AdminService) DisableUser(ctx context.Context, userID int64) error {
+	if userID == rfcontext.UserIDFromContext(ctx) {
+		return errors.InvalidDataf(errors.ErrCannotDisableSelf)
+	}
+
+	if err := as.store.SetAuthEnabled(ctx, userID, false); err != nil {
+		return err
+	}
+
+	as.record(ctx, audit.EventAdminUserDisabled, audit.TargetUser, userID)
+	return nil
+}
+
+func (as *AdminService) EnableUser(ctx context.Context, userID int64) error {
+	if err := as.store.SetAuthEnabled(ctx, userID, true); err != nil {
+		return err
+	}
+
+	as.record(ctx, audit.EventAdminUserEnabled, audit.TargetUser, userID)
+	return nil
+}
+
+// PromoteUser makes the user signed up with email an administrator. It is
+// how the first administrator of a new install is made, so it does not
+// check the role of the caller.
+func (as *AdminService) PromoteUser(ctx context.Context, email string) error {
+	userID, err := as.store.SetUserRole(ctx, email, rf.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	as.record(ctx, audit.EventAdminUserPromoted, audit.TargetUser, userID)
+	return nil
+}
+
+// ResyncFeed has the feed fetched on the next sync whatever its poll
+// interval.
+func (as *AdminService) ResyncFeed(ctx context.Context, feedID int64) error {
+	if err := as.store.RequestFeedResync(ctx, feedID); err != nil {
+		return err
+	}
+
+	as.record(ctx, audit.EventAdminFeedResync, audit.TargetFeed, feedID)
+	return nil
+}
+
+// DisableFeed stops the feed being fetched for every subscriber.
+func (as *AdminService) DisableFeed(ctx context.Context, feedID int64) error {
+	if err := as.store.SetFeedEnabled(ctx, feedID, false); err != nil {
+		return err
+	}
+
+	as.record(ctx, audit.EventAdminFeedDisabled, audit.TargetFeed, feedID)
+	return nil
+}
+
+func (as *AdminService) EnableFeed(ctx context.Context, feedID int64) error {
+	if err := as.store.SetFeedEnabled(ctx, feedID, true); err != nil {
+		return err
+	}
+
+	as.record(ctx, audit.EventAdminFeedEnabled, audit.TargetFeed, feedID)
+	return nil
+}
+
+func (as *AdminService) ListFetchErrors(ctx context.Context, limit int) ([]rf.FeedFetchError, error) {
+	return as.store.ListFeedFetchErrors(ctx, listLimit(limit))
+}
+
+func (as *AdminService) record(ctx context.Context, eventType audit.EventType, targetType audit.TargetType, targetID int64) {
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       eventType,
+		TargetType: targetType,
+		TargetID:   targetID,
+	})
+}
+
+func listLimit(limit int) int {
+	if limit <= 0 {
+		return defaultListLimit
+	}
+	return min(limit, maxListLimit)
+}
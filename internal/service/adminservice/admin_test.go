@@ -0,0 +1,126 @@
+package adminservice_test
+
+import (
+	"context"
+	"testing"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/adminservice"
+	"github.com/matryer/is"
+)
+
+func TestAdminService(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+	newService := func(store *mock.AdminStore) (*adminservice.AdminService, *mock.AuditRecorder) {
+		recorder := &mock.AuditRecorder{
+			RecordFn: func(ctx context.Context, event audit.Event) error {
+				return nil
+			},
+		}
+
+		service := adminservice.NewAdminService(store)
+		service.Audit = recorder
+		return service, recorder
+	}
+
+	t.Run("Should not let an admin disable themselves", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AdminStore{}
+		service, recorder := newService(store)
+
+		err := service.DisableUser(ctx, 1)
+
+		is.Equal(errors.ToErr(err), errors.ErrCannotDisableSelf) // should refuse
+		is.True(!store.SetAuthEnabledInvoked)                    // should not disable the account
+		is.True(!recorder.RecordInvoked)                         // should not audit anything
+	})
+
+	t.Run("Should not audit a change to a missing user", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AdminStore{
+			SetAuthEnabledFn: func(ctx context.Context, userID int64, enabled bool) error {
+				return errors.NotFoundf(errors.ErrUserNotFound)
+			},
+		}
+		service, recorder := newService(store)
+
+		err := service.EnableUser(ctx, 2)
+
+		is.Equal(errors.ToReferenceCode(err), errors.NotFound) // should not be found
+		is.True(!recorder.RecordInvoked)                       // should not audit a change that did not happen
+	})
+
+	t.Run("Should bound the search limit", func(t *testing.T) {
+		t.Parallel()
+
+		var got *rf.UserSearchRequest
+		store := &mock.AdminStore{
+			SearchUsersFn: func(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error) {
+				got = req
+				return nil, nil
+			},
+		}
+		service, _ := newService(store)
+
+		_, err := service.SearchUsers(ctx, &rf.UserSearchRequest{Limit: 10000, Offset: -5})
+
+		is.NoErr(err)            // should search
+		is.Equal(got.Limit, 200) // should cap the limit
+		is.Equal(got.Offset, 0)  // should not use a negative offset
+	})
+
+	t.Run("Should promote a user and audit it", func(t *testing.T) {
+		t.Parallel()
+
+		var gotRole rf.Role
+		store := &mock.AdminStore{
+			SetUserRoleFn: func(ctx context.Context, email string, role rf.Role) (int64, error) {
+				gotRole = role
+				return 2, nil
+			},
+		}
+		var got audit.Event
+		recorder := &mock.AuditRecorder{
+			RecordFn: func(ctx context.Context, event audit.Event) error {
+				got = event
+				return nil
+			},
+		}
+		service := adminservice.NewAdminService(store)
+		service.Audit = recorder
+
+		err := service.PromoteUser(context.Background(), "gopher@go.com")
+
+		is.NoErr(err)                                    // should promote the user
+		is.Equal(gotRole, rf.RoleAdmin)                  // should make the user an admin
+		is.True(recorder.RecordInvoked)                  // should audit the promotion
+		is.Equal(got.Type, audit.EventAdminUserPromoted) // should record a promotion
+		is.Equal(got.TargetID, int64(2))                 // should record who was promoted
+	})
+
+	t.Run("Should not promote a missing user", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AdminStore{
+			SetUserRoleFn: func(ctx context.Context, email string, role rf.Role) (int64, error) {
+				return 0, errors.NotFoundf(errors.ErrUserNotFound)
+			},
+		}
+		service, recorder := newService(store)
+
+		err := service.PromoteUser(context.Background(), "nobody@go.com")
+
+		is.Equal(errors.ToReferenceCode(err), errors.NotFound) // should not be found
+		is.True(!recorder.RecordInvoked)                       // should not audit a change that did not happen
+	})
+}
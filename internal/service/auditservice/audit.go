@@ -0,0 +1,54 @@
+package auditservice
+
+import (
+	"context"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type AuditStore interface {
+	ListAuditEvents(ctx context.Context, query *audit.Query) ([]audit.Event, error)
+}
+
+type AuditService struct {
+	store AuditStore
+}
+
+func NewAuditService(store AuditStore) *AuditService {
+	return &AuditService{
+		store: store,
+	}
+}
+
+// ListUserEvents returns the events the user did or that were done to
+// their account, filtered by type and time only.
+func (as *AuditService) ListUserEvents(ctx context.Context, query *audit.Query) ([]audit.Event, error) {
+	return as.store.ListAuditEvents(ctx, &audit.Query{
+		Types:    query.Types,
+		UserID:   rfcontext.UserIDFromContext(ctx),
+		Since:    query.Since,
+		Until:    query.Until,
+		BeforeID: query.BeforeID,
+		Limit:    listLimit(query.Limit),
+	})
+}
+
+// ListEvents returns every event matching query, callers must have checked
+// the user is an administrator.
+func (as *AuditService) ListEvents(ctx context.Context, query *audit.Query) ([]audit.Event, error) {
+	query.Limit = listLimit(query.Limit)
+	return as.store.ListAuditEvents(ctx, query)
+}
+
+func listLimit(limit int) int {
+	if limit <= 0 {
+		return defaultListLimit
+	}
+	return min(limit, maxListLimit)
+}
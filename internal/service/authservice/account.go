@@ -0,0 +1,150 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
+)
+
+// AccountDeletionGracePeriod is how long a deleted account is kept before
+// it is purged by default.
+const AccountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// ChangePassword sets a new password after checking the current one and
+// signs the user out of every other session.
+func (as *AuthService) ChangePassword(ctx context.Context, req *rf.ChangePasswordRequest) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+	sessionID := rfcontext.SessionIDFromContext(ctx)
+
+	errs := map[string]string{}
+
+	if req.CurrentPassword == "" {
+		errs["currentPassword"] = errors.ErrPasswordRequired
+	}
+
+	if req.NewPassword == "" {
+		errs["newPassword"] = errors.ErrPasswordRequired
+	}
+
+	if len(errs) > 0 {
+		return errors.InvalidError(errs)
+	}
+
+	if req.CurrentPassword == req.NewPassword {
+		return errors.InvalidDataf(errors.ErrPasswordUnchanged)
+	}
+
+	foundAuth, err := as.store.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if foundAuth == nil {
+		return errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	match, err := password.Matches(req.CurrentPassword, foundAuth.BasicAuth.Password)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return errors.Unauthorizedf(errors.ErrInvalidCredentials)
+	}
+
+	identifiers := []string{foundAuth.BasicAuth.Email}
+	if foundAuth.User != nil {
+		identifiers = append(identifiers, foundAuth.User.Name)
+	}
+
+	err = checkPasswordPolicy(as.PasswordPolicy, errs, "newPassword", req.NewPassword, identifiers...)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errors.InvalidError(errs)
+	}
+
+	hashedPassword, err := password.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	err = as.store.ChangePassword(ctx, userID, sessionID, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventPasswordChanged,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	return nil
+}
+
+// DeleteAccount deletes the account of the user and signs them out
+// everywhere. It is purged after DeletionGracePeriod, signing in before
+// then restores it.
+func (as *AuthService) DeleteAccount(ctx context.Context) (*rf.AccountDeletion, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	foundAuth, err := as.store.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if foundAuth == nil {
+		return nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	purgeAt := time.Now().UTC().Add(as.DeletionGracePeriod).Truncate(time.Second)
+
+	err = as.store.DeleteAccount(ctx, userID, purgeAt)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventAccountDeleted,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	msg := mail.Message{
+		To:      foundAuth.BasicAuth.Email,
+		Subject: "Your account was deleted",
+		Body: fmt.Sprintf("Your account was deleted and will be removed for good on %s.\n\n"+
+			"If you change your mind, sign in before then to restore it.\n",
+			purgeAt.Format(time.RFC1123)),
+	}
+
+	// The account is deleted either way, a failure to send is only logged.
+	if err := as.Mailer.Send(ctx, msg); err != nil {
+		slog.Error("Account deletion mail error", "err", err.Error(), "userID", userID)
+	}
+
+	return &rf.AccountDeletion{PurgeAt: purgeAt}, nil
+}
+
+// PurgeDeletedAccounts removes the accounts whose grace period is over.
+func (as *AuthService) PurgeDeletedAccounts(ctx context.Context) error {
+	purged, err := as.store.PurgeDeletedAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		slog.Info("Purged deleted accounts", "count", purged)
+	}
+
+	return nil
+}
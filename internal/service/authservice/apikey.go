@@ -0,0 +1,131 @@
+package authservice
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+const (
+	// APIKeyPrefix marks bearer tokens that are api keys rather than access
+	// tokens.
+	APIKeyPrefix = "rfk_"
+
+	// apiKeyDisplayChars is how much of a key is kept in the clear so users
+	// can tell their keys apart.
+	apiKeyDisplayChars = 8
+)
+
+func (as *AuthService) CreateAPIKey(ctx context.Context, req *rf.CreateAPIKeyRequest) (*rf.APIKey, error) {
+	errs := map[string]string{}
+
+	if strings.TrimSpace(req.Name) == "" {
+		errs["name"] = errors.ErrNameRequired
+	}
+
+	if req.Scope != rf.APIKeyScopeRead && req.Scope != rf.APIKeyScopeReadWrite {
+		errs["scope"] = errors.ErrAPIKeyScopeInvalid
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		errs["expiresAt"] = errors.ErrAPIKeyExpiryPast
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.InvalidError(errs)
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &rf.APIKey{
+		UserID:    rfcontext.UserIDFromContext(ctx),
+		Name:      strings.TrimSpace(req.Name),
+		Scope:     req.Scope,
+		ExpiresAt: req.ExpiresAt,
+		Key:       APIKeyPrefix + secret,
+		Prefix:    APIKeyPrefix + secret[:apiKeyDisplayChars],
+	}
+	key.KeyHash = hashToken(key.Key)
+
+	err = as.store.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventAPIKeyCreated,
+		TargetType: audit.TargetAPIKey,
+		TargetID:   key.ID,
+		Detail:     string(key.Scope),
+	})
+
+	return key, nil
+}
+
+func (as *AuthService) ListAPIKeys(ctx context.Context) ([]rf.APIKey, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	keys, err := as.store.ListUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (as *AuthService) RevokeAPIKey(ctx context.Context, keyID int64) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	err := as.store.RevokeAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventAPIKeyRevoked,
+		TargetType: audit.TargetAPIKey,
+		TargetID:   keyID,
+	})
+
+	return nil
+}
+
+// VerifyAPIKey returns the active api key matching key and records its use.
+func (as *AuthService) VerifyAPIKey(ctx context.Context, key string) (*rf.APIKey, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	foundKey, err := as.store.FindAPIKeyByHash(ctx, hashToken(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if foundKey == nil || foundKey.RevokedAt != nil {
+		return nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	if foundKey.ExpiresAt != nil && time.Now().After(*foundKey.ExpiresAt) {
+		return nil, errors.Unauthorizedf(errors.ErrAPIKeyExpired)
+	}
+
+	_, err = findActiveAuth(ctx, as.store, foundKey.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = as.store.TouchAPIKey(ctx, foundKey.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundKey, nil
+}
@@ -3,30 +3,130 @@ package authservice
 import (
 	"context"
 	"log"
+	"net/url"
+	"time"
 
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/limiter"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
 )
 
+const (
+	// AccessTokenTTL is how long an access token is accepted, sessions are
+	// kept alive past it with refresh tokens.
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a session lasts without being refreshed.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type AuthStore interface {
 	CreateAuthAndUser(ctx context.Context, auth *rf.Auth) error
 	FindByEmail(ctx context.Context, email string) (*rf.Auth, error)
+	CreateSession(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error
+	FindRefreshToken(ctx context.Context, tokenHash string) (*rf.RefreshToken, error)
+	FindSessionByID(ctx context.Context, sessionID int64) (*rf.Session, error)
+	RotateRefreshToken(ctx context.Context, session *rf.Session, used, next *rf.RefreshToken) error
+	RevokeSession(ctx context.Context, userID, sessionID int64) error
+	ListUserSessions(ctx context.Context, userID int64) ([]rf.Session, error)
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID int64) error
+	CreateAPIKey(ctx context.Context, key *rf.APIKey) error
+	ListUserAPIKeys(ctx context.Context, userID int64) ([]rf.APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*rf.APIKey, error)
+	TouchAPIKey(ctx context.Context, keyID int64) error
+	RevokeAPIKey(ctx context.Context, userID, keyID int64) error
+	CreatePasswordResetToken(ctx context.Context, token *rf.PasswordResetToken) error
+	ResetPassword(ctx context.Context, tokenHash, hashedPassword string) (int64, error)
+	FindByUserID(ctx context.Context, userID int64) (*rf.Auth, error)
+	RecordSignInAttempt(ctx context.Context, auth *rf.Auth, succeeded bool) error
+	CreateEmailVerificationToken(ctx context.Context, token *rf.EmailVerificationToken) error
+	ConfirmEmail(ctx context.Context, tokenHash string) (int64, error)
+	SaveTOTPSecret(ctx context.Context, totp *rf.TOTP) error
+	FindTOTP(ctx context.Context, userID int64) (*rf.TOTP, error)
+	EnableTOTP(ctx context.Context, userID, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID, step int64) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+	DisableTOTP(ctx context.Context, userID, step int64) error
+	CreateUnlockToken(ctx context.Context, token *rf.UnlockToken) error
+	UseUnlockToken(ctx context.Context, tokenHash string) (*rf.Auth, error)
+	CreateOIDCLoginState(ctx context.Context, state *rf.OIDCLoginState) error
+	UseOIDCLoginState(ctx context.Context, provider, stateHash string) (*rf.OIDCLoginState, error)
+	FindIdentity(ctx context.Context, issuer, subject string) (*rf.AuthIdentity, error)
+	CreateIdentity(ctx context.Context, identity *rf.AuthIdentity) error
+	CreateAuthAndUserWithIdentity(ctx context.Context, auth *rf.Auth, identity *rf.AuthIdentity) error
+	ChangePassword(ctx context.Context, userID, keepSessionID int64, hashedPassword string) error
+	RehashPassword(ctx context.Context, userID int64, oldHash, newHash string) error
+	DeleteAccount(ctx context.Context, userID int64, purgeAt time.Time) error
+	PurgeDeletedAccounts(ctx context.Context) (int64, error)
 }
 
 type AuthService struct {
 	store AuthStore
+
+	// Mailer sends account mail such as password reset links.
+	Mailer mail.Mailer
+	// PasswordResetURL returns the link a user follows to reset their
+	// password with token.
+	PasswordResetURL func(token string) string
+	// EmailVerificationURL returns the link a user follows to confirm their
+	// email address with token.
+	EmailVerificationURL func(token string) string
+	// UnlockURL returns the link a user follows to unlock their account
+	// after a lockout with token.
+	UnlockURL func(token string) string
+	// Limiter counts failed sign ins by email and IP.
+	Limiter limiter.Limiter
+	// Audit records security relevant account changes.
+	Audit audit.Recorder
+	// TOTPIssuer names the service in authenticator apps.
+	TOTPIssuer string
+	// OIDCProviders are the OpenID Connect providers users can sign in
+	// with by name.
+	OIDCProviders map[string]OIDCProvider
+	// DeletionGracePeriod is how long a deleted account can be restored by
+	// signing in before it is purged.
+	DeletionGracePeriod time.Duration
+	// PasswordPolicy is what new passwords have to satisfy.
+	PasswordPolicy *password.Policy
 }
 
 func NewAuthService(store AuthStore) *AuthService {
 	return &AuthService{
-		store: store,
+		store:  store,
+		Mailer: mail.NewMemoryMailer(),
+		PasswordResetURL: func(token string) string {
+			return "/reset-password?token=" + url.QueryEscape(token)
+		},
+		EmailVerificationURL: func(token string) string {
+			return "/verify-email?token=" + url.QueryEscape(token)
+		},
+		UnlockURL: func(token string) string {
+			return "/unlock-account?token=" + url.QueryEscape(token)
+		},
+		Limiter:             limiter.NewMemoryLimiter(),
+		Audit:               audit.NewLogRecorder(),
+		TOTPIssuer:          DefaultTOTPIssuer,
+		DeletionGracePeriod: AccountDeletionGracePeriod,
+		PasswordPolicy:      password.DefaultPolicy(),
 	}
 }
 
-func (as *AuthService) SignUp(ctx context.Context, req *rf.SignUpRequest) (string, error) {
+func (as *AuthService) SignUp(ctx context.Context, req *rf.SignUpRequest) (*rf.AuthTokens, error) {
 	args := AuthArgs{
-		store: as.store,
+		store:                as.store,
+		mailer:               as.Mailer,
+		emailVerificationURL: as.EmailVerificationURL,
+		passwordPolicy:       as.PasswordPolicy,
+		session: &rf.Session{
+			DeviceName: req.DeviceName,
+			IP:         req.IP,
+			UserAgent:  req.UserAgent,
+		},
 		auth: builder.NewAuthBuilder().
 			WithUser(builder.NewUserBuilder().
 				WithName(req.Name)).
@@ -38,20 +138,38 @@ func (as *AuthService) SignUp(ctx context.Context, req *rf.SignUpRequest) (strin
 
 	if err := args.validateSignUp(); err != nil {
 		log.Printf("Val: %#v", err)
-		return "", err
+		return nil, err
 	}
 
 	result, err := statemachine.Run(ctx, args, canSignUpCheckState)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return result.auth.Token, nil
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventSignUp,
+		ActorID:    result.auth.UserID,
+		TargetType: audit.TargetUser,
+		TargetID:   result.auth.UserID,
+		IP:         req.IP,
+		UserAgent:  req.UserAgent,
+	})
+
+	return result.tokens(), nil
 }
 
-func (as *AuthService) SignIn(ctx context.Context, req *rf.SignInRequest) (string, error) {
+func (as *AuthService) SignIn(ctx context.Context, req *rf.SignInRequest) (*rf.AuthTokens, error) {
 	args := AuthArgs{
-		store: as.store,
+		store:     as.store,
+		mailer:    as.Mailer,
+		unlockURL: as.UnlockURL,
+		limiter:   as.Limiter,
+		recorder:  as.Audit,
+		session: &rf.Session{
+			DeviceName: req.DeviceName,
+			IP:         req.IP,
+			UserAgent:  req.UserAgent,
+		},
 		auth: builder.NewAuthBuilder().
 			WithBasicAuth(builder.NewBasicAuthBuilder().
 				WithEmail(req.Email).
@@ -60,13 +178,206 @@ func (as *AuthService) SignIn(ctx context.Context, req *rf.SignInRequest) (strin
 	}
 
 	if err := args.validateSignIn(); err != nil {
-		return "", err
+		return nil, err
+	}
+
+	result, err := statemachine.Run(ctx, args, throttleSignInState)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.tokens(), nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token
+// pair. Presenting an already used refresh token revokes its session.
+func (as *AuthService) Refresh(ctx context.Context, req *rf.RefreshRequest) (*rf.AuthTokens, error) {
+	args := AuthArgs{
+		store:        as.store,
+		recorder:     as.Audit,
+		auth:         &rf.Auth{},
+		refreshToken: req.RefreshToken,
+		session: &rf.Session{
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		},
+	}
+
+	if err := args.validateRefresh(); err != nil {
+		return nil, err
+	}
+
+	result, err := statemachine.Run(ctx, args, findRefreshTokenState)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.tokens(), nil
+}
+
+// SignOut revokes the session of a refresh token, unknown tokens are
+// ignored as there is nothing left to sign out of.
+func (as *AuthService) SignOut(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+
+	token, err := as.store.FindRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+
+	if token == nil {
+		return nil
+	}
+
+	session, err := as.store.FindSessionByID(ctx, token.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || !session.RevokedAt.IsZero() {
+		return nil
+	}
+
+	err = as.store.RevokeSession(ctx, session.UserID, session.ID)
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventSessionRevoked,
+		ActorID:    session.UserID,
+		TargetType: audit.TargetSession,
+		TargetID:   session.ID,
+		Detail:     "signed out",
+	})
+
+	return nil
+}
+
+// VerifySession checks an access token's session is still active, so
+// revoking a session signs its device out before the token expires.
+func (as *AuthService) VerifySession(ctx context.Context, userID, sessionID int64) error {
+	if sessionID == 0 {
+		return errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	session, err := as.store.FindSessionByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || session.UserID != userID {
+		return errors.Unauthorizedf(errors.ErrUnauthorized)
 	}
 
-	result, err := statemachine.Run(ctx, args, canSignInCheckState)
+	if !session.RevokedAt.IsZero() {
+		return errors.Unauthorizedf(errors.ErrSessionRevoked)
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return errors.Unauthorizedf(errors.ErrSessionExpired)
+	}
+
+	_, err = findActiveAuth(ctx, as.store, userID)
+	return err
+}
+
+// findActiveAuth rejects users whose account was disabled or deleted after
+// their token was issued.
+func findActiveAuth(ctx context.Context, store AuthStore, userID int64) (*rf.Auth, error) {
+	foundAuth, err := store.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if foundAuth == nil {
+		return nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	if err := checkAccountActive(foundAuth); err != nil {
+		return nil, err
+	}
+
+	return foundAuth, nil
+}
+
+func checkAccountActive(auth *rf.Auth) error {
+	if auth.Deleted {
+		return errors.AccountDisabledf(errors.ErrAccountDeleted)
+	}
+
+	if !auth.Enabled {
+		return errors.AccountDisabledf(errors.ErrAccountDisabled)
+	}
+
+	return nil
+}
+
+// checkCanSignIn is checkAccountActive for sign ins, an account its user
+// deleted can still sign in until it is purged, which restores it.
+func checkCanSignIn(auth *rf.Auth) error {
+	if auth.Deleted && !auth.PurgeAt.After(time.Now()) {
+		return errors.AccountDisabledf(errors.ErrAccountDeleted)
+	}
+
+	if !auth.Enabled {
+		return errors.AccountDisabledf(errors.ErrAccountDisabled)
+	}
+
+	return nil
+}
+
+func (as *AuthService) ListSessions(ctx context.Context) ([]rf.Session, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+	sessionID := rfcontext.SessionIDFromContext(ctx)
+
+	sessions, err := as.store.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == sessionID
+	}
+
+	return sessions, nil
+}
+
+func (as *AuthService) RevokeSession(ctx context.Context, sessionID int64) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	err := as.store.RevokeSession(ctx, userID, sessionID)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return result.auth.Token, nil
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventSessionRevoked,
+		TargetType: audit.TargetSession,
+		TargetID:   sessionID,
+	})
+
+	return nil
+}
+
+// RevokeOtherSessions signs the user out everywhere but the current session.
+func (as *AuthService) RevokeOtherSessions(ctx context.Context) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+	sessionID := rfcontext.SessionIDFromContext(ctx)
+
+	err := as.store.RevokeOtherSessions(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventSessionRevoked,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+		Detail:     "every other session",
+	})
+
+	return nil
 }
@@ -5,16 +5,64 @@ import (
 	"time"
 
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/limiter"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
 )
 
 type AuthArgs struct {
-	store          AuthStore
-	auth           *rf.Auth
-	authToValidate *rf.Auth
+	store                AuthStore
+	mailer               mail.Mailer
+	emailVerificationURL func(token string) string
+	passwordPolicy       *password.Policy
+	unlockURL            func(token string) string
+	limiter              limiter.Limiter
+	recorder             audit.Recorder
+	auth                 *rf.Auth
+	authToValidate       *rf.Auth
+	session              *rf.Session
+	refreshToken         string
+	mfaToken             string
+	mfaTokenExpiresAt    time.Time
+	mfaCode              string
+	oidcProviderName     string
+	oidcProvider         OIDCProvider
+	oidcCode             string
+	oidcState            string
+	oidcLoginState       *rf.OIDCLoginState
+	oidcClaims           *oidc.Claims
+	identity             *rf.AuthIdentity
+	linkToken            string
+	linkTokenExpiresAt   time.Time
+}
+
+func (as AuthArgs) tokens() *rf.AuthTokens {
+	if as.linkToken != "" {
+		return &rf.AuthTokens{
+			LinkToken:          as.linkToken,
+			LinkTokenExpiresAt: as.linkTokenExpiresAt,
+		}
+	}
+
+	if as.mfaToken != "" {
+		return &rf.AuthTokens{
+			MFAToken:          as.mfaToken,
+			MFATokenExpiresAt: as.mfaTokenExpiresAt,
+		}
+	}
+
+	return &rf.AuthTokens{
+		SessionID:             as.auth.Session.ID,
+		AccessToken:           as.auth.Token,
+		AccessTokenExpiresAt:  as.auth.Session.LastUsedAt.Add(AccessTokenTTL),
+		RefreshToken:          as.auth.RefreshToken,
+		RefreshTokenExpiresAt: as.auth.Session.ExpiresAt,
+	}
 }
 
 func (as AuthArgs) validateSignUp() error {
@@ -36,6 +84,18 @@ func (as AuthArgs) validateSignUp() error {
 		errs["name"] = errors.ErrNameRequired
 	}
 
+	if errs["password"] == "" {
+		identifiers := []string{as.auth.BasicAuth.Email}
+		if as.auth.User != nil {
+			identifiers = append(identifiers, as.auth.User.Name)
+		}
+
+		err := checkPasswordPolicy(as.passwordPolicy, errs, "password", as.auth.BasicAuth.Password, identifiers...)
+		if err != nil {
+			return err
+		}
+	}
+
 	if len(errs) > 0 {
 		return errors.InvalidError(errs)
 	}
@@ -65,6 +125,18 @@ func (as AuthArgs) validateSignIn() error {
 	return nil
 }
 
+func (as AuthArgs) validateRefresh() error {
+	if as.store == nil {
+		return errors.InternalErrorf("store cannot be nil")
+	}
+
+	if as.refreshToken == "" {
+		return errors.Unauthorizedf(errors.ErrRefreshTokenAbsent)
+	}
+
+	return nil
+}
+
 func canSignUpCheckState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
 	hasAuth, err := args.store.FindByEmail(ctx, args.auth.BasicAuth.Email)
 	if err != nil {
@@ -85,6 +157,9 @@ func canSignInCheckState(ctx context.Context, args AuthArgs) (AuthArgs, statemac
 	}
 
 	if hasAuth == nil {
+		if err := recordSignInFailure(ctx, args, nil); err != nil {
+			return args, nil, err
+		}
 		return args, nil, errors.Unauthorizedf(errors.ErrInvalidCredentials)
 	}
 
@@ -105,24 +180,210 @@ func createAuthAndUserState(ctx context.Context, args AuthArgs) (AuthArgs, state
 		return args, nil, err
 	}
 
-	return args, generateUserTokenState, nil
+	return args, sendEmailVerificationState, nil
+}
+
+func sendEmailVerificationState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	err := sendEmailVerification(ctx, args.store, args.mailer, args.emailVerificationURL, args.auth.UserID, args.auth.BasicAuth.Email)
+	if err != nil {
+		return args, nil, err
+	}
+
+	return args, createSessionState, nil
 }
 
 func validateAuthState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
 	match, err := password.Matches(args.auth.BasicAuth.Password, args.authToValidate.BasicAuth.Password)
-	if err != nil {
+	if err != nil && errors.ToErr(err) != errors.ErrInvalidCredentials {
 		return args, nil, err
 	}
 	if !match {
+		if err := args.store.RecordSignInAttempt(ctx, args.authToValidate, false); err != nil {
+			return args, nil, err
+		}
+		if err := recordSignInFailure(ctx, args, args.authToValidate); err != nil {
+			return args, nil, err
+		}
 		return args, nil, errors.Unauthorizedf(errors.ErrInvalidCredentials)
 	}
 
+	// Account status is only revealed to someone who knows the password.
+	if err := checkCanSignIn(args.authToValidate); err != nil {
+		return args, nil, err
+	}
+
+	rehashPassword(ctx, args.store, args.authToValidate, args.auth.BasicAuth.Password)
+
+	if args.identity != nil {
+		return args, linkIdentityState, nil
+	}
+
+	return args, firstFactorVerifiedState, nil
+}
+
+// firstFactorVerifiedState signs in a user who gave their password or
+// signed in with a provider, unless a second factor is still needed.
+func firstFactorVerifiedState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	if !args.authToValidate.TOTPEnabledAt.IsZero() {
+		return args, mfaPendingState, nil
+	}
+
 	args.auth.UserID = args.authToValidate.UserID
+	args.auth.Role = args.authToValidate.Role
+	return args, recordSignInState, nil
+}
+
+func recordSignInState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	err := args.store.RecordSignInAttempt(ctx, args.authToValidate, true)
+	if err != nil {
+		return args, nil, err
+	}
+
+	err = args.limiter.Reset(ctx, emailLimiterKey(args.auth.BasicAuth.Email))
+	if err != nil {
+		return args, nil, err
+	}
+
+	audit.Record(ctx, args.recorder, audit.Event{
+		Type:       audit.EventSignInSucceeded,
+		ActorID:    args.authToValidate.UserID,
+		TargetType: audit.TargetUser,
+		TargetID:   args.authToValidate.UserID,
+		IP:         args.session.IP,
+		UserAgent:  args.session.UserAgent,
+		Detail:     args.oidcProviderName,
+	})
+
+	args.auth.LastSignedInAt = args.authToValidate.LastSignedInAt
+	return args, createSessionState, nil
+}
+
+func createSessionState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	refreshToken, err := generateToken()
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.session.UserID = args.auth.UserID
+	args.session.ExpiresAt = time.Now().UTC().Add(RefreshTokenTTL).Truncate(time.Second)
+
+	err = args.store.CreateSession(ctx, args.session, &rf.RefreshToken{TokenHash: hashToken(refreshToken)})
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.auth.Session = args.session
+	args.auth.RefreshToken = refreshToken
 	return args, generateUserTokenState, nil
 }
 
+func findRefreshTokenState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	token, err := args.store.FindRefreshToken(ctx, hashToken(args.refreshToken))
+	if err != nil {
+		return args, nil, err
+	}
+
+	if token == nil {
+		return args, nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	session, err := args.store.FindSessionByID(ctx, token.SessionID)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if session == nil {
+		return args, nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	// A refresh token is only ever handed out once, seeing it again means
+	// it leaked so every token of the session is revoked.
+	if !token.UsedAt.IsZero() {
+		if err := revokeReusedSession(ctx, args, session); err != nil {
+			return args, nil, err
+		}
+		return args, nil, errors.Unauthorizedf(errors.ErrRefreshTokenReused)
+	}
+
+	if !session.RevokedAt.IsZero() {
+		return args, nil, errors.Unauthorizedf(errors.ErrSessionRevoked)
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return args, nil, errors.Unauthorizedf(errors.ErrSessionExpired)
+	}
+
+	foundAuth, err := findActiveAuth(ctx, args.store, session.UserID)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if args.session.IP != "" {
+		session.IP = args.session.IP
+	}
+	if args.session.UserAgent != "" {
+		session.UserAgent = args.session.UserAgent
+	}
+
+	args.session = session
+	args.auth.UserID = session.UserID
+	args.auth.Role = foundAuth.Role
+	args.auth.Session = session
+	return args, rotateRefreshTokenState, nil
+}
+
+func rotateRefreshTokenState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	refreshToken, err := generateToken()
+	if err != nil {
+		return args, nil, err
+	}
+
+	used := &rf.RefreshToken{TokenHash: hashToken(args.refreshToken)}
+	next := &rf.RefreshToken{TokenHash: hashToken(refreshToken)}
+
+	// Each refresh keeps the session alive for another RefreshTokenTTL.
+	args.session.ExpiresAt = time.Now().UTC().Add(RefreshTokenTTL).Truncate(time.Second)
+
+	err = args.store.RotateRefreshToken(ctx, args.session, used, next)
+	if err != nil {
+		if errors.ToErr(err) == errors.ErrRefreshTokenReused {
+			if err := revokeReusedSession(ctx, args, args.session); err != nil {
+				return args, nil, err
+			}
+		}
+		return args, nil, err
+	}
+
+	args.auth.RefreshToken = refreshToken
+	return args, generateUserTokenState, nil
+}
+
+// revokeReusedSession revokes a session whose refresh token was presented
+// twice.
+func revokeReusedSession(ctx context.Context, args AuthArgs, session *rf.Session) error {
+	if err := args.store.RevokeSession(ctx, session.UserID, session.ID); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, args.recorder, audit.Event{
+		Type:       audit.EventSessionRevoked,
+		TargetType: audit.TargetSession,
+		TargetID:   session.ID,
+		IP:         args.session.IP,
+		UserAgent:  args.session.UserAgent,
+		Detail:     "refresh token reused",
+	})
+
+	return nil
+}
+
 func generateUserTokenState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
-	token, err := jwt.GenerateAndSignUserID(args.auth.UserID, time.Now().Add(time.Hour*24))
+	if args.auth.Session.LastUsedAt.IsZero() {
+		args.auth.Session.LastUsedAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	ttl := args.auth.Session.LastUsedAt.Add(AccessTokenTTL)
+	token, err := jwt.GenerateAndSignUserSession(args.auth.UserID, args.auth.Session.ID, args.auth.Role, ttl)
 	if err != nil {
 		return args, nil, err
 	}
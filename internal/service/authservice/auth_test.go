@@ -9,10 +9,16 @@ import (
 
 	"github.com/alexedwards/argon2id"
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc/oidctest"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/authservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/totp"
 	"github.com/matryer/is"
 )
 
@@ -32,6 +38,13 @@ func TestAuthService_SignUp_Success(t *testing.T) {
 				auth.LastSignedInAt = time.Now()
 				return nil
 			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+			CreateEmailVerificationTokenFn: func(ctx context.Context, token *rf.EmailVerificationToken) error {
+				return nil
+			},
 			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
 				return nil, nil
 			},
@@ -45,12 +58,12 @@ func TestAuthService_SignUp_Success(t *testing.T) {
 			WithPassword("gogopher1").
 			Build()
 
-		token, err := service.SignUp(context.Background(), req)
+		tokens, err := service.SignUp(context.Background(), req)
 
-		is.NoErr(err)                     // should be signed up
-		is.True(len(token) > 0)           // should receive token
-		is.True(store.CreateInvoked)      // auth store Create should have been invoked
-		is.True(store.FindByEmailInvoked) // auth store FindByEmail should have been invoked
+		is.NoErr(err)                        // should be signed up
+		is.True(len(tokens.AccessToken) > 0) // should receive token
+		is.True(store.CreateInvoked)         // auth store Create should have been invoked
+		is.True(store.FindByEmailInvoked)    // auth store FindByEmail should have been invoked
 	})
 }
 
@@ -93,9 +106,17 @@ func TestAuthService_SignIn_Success(t *testing.T) {
 		is.NoErr(err) // Should hash password
 
 		store := &mock.AuthStore{
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
 			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
 				auth := builder.NewAuthBuilder().
 					WithUserID(1).
+					AsEnabled(true).
 					WithBasicAuth(builder.NewBasicAuthBuilder().WithPassword(hashedPassword)).
 					Build()
 				return auth, nil
@@ -109,12 +130,72 @@ func TestAuthService_SignIn_Success(t *testing.T) {
 			WithPassword(password).
 			Build()
 
-		token, err := service.SignIn(context.Background(), req)
+		tokens, err := service.SignIn(context.Background(), req)
+
+		is.NoErr(err)                         // should be signed in
+		is.True(len(tokens.AccessToken) > 0)  // should receive token
+		is.True(!store.CreateInvoked)         // auth store Create should not have been invoked
+		is.True(store.FindByEmailInvoked)     // auth store FindByEmail should  have been invoked
+		is.True(!store.RehashPasswordInvoked) // should keep a hash made with the current parameters
+	})
+
+	t.Run("Should rehash a password hashed with weaker parameters", func(t *testing.T) {
+		t.Parallel()
+
+		password := "gogopher1"
+		weakHash, err := argon2id.CreateHash(password, &argon2id.Params{
+			Memory:      1024,
+			Iterations:  1,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		})
+		is.NoErr(err) // Should hash password
+
+		var oldHash, newHash string
+		store := &mock.AuthStore{
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				auth := builder.NewAuthBuilder().
+					WithUserID(1).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().WithPassword(weakHash)).
+					Build()
+				return auth, nil
+			},
+			RehashPasswordFn: func(ctx context.Context, userID int64, old, new string) error {
+				oldHash, newHash = old, new
+				return nil
+			},
+		}
+
+		service := authservice.NewAuthService(store)
+
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher1@go.com").
+			WithPassword(password).
+			Build()
+
+		_, err = service.SignIn(context.Background(), req)
+
+		is.NoErr(err)                        // should be signed in
+		is.True(store.RehashPasswordInvoked) // should upgrade the hash
+		is.Equal(oldHash, weakHash)          // should only replace the hash it checked
 
-		is.NoErr(err)                     // should be signed in
-		is.True(len(token) > 0)           // should receive token
-		is.True(!store.CreateInvoked)     // auth store Create should not have been invoked
-		is.True(store.FindByEmailInvoked) // auth store FindByEmail should  have been invoked
+		match, err := argon2id.ComparePasswordAndHash(password, newHash)
+		is.NoErr(err)  // should compare the hash
+		is.True(match) // should hash the same password
+
+		params, _, _, err := argon2id.DecodeHash(newHash)
+		is.NoErr(err)                                                  // should decode the hash
+		is.Equal(params.Memory, argon2id.DefaultParams.Memory)         // should use the current memory
+		is.Equal(params.Iterations, argon2id.DefaultParams.Iterations) // should use the current iterations
 	})
 }
 
@@ -143,3 +224,1615 @@ func TestAuthService_SignIn_Failure(t *testing.T) {
 		})
 	}
 }
+
+func newSessionAuthStore(t *testing.T) *mock.AuthStore {
+	t.Helper()
+
+	hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := map[int64]*rf.Session{}
+	tokens := map[string]*rf.RefreshToken{}
+
+	return &mock.AuthStore{
+		FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+			return builder.NewAuthBuilder().
+				WithUserID(1).
+				AsEnabled(true).
+				WithBasicAuth(builder.NewBasicAuthBuilder().
+					WithPassword(hashedPassword)).
+				Build(), nil
+		},
+		RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+			return nil
+		},
+		CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+			session.ID = int64(len(sessions) + 1)
+			session.CreatedAt = time.Now()
+			session.LastUsedAt = session.CreatedAt
+			sessions[session.ID] = session
+			token.SessionID = session.ID
+			tokens[token.TokenHash] = token
+			return nil
+		},
+		FindRefreshTokenFn: func(ctx context.Context, tokenHash string) (*rf.RefreshToken, error) {
+			if token, ok := tokens[tokenHash]; ok {
+				copied := *token
+				return &copied, nil
+			}
+			return nil, nil
+		},
+		FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+			return builder.NewAuthBuilder().WithUserID(userID).AsEnabled(true).Build(), nil
+		},
+		FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+			if session, ok := sessions[sessionID]; ok {
+				copied := *session
+				return &copied, nil
+			}
+			return nil, nil
+		},
+		RotateRefreshTokenFn: func(ctx context.Context, session *rf.Session, used, next *rf.RefreshToken) error {
+			if !tokens[used.TokenHash].UsedAt.IsZero() {
+				return errors.Unauthorizedf(errors.ErrRefreshTokenReused)
+			}
+			tokens[used.TokenHash].UsedAt = time.Now()
+			next.SessionID = session.ID
+			tokens[next.TokenHash] = next
+			session.LastUsedAt = time.Now()
+			sessions[session.ID].LastUsedAt = session.LastUsedAt
+			sessions[session.ID].ExpiresAt = session.ExpiresAt
+			return nil
+		},
+		RevokeSessionFn: func(ctx context.Context, userID, sessionID int64) error {
+			sessions[sessionID].RevokedAt = time.Now()
+			return nil
+		},
+	}
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	signIn := func(service *authservice.AuthService) *rf.AuthTokens {
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher1@go.com").
+			WithPassword("gogopher1").
+			Build()
+
+		tokens, err := service.SignIn(context.Background(), req)
+		is.NoErr(err) // should be signed in
+		return tokens
+	}
+
+	t.Run("Should rotate the refresh token", func(t *testing.T) {
+		t.Parallel()
+
+		store := newSessionAuthStore(t)
+		service := authservice.NewAuthService(store)
+
+		signedIn := signIn(service)
+		is.True(len(signedIn.RefreshToken) > 0) // should receive a refresh token
+
+		refreshed, err := service.Refresh(context.Background(), &rf.RefreshRequest{RefreshToken: signedIn.RefreshToken})
+
+		is.NoErr(err)                                                                   // should refresh
+		is.True(len(refreshed.AccessToken) > 0)                                         // should receive a new access token
+		is.True(refreshed.RefreshToken != signedIn.RefreshToken)                        // should rotate the refresh token
+		is.True(refreshed.AccessTokenExpiresAt.After(time.Now()))                       // should expire in the future
+		is.True(refreshed.AccessTokenExpiresAt.Before(refreshed.RefreshTokenExpiresAt)) // access tokens should be short lived
+
+		_, err = service.Refresh(context.Background(), &rf.RefreshRequest{RefreshToken: refreshed.RefreshToken})
+
+		is.NoErr(err) // the rotated refresh token should refresh
+	})
+
+	t.Run("Should keep a refreshed session alive for another RefreshTokenTTL", func(t *testing.T) {
+		t.Parallel()
+
+		store := newSessionAuthStore(t)
+		service := authservice.NewAuthService(store)
+
+		signedIn := signIn(service)
+
+		findSession := store.FindSessionByIDFn
+		store.FindSessionByIDFn = func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+			session, err := findSession(ctx, sessionID)
+			if session != nil {
+				session.ExpiresAt = time.Now().Add(time.Hour)
+			}
+			return session, err
+		}
+
+		refreshed, err := service.Refresh(context.Background(), &rf.RefreshRequest{RefreshToken: signedIn.RefreshToken})
+
+		is.NoErr(err)                                                                                             // should refresh
+		is.True(refreshed.RefreshTokenExpiresAt.After(time.Now().Add(authservice.RefreshTokenTTL - time.Minute))) // should slide the session expiry
+	})
+
+	t.Run("Should revoke the session when a refresh token is reused", func(t *testing.T) {
+		t.Parallel()
+
+		store := newSessionAuthStore(t)
+		service := authservice.NewAuthService(store)
+
+		signedIn := signIn(service)
+
+		refreshed, err := service.Refresh(context.Background(), &rf.RefreshRequest{RefreshToken: signedIn.RefreshToken})
+		is.NoErr(err) // should refresh
+
+		_, err = service.Refresh(context.Background(), &rf.RefreshRequest{RefreshToken: signedIn.RefreshToken})
+
+		is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // reuse should be unauthorized
+		is.Equal(errors.ToErr(err), errors.ErrRefreshTokenReused)  // should report the reuse
+		is.True(store.RevokeSessionInvoked)                        // should revoke the session
+
+		_, err = service.Refresh(context.Background(), &rf.RefreshRequest{RefreshToken: refreshed.RefreshToken})
+
+		is.Equal(errors.ToErr(err), errors.ErrSessionRevoked) // the whole family should be revoked
+	})
+
+	t.Run("Should revoke the session on sign out", func(t *testing.T) {
+		t.Parallel()
+
+		store := newSessionAuthStore(t)
+		service := authservice.NewAuthService(store)
+
+		signedIn := signIn(service)
+
+		err := service.SignOut(context.Background(), signedIn.RefreshToken)
+		is.NoErr(err) // should sign out
+
+		_, err = service.Refresh(context.Background(), &rf.RefreshRequest{RefreshToken: signedIn.RefreshToken})
+
+		is.Equal(errors.ToErr(err), errors.ErrSessionRevoked) // should not refresh a signed out session
+	})
+
+	t.Run("Should fail to refresh unknown or missing tokens", func(t *testing.T) {
+		t.Parallel()
+
+		store := newSessionAuthStore(t)
+		service := authservice.NewAuthService(store)
+
+		_, err := service.Refresh(context.Background(), &rf.RefreshRequest{})
+		is.Equal(errors.ToErr(err), errors.ErrRefreshTokenAbsent) // should require a refresh token
+		is.True(!store.FindRefreshTokenInvoked)                   // should not look up the token
+
+		_, err = service.Refresh(context.Background(), &rf.RefreshRequest{RefreshToken: "unknown"})
+		is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // unknown tokens should be unauthorized
+	})
+}
+
+func TestAuthService_Sessions(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should only verify active sessions of the user", func(t *testing.T) {
+		t.Parallel()
+
+		store := newSessionAuthStore(t)
+		service := authservice.NewAuthService(store)
+
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher1@go.com").
+			WithPassword("gogopher1").
+			Build()
+		_, err := service.SignIn(context.Background(), req)
+		is.NoErr(err) // should be signed in
+
+		is.NoErr(service.VerifySession(context.Background(), 1, 1)) // active sessions should verify
+
+		err = service.VerifySession(context.Background(), 2, 1)
+		is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // sessions of other users should not verify
+
+		err = service.VerifySession(context.Background(), 1, 0)
+		is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // tokens without a session should not verify
+
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+		is.NoErr(service.RevokeSession(ctx, 1)) // should revoke the session
+
+		err = service.VerifySession(context.Background(), 1, 1)
+		is.Equal(errors.ToErr(err), errors.ErrSessionRevoked) // revoked sessions should not verify
+	})
+
+	t.Run("Should mark the current session", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{
+			ListUserSessionsFn: func(ctx context.Context, userID int64) ([]rf.Session, error) {
+				return []rf.Session{{ID: 1, UserID: userID}, {ID: 2, UserID: userID}}, nil
+			},
+		}
+		service := authservice.NewAuthService(store)
+
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+		ctx = rfcontext.SetSessionIDToContext(ctx, 2)
+
+		sessions, err := service.ListSessions(ctx)
+
+		is.NoErr(err)                 // should list sessions
+		is.True(!sessions[0].Current) // other sessions should not be current
+		is.True(sessions[1].Current)  // should mark the current session
+	})
+
+	t.Run("Should keep the current session when signing out everywhere else", func(t *testing.T) {
+		t.Parallel()
+
+		var kept int64
+		store := &mock.AuthStore{
+			RevokeOtherSessionsFn: func(ctx context.Context, userID, keepSessionID int64) error {
+				kept = keepSessionID
+				return nil
+			},
+		}
+		service := authservice.NewAuthService(store)
+
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+		ctx = rfcontext.SetSessionIDToContext(ctx, 3)
+
+		err := service.RevokeOtherSessions(ctx)
+
+		is.NoErr(err)            // should revoke the other sessions
+		is.Equal(kept, int64(3)) // should keep the current session
+	})
+}
+
+func TestAuthService_APIKeys(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	newKeyStore := func() *mock.AuthStore {
+		keys := map[string]*rf.APIKey{}
+		return &mock.AuthStore{
+			CreateAPIKeyFn: func(ctx context.Context, key *rf.APIKey) error {
+				key.ID = int64(len(keys) + 1)
+				keys[key.KeyHash] = key
+				return nil
+			},
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().WithUserID(userID).AsEnabled(true).Build(), nil
+			},
+			FindAPIKeyByHashFn: func(ctx context.Context, keyHash string) (*rf.APIKey, error) {
+				return keys[keyHash], nil
+			},
+			TouchAPIKeyFn: func(ctx context.Context, keyID int64) error {
+				return nil
+			},
+		}
+	}
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+	t.Run("Should create a hashed key that verifies", func(t *testing.T) {
+		t.Parallel()
+
+		store := newKeyStore()
+		service := authservice.NewAuthService(store)
+
+		key, err := service.CreateAPIKey(ctx, &rf.CreateAPIKeyRequest{Name: "cli", Scope: rf.APIKeyScopeRead})
+
+		is.NoErr(err)                                                 // should create the key
+		is.True(strings.HasPrefix(key.Key, authservice.APIKeyPrefix)) // should be recognisable as a key
+		is.True(strings.HasPrefix(key.Key, key.Prefix))               // should keep a prefix to tell keys apart
+		is.True(key.KeyHash != key.Key)                               // should not store the key in the clear
+		is.Equal(key.UserID, int64(1))                                // should belong to the user in context
+
+		verified, err := service.VerifyAPIKey(context.Background(), key.Key)
+
+		is.NoErr(err)                     // should verify the key
+		is.Equal(verified.ID, key.ID)     // should find the key
+		is.True(store.TouchAPIKeyInvoked) // should record the use
+
+		_, err = service.VerifyAPIKey(context.Background(), authservice.APIKeyPrefix+"unknown")
+		is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // unknown keys should not verify
+	})
+
+	t.Run("Should not verify expired or revoked keys", func(t *testing.T) {
+		t.Parallel()
+
+		store := newKeyStore()
+		service := authservice.NewAuthService(store)
+
+		expiresAt := time.Now().Add(time.Hour)
+		key, err := service.CreateAPIKey(ctx, &rf.CreateAPIKeyRequest{Name: "cli", Scope: rf.APIKeyScopeReadWrite, ExpiresAt: &expiresAt})
+		is.NoErr(err) // should create the key
+
+		past := time.Now().Add(-time.Minute)
+		key.ExpiresAt = &past
+
+		_, err = service.VerifyAPIKey(context.Background(), key.Key)
+		is.Equal(errors.ToErr(err), errors.ErrAPIKeyExpired) // expired keys should not verify
+
+		key.ExpiresAt = nil
+		key.RevokedAt = &past
+
+		_, err = service.VerifyAPIKey(context.Background(), key.Key)
+		is.Equal(errors.ToReferenceCode(err), errors.Unauthorized) // revoked keys should not verify
+	})
+
+	t.Run("Should fail to create keys with invalid fields", func(t *testing.T) {
+		t.Parallel()
+
+		store := newKeyStore()
+		service := authservice.NewAuthService(store)
+
+		past := time.Now().Add(-time.Hour)
+		_, err := service.CreateAPIKey(ctx, &rf.CreateAPIKeyRequest{Scope: "admin", ExpiresAt: &past})
+
+		is.Equal(errors.ToReferenceCode(err), errors.InvalidData)                  // should be invalid
+		is.True(strings.Contains(errors.ToErr(err), errors.ErrNameRequired))       // should require a name
+		is.True(strings.Contains(errors.ToErr(err), errors.ErrAPIKeyScopeInvalid)) // should require a known scope
+		is.True(strings.Contains(errors.ToErr(err), errors.ErrAPIKeyExpiryPast))   // should require a future expiry
+		is.True(!store.CreateAPIKeyInvoked)                                        // should not create the key
+	})
+}
+
+func TestAuthService_PasswordReset(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	newResetStore := func(exists bool) (*mock.AuthStore, *rf.PasswordResetToken) {
+		created := &rf.PasswordResetToken{}
+		store := &mock.AuthStore{
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				if !exists {
+					return nil, nil
+				}
+				return builder.NewAuthBuilder().WithUserID(1).WithEmailVerifiedAt(time.Now()).Build(), nil
+			},
+			CreatePasswordResetTokenFn: func(ctx context.Context, token *rf.PasswordResetToken) error {
+				*created = *token
+				return nil
+			},
+			ResetPasswordFn: func(ctx context.Context, tokenHash, hashedPassword string) (int64, error) {
+				if tokenHash != created.TokenHash {
+					return 0, errors.InvalidDataf(errors.ErrResetTokenInvalid)
+				}
+				return created.UserID, nil
+			},
+		}
+		return store, created
+	}
+
+	t.Run("Should mail a single use link and reset with it", func(t *testing.T) {
+		t.Parallel()
+
+		store, created := newResetStore(true)
+		mailer := mail.NewMemoryMailer()
+		service := authservice.NewAuthService(store)
+		service.Mailer = mailer
+
+		var token string
+		service.PasswordResetURL = func(resetToken string) string {
+			token = resetToken
+			return "https://rss.example.com/reset-password?token=" + resetToken
+		}
+
+		err := service.ForgotPassword(context.Background(), &rf.ForgotPasswordRequest{Email: "gopher1@go.com"})
+
+		is.NoErr(err)                                               // should accept the request
+		is.Equal(len(mailer.Messages()), 1)                         // should mail a link
+		is.Equal(mailer.Messages()[0].To, "gopher1@go.com")         // should mail the account
+		is.True(strings.Contains(mailer.Messages()[0].Body, token)) // should include the token
+		is.True(created.TokenHash != token)                         // should not store the token in the clear
+		is.Equal(created.UserID, int64(1))                          // should be for the account
+		is.True(created.ExpiresAt.After(time.Now()))                // should expire in the future
+
+		err = service.ResetPassword(context.Background(), &rf.ResetPasswordRequest{Token: token, Password: "gogopher2"})
+
+		is.NoErr(err)                       // should reset the password
+		is.True(store.ResetPasswordInvoked) // should reset through the store
+
+		err = service.ResetPassword(context.Background(), &rf.ResetPasswordRequest{Token: "other", Password: "gogopher2"})
+
+		is.Equal(errors.ToErr(err), errors.ErrResetTokenInvalid) // unknown tokens should be invalid
+	})
+
+	t.Run("Should not reveal unknown accounts", func(t *testing.T) {
+		t.Parallel()
+
+		store, _ := newResetStore(false)
+		mailer := mail.NewMemoryMailer()
+		service := authservice.NewAuthService(store)
+		service.Mailer = mailer
+
+		err := service.ForgotPassword(context.Background(), &rf.ForgotPasswordRequest{Email: "nobody@go.com"})
+
+		is.NoErr(err)                                   // should succeed for unknown accounts
+		is.Equal(len(mailer.Messages()), 0)             // should not mail anyone
+		is.True(!store.CreatePasswordResetTokenInvoked) // should not create a token
+	})
+
+	t.Run("Should fail to reset with missing fields", func(t *testing.T) {
+		t.Parallel()
+
+		store, _ := newResetStore(true)
+		service := authservice.NewAuthService(store)
+
+		err := service.ResetPassword(context.Background(), &rf.ResetPasswordRequest{})
+
+		is.Equal(errors.ToReferenceCode(err), errors.InvalidData)                  // should be invalid
+		is.True(strings.Contains(errors.ToErr(err), errors.ErrResetTokenRequired)) // should require a token
+		is.True(strings.Contains(errors.ToErr(err), errors.ErrPasswordRequired))   // should require a password
+		is.True(!store.ResetPasswordInvoked)                                       // should not reset
+	})
+}
+
+func TestAuthService_EmailVerification(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+	is.NoErr(err) // should hash the password
+
+	newEmailStore := func(verified bool) (*mock.AuthStore, *rf.EmailVerificationToken) {
+		created := &rf.EmailVerificationToken{}
+		auth := builder.NewAuthBuilder().
+			WithUserID(1).
+			AsEnabled(true).
+			WithBasicAuth(builder.NewBasicAuthBuilder().
+				WithEmail("gopher1@go.com").
+				WithPassword(hashedPassword)).
+			Build()
+		if verified {
+			auth.EmailVerifiedAt = time.Now()
+		}
+
+		store := &mock.AuthStore{
+			CreateAuthAndUserFn: func(ctx context.Context, auth *rf.Auth) error {
+				auth.ID = 1
+				auth.UserID = 1
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				if email == "taken@go.com" {
+					return builder.NewAuthBuilder().WithUserID(2).Build(), nil
+				}
+				return nil, nil
+			},
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return auth, nil
+			},
+			CreateEmailVerificationTokenFn: func(ctx context.Context, token *rf.EmailVerificationToken) error {
+				*created = *token
+				return nil
+			},
+			ConfirmEmailFn: func(ctx context.Context, tokenHash string) (int64, error) {
+				if tokenHash != created.TokenHash {
+					return 0, errors.InvalidDataf(errors.ErrVerifyTokenInvalid)
+				}
+				return created.UserID, nil
+			},
+		}
+		return store, created
+	}
+
+	t.Run("Should mail a verification link on sign up and confirm with it", func(t *testing.T) {
+		t.Parallel()
+
+		store, created := newEmailStore(false)
+		mailer := mail.NewMemoryMailer()
+		service := authservice.NewAuthService(store)
+		service.Mailer = mailer
+		var events []audit.Event
+		service.Audit = recordEvents(&events)
+
+		var token string
+		service.EmailVerificationURL = func(verifyToken string) string {
+			token = verifyToken
+			return "https://rss.example.com/verify-email?token=" + verifyToken
+		}
+
+		req := builder.NewSignUpRequestBuilder().
+			WithName("Gopher").
+			WithEmail("gopher1@go.com").
+			WithPassword("gogopher1").
+			Build()
+
+		_, err := service.SignUp(context.Background(), req)
+
+		is.NoErr(err)                                               // should sign up
+		is.Equal(len(mailer.Messages()), 1)                         // should mail a link
+		is.Equal(mailer.Messages()[0].To, "gopher1@go.com")         // should mail the new account
+		is.True(strings.Contains(mailer.Messages()[0].Body, token)) // should include the token
+		is.True(created.TokenHash != token)                         // should not store the token in the clear
+		is.Equal(created.Email, "gopher1@go.com")                   // should be for the sign up address
+
+		err = service.ConfirmEmail(context.Background(), &rf.ConfirmEmailRequest{Token: token})
+
+		is.NoErr(err)                                               // should confirm the address
+		is.True(store.ConfirmEmailInvoked)                          // should confirm through the store
+		is.Equal(lastEvent(events).Type, audit.EventEmailConfirmed) // should audit the confirmation
+		is.Equal(lastEvent(events).TargetID, created.UserID)        // should be for the account
+
+		err = service.ConfirmEmail(context.Background(), &rf.ConfirmEmailRequest{Token: "other"})
+
+		is.Equal(errors.ToErr(err), errors.ErrVerifyTokenInvalid) // unknown tokens should be invalid
+	})
+
+	t.Run("Should mail the new address when changing email", func(t *testing.T) {
+		t.Parallel()
+
+		store, created := newEmailStore(true)
+		mailer := mail.NewMemoryMailer()
+		service := authservice.NewAuthService(store)
+		service.Mailer = mailer
+		var events []audit.Event
+		service.Audit = recordEvents(&events)
+
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+		err := service.ChangeEmail(ctx, &rf.ChangeEmailRequest{Email: "gopher2@go.com", Password: "gogopher1"})
+
+		is.NoErr(err)                                             // should accept the change
+		is.Equal(len(mailer.Messages()), 1)                       // should mail a link
+		is.Equal(mailer.Messages()[0].To, "gopher2@go.com")       // should mail the new address
+		is.Equal(created.Email, "gopher2@go.com")                 // should hold the new address until confirmed
+		is.Equal(created.UserID, int64(1))                        // should be for the account
+		is.True(!store.ConfirmEmailInvoked)                       // should keep the old address active
+		is.Equal(len(events), 1)                                  // should audit the change
+		is.Equal(events[0].Type, audit.EventEmailChangeRequested) // should be a requested change
+		is.Equal(events[0].TargetID, int64(1))                    // should be for the account
+	})
+
+	t.Run("Should fail to change email", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+		for name, tc := range map[string]struct {
+			req     *rf.ChangeEmailRequest
+			refCode errors.ReferenceCode
+			err     string
+		}{
+			"wrong password": {&rf.ChangeEmailRequest{Email: "gopher2@go.com", Password: "wrong"}, errors.Unauthorized, errors.ErrInvalidCredentials},
+			"same address":   {&rf.ChangeEmailRequest{Email: "Gopher1@go.com", Password: "gogopher1"}, errors.InvalidData, errors.ErrEmailUnchanged},
+			"taken address":  {&rf.ChangeEmailRequest{Email: "taken@go.com", Password: "gogopher1"}, errors.InvalidData, errors.ErrCouldNotProcess},
+		} {
+			t.Run(name, func(t *testing.T) {
+				store, _ := newEmailStore(true)
+				service := authservice.NewAuthService(store)
+
+				err := service.ChangeEmail(ctx, tc.req)
+
+				is.Equal(errors.ToReferenceCode(err), tc.refCode)   // should fail with the reference code
+				is.Equal(errors.ToErr(err), tc.err)                 // should fail with the error
+				is.True(!store.CreateEmailVerificationTokenInvoked) // should not create a token
+			})
+		}
+	})
+
+	t.Run("Should not resend to verified addresses", func(t *testing.T) {
+		t.Parallel()
+
+		store, _ := newEmailStore(true)
+		service := authservice.NewAuthService(store)
+
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+		err := service.ResendVerification(ctx)
+
+		is.Equal(errors.ToErr(err), errors.ErrEmailAlreadyVerified) // should already be verified
+		is.True(!store.CreateEmailVerificationTokenInvoked)         // should not create a token
+	})
+
+	t.Run("Should not mail password resets to unverified addresses", func(t *testing.T) {
+		t.Parallel()
+
+		store, _ := newEmailStore(false)
+		store.FindByEmailFn = func(ctx context.Context, email string) (*rf.Auth, error) {
+			return builder.NewAuthBuilder().WithUserID(1).Build(), nil
+		}
+		mailer := mail.NewMemoryMailer()
+		service := authservice.NewAuthService(store)
+		service.Mailer = mailer
+
+		err := service.ForgotPassword(context.Background(), &rf.ForgotPasswordRequest{Email: "gopher1@go.com"})
+
+		is.NoErr(err)                                   // should not reveal the account is unverified
+		is.Equal(len(mailer.Messages()), 0)             // should not mail an unverified address
+		is.True(!store.CreatePasswordResetTokenInvoked) // should not create a token
+	})
+}
+
+func TestAuthService_AccountStatus(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+	is.NoErr(err) // should hash the password
+
+	newStatusStore := func(enabled, deleted bool) (*mock.AuthStore, *[]bool) {
+		attempts := &[]bool{}
+		store := &mock.AuthStore{
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(1).
+					AsEnabled(enabled).
+					AsDeleted(deleted).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithPassword(hashedPassword)).
+					Build(), nil
+			},
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().WithUserID(userID).AsEnabled(enabled).AsDeleted(deleted).Build(), nil
+			},
+			FindSessionByIDFn: func(ctx context.Context, sessionID int64) (*rf.Session, error) {
+				return &rf.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				*attempts = append(*attempts, succeeded)
+				if succeeded {
+					auth.LastSignedInAt = time.Now()
+				}
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+		}
+		return store, attempts
+	}
+
+	signIn := func(service *authservice.AuthService, password string) error {
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher1@go.com").
+			WithPassword(password).
+			Build()
+		_, err := service.SignIn(context.Background(), req)
+		return err
+	}
+
+	t.Run("Should record sign in attempts", func(t *testing.T) {
+		t.Parallel()
+
+		store, attempts := newStatusStore(true, false)
+		service := authservice.NewAuthService(store)
+
+		err := signIn(service, "wrong")
+
+		is.Equal(errors.ToErr(err), errors.ErrInvalidCredentials) // should reject the password
+
+		err = signIn(service, "gogopher1")
+
+		is.NoErr(err)                            // should sign in
+		is.Equal(*attempts, []bool{false, true}) // should record the failure then the success
+		is.True(!store.FindByUserIDInvoked)      // sign in should not need a second lookup
+	})
+
+	t.Run("Should reject disabled and deleted accounts", func(t *testing.T) {
+		t.Parallel()
+
+		for name, tc := range map[string]struct {
+			enabled bool
+			deleted bool
+			err     string
+		}{
+			"disabled": {false, false, errors.ErrAccountDisabled},
+			"deleted":  {true, true, errors.ErrAccountDeleted},
+		} {
+			t.Run(name, func(t *testing.T) {
+				store, attempts := newStatusStore(tc.enabled, tc.deleted)
+				service := authservice.NewAuthService(store)
+
+				err := signIn(service, "gogopher1")
+
+				is.Equal(errors.ToReferenceCode(err), errors.AccountDisabled) // should have a distinct reference code
+				is.Equal(errors.ToErr(err), tc.err)                           // should say why
+				is.Equal(len(*attempts), 0)                                   // should not record a sign in
+				is.True(!store.CreateSessionInvoked)                          // should not create a session
+
+				err = signIn(service, "wrong")
+
+				is.Equal(errors.ToErr(err), errors.ErrInvalidCredentials) // should not reveal the status without the password
+
+				err = service.VerifySession(context.Background(), 1, 1)
+
+				is.Equal(errors.ToReferenceCode(err), errors.AccountDisabled) // should reject tokens issued before
+			})
+		}
+	})
+}
+
+func TestAuthService_SignInThrottle(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+	is.NoErr(err) // should hash the password
+
+	newThrottleStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(1).
+					AsEnabled(true).
+					WithEmailVerifiedAt(time.Now()).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithPassword(hashedPassword)).
+					Build(), nil
+			},
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+		}
+	}
+
+	// newCountingLimiter starts every key at failures, with the last one
+	// at lastFailedAt.
+	newCountingLimiter := func(failures int, lastFailedAt time.Time) (*mock.Limiter, map[string]int) {
+		counts := map[string]int{}
+		return &mock.Limiter{
+			FailFn: func(ctx context.Context, key string) error {
+				counts[key]++
+				return nil
+			},
+			FailuresFn: func(ctx context.Context, key string, since time.Time) (int, time.Time, error) {
+				return failures + counts[key], lastFailedAt, nil
+			},
+			ResetFn: func(ctx context.Context, key string) error {
+				delete(counts, key)
+				return nil
+			},
+		}, counts
+	}
+
+	signIn := func(service *authservice.AuthService, password string) error {
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("Gopher1@go.com").
+			WithPassword(password).
+			Build()
+		req.IP = "192.0.2.1"
+		_, err := service.SignIn(context.Background(), req)
+		return err
+	}
+
+	t.Run("Should count failures by email and IP and clear the email on success", func(t *testing.T) {
+		t.Parallel()
+
+		l, counts := newCountingLimiter(0, time.Time{})
+		service := authservice.NewAuthService(newThrottleStore())
+		service.Limiter = l
+
+		err := signIn(service, "wrong")
+
+		is.Equal(errors.ToErr(err), errors.ErrInvalidCredentials) // should reject the password
+		is.Equal(counts["sign_in:email:gopher1@go.com"], 1)       // should count the email case insensitively
+		is.Equal(counts["sign_in:ip:192.0.2.1"], 1)               // should count the IP
+
+		err = signIn(service, "gogopher1")
+
+		is.NoErr(err)                                       // should sign in
+		is.Equal(counts["sign_in:email:gopher1@go.com"], 0) // should clear the email failures
+		is.Equal(counts["sign_in:ip:192.0.2.1"], 1)         // should keep the IP failures
+	})
+
+	t.Run("Should make attempts wait after repeated failures", func(t *testing.T) {
+		t.Parallel()
+
+		l, _ := newCountingLimiter(authservice.SignInFreeFailures+1, time.Now())
+		store := newThrottleStore()
+		service := authservice.NewAuthService(store)
+		service.Limiter = l
+
+		err := signIn(service, "gogopher1")
+
+		is.Equal(errors.ToReferenceCode(err), errors.TooManyRequests) // should be throttled
+		is.Equal(errors.ToErr(err), errors.ErrTooManySignIns)         // should ask to wait
+		is.True(!store.FindByEmailInvoked)                            // should not check the password
+	})
+
+	t.Run("Should lock the account, audit and mail an unlock link", func(t *testing.T) {
+		t.Parallel()
+
+		l, _ := newCountingLimiter(authservice.AccountLockoutFailures-1, time.Time{})
+		store := newThrottleStore()
+		unlockToken := &rf.UnlockToken{}
+		store.CreateUnlockTokenFn = func(ctx context.Context, token *rf.UnlockToken) error {
+			*unlockToken = *token
+			return nil
+		}
+		store.UseUnlockTokenFn = func(ctx context.Context, tokenHash string) (*rf.Auth, error) {
+			if tokenHash != unlockToken.TokenHash {
+				return nil, errors.InvalidDataf(errors.ErrUnlockTokenInvalid)
+			}
+			return builder.NewAuthBuilder().
+				WithUserID(1).
+				WithBasicAuth(builder.NewBasicAuthBuilder().
+					WithEmail("gopher1@go.com")).
+				Build(), nil
+		}
+
+		var events []audit.Event
+		recorder := &mock.AuditRecorder{
+			RecordFn: func(ctx context.Context, event audit.Event) error {
+				events = append(events, event)
+				return nil
+			},
+		}
+
+		mailer := mail.NewMemoryMailer()
+		service := authservice.NewAuthService(store)
+		service.Limiter = l
+		service.Audit = recorder
+		service.Mailer = mailer
+
+		var token string
+		service.UnlockURL = func(unlockToken string) string {
+			token = unlockToken
+			return "https://rss.example.com/unlock-account?token=" + unlockToken
+		}
+
+		err := signIn(service, "wrong")
+
+		is.Equal(errors.ToErr(err), errors.ErrInvalidCredentials)   // the locking attempt should fail as usual
+		is.Equal(len(events), 2)                                    // should audit the failure and the lockout
+		is.Equal(events[0].Type, audit.EventSignInFailed)           // should be a failed sign in
+		is.Equal(events[1].Type, audit.EventSignInLockout)          // should be a lockout
+		is.Equal(events[1].TargetID, int64(1))                      // should be for the account
+		is.Equal(len(mailer.Messages()), 1)                         // should mail an unlock link
+		is.True(strings.Contains(mailer.Messages()[0].Body, token)) // should include the token
+		is.Equal(unlockToken.UserID, int64(1))                      // should be for the account
+
+		err = signIn(service, "gogopher1")
+
+		is.Equal(errors.ToReferenceCode(err), errors.TooManyRequests) // should be locked
+		is.Equal(errors.ToErr(err), errors.ErrAccountLocked)          // should say the account is locked
+
+		err = service.UnlockAccount(context.Background(), &rf.UnlockAccountRequest{Token: token})
+
+		is.NoErr(err)                                                    // should unlock
+		is.True(l.ResetInvoked)                                          // should reset the failures
+		is.Equal(events[len(events)-1].Type, audit.EventAccountUnlocked) // should audit the unlock
+
+		err = service.UnlockAccount(context.Background(), &rf.UnlockAccountRequest{Token: "other"})
+
+		is.Equal(errors.ToErr(err), errors.ErrUnlockTokenInvalid) // unknown tokens should be invalid
+	})
+}
+
+func TestAuthService_TOTP(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+	is.NoErr(err) // should hash the password
+
+	// newTOTPStore keeps one user's TOTP state the way the store would.
+	newTOTPStore := func() *mock.AuthStore {
+		var saved *rf.TOTP
+		recoveryCodes := map[string]bool{}
+
+		findAuth := func() *rf.Auth {
+			auth := builder.NewAuthBuilder().
+				WithUserID(1).
+				AsEnabled(true).
+				WithBasicAuth(builder.NewBasicAuthBuilder().
+					WithEmail("gopher1@go.com").
+					WithPassword(hashedPassword)).
+				Build()
+			if saved != nil {
+				auth.TOTPEnabledAt = saved.EnabledAt
+			}
+			return auth
+		}
+
+		return &mock.AuthStore{
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return findAuth(), nil
+			},
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return findAuth(), nil
+			},
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+			SaveTOTPSecretFn: func(ctx context.Context, totp *rf.TOTP) error {
+				copied := *totp
+				saved = &copied
+				return nil
+			},
+			FindTOTPFn: func(ctx context.Context, userID int64) (*rf.TOTP, error) {
+				if saved == nil {
+					return nil, nil
+				}
+				copied := *saved
+				return &copied, nil
+			},
+			EnableTOTPFn: func(ctx context.Context, userID, step int64, recoveryCodeHashes []string) error {
+				saved.EnabledAt = time.Now()
+				saved.LastUsedStep = step
+				for _, hash := range recoveryCodeHashes {
+					recoveryCodes[hash] = true
+				}
+				return nil
+			},
+			UseTOTPStepFn: func(ctx context.Context, userID, step int64) error {
+				if step <= saved.LastUsedStep {
+					return errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)
+				}
+				saved.LastUsedStep = step
+				return nil
+			},
+			UseRecoveryCodeFn: func(ctx context.Context, userID int64, codeHash string) error {
+				if !recoveryCodes[codeHash] {
+					return errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)
+				}
+				delete(recoveryCodes, codeHash)
+				return nil
+			},
+			DisableTOTPFn: func(ctx context.Context, userID, step int64) error {
+				if step <= saved.LastUsedStep {
+					return errors.InvalidDataf(errors.ErrTOTPCodeInvalid)
+				}
+				saved = nil
+				return nil
+			},
+		}
+	}
+
+	codeAt := func(secret string, t time.Time) string {
+		code, err := totp.Code(secret, totp.Step(t))
+		is.NoErr(err) // should generate a code
+		return code
+	}
+
+	signIn := func(service *authservice.AuthService) *rf.AuthTokens {
+		req := builder.NewSignInRequestBuilder().
+			WithEmail("gopher1@go.com").
+			WithPassword("gogopher1").
+			Build()
+		tokens, err := service.SignIn(context.Background(), req)
+		is.NoErr(err) // should accept the password
+		return tokens
+	}
+
+	t.Run("Should enrol, require a code at sign in and accept recovery codes once", func(t *testing.T) {
+		t.Parallel()
+
+		store := newTOTPStore()
+		service := authservice.NewAuthService(store)
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+		enrollment, err := service.EnrollTOTP(ctx)
+
+		is.NoErr(err)                                                 // should start enrolment
+		is.True(strings.HasPrefix(enrollment.URI, "otpauth://totp/")) // should return an otpauth uri
+		is.True(strings.Contains(enrollment.URI, "gopher1@go.com"))   // should label with the account
+
+		tokens := signIn(service)
+
+		is.True(tokens.MFAToken == "")    // should not need a code before enrolment is confirmed
+		is.True(tokens.AccessToken != "") // should sign in
+
+		now := time.Now()
+		codes, err := service.ConfirmTOTP(ctx, &rf.TOTPCodeRequest{Code: codeAt(enrollment.Secret, now.Add(-totp.Period))})
+
+		is.NoErr(err)                                                     // should enable with a valid code
+		is.Equal(len(codes.RecoveryCodes), authservice.RecoveryCodeCount) // should hand out recovery codes
+
+		tokens = signIn(service)
+
+		is.True(tokens.MFAToken != "")    // should need a second factor
+		is.True(tokens.AccessToken == "") // should not issue an access token yet
+
+		_, err = service.VerifyMFA(context.Background(), &rf.MFAVerifyRequest{MFAToken: tokens.MFAToken, Code: "000000"})
+
+		is.Equal(errors.ToErr(err), errors.ErrTOTPCodeInvalid) // should reject a wrong code
+
+		verified, err := service.VerifyMFA(context.Background(), &rf.MFAVerifyRequest{MFAToken: tokens.MFAToken, Code: codeAt(enrollment.Secret, now)})
+
+		is.NoErr(err)                       // should accept the current code
+		is.True(verified.AccessToken != "") // should issue an access token
+		is.True(verified.MFAToken == "")    // should not need another factor
+
+		_, err = service.VerifyMFA(context.Background(), &rf.MFAVerifyRequest{MFAToken: tokens.MFAToken, Code: codeAt(enrollment.Secret, now)})
+
+		is.Equal(errors.ToErr(err), errors.ErrTOTPCodeInvalid) // should reject a replayed code
+
+		recoveryCode := strings.ToLower(codes.RecoveryCodes[0])
+		verified, err = service.VerifyMFA(context.Background(), &rf.MFAVerifyRequest{MFAToken: tokens.MFAToken, Code: recoveryCode})
+
+		is.NoErr(err)                       // should accept a recovery code
+		is.True(verified.AccessToken != "") // should issue an access token
+
+		_, err = service.VerifyMFA(context.Background(), &rf.MFAVerifyRequest{MFAToken: tokens.MFAToken, Code: recoveryCode})
+
+		is.Equal(errors.ToErr(err), errors.ErrTOTPCodeInvalid) // recovery codes should be single use
+
+		_, err = service.VerifyMFA(context.Background(), &rf.MFAVerifyRequest{MFAToken: verified.AccessToken, Code: codeAt(enrollment.Secret, now.Add(totp.Period))})
+
+		is.Equal(errors.ToErr(err), errors.ErrTokenClaimsFailed) // access tokens should not stand in for the mfa token
+	})
+
+	t.Run("Should require a fresh code to disable", func(t *testing.T) {
+		t.Parallel()
+
+		store := newTOTPStore()
+		service := authservice.NewAuthService(store)
+		var events []audit.Event
+		service.Audit = recordEvents(&events)
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+		enrollment, err := service.EnrollTOTP(ctx)
+		is.NoErr(err) // should start enrolment
+
+		now := time.Now()
+		_, err = service.ConfirmTOTP(ctx, &rf.TOTPCodeRequest{Code: codeAt(enrollment.Secret, now)})
+		is.NoErr(err)                                            // should enable
+		is.Equal(lastEvent(events).Type, audit.EventTOTPEnabled) // should audit enabling
+		is.Equal(lastEvent(events).TargetID, int64(1))           // should be for the account
+
+		_, err = service.EnrollTOTP(ctx)
+
+		is.Equal(errors.ToErr(err), errors.ErrTOTPAlreadyEnabled) // should not enrol twice
+
+		err = service.DisableTOTP(ctx, &rf.TOTPCodeRequest{})
+
+		is.Equal(errors.ToReferenceCode(err), errors.InvalidData) // should require a code
+
+		err = service.DisableTOTP(ctx, &rf.TOTPCodeRequest{Code: codeAt(enrollment.Secret, now)})
+
+		is.Equal(errors.ToErr(err), errors.ErrTOTPCodeInvalid) // should not accept the code already used
+
+		err = service.DisableTOTP(ctx, &rf.TOTPCodeRequest{Code: codeAt(enrollment.Secret, now.Add(totp.Period))})
+
+		is.NoErr(err)                                             // should disable with a fresh code
+		is.Equal(lastEvent(events).Type, audit.EventTOTPDisabled) // should audit disabling
+		is.Equal(lastEvent(events).TargetID, int64(1))            // should be for the account
+
+		tokens := signIn(service)
+
+		is.True(tokens.MFAToken == "") // should no longer need a second factor
+	})
+
+	t.Run("Should throttle wrong codes to confirm and disable", func(t *testing.T) {
+		t.Parallel()
+
+		failures := map[string]int{}
+		store := newTOTPStore()
+		service := authservice.NewAuthService(store)
+		service.Limiter = &mock.Limiter{
+			FailFn: func(ctx context.Context, key string) error {
+				failures[key]++
+				return nil
+			},
+			FailuresFn: func(ctx context.Context, key string, since time.Time) (int, time.Time, error) {
+				return failures[key], time.Time{}, nil
+			},
+			ResetFn: func(ctx context.Context, key string) error {
+				delete(failures, key)
+				return nil
+			},
+		}
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+		enrollment, err := service.EnrollTOTP(ctx)
+		is.NoErr(err) // should start enrolment
+
+		_, err = service.ConfirmTOTP(ctx, &rf.TOTPCodeRequest{Code: "000000"})
+
+		is.Equal(errors.ToErr(err), errors.ErrTOTPCodeInvalid) // should reject a wrong code
+		is.Equal(failures["mfa:user:1"], 1)                    // should count the wrong code
+
+		now := time.Now()
+		_, err = service.ConfirmTOTP(ctx, &rf.TOTPCodeRequest{Code: codeAt(enrollment.Secret, now)})
+
+		is.NoErr(err)                       // should enable with a valid code
+		is.Equal(failures["mfa:user:1"], 0) // should clear the failures
+
+		failures["mfa:user:1"] = authservice.AccountLockoutFailures - 1
+
+		err = service.DisableTOTP(ctx, &rf.TOTPCodeRequest{Code: "000000"})
+
+		is.Equal(errors.ToErr(err), errors.ErrTOTPCodeInvalid) // should reject a wrong code
+
+		err = service.DisableTOTP(ctx, &rf.TOTPCodeRequest{Code: codeAt(enrollment.Secret, now.Add(totp.Period))})
+
+		is.Equal(errors.ToReferenceCode(err), errors.TooManyRequests) // should lock out once too many codes were wrong
+		is.True(!store.DisableTOTPInvoked)                            // should not disable while locked out
+	})
+}
+
+func TestAuthService_OIDC(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	server := oidctest.NewProvider()
+	t.Cleanup(server.Close)
+
+	hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+	is.NoErr(err) // should hash the password
+
+	// newOIDCStore keeps login states, identities and accounts the way the
+	// store would, starting with a password account for gopher1@go.com.
+	newOIDCStore := func() *mock.AuthStore {
+		states := map[string]*rf.OIDCLoginState{}
+		identities := map[string]*rf.AuthIdentity{}
+		auths := []*rf.Auth{
+			builder.NewAuthBuilder().
+				WithUserID(1).
+				AsEnabled(true).
+				WithBasicAuth(builder.NewBasicAuthBuilder().
+					WithEmail("gopher1@go.com").
+					WithPassword(hashedPassword)).
+				Build(),
+		}
+
+		findAuth := func(match func(auth *rf.Auth) bool) *rf.Auth {
+			for _, auth := range auths {
+				if match(auth) {
+					copied := *auth
+					return &copied
+				}
+			}
+			return nil
+		}
+
+		return &mock.AuthStore{
+			CreateOIDCLoginStateFn: func(ctx context.Context, state *rf.OIDCLoginState) error {
+				states[state.StateHash] = state
+				return nil
+			},
+			UseOIDCLoginStateFn: func(ctx context.Context, provider, stateHash string) (*rf.OIDCLoginState, error) {
+				state, ok := states[stateHash]
+				if !ok || state.Provider != provider {
+					return nil, errors.InvalidDataf(errors.ErrOIDCStateInvalid)
+				}
+				delete(states, stateHash)
+				return state, nil
+			},
+			FindIdentityFn: func(ctx context.Context, issuer, subject string) (*rf.AuthIdentity, error) {
+				return identities[issuer+" "+subject], nil
+			},
+			CreateIdentityFn: func(ctx context.Context, identity *rf.AuthIdentity) error {
+				identities[identity.Issuer+" "+identity.Subject] = identity
+				return nil
+			},
+			CreateAuthAndUserWithIdentityFn: func(ctx context.Context, auth *rf.Auth, identity *rf.AuthIdentity) error {
+				auth.UserID = int64(len(auths) + 1)
+				auth.Enabled = true
+				auths = append(auths, auth)
+				identity.UserID = auth.UserID
+				identities[identity.Issuer+" "+identity.Subject] = identity
+				return nil
+			},
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return findAuth(func(auth *rf.Auth) bool { return auth.BasicAuth.Email == email }), nil
+			},
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return findAuth(func(auth *rf.Auth) bool { return auth.UserID == userID }), nil
+			},
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+			CreateEmailVerificationTokenFn: func(ctx context.Context, token *rf.EmailVerificationToken) error {
+				return nil
+			},
+		}
+	}
+
+	newService := func(store *mock.AuthStore) *authservice.AuthService {
+		service := authservice.NewAuthService(store)
+		service.OIDCProviders = map[string]authservice.OIDCProvider{
+			"test": oidc.NewProvider(server.Config("http://localhost/callback")),
+		}
+		return service
+	}
+
+	signIn := func(service *authservice.AuthService, user oidctest.User) (*rf.AuthTokens, error) {
+		authURL, state, err := service.StartOIDC(context.Background(), "test")
+		is.NoErr(err) // should start the sign in
+
+		code, returnedState, err := server.Authorize(authURL, user)
+		is.NoErr(err)                  // should authorize at the provider
+		is.Equal(returnedState, state) // should send the state to the provider
+
+		return service.CompleteOIDC(context.Background(), &rf.OIDCCallbackRequest{
+			Provider: "test",
+			Code:     code,
+			State:    state,
+		})
+	}
+
+	t.Run("Should sign up on first sign in and sign in after", func(t *testing.T) {
+		t.Parallel()
+
+		store := newOIDCStore()
+		service := newService(store)
+		var events []audit.Event
+		service.Audit = recordEvents(&events)
+		user := oidctest.User{Subject: "new", Email: "new@go.com", EmailVerified: true, Name: "New Gopher"}
+
+		tokens, err := signIn(service, user)
+
+		is.NoErr(err)                                       // should sign up
+		is.True(tokens.AccessToken != "")                   // should issue an access token
+		is.True(store.CreateAuthAndUserWithIdentityInvoked) // should create the account with its identity
+		is.True(!store.CreateEmailVerificationTokenInvoked) // should trust the provider's verified email
+		is.Equal(events[0].Type, audit.EventSignUp)         // should audit the sign up
+		is.Equal(events[0].TargetID, int64(2))              // should be for the new account
+		is.Equal(events[0].Detail, "test")                  // should name the provider
+
+		store.CreateAuthAndUserWithIdentityInvoked = false
+		tokens, err = signIn(service, user)
+
+		is.NoErr(err)                                        // should sign in
+		is.True(tokens.AccessToken != "")                    // should issue an access token
+		is.True(!store.CreateAuthAndUserWithIdentityInvoked) // should not sign up twice
+	})
+
+	t.Run("Should verify an email the provider did not", func(t *testing.T) {
+		t.Parallel()
+
+		store := newOIDCStore()
+		service := newService(store)
+
+		tokens, err := signIn(service, oidctest.User{Subject: "unverified", Email: "unverified@go.com"})
+
+		is.NoErr(err)                                      // should sign up
+		is.True(tokens.AccessToken != "")                  // should issue an access token
+		is.True(store.CreateEmailVerificationTokenInvoked) // should send a verification link
+	})
+
+	t.Run("Should link to an existing account after its password is given", func(t *testing.T) {
+		t.Parallel()
+
+		store := newOIDCStore()
+		service := newService(store)
+		var events []audit.Event
+		service.Audit = recordEvents(&events)
+		user := oidctest.User{Subject: "gopher1", Email: "gopher1@go.com", EmailVerified: true}
+
+		tokens, err := signIn(service, user)
+
+		is.NoErr(err)                                        // should complete the sign in
+		is.True(tokens.LinkToken != "")                      // should ask to link the account
+		is.True(tokens.AccessToken == "")                    // should not sign in before linking
+		is.True(!store.CreateAuthAndUserWithIdentityInvoked) // should not sign up a second account
+
+		_, err = service.LinkOIDC(context.Background(), &rf.OIDCLinkRequest{LinkToken: tokens.LinkToken, Password: "wrong"})
+
+		is.Equal(errors.ToErr(err), errors.ErrInvalidCredentials) // should reject a wrong password
+		is.True(!store.CreateIdentityInvoked)                     // should not link
+
+		linked, err := service.LinkOIDC(context.Background(), &rf.OIDCLinkRequest{LinkToken: tokens.LinkToken, Password: "gogopher1"})
+
+		is.NoErr(err)                                            // should link with the password
+		is.True(linked.AccessToken != "")                        // should sign in
+		is.True(store.CreateIdentityInvoked)                     // should link the identity
+		is.True(hasEvent(events, audit.EventOIDCIdentityLinked)) // should audit the link
+
+		tokens, err = signIn(service, user)
+
+		is.NoErr(err)                     // should sign in
+		is.True(tokens.AccessToken != "") // should sign in without linking again
+	})
+
+	t.Run("Should reject a reused state and unknown providers", func(t *testing.T) {
+		t.Parallel()
+
+		store := newOIDCStore()
+		service := newService(store)
+
+		authURL, state, err := service.StartOIDC(context.Background(), "test")
+		is.NoErr(err) // should start the sign in
+
+		code, _, err := server.Authorize(authURL, oidctest.User{Subject: "replay", Email: "replay@go.com"})
+		is.NoErr(err) // should authorize at the provider
+
+		req := &rf.OIDCCallbackRequest{Provider: "test", Code: code, State: state}
+		_, err = service.CompleteOIDC(context.Background(), req)
+		is.NoErr(err) // should complete the sign in
+
+		_, err = service.CompleteOIDC(context.Background(), req)
+
+		is.Equal(errors.ToErr(err), errors.ErrOIDCStateInvalid) // should not reuse a state
+
+		_, _, err = service.StartOIDC(context.Background(), "other")
+
+		is.Equal(errors.ToReferenceCode(err), errors.NotFound) // should not know the provider
+
+		_, err = service.CompleteOIDC(context.Background(), &rf.OIDCCallbackRequest{Provider: "test", State: state, Error: "access_denied"})
+
+		is.Equal(errors.ToErr(err), errors.ErrOIDCDenied) // should report a denied sign in
+	})
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+	is.NoErr(err) // should hash the password
+
+	newStore := func() *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(userID).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithPassword(hashedPassword)).
+					Build(), nil
+			},
+			ChangePasswordFn: func(ctx context.Context, userID, keepSessionID int64, hashedPassword string) error {
+				return nil
+			},
+		}
+	}
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+	ctx = rfcontext.SetSessionIDToContext(ctx, 2)
+
+	t.Run("Should change the password and keep the current session", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore()
+		var keptSessionID int64
+		var newHash string
+		store.ChangePasswordFn = func(ctx context.Context, userID, keepSessionID int64, hashedPassword string) error {
+			keptSessionID = keepSessionID
+			newHash = hashedPassword
+			return nil
+		}
+		service := authservice.NewAuthService(store)
+
+		err := service.ChangePassword(ctx, &rf.ChangePasswordRequest{CurrentPassword: "gogopher1", NewPassword: "gogopher2"})
+
+		is.NoErr(err)                     // should change the password
+		is.Equal(keptSessionID, int64(2)) // should only sign out the other sessions
+
+		match, err := argon2id.ComparePasswordAndHash("gogopher2", newHash)
+		is.NoErr(err)  // should compare the hash
+		is.True(match) // should store a hash of the new password
+	})
+
+	t.Run("Should reject a wrong current password", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore()
+		service := authservice.NewAuthService(store)
+
+		err := service.ChangePassword(ctx, &rf.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "gogopher2"})
+
+		is.Equal(errors.ToErr(err), errors.ErrInvalidCredentials) // should reject the current password
+		is.True(!store.ChangePasswordInvoked)                     // should not change the password
+	})
+
+	t.Run("Should reject missing and unchanged passwords", func(t *testing.T) {
+		t.Parallel()
+
+		for _, req := range []*rf.ChangePasswordRequest{
+			{NewPassword: "gogopher2"},
+			{CurrentPassword: "gogopher1"},
+			{CurrentPassword: "gogopher1", NewPassword: "gogopher1"},
+		} {
+			store := newStore()
+			service := authservice.NewAuthService(store)
+
+			err := service.ChangePassword(ctx, req)
+
+			is.Equal(errors.ToReferenceCode(err), errors.InvalidData) // should be invalid
+			is.True(!store.ChangePasswordInvoked)                     // should not change the password
+		}
+	})
+
+	t.Run("Should reject a new password breaking the policy", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore()
+		service := authservice.NewAuthService(store)
+
+		err := service.ChangePassword(ctx, &rf.ChangePasswordRequest{CurrentPassword: "gogopher1", NewPassword: "go"})
+
+		is.Equal(errors.ToReferenceCode(err), errors.InvalidData) // should be invalid
+
+		errs, ok := err.(errors.Error).Err.(map[string]string)
+		is.True(ok)                                                               // should have per field errors
+		is.Equal(errs["newPassword"], fmt.Sprintf(errors.ErrPasswordTooShort, 8)) // should say why under the field
+		is.True(!store.ChangePasswordInvoked)                                     // should not change the password
+	})
+}
+
+func TestAuthService_PasswordPolicy(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should fail to sign up with a password breaking the policy", func(t *testing.T) {
+		t.Parallel()
+
+		breached := &mock.BreachChecker{
+			BreachedFn: func(plaintextPassword string) (bool, error) {
+				return plaintextPassword == "letmein123", nil
+			},
+		}
+
+		for password, reason := range map[string]string{
+			"gopher":          fmt.Sprintf(errors.ErrPasswordTooShort, 8),
+			"gopher1@go.com":  errors.ErrPasswordPersonal,
+			"Gopher The Last": errors.ErrPasswordPersonal,
+			"letmein123":      errors.ErrPasswordBreached,
+		} {
+			store := &mock.AuthStore{}
+			service := authservice.NewAuthService(store)
+			service.PasswordPolicy.Breached = breached
+
+			req := builder.NewSignUpRequestBuilder().
+				WithName("Gopher the Last").
+				WithEmail("gopher1@go.com").
+				WithPassword(password).
+				Build()
+
+			_, err := service.SignUp(context.Background(), req)
+
+			is.Equal(errors.ToReferenceCode(err), errors.InvalidData) // should be invalid
+			is.True(strings.Contains(errors.ToErr(err), reason))      // should say why
+			is.True(!store.CreateInvoked)                             // should not sign up
+		}
+	})
+
+	t.Run("Should fail to reset to a password breaking the policy", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.AuthStore{}
+		service := authservice.NewAuthService(store)
+
+		err := service.ResetPassword(context.Background(), &rf.ResetPasswordRequest{Token: "token", Password: strings.Repeat("go", 65)})
+
+		is.Equal(errors.ToReferenceCode(err), errors.InvalidData)                                 // should be invalid
+		is.True(strings.Contains(errors.ToErr(err), fmt.Sprintf(errors.ErrPasswordTooLong, 128))) // should bound the length
+		is.True(!store.ResetPasswordInvoked)                                                      // should not reset
+	})
+}
+
+func TestAuthService_DeleteAccount(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	hashedPassword, err := argon2id.CreateHash("gogopher1", argon2id.DefaultParams)
+	is.NoErr(err) // should hash the password
+
+	newDeletedStore := func(purgeAt time.Time) *mock.AuthStore {
+		return &mock.AuthStore{
+			FindByEmailFn: func(ctx context.Context, email string) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(1).
+					AsEnabled(true).
+					AsDeleted(true).
+					WithPurgeAt(purgeAt).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithEmail(email).
+						WithPassword(hashedPassword)).
+					Build(), nil
+			},
+			RecordSignInAttemptFn: func(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+				auth.Deleted = false
+				return nil
+			},
+			CreateSessionFn: func(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+				session.ID = 1
+				return nil
+			},
+		}
+	}
+
+	signIn := func(service *authservice.AuthService) (*rf.AuthTokens, error) {
+		return service.SignIn(context.Background(), builder.NewSignInRequestBuilder().
+			WithEmail("gopher1@go.com").
+			WithPassword("gogopher1").
+			Build())
+	}
+
+	t.Run("Should delete the account until the grace period ends and mail the user", func(t *testing.T) {
+		t.Parallel()
+
+		var deletedPurgeAt time.Time
+		store := &mock.AuthStore{
+			FindByUserIDFn: func(ctx context.Context, userID int64) (*rf.Auth, error) {
+				return builder.NewAuthBuilder().
+					WithUserID(userID).
+					AsEnabled(true).
+					WithBasicAuth(builder.NewBasicAuthBuilder().
+						WithEmail("gopher1@go.com")).
+					Build(), nil
+			},
+			DeleteAccountFn: func(ctx context.Context, userID int64, purgeAt time.Time) error {
+				deletedPurgeAt = purgeAt
+				return nil
+			},
+		}
+		mailer := mail.NewMemoryMailer()
+		service := authservice.NewAuthService(store)
+		service.Mailer = mailer
+		service.DeletionGracePeriod = 7 * 24 * time.Hour
+
+		deletion, err := service.DeleteAccount(rfcontext.SetUserIDToContext(context.Background(), 1))
+
+		is.NoErr(err)                                           // should delete the account
+		is.Equal(deletion.PurgeAt, deletedPurgeAt)              // should say when it is purged
+		is.True(time.Until(deletion.PurgeAt) > 6*24*time.Hour)  // should keep it for the grace period
+		is.True(time.Until(deletion.PurgeAt) <= 7*24*time.Hour) // should not keep it longer
+		is.Equal(len(mailer.Messages()), 1)                     // should mail the user
+		is.Equal(mailer.Messages()[0].To, "gopher1@go.com")     // should mail the account address
+	})
+
+	t.Run("Should restore the account when signing in before it is purged", func(t *testing.T) {
+		t.Parallel()
+
+		store := newDeletedStore(time.Now().Add(time.Hour))
+		service := authservice.NewAuthService(store)
+
+		tokens, err := signIn(service)
+
+		is.NoErr(err)                             // should sign in
+		is.True(tokens.AccessToken != "")         // should issue tokens
+		is.True(store.RecordSignInAttemptInvoked) // should restore the account with the sign in
+	})
+
+	t.Run("Should reject signing in after the grace period", func(t *testing.T) {
+		t.Parallel()
+
+		for name, purgeAt := range map[string]time.Time{
+			"expired":        time.Now().Add(-time.Minute),
+			"not restorable": {},
+		} {
+			t.Run(name, func(t *testing.T) {
+				store := newDeletedStore(purgeAt)
+				service := authservice.NewAuthService(store)
+
+				_, err := signIn(service)
+
+				is.Equal(errors.ToErr(err), errors.ErrAccountDeleted) // should stay deleted
+				is.True(!store.CreateSessionInvoked)                  // should not create a session
+			})
+		}
+	})
+}
+
+// recordEvents returns a recorder appending the events it records to
+// events.
+func recordEvents(events *[]audit.Event) *mock.AuditRecorder {
+	return &mock.AuditRecorder{
+		RecordFn: func(ctx context.Context, event audit.Event) error {
+			*events = append(*events, event)
+			return nil
+		},
+	}
+}
+
+func lastEvent(events []audit.Event) audit.Event {
+	if len(events) == 0 {
+		return audit.Event{}
+	}
+	return events[len(events)-1]
+}
+
+func hasEvent(events []audit.Event, eventType audit.EventType) bool {
+	for _, event := range events {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
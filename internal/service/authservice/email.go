@@ -0,0 +1,154 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
+)
+
+// EmailVerificationTTL is how long a mailed verification link works.
+const EmailVerificationTTL = 48 * time.Hour
+
+func (as *AuthService) ConfirmEmail(ctx context.Context, req *rf.ConfirmEmailRequest) error {
+	if req.Token == "" {
+		return errors.InvalidError(map[string]string{"token": errors.ErrVerifyTokenRequired})
+	}
+
+	userID, err := as.store.ConfirmEmail(ctx, hashToken(req.Token))
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventEmailConfirmed,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	return nil
+}
+
+// ResendVerification mails a new verification link to the current address
+// of an unverified account.
+func (as *AuthService) ResendVerification(ctx context.Context) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	foundAuth, err := as.store.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if foundAuth == nil {
+		return errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	if !foundAuth.EmailVerifiedAt.IsZero() {
+		return errors.InvalidDataf(errors.ErrEmailAlreadyVerified)
+	}
+
+	return sendEmailVerification(ctx, as.store, as.Mailer, as.EmailVerificationURL, userID, foundAuth.BasicAuth.Email)
+}
+
+// ChangeEmail mails a verification link to a new address, the current
+// address stays in use until the link is followed.
+func (as *AuthService) ChangeEmail(ctx context.Context, req *rf.ChangeEmailRequest) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+	email := strings.TrimSpace(req.Email)
+
+	errs := map[string]string{}
+
+	if email == "" {
+		errs["email"] = errors.ErrEmailRequired
+	}
+
+	if req.Password == "" {
+		errs["password"] = errors.ErrPasswordRequired
+	}
+
+	if len(errs) > 0 {
+		return errors.InvalidError(errs)
+	}
+
+	foundAuth, err := as.store.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if foundAuth == nil {
+		return errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	match, err := password.Matches(req.Password, foundAuth.BasicAuth.Password)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return errors.Unauthorizedf(errors.ErrInvalidCredentials)
+	}
+
+	if strings.EqualFold(email, foundAuth.BasicAuth.Email) {
+		return errors.InvalidDataf(errors.ErrEmailUnchanged)
+	}
+
+	hasAuth, err := as.store.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if hasAuth != nil {
+		return errors.InvalidDataf(errors.ErrCouldNotProcess)
+	}
+
+	err = sendEmailVerification(ctx, as.store, as.Mailer, as.EmailVerificationURL, userID, email)
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventEmailChangeRequested,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	return nil
+}
+
+func sendEmailVerification(ctx context.Context, store AuthStore, mailer mail.Mailer, verificationURL func(string) string, userID int64, email string) error {
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	err = store.CreateEmailVerificationToken(ctx, &rf.EmailVerificationToken{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().UTC().Add(EmailVerificationTTL).Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Follow this link within %s to confirm this is your email address:\n\n%s\n\n"+
+			"If you didn't ask for this, you can ignore this email.\n",
+			EmailVerificationTTL, verificationURL(token)),
+	}
+
+	if err := mailer.Send(ctx, msg); err != nil {
+		slog.Error("Email verification mail error", "err", err.Error(), "userID", userID)
+	}
+
+	return nil
+}
@@ -0,0 +1,337 @@
+package authservice
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/oidc"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
+)
+
+const (
+	// OIDCLoginTTL is how long a user has to sign in at a provider.
+	OIDCLoginTTL = 10 * time.Minute
+	// OIDCLinkTTL is how long a link token can be exchanged with the
+	// account password.
+	OIDCLinkTTL = 10 * time.Minute
+
+	maxNameLength = 50
+)
+
+// OIDCProvider is an OpenID Connect issuer users can sign in with.
+type OIDCProvider interface {
+	Issuer() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*oidc.Claims, error)
+}
+
+// StartOIDC begins a sign in with a provider, it returns where to send the
+// user and the state the callback must come back with.
+func (as *AuthService) StartOIDC(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := as.OIDCProviders[providerName]
+	if !ok {
+		return "", "", errors.NotFoundf(errors.ErrOIDCProviderNotFound)
+	}
+
+	state, err := oidc.GenerateState()
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := oidc.GenerateState()
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = as.store.CreateOIDCLoginState(ctx, &rf.OIDCLoginState{
+		StateHash:    hashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().UTC().Add(OIDCLoginTTL).Truncate(time.Second),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.Challenge(verifier))
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+// CompleteOIDC finishes a sign in with a provider. A linked identity signs
+// in, an email of an existing account gets a link token and anyone else
+// is signed up.
+func (as *AuthService) CompleteOIDC(ctx context.Context, req *rf.OIDCCallbackRequest) (*rf.AuthTokens, error) {
+	provider, ok := as.OIDCProviders[req.Provider]
+	if !ok {
+		return nil, errors.NotFoundf(errors.ErrOIDCProviderNotFound)
+	}
+
+	if req.Error != "" {
+		return nil, errors.Unauthorizedf(errors.ErrOIDCDenied)
+	}
+
+	args := AuthArgs{
+		store:                as.store,
+		mailer:               as.Mailer,
+		emailVerificationURL: as.EmailVerificationURL,
+		limiter:              as.Limiter,
+		recorder:             as.Audit,
+		oidcProviderName:     req.Provider,
+		oidcProvider:         provider,
+		oidcCode:             req.Code,
+		oidcState:            req.State,
+		session: &rf.Session{
+			DeviceName: req.DeviceName,
+			IP:         req.IP,
+			UserAgent:  req.UserAgent,
+		},
+		auth: &rf.Auth{},
+	}
+
+	if err := args.validateOIDCCallback(); err != nil {
+		return nil, err
+	}
+
+	result, err := statemachine.Run(ctx, args, useOIDCLoginStateState)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.tokens(), nil
+}
+
+// LinkOIDC links the identity of a link token to its account once the
+// account password is given, then signs in like a password sign in.
+func (as *AuthService) LinkOIDC(ctx context.Context, req *rf.OIDCLinkRequest) (*rf.AuthTokens, error) {
+	if req.LinkToken == "" {
+		return nil, errors.InvalidError(map[string]string{"linkToken": errors.ErrOIDCLinkTokenRequired})
+	}
+
+	identity, err := jwt.ParseAndVerifyOIDCLink(req.LinkToken)
+	if err != nil {
+		return nil, err
+	}
+
+	args := AuthArgs{
+		store:     as.store,
+		mailer:    as.Mailer,
+		unlockURL: as.UnlockURL,
+		limiter:   as.Limiter,
+		recorder:  as.Audit,
+		identity:  identity,
+		session: &rf.Session{
+			DeviceName: req.DeviceName,
+			IP:         req.IP,
+			UserAgent:  req.UserAgent,
+		},
+		auth: builder.NewAuthBuilder().
+			WithBasicAuth(builder.NewBasicAuthBuilder().
+				WithEmail(identity.Email).
+				WithPassword(req.Password)).
+			Build(),
+	}
+
+	if err := args.validateSignIn(); err != nil {
+		return nil, err
+	}
+
+	result, err := statemachine.Run(ctx, args, throttleSignInState)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.tokens(), nil
+}
+
+func (as AuthArgs) validateOIDCCallback() error {
+	if as.store == nil {
+		return errors.InternalErrorf("store cannot be nil")
+	}
+
+	if as.oidcCode == "" {
+		return errors.InvalidError(map[string]string{"code": errors.ErrOIDCCodeRequired})
+	}
+
+	if as.oidcState == "" {
+		return errors.InvalidDataf(errors.ErrOIDCStateInvalid)
+	}
+
+	return nil
+}
+
+func useOIDCLoginStateState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	loginState, err := args.store.UseOIDCLoginState(ctx, args.oidcProviderName, hashToken(args.oidcState))
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.oidcLoginState = loginState
+	return args, exchangeOIDCCodeState, nil
+}
+
+func exchangeOIDCCodeState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	claims, err := args.oidcProvider.Exchange(ctx, args.oidcCode, args.oidcLoginState.CodeVerifier, args.oidcLoginState.Nonce)
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.oidcClaims = claims
+	return args, findIdentityState, nil
+}
+
+func findIdentityState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	identity, err := args.store.FindIdentity(ctx, args.oidcClaims.Issuer, args.oidcClaims.Subject)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if identity == nil {
+		return args, findOIDCEmailState, nil
+	}
+
+	foundAuth, err := args.store.FindByUserID(ctx, identity.UserID)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if foundAuth == nil {
+		return args, nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	if err := checkCanSignIn(foundAuth); err != nil {
+		return args, nil, err
+	}
+
+	args.authToValidate = foundAuth
+	args.auth.BasicAuth = foundAuth.BasicAuth
+	return args, firstFactorVerifiedState, nil
+}
+
+// findOIDCEmailState never links an identity to an account by email alone,
+// the provider may not have verified the address and the account owner
+// must agree with their password.
+func findOIDCEmailState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	if args.oidcClaims.Email == "" {
+		return args, nil, errors.InvalidDataf(errors.ErrOIDCEmailRequired)
+	}
+
+	foundAuth, err := args.store.FindByEmail(ctx, args.oidcClaims.Email)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if foundAuth == nil {
+		return args, createAuthWithIdentityState, nil
+	}
+
+	expiresAt := time.Now().UTC().Add(OIDCLinkTTL).Truncate(time.Second)
+
+	token, err := jwt.GenerateAndSignOIDCLink(foundAuth.UserID, args.oidcClaims.Issuer, args.oidcClaims.Subject,
+		foundAuth.BasicAuth.Email, expiresAt)
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.linkToken = token
+	args.linkTokenExpiresAt = expiresAt
+	return args, nil, nil
+}
+
+func createAuthWithIdentityState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	claims := args.oidcClaims
+
+	authBuilder := builder.NewAuthBuilder().
+		WithUser(builder.NewUserBuilder().
+			WithName(oidcName(claims))).
+		WithBasicAuth(builder.NewBasicAuthBuilder().
+			WithEmail(claims.Email))
+	if claims.EmailVerified {
+		authBuilder = authBuilder.WithEmailVerifiedAt(time.Now().UTC())
+	}
+	args.auth = authBuilder.Build()
+
+	identity := &rf.AuthIdentity{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}
+
+	err := args.store.CreateAuthAndUserWithIdentity(ctx, args.auth, identity)
+	if err != nil {
+		return args, nil, err
+	}
+
+	audit.Record(ctx, args.recorder, audit.Event{
+		Type:       audit.EventSignUp,
+		ActorID:    args.auth.UserID,
+		TargetType: audit.TargetUser,
+		TargetID:   args.auth.UserID,
+		IP:         args.session.IP,
+		UserAgent:  args.session.UserAgent,
+		Detail:     args.oidcProviderName,
+	})
+
+	if claims.EmailVerified {
+		return args, createSessionState, nil
+	}
+
+	return args, sendEmailVerificationState, nil
+}
+
+// linkIdentityState links the identity once the password of the account it
+// was matched to by email is confirmed.
+func linkIdentityState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	if args.authToValidate.UserID != args.identity.UserID {
+		return args, nil, errors.Unauthorizedf(errors.ErrInvalidCredentials)
+	}
+
+	err := args.store.CreateIdentity(ctx, args.identity)
+	if err != nil {
+		return args, nil, err
+	}
+
+	audit.Record(ctx, args.recorder, audit.Event{
+		Type:       audit.EventOIDCIdentityLinked,
+		ActorID:    args.identity.UserID,
+		TargetType: audit.TargetUser,
+		TargetID:   args.identity.UserID,
+		IP:         args.session.IP,
+		UserAgent:  args.session.UserAgent,
+		Detail:     args.identity.Issuer,
+	})
+
+	return args, firstFactorVerifiedState, nil
+}
+
+// oidcName is the name of a user signing up with a provider, falling back
+// to their email when the provider does not share it.
+func oidcName(claims *oidc.Claims) string {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	for utf8.RuneCountInString(name) > maxNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	return name
+}
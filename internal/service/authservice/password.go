@@ -0,0 +1,148 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/password"
+)
+
+// PasswordResetTTL is how long a mailed password reset link works.
+const PasswordResetTTL = time.Hour
+
+// ForgotPassword mails a password reset link when email belongs to an
+// account. It succeeds either way so it cannot be used to find accounts.
+func (as *AuthService) ForgotPassword(ctx context.Context, req *rf.ForgotPasswordRequest) error {
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return errors.InvalidError(map[string]string{"email": errors.ErrEmailRequired})
+	}
+
+	foundAuth, err := as.store.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	// Unverified addresses may not be the user's, mail is not sent to them.
+	if foundAuth == nil || foundAuth.EmailVerifiedAt.IsZero() {
+		return nil
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	err = as.store.CreatePasswordResetToken(ctx, &rf.PasswordResetToken{
+		TokenHash: hashToken(token),
+		UserID:    foundAuth.UserID,
+		ExpiresAt: time.Now().UTC().Add(PasswordResetTTL).Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account.\n\n"+
+			"Follow this link within %s to choose a new password:\n\n%s\n\n"+
+			"If it wasn't you, you can ignore this email.\n",
+			PasswordResetTTL, as.PasswordResetURL(token)),
+	}
+
+	// A failure to send is only logged, reporting it would tell the caller
+	// the account exists.
+	if err := as.Mailer.Send(ctx, msg); err != nil {
+		slog.Error("Password reset mail error", "err", err.Error(), "userID", foundAuth.UserID)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with a mailed reset token and signs the
+// user out of every session.
+func (as *AuthService) ResetPassword(ctx context.Context, req *rf.ResetPasswordRequest) error {
+	errs := map[string]string{}
+
+	if req.Token == "" {
+		errs["token"] = errors.ErrResetTokenRequired
+	}
+
+	if req.Password == "" {
+		errs["password"] = errors.ErrPasswordRequired
+	}
+
+	if errs["password"] == "" {
+		// The account is only known once the token is used, so the
+		// password can't be compared with its email address or name.
+		if err := checkPasswordPolicy(as.PasswordPolicy, errs, "password", req.Password); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.InvalidError(errs)
+	}
+
+	hashedPassword, err := password.Hash(req.Password)
+	if err != nil {
+		return err
+	}
+
+	userID, err := as.store.ResetPassword(ctx, hashToken(req.Token), hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventPasswordReset,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	return nil
+}
+
+// checkPasswordPolicy adds why plaintextPassword breaks policy to errs under
+// field, a nil policy allows any password.
+func checkPasswordPolicy(policy *password.Policy, errs map[string]string, field, plaintextPassword string, identifiers ...string) error {
+	if policy == nil {
+		return nil
+	}
+
+	reason, err := policy.Check(plaintextPassword, identifiers...)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		errs[field] = reason
+	}
+
+	return nil
+}
+
+// rehashPassword upgrades the stored hash of plaintextPassword when it was
+// made with weaker parameters than the current ones. Failing to must not
+// fail the sign in, the error is logged and the upgrade tried again on the
+// next one.
+func rehashPassword(ctx context.Context, store AuthStore, auth *rf.Auth, plaintextPassword string) {
+	if !password.NeedsRehash(auth.BasicAuth.Password) {
+		return
+	}
+
+	hashedPassword, err := password.Hash(plaintextPassword)
+	if err == nil {
+		err = store.RehashPassword(ctx, auth.UserID, auth.BasicAuth.Password, hashedPassword)
+	}
+	if err != nil {
+		slog.Error("Password rehash error", "err", err.Error(), "userID", auth.UserID)
+	}
+}
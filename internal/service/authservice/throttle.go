@@ -0,0 +1,212 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/limiter"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mail"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
+)
+
+const (
+	// SignInWindow is the sliding window failed sign ins are counted over.
+	SignInWindow = 15 * time.Minute
+	// SignInFreeFailures is how many failures an email or IP gets before
+	// each further attempt has to wait.
+	SignInFreeFailures = 3
+	// SignInMaxDelay caps the wait between attempts, it doubles per failure.
+	SignInMaxDelay = 30 * time.Second
+	// AccountLockoutFailures locks an account until the window slides past
+	// them or the mailed unlock link is followed.
+	AccountLockoutFailures = 10
+	// IPLockoutFailures locks out an IP guessing across many accounts.
+	IPLockoutFailures = 50
+	// UnlockTokenTTL is how long a mailed unlock link works.
+	UnlockTokenTTL = 1 * time.Hour
+)
+
+func emailLimiterKey(email string) string {
+	return "sign_in:email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipLimiterKey(ip string) string {
+	return "sign_in:ip:" + ip
+}
+
+// signInDelay is how long to wait after the last of failures before the
+// next attempt is looked at.
+func signInDelay(failures int) time.Duration {
+	if failures < SignInFreeFailures {
+		return 0
+	}
+
+	delay := time.Second << min(failures-SignInFreeFailures, 16)
+	return min(delay, SignInMaxDelay)
+}
+
+// checkSignInThrottle rejects an attempt from a locked out key or one that
+// comes before its delay is over.
+func checkSignInThrottle(ctx context.Context, l limiter.Limiter, key string, lockout int, lockedErr string) error {
+	now := time.Now()
+
+	failures, lastFailedAt, err := l.Failures(ctx, key, now.Add(-SignInWindow))
+	if err != nil {
+		return err
+	}
+
+	if failures >= lockout {
+		return errors.TooManyRequestsf(lockedErr)
+	}
+
+	if now.Before(lastFailedAt.Add(signInDelay(failures))) {
+		return errors.TooManyRequestsf(errors.ErrTooManySignIns)
+	}
+
+	return nil
+}
+
+func throttleSignInState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	if args.session.IP != "" {
+		err := checkSignInThrottle(ctx, args.limiter, ipLimiterKey(args.session.IP), IPLockoutFailures, errors.ErrTooManySignIns)
+		if err != nil {
+			return args, nil, err
+		}
+	}
+
+	err := checkSignInThrottle(ctx, args.limiter, emailLimiterKey(args.auth.BasicAuth.Email), AccountLockoutFailures, errors.ErrAccountLocked)
+	if err != nil {
+		return args, nil, err
+	}
+
+	return args, canSignInCheckState, nil
+}
+
+// recordSignInFailure counts a failed sign in against the email and IP,
+// auth is nil when the email has no account. Every failure is audited,
+// reaching a lockout is too and the account holder is mailed an unlock
+// link.
+func recordSignInFailure(ctx context.Context, args AuthArgs, auth *rf.Auth) error {
+	since := time.Now().Add(-SignInWindow)
+
+	failed := audit.Event{
+		Type:      audit.EventSignInFailed,
+		IP:        args.session.IP,
+		UserAgent: args.session.UserAgent,
+	}
+	if auth != nil {
+		failed.TargetType = audit.TargetUser
+		failed.TargetID = auth.UserID
+	}
+	audit.Record(ctx, args.recorder, failed)
+
+	if args.session.IP != "" {
+		key := ipLimiterKey(args.session.IP)
+		if err := args.limiter.Fail(ctx, key); err != nil {
+			return err
+		}
+
+		failures, _, err := args.limiter.Failures(ctx, key, since)
+		if err != nil {
+			return err
+		}
+
+		if failures == IPLockoutFailures {
+			audit.Record(ctx, args.recorder, audit.Event{
+				Type:      audit.EventSignInIPLockout,
+				IP:        args.session.IP,
+				UserAgent: args.session.UserAgent,
+			})
+		}
+	}
+
+	key := emailLimiterKey(args.auth.BasicAuth.Email)
+	if err := args.limiter.Fail(ctx, key); err != nil {
+		return err
+	}
+
+	failures, _, err := args.limiter.Failures(ctx, key, since)
+	if err != nil {
+		return err
+	}
+
+	if failures != AccountLockoutFailures || auth == nil {
+		return nil
+	}
+
+	audit.Record(ctx, args.recorder, audit.Event{
+		Type:       audit.EventSignInLockout,
+		TargetType: audit.TargetUser,
+		TargetID:   auth.UserID,
+		IP:         args.session.IP,
+		UserAgent:  args.session.UserAgent,
+	})
+
+	// Unverified addresses may not be the user's, mail is not sent to them.
+	if auth.EmailVerifiedAt.IsZero() {
+		return nil
+	}
+
+	return sendUnlock(ctx, args.store, args.mailer, args.unlockURL, auth.UserID, args.auth.BasicAuth.Email)
+}
+
+func (as *AuthService) UnlockAccount(ctx context.Context, req *rf.UnlockAccountRequest) error {
+	if req.Token == "" {
+		return errors.InvalidError(map[string]string{"token": errors.ErrUnlockTokenRequired})
+	}
+
+	auth, err := as.store.UseUnlockToken(ctx, hashToken(req.Token))
+	if err != nil {
+		return err
+	}
+
+	err = as.Limiter.Reset(ctx, emailLimiterKey(auth.BasicAuth.Email))
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventAccountUnlocked,
+		TargetType: audit.TargetUser,
+		TargetID:   auth.UserID,
+	})
+
+	return nil
+}
+
+func sendUnlock(ctx context.Context, store AuthStore, mailer mail.Mailer, unlockURL func(string) string, userID int64, email string) error {
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	err = store.CreateUnlockToken(ctx, &rf.UnlockToken{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Add(UnlockTokenTTL).Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      email,
+		Subject: "Your account was locked",
+		Body: fmt.Sprintf("Your account was locked after %d failed sign ins. It unlocks by itself within %s, "+
+			"or follow this link within %s to unlock it now:\n\n%s\n\n"+
+			"If these sign ins weren't you, consider changing your password.\n",
+			AccountLockoutFailures, SignInWindow, UnlockTokenTTL, unlockURL(token)),
+	}
+
+	if err := mailer.Send(ctx, msg); err != nil {
+		slog.Error("Unlock mail error", "err", err.Error(), "userID", userID)
+	}
+
+	return nil
+}
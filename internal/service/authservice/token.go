@@ -0,0 +1,29 @@
+package authservice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+const tokenBytes = 32
+
+// generateToken returns a random url safe token for refresh tokens and api
+// keys.
+func generateToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.InternalErrorf("%s: %v", errors.ErrTokenGenerationFailed, err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the form a token is stored in, so a leaked table of
+// tokens cannot be used to authenticate.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,367 @@
+package authservice
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strconv"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/jwt"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/totp"
+)
+
+const (
+	// MFAPendingTTL is how long after the password a second factor can be
+	// given before signing in starts over.
+	MFAPendingTTL = 5 * time.Minute
+	// RecoveryCodeCount is how many single use recovery codes are handed
+	// out when two-factor authentication is enabled.
+	RecoveryCodeCount = 10
+	// DefaultTOTPIssuer names the service in authenticator apps.
+	DefaultTOTPIssuer = "RSS Feed Aggregator"
+)
+
+func mfaLimiterKey(userID int64) string {
+	return "mfa:user:" + strconv.FormatInt(userID, 10)
+}
+
+// EnrollTOTP starts two-factor enrolment with a new secret, it is not
+// required at sign in until confirmed with ConfirmTOTP.
+func (as *AuthService) EnrollTOTP(ctx context.Context) (*rf.TOTPEnrollment, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	foundAuth, err := as.store.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if foundAuth == nil {
+		return nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	if !foundAuth.TOTPEnabledAt.IsZero() {
+		return nil, errors.InvalidDataf(errors.ErrTOTPAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = as.store.SaveTOTPSecret(ctx, &rf.TOTP{UserID: userID, Secret: secret})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rf.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(as.TOTPIssuer, foundAuth.BasicAuth.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication with the first code of
+// the enrolled secret and returns recovery codes, only shown this once.
+func (as *AuthService) ConfirmTOTP(ctx context.Context, req *rf.TOTPCodeRequest) (*rf.TOTPRecoveryCodes, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	if req.Code == "" {
+		return nil, errors.InvalidError(map[string]string{"code": errors.ErrTOTPCodeRequired})
+	}
+
+	key := mfaLimiterKey(userID)
+	err := checkSignInThrottle(ctx, as.Limiter, key, AccountLockoutFailures, errors.ErrAccountLocked)
+	if err != nil {
+		return nil, err
+	}
+
+	foundTOTP, err := as.store.FindTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if foundTOTP == nil {
+		return nil, errors.InvalidDataf(errors.ErrTOTPNotEnrolled)
+	}
+
+	if !foundTOTP.EnabledAt.IsZero() {
+		return nil, errors.InvalidDataf(errors.ErrTOTPAlreadyEnabled)
+	}
+
+	step, ok, err := totp.Validate(foundTOTP.Secret, req.Code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, as.failTOTPCode(ctx, key, errors.InvalidDataf(errors.ErrTOTPCodeInvalid))
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashToken(normalizeRecoveryCode(code))
+	}
+
+	err = as.store.EnableTOTP(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, as.failTOTPCode(ctx, key, err)
+	}
+
+	err = as.Limiter.Reset(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventTOTPEnabled,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	return &rf.TOTPRecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns two-factor authentication off, it needs a current code
+// so a stolen session alone cannot.
+func (as *AuthService) DisableTOTP(ctx context.Context, req *rf.TOTPCodeRequest) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	if req.Code == "" {
+		return errors.InvalidError(map[string]string{"code": errors.ErrTOTPCodeRequired})
+	}
+
+	key := mfaLimiterKey(userID)
+	err := checkSignInThrottle(ctx, as.Limiter, key, AccountLockoutFailures, errors.ErrAccountLocked)
+	if err != nil {
+		return err
+	}
+
+	foundTOTP, err := as.store.FindTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if foundTOTP == nil || foundTOTP.EnabledAt.IsZero() {
+		return errors.InvalidDataf(errors.ErrTOTPNotEnabled)
+	}
+
+	step, ok, err := totp.Validate(foundTOTP.Secret, req.Code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return as.failTOTPCode(ctx, key, errors.InvalidDataf(errors.ErrTOTPCodeInvalid))
+	}
+
+	err = as.store.DisableTOTP(ctx, userID, step)
+	if err != nil {
+		return as.failTOTPCode(ctx, key, err)
+	}
+
+	err = as.Limiter.Reset(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, as.Audit, audit.Event{
+		Type:       audit.EventTOTPDisabled,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	return nil
+}
+
+// failTOTPCode counts err against key when it is a wrong or reused code,
+// so codes cannot be guessed by enabling or disabling any faster than at
+// sign in. It returns err.
+func (as *AuthService) failTOTPCode(ctx context.Context, key string, err error) error {
+	if errors.ToErr(err) != errors.ErrTOTPCodeInvalid {
+		return err
+	}
+
+	if failErr := as.Limiter.Fail(ctx, key); failErr != nil {
+		return failErr
+	}
+
+	return err
+}
+
+// VerifyMFA completes a sign in that needed a second factor, code is a
+// TOTP code or an unused recovery code.
+func (as *AuthService) VerifyMFA(ctx context.Context, req *rf.MFAVerifyRequest) (*rf.AuthTokens, error) {
+	args := AuthArgs{
+		store:    as.store,
+		limiter:  as.Limiter,
+		recorder: as.Audit,
+		mfaToken: req.MFAToken,
+		mfaCode:  req.Code,
+		session: &rf.Session{
+			DeviceName: req.DeviceName,
+			IP:         req.IP,
+			UserAgent:  req.UserAgent,
+		},
+		auth: &rf.Auth{},
+	}
+
+	if err := args.validateMFA(); err != nil {
+		return nil, err
+	}
+
+	result, err := statemachine.Run(ctx, args, verifyMFAState)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.tokens(), nil
+}
+
+func (as AuthArgs) validateMFA() error {
+	if as.store == nil {
+		return errors.InternalErrorf("store cannot be nil")
+	}
+
+	errs := map[string]string{}
+
+	if as.mfaToken == "" {
+		errs["mfaToken"] = errors.ErrMFATokenRequired
+	}
+
+	if as.mfaCode == "" {
+		errs["code"] = errors.ErrTOTPCodeRequired
+	}
+
+	if len(errs) > 0 {
+		return errors.InvalidError(errs)
+	}
+
+	return nil
+}
+
+func mfaPendingState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	expiresAt := time.Now().UTC().Add(MFAPendingTTL).Truncate(time.Second)
+
+	token, err := jwt.GenerateAndSignMFAPending(args.authToValidate.UserID, expiresAt)
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.mfaToken = token
+	args.mfaTokenExpiresAt = expiresAt
+	return args, nil, nil
+}
+
+func verifyMFAState(ctx context.Context, args AuthArgs) (AuthArgs, statemachine.StateFn[AuthArgs], error) {
+	userID, err := jwt.ParseAndVerifyMFAPending(args.mfaToken)
+	if err != nil {
+		return args, nil, err
+	}
+
+	key := mfaLimiterKey(userID)
+	err = checkSignInThrottle(ctx, args.limiter, key, AccountLockoutFailures, errors.ErrAccountLocked)
+	if err != nil {
+		return args, nil, err
+	}
+
+	foundAuth, err := args.store.FindByUserID(ctx, userID)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if foundAuth == nil || foundAuth.TOTPEnabledAt.IsZero() {
+		return args, nil, errors.Unauthorizedf(errors.ErrUnauthorized)
+	}
+
+	if err := checkCanSignIn(foundAuth); err != nil {
+		return args, nil, err
+	}
+
+	err = verifySecondFactor(ctx, args.store, userID, args.mfaCode)
+	if err != nil {
+		if errors.ToErr(err) != errors.ErrTOTPCodeInvalid {
+			return args, nil, err
+		}
+		if err := args.limiter.Fail(ctx, key); err != nil {
+			return args, nil, err
+		}
+		if err := args.store.RecordSignInAttempt(ctx, foundAuth, false); err != nil {
+			return args, nil, err
+		}
+		audit.Record(ctx, args.recorder, audit.Event{
+			Type:       audit.EventSignInFailed,
+			TargetType: audit.TargetUser,
+			TargetID:   userID,
+			Detail:     "second factor",
+		})
+		return args, nil, err
+	}
+
+	err = args.limiter.Reset(ctx, key)
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.mfaToken = ""
+	args.authToValidate = foundAuth
+	args.auth.UserID = foundAuth.UserID
+	args.auth.Role = foundAuth.Role
+	args.auth.BasicAuth = foundAuth.BasicAuth
+	return args, recordSignInState, nil
+}
+
+// verifySecondFactor spends code as a TOTP code when it looks like one and
+// as a recovery code otherwise.
+func verifySecondFactor(ctx context.Context, store AuthStore, userID int64, code string) error {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+
+	if len(code) != totp.Digits {
+		return store.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	foundTOTP, err := store.FindTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if foundTOTP == nil {
+		return errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)
+	}
+
+	step, ok, err := totp.Validate(foundTOTP.Secret, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)
+	}
+
+	return store.UseTOTPStep(ctx, userID, step)
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCode returns a random code like "ABCDE-FGHIJ".
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := recoveryCodeEncoding.EncodeToString(b)[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,133 @@
+package exportservice
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/opml"
+)
+
+// Files of an export, records of the .jsonl files are one JSON object per
+// line so they can be read without loading the whole file.
+const (
+	ProfileFile           = "profile.json"
+	SubscriptionsFile     = "subscriptions.opml"
+	ItemStatesFile        = "item_states.jsonl"
+	PlaybackPositionsFile = "playback_positions.jsonl"
+	SessionsFile          = "sessions.jsonl"
+	APIKeysFile           = "api_keys.jsonl"
+	IdentitiesFile        = "identities.jsonl"
+)
+
+// writeArchive streams the zip of a user's data to w, each record goes
+// straight from its database row to the zip.
+func writeArchive(ctx context.Context, w io.Writer, store ExportStore, userID int64) error {
+	zw := zip.NewWriter(w)
+
+	profile, err := store.FindExportProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if profile == nil {
+		return errors.NotFoundf(errors.ErrUserNotFound)
+	}
+
+	err = writeEntry(zw, ProfileFile, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(profile)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeEntry(zw, SubscriptionsFile, func(w io.Writer) error {
+		ow, err := opml.NewWriter(w, "Subscriptions of "+profile.User.Name)
+		if err != nil {
+			return err
+		}
+
+		err = store.EachSubscription(ctx, userID, func(feed rf.Feed) error {
+			return ow.WriteOutline(opml.Outline{
+				Text:   feed.Name,
+				Title:  feed.Name,
+				Type:   "rss",
+				XMLURL: feed.URL,
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		return ow.Close()
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeEntry(zw, ItemStatesFile, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		return store.EachItemState(ctx, userID, func(state rf.ExportItemState) error {
+			return enc.Encode(state)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeEntry(zw, PlaybackPositionsFile, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		return store.EachPlaybackPosition(ctx, userID, func(position rf.ExportPlaybackPosition) error {
+			return enc.Encode(position)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeEntry(zw, SessionsFile, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		return store.EachSession(ctx, userID, func(session rf.ExportSession) error {
+			return enc.Encode(session)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeEntry(zw, APIKeysFile, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		return store.EachAPIKey(ctx, userID, func(key rf.ExportAPIKey) error {
+			return enc.Encode(key)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeEntry(zw, IdentitiesFile, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		return store.EachIdentity(ctx, userID, func(identity rf.ExportIdentity) error {
+			return enc.Encode(identity)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeEntry(zw *zip.Writer, name string, write func(w io.Writer) error) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	return write(w)
+}
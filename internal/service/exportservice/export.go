@@ -0,0 +1,233 @@
+package exportservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+const (
+	// ExportTTL is how long a finished export is kept for download.
+	ExportTTL = 7 * 24 * time.Hour
+	// DownloadLinkTTL is how long a signed download link works, a new one is
+	// handed out each time the export is looked up.
+	DownloadLinkTTL = time.Hour
+)
+
+type ExportStore interface {
+	CreateExport(ctx context.Context, export *rf.DataExport) error
+	FindExport(ctx context.Context, exportID int64) (*rf.DataExport, error)
+	ListUserExports(ctx context.Context, userID int64) ([]rf.DataExport, error)
+	ClaimPendingExport(ctx context.Context) (*rf.DataExport, error)
+	FinishExport(ctx context.Context, exportID int64, state rf.DataExportState, size int64) error
+	DeleteExpiredExports(ctx context.Context) ([]int64, error)
+	FindExportProfile(ctx context.Context, userID int64) (*rf.ExportProfile, error)
+	EachSubscription(ctx context.Context, userID int64, fn func(rf.Feed) error) error
+	EachItemState(ctx context.Context, userID int64, fn func(rf.ExportItemState) error) error
+	EachPlaybackPosition(ctx context.Context, userID int64, fn func(rf.ExportPlaybackPosition) error) error
+	EachSession(ctx context.Context, userID int64, fn func(rf.ExportSession) error) error
+	EachAPIKey(ctx context.Context, userID int64, fn func(rf.ExportAPIKey) error) error
+	EachIdentity(ctx context.Context, userID int64, fn func(rf.ExportIdentity) error) error
+}
+
+type ExportService struct {
+	store ExportStore
+
+	// Dir is where finished exports are kept until they expire.
+	Dir string
+	// DownloadURL returns a link to download an export that works until
+	// expiresAt without signing in.
+	DownloadURL func(exportID int64, expiresAt time.Time) string
+}
+
+func NewExportService(store ExportStore) *ExportService {
+	return &ExportService{
+		store: store,
+		Dir:   filepath.Join(os.TempDir(), "rss-feed-aggregator-exports"),
+		DownloadURL: func(exportID int64, expiresAt time.Time) string {
+			return fmt.Sprintf("/api/v1/exports/%d/download", exportID)
+		},
+	}
+}
+
+// RequestExport queues an export of everything held about the user, a
+// request while one is still being built returns that one.
+func (es *ExportService) RequestExport(ctx context.Context) (*rf.DataExport, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	export := &rf.DataExport{
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Add(ExportTTL).Truncate(time.Second),
+	}
+
+	err := es.store.CreateExport(ctx, export)
+	if err != nil {
+		return nil, err
+	}
+
+	return es.withDownloadURL(export), nil
+}
+
+func (es *ExportService) ListExports(ctx context.Context) ([]rf.DataExport, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	exports, err := es.store.ListUserExports(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range exports {
+		es.withDownloadURL(&exports[i])
+	}
+
+	return exports, nil
+}
+
+func (es *ExportService) GetExport(ctx context.Context, exportID int64) (*rf.DataExport, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	export, err := es.store.FindExport(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if export == nil || export.UserID != userID {
+		return nil, errors.NotFoundf(errors.ErrExportNotFound)
+	}
+
+	return es.withDownloadURL(export), nil
+}
+
+// OpenExport opens the zip of a ready export. It does not check who asks,
+// callers must have checked a signed download link first.
+func (es *ExportService) OpenExport(ctx context.Context, exportID int64) (*os.File, *rf.DataExport, error) {
+	export, err := es.store.FindExport(ctx, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if export == nil {
+		return nil, nil, errors.NotFoundf(errors.ErrExportNotFound)
+	}
+
+	if export.State != rf.DataExportStateReady {
+		return nil, nil, errors.NotFoundf(errors.ErrExportNotReady)
+	}
+
+	file, err := os.Open(es.path(export.ID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, errors.NotFoundf(errors.ErrExportNotFound)
+		}
+		return nil, nil, err
+	}
+
+	return file, export, nil
+}
+
+// ProcessExports builds every queued export and removes expired ones.
+func (es *ExportService) ProcessExports(ctx context.Context) error {
+	if err := es.removeExpired(ctx); err != nil {
+		return err
+	}
+
+	for {
+		export, err := es.store.ClaimPendingExport(ctx)
+		if err != nil {
+			return err
+		}
+
+		if export == nil {
+			return nil
+		}
+
+		state := rf.DataExportStateReady
+		size, err := es.build(ctx, export)
+		if err != nil {
+			slog.Error("Data export error", "err", err.Error(), "exportID", export.ID, "userID", export.UserID)
+			state = rf.DataExportStateFailed
+			size = 0
+		}
+
+		err = es.store.FinishExport(ctx, export.ID, state, size)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// build writes the zip next to its final path and renames it into place, a
+// half written export is never served.
+func (es *ExportService) build(ctx context.Context, export *rf.DataExport) (int64, error) {
+	if err := os.MkdirAll(es.Dir, 0o700); err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp(es.Dir, fmt.Sprintf("export-%d-*.tmp", export.ID))
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	err = writeArchive(ctx, file, es.store, export.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	err = os.Rename(file.Name(), es.path(export.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+func (es *ExportService) removeExpired(ctx context.Context) error {
+	ids, err := es.store.DeleteExpiredExports(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := os.Remove(es.path(id)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (es *ExportService) withDownloadURL(export *rf.DataExport) *rf.DataExport {
+	if export.State != rf.DataExportStateReady {
+		return export
+	}
+
+	expiresAt := time.Now().Add(DownloadLinkTTL)
+	if export.ExpiresAt.Before(expiresAt) {
+		expiresAt = export.ExpiresAt
+	}
+
+	export.DownloadURL = es.DownloadURL(export.ID, expiresAt)
+	return export
+}
+
+func (es *ExportService) path(exportID int64) string {
+	return filepath.Join(es.Dir, fmt.Sprintf("export-%d.zip", exportID))
+}
@@ -0,0 +1,272 @@
+package exportservice_test
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/opml"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/exportservice"
+	"github.com/matryer/is"
+)
+
+// newExportStore keeps exports in memory and holds one user with a few
+// records of each kind.
+func newExportStore() *mock.ExportStore {
+	exports := map[int64]*rf.DataExport{}
+
+	return &mock.ExportStore{
+		CreateExportFn: func(ctx context.Context, export *rf.DataExport) error {
+			export.ID = int64(len(exports) + 1)
+			export.State = rf.DataExportStatePending
+			export.CreatedAt = time.Now()
+			exports[export.ID] = export
+			return nil
+		},
+		FindExportFn: func(ctx context.Context, exportID int64) (*rf.DataExport, error) {
+			export, ok := exports[exportID]
+			if !ok {
+				return nil, nil
+			}
+			found := *export
+			return &found, nil
+		},
+		ClaimPendingExportFn: func(ctx context.Context) (*rf.DataExport, error) {
+			for _, export := range exports {
+				if export.State == rf.DataExportStatePending {
+					export.State = rf.DataExportStateRunning
+					claimed := *export
+					return &claimed, nil
+				}
+			}
+			return nil, nil
+		},
+		FinishExportFn: func(ctx context.Context, exportID int64, state rf.DataExportState, size int64) error {
+			exports[exportID].State = state
+			exports[exportID].Size = size
+			exports[exportID].CompletedAt = time.Now()
+			return nil
+		},
+		DeleteExpiredExportsFn: func(ctx context.Context) ([]int64, error) {
+			return nil, nil
+		},
+		FindExportProfileFn: func(ctx context.Context, userID int64) (*rf.ExportProfile, error) {
+			if userID != 1 {
+				return nil, nil
+			}
+			return &rf.ExportProfile{
+				User:  &rf.User{ID: 1, Name: "Gopher", Timezone: "UTC"},
+				Email: "gopher@go.com",
+			}, nil
+		},
+		EachSubscriptionFn: func(ctx context.Context, userID int64, fn func(rf.Feed) error) error {
+			for i := 1; i <= 3; i++ {
+				err := fn(rf.Feed{ID: int64(i), Name: fmt.Sprintf("Feed %d", i), URL: fmt.Sprintf("https://example.com/%d.xml", i)})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		EachItemStateFn: func(ctx context.Context, userID int64, fn func(rf.ExportItemState) error) error {
+			if err := fn(rf.ExportItemState{ItemID: 7, FeedID: 1, Title: "Hello", ReadAt: time.Now()}); err != nil {
+				return err
+			}
+			return fn(rf.ExportItemState{ItemID: 8, FeedID: 1, Title: "Later", StarredAt: time.Now(), SavedAt: time.Now()})
+		},
+		EachPlaybackPositionFn: func(ctx context.Context, userID int64, fn func(rf.ExportPlaybackPosition) error) error {
+			return nil
+		},
+		EachSessionFn: func(ctx context.Context, userID int64, fn func(rf.ExportSession) error) error {
+			for i := 1; i <= 2; i++ {
+				if err := fn(rf.ExportSession{ID: int64(i), DeviceName: "Phone"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		EachAPIKeyFn: func(ctx context.Context, userID int64, fn func(rf.ExportAPIKey) error) error {
+			return fn(rf.ExportAPIKey{ID: 1, Name: "Reader", Prefix: "rf_abc", Scope: rf.APIKeyScopeRead,
+				CreatedAt: time.Now(), LastUsedAt: time.Now()})
+		},
+		EachIdentityFn: func(ctx context.Context, userID int64, fn func(rf.ExportIdentity) error) error {
+			return fn(rf.ExportIdentity{Issuer: "https://accounts.example.com", Subject: "gopher-123", Email: "gopher@go.com"})
+		},
+	}
+}
+
+func TestExportService(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+	t.Run("Should build a zip of the user's data", func(t *testing.T) {
+		t.Parallel()
+
+		service := exportservice.NewExportService(newExportStore())
+		service.Dir = t.TempDir()
+		service.DownloadURL = func(exportID int64, expiresAt time.Time) string {
+			return fmt.Sprintf("/download/%d", exportID)
+		}
+
+		export, err := service.RequestExport(ctx)
+
+		is.NoErr(err)                                              // should queue the export
+		is.Equal(export.State, rf.DataExportStatePending)          // should be built later
+		is.Equal(export.DownloadURL, "")                           // should not link to an export that is not ready
+		is.True(export.ExpiresAt.After(time.Now().Add(time.Hour))) // should be kept for a while
+
+		is.NoErr(service.ProcessExports(context.Background())) // should build the export
+
+		export, err = service.GetExport(ctx, export.ID)
+
+		is.NoErr(err)                                   // should find the export
+		is.Equal(export.State, rf.DataExportStateReady) // should be ready
+		is.Equal(export.DownloadURL, "/download/1")     // should link to the download
+		is.True(export.Size > 0)                        // should record the size
+
+		file, _, err := service.OpenExport(context.Background(), export.ID)
+		is.NoErr(err) // should open the zip
+		defer file.Close()
+
+		info, err := file.Stat()
+		is.NoErr(err)                      // should stat the zip
+		is.Equal(info.Size(), export.Size) // should be the recorded size
+
+		zr, err := zip.NewReader(file, info.Size())
+		is.NoErr(err) // should be a zip
+
+		files := map[string]*zip.File{}
+		for _, f := range zr.File {
+			files[f.Name] = f
+		}
+
+		read := func(name string) io.ReadCloser {
+			f, ok := files[name]
+			is.True(ok) // should have the file
+			rc, err := f.Open()
+			is.NoErr(err) // should open the file
+			return rc
+		}
+
+		var profile rf.ExportProfile
+		rc := read(exportservice.ProfileFile)
+		is.NoErr(json.NewDecoder(rc).Decode(&profile)) // should be json
+		rc.Close()
+		is.Equal(profile.Email, "gopher@go.com") // should have the account email
+		is.Equal(profile.User.Name, "Gopher")    // should have the profile
+
+		var doc opml.Document
+		rc = read(exportservice.SubscriptionsFile)
+		is.NoErr(xml.NewDecoder(rc).Decode(&doc)) // should be opml
+		rc.Close()
+		is.Equal(len(doc.Body), 3)                                // should have every subscription
+		is.Equal(doc.Body[0].XMLURL, "https://example.com/1.xml") // should have the feed url
+
+		lines := func(name string) int {
+			rc := read(name)
+			defer rc.Close()
+			n := 0
+			scanner := bufio.NewScanner(rc)
+			for scanner.Scan() {
+				is.True(json.Valid(scanner.Bytes())) // each line should be json
+				n++
+			}
+			return n
+		}
+
+		is.Equal(lines(exportservice.ItemStatesFile), 2)        // should have the read, starred and saved items
+		is.Equal(lines(exportservice.PlaybackPositionsFile), 0) // should have no playback positions
+		is.Equal(lines(exportservice.SessionsFile), 2)          // should have the session history
+		is.Equal(lines(exportservice.APIKeysFile), 1)           // should have the api keys
+		is.Equal(lines(exportservice.IdentitiesFile), 1)        // should have the linked identities
+
+		decode := func(name string, v any) {
+			rc := read(name)
+			defer rc.Close()
+			dec := json.NewDecoder(rc)
+			for dec.More() {
+				is.NoErr(dec.Decode(v)) // should be json
+			}
+		}
+
+		var state rf.ExportItemState
+		decode(exportservice.ItemStatesFile, &state)
+		is.Equal(state.ItemID, int64(8))   // should have the starred and saved item last
+		is.True(state.ReadAt.IsZero())     // should not be read
+		is.True(!state.StarredAt.IsZero()) // should have when it was starred
+		is.True(!state.SavedAt.IsZero())   // should have when it was saved
+
+		var key map[string]any
+		decode(exportservice.APIKeysFile, &key)
+		is.Equal(key["name"], "Reader")                    // should have the key name
+		is.Equal(key["prefix"], "rf_abc")                  // should have the key prefix
+		is.Equal(key["scope"], string(rf.APIKeyScopeRead)) // should have the key scope
+		is.True(key["createdAt"] != nil)                   // should have when the key was created
+		is.True(key["lastUsedAt"] != nil)                  // should have when the key was last used
+		_, hasKey := key["key"]
+		is.True(!hasKey) // should not have the key itself
+		_, hasHash := key["keyHash"]
+		is.True(!hasHash) // should not have the key hash
+
+		var identity rf.ExportIdentity
+		decode(exportservice.IdentitiesFile, &identity)
+		is.Equal(identity.Issuer, "https://accounts.example.com") // should have the issuer
+		is.Equal(identity.Subject, "gopher-123")                  // should have the subject
+	})
+
+	t.Run("Should mark an export failed when it cannot be built", func(t *testing.T) {
+		t.Parallel()
+
+		store := newExportStore()
+		store.EachSessionFn = func(ctx context.Context, userID int64, fn func(rf.ExportSession) error) error {
+			return fmt.Errorf("connection lost")
+		}
+		service := exportservice.NewExportService(store)
+		service.Dir = t.TempDir()
+
+		export, err := service.RequestExport(ctx)
+		is.NoErr(err) // should queue the export
+
+		is.NoErr(service.ProcessExports(context.Background())) // should carry on past a failed export
+
+		export, err = service.GetExport(ctx, export.ID)
+
+		is.NoErr(err)                                    // should find the export
+		is.Equal(export.State, rf.DataExportStateFailed) // should be failed
+
+		_, _, err = service.OpenExport(context.Background(), export.ID)
+
+		is.Equal(errors.ToErr(err), errors.ErrExportNotReady) // should not serve a failed export
+
+		entries, err := os.ReadDir(service.Dir)
+		is.NoErr(err)             // should read the export dir
+		is.Equal(len(entries), 0) // should not leave a partial file behind
+	})
+
+	t.Run("Should not show an export to another user", func(t *testing.T) {
+		t.Parallel()
+
+		service := exportservice.NewExportService(newExportStore())
+		service.Dir = t.TempDir()
+
+		export, err := service.RequestExport(ctx)
+		is.NoErr(err) // should queue the export
+
+		_, err = service.GetExport(rfcontext.SetUserIDToContext(context.Background(), 2), export.ID)
+
+		is.Equal(errors.ToReferenceCode(err), errors.NotFound) // should not be found
+	})
+}
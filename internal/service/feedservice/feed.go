@@ -2,29 +2,49 @@ package feedservice
 
 import (
 	"context"
+	"log/slog"
 
 	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
 	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
 )
 
 type FeedStore interface {
-	CreateFeed(ctx context.Context, feed *rf.Feed) error
-	CreateUserFeed(ctx context.Context, feed *rf.Feed) error
+	// BeginTx begins the unit of work CreateFeed and CreateUserFeed run in,
+	// nothing they do is kept until it is committed.
+	BeginTx(ctx context.Context) (rf.Tx, error)
+	// CreateFeed creates the feed, or finds the existing one when its url
+	// is taken.
+	CreateFeed(ctx context.Context, tx rf.Tx, feed *rf.Feed) error
+	CreateUserFeed(ctx context.Context, tx rf.Tx, feed *rf.Feed) error
 	ListUserFeeds(ctx context.Context, userID int64) ([]rf.Feed, error)
 	FindUserFeedByID(ctx context.Context, userID, feedID int64) (*rf.Feed, error)
+	UpdateUserFeed(ctx context.Context, feed *rf.Feed) error
 	FindByURL(ctx context.Context, url string) (*rf.Feed, error)
 	DeleteFeed(ctx context.Context, userID, feedID int64) error
+	CountFeedSubscribers(ctx context.Context, feedID int64) (int, error)
+}
+
+type HubUnsubscriber interface {
+	UnsubscribeFeed(ctx context.Context, feedID int64) error
 }
 
 type FeedService struct {
 	store FeedStore
+
+	// Audit records subscribing to and unsubscribing from feeds.
+	Audit audit.Recorder
+	// WebSub stops push updates for feeds nobody subscribes to anymore.
+	WebSub HubUnsubscriber
 }
 
 func NewFeedService(store FeedStore) *FeedService {
 	return &FeedService{
 		store: store,
+		Audit: audit.NewLogRecorder(),
 	}
 }
 
@@ -46,11 +66,31 @@ func (fs *FeedService) AddFeed(ctx context.Context, req *rf.AddFeedRequest) (int
 		return 0, err
 	}
 
-	result, err := statemachine.Run(ctx, args, addFeedState)
+	// The feed and the subscription are created together or not at all.
+	tx, err := fs.store.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	args.tx = tx
+
+	result, err := statemachine.Run(ctx, args, createFeedState)
 	if err != nil {
 		return 0, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	audit.Record(ctx, fs.Audit, audit.Event{
+		Type:       audit.EventFeedSubscribed,
+		TargetType: audit.TargetFeed,
+		TargetID:   result.feed.ID,
+		Detail:     result.feed.URL,
+	})
+
 	return result.feed.ID, nil
 }
 
@@ -62,9 +102,32 @@ func (fs *FeedService) RemoveFeed(ctx context.Context, feedID int64) error {
 		return err
 	}
 
+	audit.Record(ctx, fs.Audit, audit.Event{
+		Type:       audit.EventFeedUnsubscribed,
+		TargetType: audit.TargetFeed,
+		TargetID:   feedID,
+	})
+
+	if fs.WebSub != nil {
+		fs.unsubscribeHub(ctx, feedID)
+	}
+
 	return nil
 }
 
+// unsubscribeHub stops push updates for the feed once its last subscriber
+// is gone. The user is already unsubscribed, so failures are only logged.
+func (fs *FeedService) unsubscribeHub(ctx context.Context, feedID int64) {
+	count, err := fs.store.CountFeedSubscribers(ctx, feedID)
+	if err == nil && count == 0 {
+		err = fs.WebSub.UnsubscribeFeed(ctx, feedID)
+	}
+
+	if err != nil {
+		slog.Error("WebSub unsubscribe error", "err", err.Error(), "feedID", feedID)
+	}
+}
+
 func (fs *FeedService) GetFeeds(ctx context.Context) ([]rf.Feed, error) {
 	userID := rfcontext.UserIDFromContext(ctx)
 
@@ -85,3 +148,34 @@ func (fs *FeedService) GetFeed(ctx context.Context, feedID int64) (*rf.Feed, err
 
 	return foundFeed, nil
 }
+
+func (fs *FeedService) UpdateFeed(ctx context.Context, req *rf.UpdateFeedRequest) (*rf.Feed, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	foundFeed, err := fs.store.FindUserFeedByID(ctx, userID, req.FeedID)
+	if err != nil {
+		return nil, err
+	}
+
+	if foundFeed == nil {
+		return nil, errors.NotFoundf(errors.ErrFeedNotFound)
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, errors.InvalidDataf(errors.ErrNameRequired)
+		}
+		foundFeed.Name = *req.Name
+	}
+
+	if req.FetchFullContent != nil {
+		foundFeed.FetchFullContent = *req.FetchFullContent
+	}
+
+	err = fs.store.UpdateUserFeed(ctx, foundFeed)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundFeed, nil
+}
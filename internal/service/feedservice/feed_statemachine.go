@@ -10,6 +10,7 @@ import (
 
 type FeedArgs struct {
 	store FeedStore
+	tx    rf.Tx
 	feed  *rf.Feed
 }
 
@@ -25,22 +26,8 @@ func (fs FeedArgs) validateAddFeed() error {
 	return nil
 }
 
-func addFeedState(ctx context.Context, args FeedArgs) (FeedArgs, statemachine.StateFn[FeedArgs], error) {
-	hasFeed, err := args.store.FindByURL(ctx, args.feed.URL)
-	if err != nil {
-		return args, nil, err
-	}
-
-	if hasFeed != nil {
-		args.feed.ID = hasFeed.ID
-		return args, createUserFeedState, nil
-	}
-
-	return args, createFeedState, nil
-}
-
 func createFeedState(ctx context.Context, args FeedArgs) (FeedArgs, statemachine.StateFn[FeedArgs], error) {
-	if err := args.store.CreateFeed(ctx, args.feed); err != nil {
+	if err := args.store.CreateFeed(ctx, args.tx, args.feed); err != nil {
 		return args, nil, err
 	}
 
@@ -48,7 +35,7 @@ func createFeedState(ctx context.Context, args FeedArgs) (FeedArgs, statemachine
 }
 
 func createUserFeedState(ctx context.Context, args FeedArgs) (FeedArgs, statemachine.StateFn[FeedArgs], error) {
-	if err := args.store.CreateUserFeed(ctx, args.feed); err != nil {
+	if err := args.store.CreateUserFeed(ctx, args.tx, args.feed); err != nil {
 		return args, nil, err
 	}
 
@@ -0,0 +1,133 @@
+package feedservice_test
+
+import (
+	"context"
+	"testing"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/feedservice"
+	"github.com/matryer/is"
+)
+
+func TestFeedService_AddFeed(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	newStore := func(tx *mock.Tx, createUserFeedErr error) *mock.FeedStore {
+		return &mock.FeedStore{
+			BeginTxFn: func(ctx context.Context) (rf.Tx, error) {
+				return tx, nil
+			},
+			CreateFeedFn: func(ctx context.Context, gotTx rf.Tx, feed *rf.Feed) error {
+				is.Equal(gotTx, tx) // should create the feed in the transaction
+				feed.ID = 7
+				return nil
+			},
+			CreateUserFeedFn: func(ctx context.Context, gotTx rf.Tx, feed *rf.Feed) error {
+				is.Equal(gotTx, tx) // should subscribe in the transaction
+				return createUserFeedErr
+			},
+		}
+	}
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+	t.Run("Should create the feed and the subscription in one transaction", func(t *testing.T) {
+		t.Parallel()
+
+		tx := &mock.Tx{}
+		store := newStore(tx, nil)
+		service := feedservice.NewFeedService(store)
+
+		feedID, err := service.AddFeed(ctx, &rf.AddFeedRequest{Name: "Gopher", URL: "http://feed.com/rss"})
+
+		is.NoErr(err)                        // should add the feed
+		is.Equal(feedID, int64(7))           // should return the feed id
+		is.True(store.CreateFeedInvoked)     // should create the feed
+		is.True(store.CreateUserFeedInvoked) // should subscribe the user
+		is.True(tx.CommitInvoked)            // should commit
+		is.True(!tx.RollbackInvoked)         // should not roll back
+	})
+
+	t.Run("Should roll back the feed when the subscription fails", func(t *testing.T) {
+		t.Parallel()
+
+		tx := &mock.Tx{}
+		store := newStore(tx, errors.InvalidDataf(errors.ErrCouldNotProcess))
+		service := feedservice.NewFeedService(store)
+
+		_, err := service.AddFeed(ctx, &rf.AddFeedRequest{Name: "Gopher", URL: "http://feed.com/rss"})
+
+		is.Equal(err, errors.InvalidDataf(errors.ErrCouldNotProcess)) // should return the subscription error
+		is.True(!tx.CommitInvoked)                                    // should not commit
+		is.True(tx.RollbackInvoked)                                   // should roll back the feed
+	})
+
+	t.Run("Should not begin a transaction without a url", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(&mock.Tx{}, nil)
+		service := feedservice.NewFeedService(store)
+
+		_, err := service.AddFeed(ctx, &rf.AddFeedRequest{Name: "Gopher"})
+
+		is.Equal(err, errors.InvalidDataf(errors.ErrURLRequired)) // should require a url
+		is.True(!store.BeginTxInvoked)                            // should not begin a transaction
+	})
+}
+
+func TestFeedService_RemoveFeed(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	newStore := func(subscribers int) *mock.FeedStore {
+		return &mock.FeedStore{
+			DeleteFeedFn: func(ctx context.Context, userID, feedID int64) error {
+				return nil
+			},
+			CountFeedSubscribersFn: func(ctx context.Context, feedID int64) (int, error) {
+				return subscribers, nil
+			},
+		}
+	}
+
+	newWebSub := func() *mock.WebSubSubscriber {
+		return &mock.WebSubSubscriber{
+			UnsubscribeFeedFn: func(ctx context.Context, feedID int64) error {
+				is.Equal(feedID, int64(7)) // should unsubscribe the removed feed
+				return nil
+			},
+		}
+	}
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+	t.Run("Should unsubscribe from the hub when the last subscriber removes the feed", func(t *testing.T) {
+		t.Parallel()
+
+		webSub := newWebSub()
+		service := feedservice.NewFeedService(newStore(0))
+		service.WebSub = webSub
+
+		err := service.RemoveFeed(ctx, 7)
+
+		is.NoErr(err)                          // should remove the feed
+		is.True(webSub.UnsubscribeFeedInvoked) // should unsubscribe from the hub
+	})
+
+	t.Run("Should keep the hub subscription while others subscribe", func(t *testing.T) {
+		t.Parallel()
+
+		webSub := newWebSub()
+		service := feedservice.NewFeedService(newStore(1))
+		service.WebSub = webSub
+
+		err := service.RemoveFeed(ctx, 7)
+
+		is.NoErr(err)                           // should remove the feed
+		is.True(!webSub.UnsubscribeFeedInvoked) // should keep the hub subscription
+	})
+}
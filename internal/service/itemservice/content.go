@@ -0,0 +1,79 @@
+package itemservice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+const (
+	// retryFailedContentAfter is how long a failed extraction is cached
+	// before the article is downloaded again.
+	retryFailedContentAfter = 24 * time.Hour
+	// contentBatchSize is how many articles one ExtractPendingContent
+	// downloads, the rest wait for the next run.
+	contentBatchSize = 20
+)
+
+type ContentExtractor interface {
+	Extract(ctx context.Context, link string) (string, error)
+}
+
+// ExtractPendingContent downloads and caches the full article of the
+// newest items whose subscribers asked for it. Syncing and reading items
+// never wait on a download, reads serve the cached article once it is
+// there and the description until then.
+func (is *ItemService) ExtractPendingContent(ctx context.Context) error {
+	if is.Extractor == nil {
+		return nil
+	}
+
+	items, err := is.store.ListItemsWantingContent(ctx, time.Now().Add(-retryFailedContentAfter), contentBatchSize)
+	if err != nil {
+		return err
+	}
+
+	// A failed download is cached against the item and must not hold up
+	// the rest of the batch.
+	var errs []error
+	for _, item := range items {
+		if _, err := fullContent(ctx, is.store, is.Extractor, &item); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// fullContent returns the cached full article of item, downloading and
+// extracting it only when there is no usable cache entry so subscribers of
+// the same feed share a single download.
+func fullContent(ctx context.Context, store ItemStore, extractor ContentExtractor, item *rf.Item) (*rf.ItemContent, error) {
+	cached, err := store.FindItemContent(ctx, item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && (cached.Error == "" || time.Since(cached.FetchedAt) < retryFailedContentAfter) {
+		return cached, nil
+	}
+
+	content := &rf.ItemContent{
+		ItemID: item.ID,
+	}
+
+	extracted, err := extractor.Extract(ctx, item.Link)
+	if err != nil {
+		content.Error = err.Error()
+	} else {
+		content.Content = extracted
+	}
+
+	if err := store.SaveItemContent(ctx, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
@@ -0,0 +1,62 @@
+package itemservice
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/simhash"
+)
+
+// maxStoryDistance is the largest SimHash distance between two items from
+// different feeds that are still considered the same story.
+const maxStoryDistance = 6
+
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"mc_cid": true,
+	"mc_eid": true,
+	"ref":    true,
+}
+
+func fingerprint(item *rf.Item) {
+	item.CanonicalLink = canonicalLink(itemLink(item))
+	item.SimHash = simhash.Fingerprint(item.Title + " " + item.Description + " " + item.Content)
+}
+
+// canonicalLink reduces a link to the parts that identify the story so the
+// same article syndicated with different schemes, hosts prefixes or tracking
+// parameters compares equal.
+func canonicalLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || trackingParams[key] {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var params []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	if len(params) == 0 {
+		return host + path
+	}
+	return host + path + "?" + strings.Join(params, "&")
+}
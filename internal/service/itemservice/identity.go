@@ -0,0 +1,78 @@
+package itemservice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+// minUnstableGUIDMatches is the number of already stored items that must be
+// seen again under a new GUID before a feed is considered to regenerate them.
+const minUnstableGUIDMatches = 2
+
+// identityKey keys item by the identity of its feed, items missing what
+// the identity needs fall back to their link and then their content. It
+// only looks at the item itself so a key never changes with the rest of the
+// batch.
+func identityKey(item *rf.Item, identity rf.ItemIdentity) string {
+	link := itemLink(item)
+
+	switch {
+	case identity == rf.ItemIdentityGUID && item.GUID != "":
+		return "guid:" + item.GUID
+	case identity != rf.ItemIdentityHash && link != "":
+		return "link:" + link
+	default:
+		sum := sha256.Sum256([]byte(item.Title + "\x00" + item.Content))
+		return "hash:" + hex.EncodeToString(sum[:])
+	}
+}
+
+// itemLink is the link of item, a GUID that is a perma link stands in for
+// a missing one. Any other GUID is only an id and never a link.
+func itemLink(item *rf.Item) string {
+	if item.Link == "" && item.GUIDIsPermaLink {
+		return item.GUID
+	}
+	return item.Link
+}
+
+// hasDuplicateGUIDs reports whether two items share a GUID, which makes
+// GUIDs useless to tell the items of the feed apart.
+func hasDuplicateGUIDs(items []*rf.Item) bool {
+	seen := map[string]bool{}
+	for _, item := range items {
+		if item.GUID == "" {
+			continue
+		}
+		if seen[item.GUID] {
+			return true
+		}
+		seen[item.GUID] = true
+	}
+	return false
+}
+
+func hasUnstableGUIDs(stored []rf.Item, items []*rf.Item) bool {
+	guidsByLink := map[string]string{}
+	for _, item := range stored {
+		if item.Link != "" && item.GUID != "" {
+			guidsByLink[item.Link] = item.GUID
+		}
+	}
+
+	matched, changed := 0, 0
+	for _, item := range items {
+		guid, ok := guidsByLink[item.Link]
+		if !ok || item.GUID == "" {
+			continue
+		}
+		matched++
+		if guid != item.GUID {
+			changed++
+		}
+	}
+
+	return changed >= minUnstableGUIDMatches && changed*2 > matched
+}
@@ -0,0 +1,152 @@
+package itemservice
+
+import (
+	"context"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
+)
+
+const (
+	defaultTimelineLimit = 50
+	maxTimelineLimit     = 200
+)
+
+type ItemStore interface {
+	FindFeedByID(ctx context.Context, feedID int64) (*rf.Feed, error)
+	UpsertChannel(ctx context.Context, channel *rf.Channel) error
+	ListChannelItems(ctx context.Context, channelID int64, limit int) ([]rf.Item, error)
+	UpsertItems(ctx context.Context, items []*rf.Item) error
+	SetFeedItemIdentity(ctx context.Context, feedID, channelID int64, identity rf.ItemIdentity) error
+	GroupItemStories(ctx context.Context, items []*rf.Item, maxDistance int) error
+	ListTimeline(ctx context.Context, req *rf.TimelineRequest) ([]rf.TimelineEntry, error)
+	MarkStoryRead(ctx context.Context, userID, storyID int64) error
+	StarItem(ctx context.Context, userID, itemID int64, starred bool) error
+	SaveItem(ctx context.Context, userID, itemID int64, saved bool) error
+	FindUserItemByID(ctx context.Context, userID, itemID int64) (*rf.Item, error)
+	SavePlaybackPosition(ctx context.Context, position *rf.PlaybackPosition) error
+	ListItemsWantingContent(ctx context.Context, retryBefore time.Time, limit int) ([]rf.Item, error)
+	FindItemContent(ctx context.Context, itemID int64) (*rf.ItemContent, error)
+	SaveItemContent(ctx context.Context, content *rf.ItemContent) error
+}
+
+type ItemService struct {
+	store ItemStore
+
+	// Extractor downloads full articles for subscriptions that ask for
+	// them, full content is disabled when nil.
+	Extractor ContentExtractor
+}
+
+func NewItemService(store ItemStore) *ItemService {
+	return &ItemService{
+		store: store,
+	}
+}
+
+func (is *ItemService) SyncItems(ctx context.Context, channel *rf.Channel, items []*rf.Item) error {
+	args := ItemArgs{
+		store:   is.store,
+		channel: channel,
+		items:   items,
+	}
+
+	if err := args.validateSyncItems(); err != nil {
+		return err
+	}
+
+	_, err := statemachine.Run(ctx, args, findFeedState)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (is *ItemService) GetTimeline(ctx context.Context, req *rf.TimelineRequest) ([]rf.TimelineEntry, error) {
+	req.UserID = rfcontext.UserIDFromContext(ctx)
+
+	if req.Limit <= 0 {
+		req.Limit = defaultTimelineLimit
+	}
+	req.Limit = min(req.Limit, maxTimelineLimit)
+
+	entries, err := is.store.ListTimeline(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (is *ItemService) MarkStoryRead(ctx context.Context, storyID int64) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	err := is.store.MarkStoryRead(ctx, userID, storyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (is *ItemService) StarItem(ctx context.Context, itemID int64, starred bool) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	err := is.store.StarItem(ctx, userID, itemID, starred)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (is *ItemService) SaveItem(ctx context.Context, itemID int64, saved bool) error {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	err := is.store.SaveItem(ctx, userID, itemID, saved)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (is *ItemService) GetItem(ctx context.Context, itemID int64) (*rf.Item, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	foundItem, err := is.store.FindUserItemByID(ctx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if foundItem == nil {
+		return nil, errors.NotFoundf(errors.ErrItemNotFound)
+	}
+
+	return foundItem, nil
+}
+
+func (is *ItemService) SavePlaybackPosition(ctx context.Context, req *rf.PlaybackPositionRequest) error {
+	if req.PositionSeconds < 0 {
+		return errors.InvalidDataf(errors.ErrPositionInvalid)
+	}
+
+	position := &rf.PlaybackPosition{
+		UserID:          rfcontext.UserIDFromContext(ctx),
+		ItemID:          req.ItemID,
+		EnclosureID:     req.EnclosureID,
+		PositionSeconds: req.PositionSeconds,
+		Completed:       req.Completed,
+	}
+
+	err := is.store.SavePlaybackPosition(ctx, position)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
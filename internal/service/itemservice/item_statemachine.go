@@ -0,0 +1,119 @@
+package itemservice
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
+)
+
+// storedItemsToCompare bounds how many stored items are loaded to detect
+// feeds that regenerate their GUIDs on every fetch.
+const storedItemsToCompare = 200
+
+type ItemArgs struct {
+	store   ItemStore
+	feed    *rf.Feed
+	channel *rf.Channel
+	items   []*rf.Item
+}
+
+func (is ItemArgs) validateSyncItems() error {
+	if is.store == nil {
+		return errors.InternalErrorf("store cannot be nil")
+	}
+
+	if is.channel == nil || is.channel.FeedID == 0 {
+		return errors.InvalidDataf(errors.ErrFeedRequired)
+	}
+
+	return nil
+}
+
+func findFeedState(ctx context.Context, args ItemArgs) (ItemArgs, statemachine.StateFn[ItemArgs], error) {
+	feed, err := args.store.FindFeedByID(ctx, args.channel.FeedID)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if feed == nil {
+		return args, nil, errors.NotFoundf(errors.ErrFeedNotFound)
+	}
+
+	args.feed = feed
+	return args, upsertChannelState, nil
+}
+
+func upsertChannelState(ctx context.Context, args ItemArgs) (ItemArgs, statemachine.StateFn[ItemArgs], error) {
+	if err := args.store.UpsertChannel(ctx, args.channel); err != nil {
+		return args, nil, err
+	}
+
+	return args, detectItemIdentityState, nil
+}
+
+// detectItemIdentityState is the only place the identity of a feed
+// changes, from GUID to link once its GUIDs turn out to repeat or to be
+// regenerated, and the stored items are re-keyed to match. A batch with
+// items missing GUIDs does not change it, those items fall back on their
+// own.
+func detectItemIdentityState(ctx context.Context, args ItemArgs) (ItemArgs, statemachine.StateFn[ItemArgs], error) {
+	if args.feed.ItemIdentity != rf.ItemIdentityGUID {
+		return args, upsertItemsState, nil
+	}
+
+	if !hasDuplicateGUIDs(args.items) {
+		stored, err := args.store.ListChannelItems(ctx, args.channel.ID, storedItemsToCompare)
+		if err != nil {
+			return args, nil, err
+		}
+
+		if !hasUnstableGUIDs(stored, args.items) {
+			return args, upsertItemsState, nil
+		}
+	}
+
+	err := args.store.SetFeedItemIdentity(ctx, args.feed.ID, args.channel.ID, rf.ItemIdentityLink)
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.feed.ItemIdentity = rf.ItemIdentityLink
+	return args, upsertItemsState, nil
+}
+
+// upsertItemsState leaves undated items undated, the store dates them when
+// they are first seen and keeps that date after.
+func upsertItemsState(ctx context.Context, args ItemArgs) (ItemArgs, statemachine.StateFn[ItemArgs], error) {
+	for _, item := range args.items {
+		item.ChannelID = args.channel.ID
+		item.IdentityKey = identityKey(item, args.feed.ItemIdentity)
+		fingerprint(item)
+	}
+
+	if err := args.store.UpsertItems(ctx, args.items); err != nil {
+		return args, nil, err
+	}
+
+	return args, groupItemStoriesState, nil
+}
+
+func groupItemStoriesState(ctx context.Context, args ItemArgs) (ItemArgs, statemachine.StateFn[ItemArgs], error) {
+	var ungrouped []*rf.Item
+	for _, item := range args.items {
+		if item.StoryID == 0 {
+			ungrouped = append(ungrouped, item)
+		}
+	}
+
+	if len(ungrouped) == 0 {
+		return args, nil, nil
+	}
+
+	if err := args.store.GroupItemStories(ctx, ungrouped, maxStoryDistance); err != nil {
+		return args, nil, err
+	}
+
+	return args, nil, nil
+}
@@ -0,0 +1,418 @@
+package itemservice_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/itemservice"
+	"github.com/matryer/is"
+)
+
+func newItemStore(identity rf.ItemIdentity, stored []rf.Item) (*mock.ItemStore, *[]*rf.Item) {
+	upserted := &[]*rf.Item{}
+	store := &mock.ItemStore{
+		FindFeedByIDFn: func(ctx context.Context, feedID int64) (*rf.Feed, error) {
+			return builder.NewFeedBuilder().WithID(feedID).WithItemIdentity(identity).Build(), nil
+		},
+		UpsertChannelFn: func(ctx context.Context, channel *rf.Channel) error {
+			channel.ID = 1
+			return nil
+		},
+		ListChannelItemsFn: func(ctx context.Context, channelID int64, limit int) ([]rf.Item, error) {
+			return stored, nil
+		},
+		UpsertItemsFn: func(ctx context.Context, items []*rf.Item) error {
+			*upserted = items
+			return nil
+		},
+		SetFeedItemIdentityFn: func(ctx context.Context, feedID, channelID int64, changed rf.ItemIdentity) error {
+			identity = changed
+			return nil
+		},
+		GroupItemStoriesFn: func(ctx context.Context, items []*rf.Item, maxDistance int) error {
+			for _, item := range items {
+				item.StoryID = item.ID
+			}
+			return nil
+		},
+	}
+	return store, upserted
+}
+
+func TestItemService_SyncItems_Identity(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should key items by guid, then link, then content hash", func(t *testing.T) {
+		t.Parallel()
+
+		store, upserted := newItemStore(rf.ItemIdentityGUID, nil)
+		service := itemservice.NewItemService(store)
+
+		channel := builder.NewChannelBuilder().WithFeedID(1).Build()
+		items := []*rf.Item{
+			builder.NewItemBuilder().WithGUID("a", false).WithLink("http://go.com/a").Build(),
+			builder.NewItemBuilder().WithGUID("b", true).WithLink("http://go.com/b").Build(),
+		}
+
+		err := service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)                                  // should sync items
+		is.Equal(len(*upserted), 2)                    // should upsert every item
+		is.Equal((*upserted)[0].IdentityKey, "guid:a") // unique guids should be the identity
+		is.Equal((*upserted)[1].IdentityKey, "guid:b") // perma link guids should be the identity
+		is.Equal((*upserted)[0].ChannelID, channel.ID) // should belong to the channel
+		is.True((*upserted)[0].PublishedAt.IsZero())   // should leave dating undated items to the store
+		is.True(!store.SetFeedItemIdentityInvoked)     // stable guids should not switch identity
+
+		items = []*rf.Item{
+			builder.NewItemBuilder().WithGUID("dup", false).WithLink("http://go.com/a").Build(),
+			builder.NewItemBuilder().WithGUID("dup", false).WithTitle("Go").WithContent("Gopher").Build(),
+		}
+
+		err = service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)                                                   // should sync items
+		is.Equal((*upserted)[0].IdentityKey, "link:http://go.com/a")    // duplicate guids should fall back to link
+		is.True(strings.HasPrefix((*upserted)[1].IdentityKey, "hash:")) // missing link should fall back to hash
+	})
+
+	t.Run("Should keep the identity of a feed when an item has no guid", func(t *testing.T) {
+		t.Parallel()
+
+		store, upserted := newItemStore(rf.ItemIdentityGUID, nil)
+		service := itemservice.NewItemService(store)
+
+		channel := builder.NewChannelBuilder().WithFeedID(1).Build()
+		items := []*rf.Item{
+			builder.NewItemBuilder().WithGUID("a", false).WithLink("http://go.com/a").Build(),
+			builder.NewItemBuilder().WithGUID("b", false).WithLink("http://go.com/b").Build(),
+		}
+
+		err := service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)                                  // should sync items
+		is.Equal((*upserted)[0].IdentityKey, "guid:a") // should key by guid
+
+		items = []*rf.Item{
+			builder.NewItemBuilder().WithGUID("a", false).WithLink("http://go.com/a").Build(),
+			builder.NewItemBuilder().WithGUID("b", false).WithLink("http://go.com/b").Build(),
+			builder.NewItemBuilder().WithLink("http://go.com/c").Build(),
+		}
+
+		err = service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)                                                // should sync items
+		is.Equal((*upserted)[0].IdentityKey, "guid:a")               // should keep keying by guid
+		is.Equal((*upserted)[1].IdentityKey, "guid:b")               // should keep keying by guid
+		is.Equal((*upserted)[2].IdentityKey, "link:http://go.com/c") // should key the item without a guid by its link
+		is.True(!store.SetFeedItemIdentityInvoked)                   // should not switch feed identity
+	})
+
+	t.Run("Should only treat perma link guids as links", func(t *testing.T) {
+		t.Parallel()
+
+		store, upserted := newItemStore(rf.ItemIdentityLink, nil)
+		service := itemservice.NewItemService(store)
+
+		channel := builder.NewChannelBuilder().WithFeedID(1).Build()
+		items := []*rf.Item{
+			builder.NewItemBuilder().WithGUID("http://go.com/a", true).WithTitle("A").Build(),
+			builder.NewItemBuilder().WithGUID("http://go.com/b", false).WithTitle("B").Build(),
+		}
+
+		err := service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)                                                   // should sync items
+		is.Equal((*upserted)[0].IdentityKey, "link:http://go.com/a")    // a perma link guid should stand in for the link
+		is.Equal((*upserted)[0].CanonicalLink, "go.com/a")              // a perma link guid should be the canonical link
+		is.True(strings.HasPrefix((*upserted)[1].IdentityKey, "hash:")) // any other guid should not be a link
+		is.Equal((*upserted)[1].CanonicalLink, "")                      // any other guid should not be a canonical link
+	})
+
+	t.Run("Should switch to link identity when guids are regenerated", func(t *testing.T) {
+		t.Parallel()
+
+		stored := []rf.Item{
+			*builder.NewItemBuilder().WithGUID("1", false).WithLink("http://go.com/a").Build(),
+			*builder.NewItemBuilder().WithGUID("2", false).WithLink("http://go.com/b").Build(),
+		}
+		store, upserted := newItemStore(rf.ItemIdentityGUID, stored)
+		service := itemservice.NewItemService(store)
+
+		channel := builder.NewChannelBuilder().WithFeedID(1).Build()
+		items := []*rf.Item{
+			builder.NewItemBuilder().WithGUID("3", false).WithLink("http://go.com/a").Build(),
+			builder.NewItemBuilder().WithGUID("4", false).WithLink("http://go.com/b").Build(),
+		}
+
+		err := service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)                                                // should sync items
+		is.True(store.SetFeedItemIdentityInvoked)                    // should switch feed identity
+		is.Equal((*upserted)[0].IdentityKey, "link:http://go.com/a") // should key by link
+		is.Equal((*upserted)[1].IdentityKey, "link:http://go.com/b") // should key by link
+	})
+}
+
+func TestItemService_SyncItems_Stories(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should fingerprint new items and group them into stories", func(t *testing.T) {
+		t.Parallel()
+
+		store, upserted := newItemStore(rf.ItemIdentityGUID, nil)
+		service := itemservice.NewItemService(store)
+
+		channel := builder.NewChannelBuilder().WithFeedID(1).Build()
+		items := []*rf.Item{
+			builder.NewItemBuilder().WithGUID("a", false).
+				WithLink("http://www.go.com/news/release/?utm_source=rss&id=7#top").Build(),
+		}
+
+		err := service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)                                                      // should sync items
+		is.Equal((*upserted)[0].CanonicalLink, "go.com/news/release?id=7") // should canonicalise the link
+		is.True(store.GroupItemStoriesInvoked)                             // new items should be grouped
+
+		store.GroupItemStoriesInvoked = false
+		store.UpsertItemsFn = func(ctx context.Context, items []*rf.Item) error {
+			for _, item := range items {
+				item.StoryID = 1
+			}
+			return nil
+		}
+
+		err = service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)                           // should sync items
+		is.True(!store.GroupItemStoriesInvoked) // grouped items should not be regrouped
+	})
+}
+
+func TestItemService_GetTimeline(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should list the timeline of the user in context", func(t *testing.T) {
+		t.Parallel()
+
+		var got *rf.TimelineRequest
+		store := &mock.ItemStore{
+			ListTimelineFn: func(ctx context.Context, req *rf.TimelineRequest) ([]rf.TimelineEntry, error) {
+				got = req
+				return []rf.TimelineEntry{{StoryID: 1}}, nil
+			},
+		}
+		service := itemservice.NewItemService(store)
+
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 7)
+		entries, err := service.GetTimeline(ctx, &rf.TimelineRequest{Limit: 1000})
+
+		is.NoErr(err)                  // should list the timeline
+		is.Equal(len(entries), 1)      // should return the entries
+		is.Equal(got.UserID, int64(7)) // should use the user in context
+		is.Equal(got.Limit, 200)       // should cap the limit
+	})
+}
+
+func TestItemService_SyncItems_Failure(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should fail to sync items without a feed", func(t *testing.T) {
+		t.Parallel()
+
+		store, _ := newItemStore(rf.ItemIdentityGUID, nil)
+		service := itemservice.NewItemService(store)
+
+		err := service.SyncItems(context.Background(), builder.NewChannelBuilder().Build(), nil)
+
+		is.True(err != nil)                                                  // should be an error
+		is.Equal(errors.ToReferenceCode(err), errors.InvalidData)            // should have error code
+		is.True(strings.Contains(errors.ToErr(err), errors.ErrFeedRequired)) // should have error message
+		is.True(!store.FindFeedByIDInvoked)                                  // item store FindFeedByID should not have been invoked
+	})
+}
+
+func TestItemService_Enclosures(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	t.Run("Should save the playback position of the user in context", func(t *testing.T) {
+		t.Parallel()
+
+		var got *rf.PlaybackPosition
+		store := &mock.ItemStore{
+			SavePlaybackPositionFn: func(ctx context.Context, position *rf.PlaybackPosition) error {
+				got = position
+				return nil
+			},
+		}
+		service := itemservice.NewItemService(store)
+
+		ctx := rfcontext.SetUserIDToContext(context.Background(), 7)
+		req := &rf.PlaybackPositionRequest{PositionSeconds: 90, ItemID: 1, EnclosureID: 2}
+
+		err := service.SavePlaybackPosition(ctx, req)
+
+		is.NoErr(err)                       // should save the position
+		is.Equal(got.UserID, int64(7))      // should use the user in context
+		is.Equal(got.EnclosureID, int64(2)) // should save for the enclosure
+		is.Equal(got.PositionSeconds, 90)   // should save the position
+
+		err = service.SavePlaybackPosition(ctx, &rf.PlaybackPositionRequest{PositionSeconds: -1})
+
+		is.Equal(errors.ToReferenceCode(err), errors.InvalidData) // negative positions should be invalid
+	})
+
+	t.Run("Should fail to get an item that is not visible to the user", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.ItemStore{
+			FindUserItemByIDFn: func(ctx context.Context, userID, itemID int64) (*rf.Item, error) {
+				return nil, nil
+			},
+		}
+		service := itemservice.NewItemService(store)
+
+		_, err := service.GetItem(context.Background(), 1)
+
+		is.Equal(errors.ToReferenceCode(err), errors.NotFound) // should not be found
+	})
+}
+
+type extractor struct {
+	calls int
+	err   error
+}
+
+func (e *extractor) Extract(ctx context.Context, link string) (string, error) {
+	e.calls++
+	if e.err != nil {
+		return "", e.err
+	}
+	return "<p>" + link + "</p>", nil
+}
+
+func TestItemService_FullContent(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	newContentStore := func(store *mock.ItemStore, wanting ...rf.Item) map[int64]*rf.ItemContent {
+		cache := map[int64]*rf.ItemContent{}
+		store.ListItemsWantingContentFn = func(ctx context.Context, retryBefore time.Time, limit int) ([]rf.Item, error) {
+			is.True(retryBefore.Before(time.Now())) // should only retry failures from before now
+			return wanting, nil
+		}
+		store.FindItemContentFn = func(ctx context.Context, itemID int64) (*rf.ItemContent, error) {
+			return cache[itemID], nil
+		}
+		store.SaveItemContentFn = func(ctx context.Context, content *rf.ItemContent) error {
+			content.FetchedAt = time.Now()
+			cache[content.ItemID] = content
+			return nil
+		}
+		return cache
+	}
+
+	t.Run("Should extract the articles subscribers want in the background", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.ItemStore{}
+		cache := newContentStore(store, rf.Item{ID: 1, Link: "http://go.com/a"}, rf.Item{ID: 2, Link: "http://go.com/b"})
+		ext := &extractor{}
+		service := itemservice.NewItemService(store)
+		service.Extractor = ext
+
+		err := service.ExtractPendingContent(context.Background())
+
+		is.NoErr(err)                                        // should extract pending articles
+		is.Equal(ext.calls, 2)                               // should download each article
+		is.Equal(cache[1].Content, "<p>http://go.com/a</p>") // should cache the article
+		is.Equal(cache[2].Content, "<p>http://go.com/b</p>") // should cache every article
+	})
+
+	t.Run("Should cache failed extractions and carry on", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.ItemStore{}
+		cache := newContentStore(store, rf.Item{ID: 1, Link: "http://go.com/a"})
+		ext := &extractor{err: errors.InternalErrorf("boom")}
+		service := itemservice.NewItemService(store)
+		service.Extractor = ext
+
+		err := service.ExtractPendingContent(context.Background())
+
+		is.NoErr(err)                 // should not fail on a failed download
+		is.True(cache[1].Error != "") // should cache the failure
+	})
+
+	t.Run("Should not extract without an extractor", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.ItemStore{}
+		service := itemservice.NewItemService(store)
+
+		err := service.ExtractPendingContent(context.Background())
+
+		is.NoErr(err)                                  // should do nothing
+		is.True(!store.ListItemsWantingContentInvoked) // should not look for pending articles
+	})
+
+	t.Run("Should not extract while syncing", func(t *testing.T) {
+		t.Parallel()
+
+		store, _ := newItemStore(rf.ItemIdentityGUID, nil)
+		newContentStore(store)
+		ext := &extractor{}
+		service := itemservice.NewItemService(store)
+		service.Extractor = ext
+
+		channel := builder.NewChannelBuilder().WithFeedID(1).Build()
+		items := []*rf.Item{builder.NewItemBuilder().WithID(1).WithGUID("a", false).WithLink("http://go.com/a").Build()}
+
+		err := service.SyncItems(context.Background(), channel, items)
+
+		is.NoErr(err)          // should sync items
+		is.Equal(ext.calls, 0) // should leave downloads to the background
+	})
+
+	t.Run("Should serve the cached article on read without downloading", func(t *testing.T) {
+		t.Parallel()
+
+		store := &mock.ItemStore{
+			FindUserItemByIDFn: func(ctx context.Context, userID, itemID int64) (*rf.Item, error) {
+				item := builder.NewItemBuilder().WithID(itemID).WithLink("http://go.com/a").Build()
+				item.FetchFullContent = true
+				if itemID == 1 {
+					item.FullContent = "<p>cached</p>"
+				}
+				return item, nil
+			},
+		}
+		ext := &extractor{}
+		service := itemservice.NewItemService(store)
+		service.Extractor = ext
+
+		item, err := service.GetItem(context.Background(), 1)
+
+		is.NoErr(err)                               // should get the item
+		is.Equal(item.FullContent, "<p>cached</p>") // should include the cached article
+
+		item, err = service.GetItem(context.Background(), 2)
+
+		is.NoErr(err)                  // should get an item whose article is not ready
+		is.Equal(item.FullContent, "") // should leave the description to be shown
+		is.Equal(ext.calls, 0)         // should never download on read
+	})
+}
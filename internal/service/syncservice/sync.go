@@ -0,0 +1,106 @@
+package syncservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/fetch"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/parser"
+)
+
+// BatchSize is how many due feeds a single SyncDueFeeds fetches, the rest
+// wait for the next run.
+const BatchSize = 50
+
+type SyncStore interface {
+	// ListDueFeeds returns up to limit feeds that are due to be fetched.
+	ListDueFeeds(ctx context.Context, limit int) ([]rf.Feed, error)
+	// RecordFeedFetch stores the outcome of fetching a feed, fetchErr is nil
+	// when it succeeded.
+	RecordFeedFetch(ctx context.Context, feedID int64, fetchErr error) error
+}
+
+type Fetcher interface {
+	Get(ctx context.Context, url string) (*fetch.Response, error)
+}
+
+type ItemSyncer interface {
+	SyncItems(ctx context.Context, channel *rf.Channel, items []*rf.Item) error
+}
+
+type HubSubscriber interface {
+	SubscribeFeed(ctx context.Context, feedID int64, feed *parser.Feed) error
+}
+
+type SyncService struct {
+	store   SyncStore
+	fetcher Fetcher
+	items   ItemSyncer
+
+	// WebSub subscribes to the hub a fetched feed advertises, feeds are only
+	// polled when it is nil.
+	WebSub HubSubscriber
+}
+
+func NewSyncService(store SyncStore, fetcher Fetcher, items ItemSyncer) *SyncService {
+	return &SyncService{
+		store:   store,
+		fetcher: fetcher,
+		items:   items,
+	}
+}
+
+// SyncDueFeeds fetches the feeds that are due and syncs their items. A
+// feed that fails to fetch or parse is recorded as a fetch error and tried
+// again once its poll interval has passed.
+func (ss *SyncService) SyncDueFeeds(ctx context.Context) error {
+	feeds, err := ss.store.ListDueFeeds(ctx, BatchSize)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, feed := range feeds {
+		fetchErr := ss.syncFeed(ctx, feed)
+		if fetchErr != nil {
+			slog.Error("Feed sync error", "err", fetchErr.Error(), "feedID", feed.ID)
+		}
+
+		if err := ss.store.RecordFeedFetch(ctx, feed.ID, fetchErr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (ss *SyncService) syncFeed(ctx context.Context, feed rf.Feed) error {
+	res, err := ss.fetcher.Get(ctx, feed.URL)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parser.Parse(bytes.NewReader(res.Body))
+	if err != nil {
+		return err
+	}
+
+	parsed.Channel.FeedID = feed.ID
+
+	if err := ss.items.SyncItems(ctx, parsed.Channel, parsed.Items); err != nil {
+		return err
+	}
+
+	// The feed was fetched either way, a hub that fails to subscribe is
+	// tried again on the next fetch.
+	if ss.WebSub != nil && parsed.HubURL != "" && parsed.SelfURL != "" {
+		if err := ss.WebSub.SubscribeFeed(ctx, feed.ID, parsed); err != nil {
+			slog.Error("WebSub subscribe error", "err", err.Error(), "feedID", feed.ID, "hub", parsed.HubURL)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package syncservice_test
+
+import (
+	"context"
+	"testing"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/fetch"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/parser"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/syncservice"
+	"github.com/matryer/is"
+)
+
+const hubFeedBody = `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Go</title>` +
+	`<atom:link rel="hub" href="http://hub.com/"/><atom:link rel="self" href="http://feed.com/rss"/>` +
+	`<item><title>Polled</title><link>http://feed.com/polled</link></item></channel></rss>`
+
+func TestSyncService_SyncDueFeeds(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	type recorded struct {
+		feedID   int64
+		fetchErr error
+	}
+
+	newStore := func(records *[]recorded) *mock.SyncStore {
+		return &mock.SyncStore{
+			ListDueFeedsFn: func(ctx context.Context, limit int) ([]rf.Feed, error) {
+				is.Equal(limit, syncservice.BatchSize) // should list a batch of due feeds
+				return []rf.Feed{{ID: 7, URL: "http://feed.com/rss"}}, nil
+			},
+			RecordFeedFetchFn: func(ctx context.Context, feedID int64, fetchErr error) error {
+				*records = append(*records, recorded{feedID: feedID, fetchErr: fetchErr})
+				return nil
+			},
+		}
+	}
+
+	newFetcher := func(body string, err error) *mock.Fetcher {
+		return &mock.Fetcher{
+			GetFn: func(ctx context.Context, url string) (*fetch.Response, error) {
+				is.Equal(url, "http://feed.com/rss") // should fetch the feed url
+				if err != nil {
+					return nil, err
+				}
+				return &fetch.Response{URL: url, StatusCode: 200, Body: []byte(body)}, nil
+			},
+		}
+	}
+
+	t.Run("Should sync the items of a due feed and subscribe to its hub", func(t *testing.T) {
+		t.Parallel()
+
+		var records []recorded
+		var channel *rf.Channel
+		var items []*rf.Item
+		syncer := &mock.ItemSyncer{
+			SyncItemsFn: func(ctx context.Context, c *rf.Channel, i []*rf.Item) error {
+				channel, items = c, i
+				return nil
+			},
+		}
+		var hubFeed *parser.Feed
+		webSub := &mock.WebSubSubscriber{
+			SubscribeFeedFn: func(ctx context.Context, feedID int64, feed *parser.Feed) error {
+				is.Equal(feedID, int64(7)) // should subscribe the fetched feed
+				hubFeed = feed
+				return nil
+			},
+		}
+
+		service := syncservice.NewSyncService(newStore(&records), newFetcher(hubFeedBody, nil), syncer)
+		service.WebSub = webSub
+
+		err := service.SyncDueFeeds(context.Background())
+
+		is.NoErr(err)                               // should sync due feeds
+		is.Equal(channel.FeedID, int64(7))          // should sync the channel of the feed
+		is.Equal(len(items), 1)                     // should sync every item
+		is.Equal(items[0].Title, "Polled")          // should parse the items
+		is.True(webSub.SubscribeFeedInvoked)        // should subscribe to the advertised hub
+		is.Equal(hubFeed.HubURL, "http://hub.com/") // should subscribe to the hub of the feed
+		is.Equal(records, []recorded{{feedID: 7}})  // should record a successful fetch
+	})
+
+	t.Run("Should record a fetch error and not sync", func(t *testing.T) {
+		t.Parallel()
+
+		var records []recorded
+		syncer := &mock.ItemSyncer{}
+		fetchErr := errors.InternalErrorf("%s: feed.com returned 500", errors.ErrFetchFailed)
+
+		service := syncservice.NewSyncService(newStore(&records), newFetcher("", fetchErr), syncer)
+
+		err := service.SyncDueFeeds(context.Background())
+
+		is.NoErr(err)                                // should not fail the run for one feed
+		is.True(!syncer.SyncItemsInvoked)            // should not sync items
+		is.Equal(records, []recorded{{7, fetchErr}}) // should record the fetch error
+	})
+
+	t.Run("Should record a feed that does not parse as a fetch error", func(t *testing.T) {
+		t.Parallel()
+
+		var records []recorded
+		syncer := &mock.ItemSyncer{}
+
+		service := syncservice.NewSyncService(newStore(&records), newFetcher("<html></html>", nil), syncer)
+
+		err := service.SyncDueFeeds(context.Background())
+
+		is.NoErr(err)                       // should not fail the run for one feed
+		is.True(!syncer.SyncItemsInvoked)   // should not sync items
+		is.Equal(len(records), 1)           // should record the fetch
+		is.True(records[0].fetchErr != nil) // should record the parse error
+	})
+}
@@ -0,0 +1,111 @@
+package userservice
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+const (
+	// MaxNameLength matches the users table name check.
+	MaxNameLength = 50
+	// MaxPreferencesSize is the largest encoded preferences object kept for
+	// a user.
+	MaxPreferencesSize = 16 * 1024
+)
+
+type UserStore interface {
+	FindUser(ctx context.Context, userID int64) (*rf.User, error)
+	UpdateUser(ctx context.Context, user *rf.User) error
+}
+
+type UserService struct {
+	store UserStore
+}
+
+func NewUserService(store UserStore) *UserService {
+	return &UserService{
+		store: store,
+	}
+}
+
+func (us *UserService) GetProfile(ctx context.Context) (*rf.User, error) {
+	userID := rfcontext.UserIDFromContext(ctx)
+
+	foundUser, err := us.store.FindUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if foundUser == nil {
+		return nil, errors.NotFoundf(errors.ErrUserNotFound)
+	}
+
+	return foundUser, nil
+}
+
+func (us *UserService) UpdateProfile(ctx context.Context, req *rf.UpdateProfileRequest) (*rf.User, error) {
+	foundUser, err := us.GetProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := map[string]string{}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			errs["name"] = errors.ErrNameRequired
+		} else if utf8.RuneCountInString(name) > MaxNameLength {
+			errs["name"] = errors.ErrNameTooLong
+		}
+		foundUser.Name = name
+	}
+
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" || *req.Timezone == "Local" {
+			errs["timezone"] = errors.ErrTimezoneInvalid
+		}
+		foundUser.Timezone = *req.Timezone
+	}
+
+	if req.Preferences != nil {
+		preferences := foundUser.Preferences
+		if preferences == nil {
+			preferences = map[string]any{}
+		}
+		for key, value := range req.Preferences {
+			if value == nil {
+				delete(preferences, key)
+				continue
+			}
+			preferences[key] = value
+		}
+
+		encoded, err := json.Marshal(preferences)
+		if err != nil {
+			return nil, err
+		}
+		if len(encoded) > MaxPreferencesSize {
+			errs["preferences"] = errors.ErrPreferencesTooLarge
+		}
+		foundUser.Preferences = preferences
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.InvalidError(errs)
+	}
+
+	err = us.store.UpdateUser(ctx, foundUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundUser, nil
+}
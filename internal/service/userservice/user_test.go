@@ -0,0 +1,84 @@
+package userservice_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rfcontext "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/context"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/mock"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/userservice"
+	"github.com/matryer/is"
+)
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	newStore := func() *mock.UserStore {
+		return &mock.UserStore{
+			FindUserFn: func(ctx context.Context, userID int64) (*rf.User, error) {
+				return &rf.User{
+					ID:          userID,
+					Name:        "Gopher",
+					Timezone:    "UTC",
+					Preferences: map[string]any{"theme": "dark", "density": "compact"},
+				}, nil
+			},
+			UpdateUserFn: func(ctx context.Context, user *rf.User) error {
+				return nil
+			},
+		}
+	}
+
+	ctx := rfcontext.SetUserIDToContext(context.Background(), 1)
+
+	ptr := func(s string) *string {
+		return &s
+	}
+
+	t.Run("Should update the given fields and merge preferences", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore()
+		service := userservice.NewUserService(store)
+
+		user, err := service.UpdateProfile(ctx, &rf.UpdateProfileRequest{
+			Timezone:    ptr("Europe/London"),
+			Preferences: map[string]any{"theme": "light", "density": nil, "language": "en"},
+		})
+
+		is.NoErr(err)                                                                  // should update the profile
+		is.True(store.UpdateUserInvoked)                                               // should store the profile
+		is.Equal(user.Name, "Gopher")                                                  // should keep fields that are not set
+		is.Equal(user.Timezone, "Europe/London")                                       // should set the timezone
+		is.Equal(user.Preferences, map[string]any{"theme": "light", "language": "en"}) // should merge and remove preferences
+	})
+
+	t.Run("Should reject invalid fields", func(t *testing.T) {
+		t.Parallel()
+
+		for name, tc := range map[string]struct {
+			req *rf.UpdateProfileRequest
+			err string
+		}{
+			"empty name":        {&rf.UpdateProfileRequest{Name: ptr(" ")}, errors.ErrNameRequired},
+			"long name":         {&rf.UpdateProfileRequest{Name: ptr(strings.Repeat("g", 51))}, errors.ErrNameTooLong},
+			"unknown timezone":  {&rf.UpdateProfileRequest{Timezone: ptr("Mars/Olympus_Mons")}, errors.ErrTimezoneInvalid},
+			"large preferences": {&rf.UpdateProfileRequest{Preferences: map[string]any{"notes": strings.Repeat("g", 17*1024)}}, errors.ErrPreferencesTooLarge},
+		} {
+			t.Run(name, func(t *testing.T) {
+				store := newStore()
+				service := userservice.NewUserService(store)
+
+				_, err := service.UpdateProfile(ctx, tc.req)
+
+				is.Equal(errors.ToReferenceCode(err), errors.InvalidData) // should be invalid
+				is.True(strings.Contains(errors.ToErr(err), tc.err))      // should say which field is wrong
+				is.True(!store.UpdateUserInvoked)                         // should not store the profile
+			})
+		}
+	})
+}
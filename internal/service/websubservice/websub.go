@@ -0,0 +1,182 @@
+package websubservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/parser"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/websub"
+)
+
+const (
+	// DefaultPollInterval is how often feeds without push updates are polled.
+	DefaultPollInterval = 30 * time.Minute
+	// SafetyNetPollInterval is how often push enabled feeds are still polled
+	// in case the hub misses an update.
+	SafetyNetPollInterval = 24 * time.Hour
+	// RenewBefore is how long before expiry a lease is renewed.
+	RenewBefore = 24 * time.Hour
+)
+
+type WebSubStore interface {
+	FindSubscriptionByFeedID(ctx context.Context, feedID int64) (*rf.WebSubSubscription, error)
+	SaveSubscription(ctx context.Context, sub *rf.WebSubSubscription) error
+	ListExpiringSubscriptions(ctx context.Context, before time.Time) ([]rf.WebSubSubscription, error)
+	SetFeedPollInterval(ctx context.Context, feedID int64, interval time.Duration) error
+}
+
+type Hub interface {
+	Subscribe(ctx context.Context, req websub.Request) error
+	Unsubscribe(ctx context.Context, req websub.Request) error
+}
+
+type ItemSyncer interface {
+	SyncItems(ctx context.Context, channel *rf.Channel, items []*rf.Item) error
+}
+
+type WebSubService struct {
+	store WebSubStore
+	hub   Hub
+	items ItemSyncer
+
+	// CallbackURL returns the public url hubs deliver a feed's updates to.
+	CallbackURL func(feedID int64) string
+}
+
+func NewWebSubService(store WebSubStore, hub Hub, items ItemSyncer) *WebSubService {
+	return &WebSubService{
+		store: store,
+		hub:   hub,
+		items: items,
+	}
+}
+
+// SubscribeFeed subscribes to the hub advertised by a parsed feed, it is a
+// no-op for feeds that do not advertise a hub or are already subscribed.
+func (ws *WebSubService) SubscribeFeed(ctx context.Context, feedID int64, feed *parser.Feed) error {
+	args := WebSubArgs{
+		store:       ws.store,
+		hub:         ws.hub,
+		callbackURL: ws.CallbackURL,
+		sub: &rf.WebSubSubscription{
+			FeedID: feedID,
+		},
+	}
+	if feed != nil {
+		args.sub.HubURL = feed.HubURL
+		args.sub.TopicURL = feed.SelfURL
+	}
+
+	if err := args.validateSubscribeFeed(); err != nil {
+		return err
+	}
+
+	_, err := statemachine.Run(ctx, args, findSubscriptionState)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnsubscribeFeed stops push updates for a feed and restores its regular
+// poll interval.
+func (ws *WebSubService) UnsubscribeFeed(ctx context.Context, feedID int64) error {
+	sub, err := ws.store.FindSubscriptionByFeedID(ctx, feedID)
+	if err != nil {
+		return err
+	}
+
+	if sub == nil || sub.State == rf.WebSubStateUnsubscribed {
+		return nil
+	}
+
+	sub.State = rf.WebSubStateUnsubscribed
+	if err := ws.store.SaveSubscription(ctx, sub); err != nil {
+		return err
+	}
+
+	if err := ws.store.SetFeedPollInterval(ctx, feedID, DefaultPollInterval); err != nil {
+		return err
+	}
+
+	return ws.hub.Unsubscribe(ctx, websub.Request{
+		HubURL:      sub.HubURL,
+		TopicURL:    sub.TopicURL,
+		CallbackURL: ws.CallbackURL(feedID),
+	})
+}
+
+func (ws *WebSubService) VerifyIntent(ctx context.Context, v *rf.WebSubVerification) (string, error) {
+	args := WebSubArgs{
+		store:        ws.store,
+		verification: v,
+	}
+
+	if err := args.validateVerifyIntent(); err != nil {
+		return "", err
+	}
+
+	_, err := statemachine.Run(ctx, args, verifyIntentState)
+	if err != nil {
+		return "", err
+	}
+
+	return v.Challenge, nil
+}
+
+func (ws *WebSubService) Ingest(ctx context.Context, feedID int64, signature string, body []byte) error {
+	sub, err := ws.store.FindSubscriptionByFeedID(ctx, feedID)
+	if err != nil {
+		return err
+	}
+
+	if sub == nil || sub.State != rf.WebSubStateActive {
+		return rferrors.NotFoundf(rferrors.ErrHubSubscriptionNotFound)
+	}
+
+	if !websub.VerifySignature(sub.Secret, signature, body) {
+		return rferrors.Unauthorizedf(rferrors.ErrHubSignatureInvalid)
+	}
+
+	feed, err := parser.Parse(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	feed.Channel.FeedID = feedID
+
+	return ws.items.SyncItems(ctx, feed.Channel, feed.Items)
+}
+
+// RenewLeases re-subscribes every active subscription that expires within
+// RenewBefore, the hub confirms the renewal through VerifyIntent.
+func (ws *WebSubService) RenewLeases(ctx context.Context) error {
+	subs, err := ws.store.ListExpiringSubscriptions(ctx, time.Now().Add(RenewBefore))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, sub := range subs {
+		err := ws.hub.Subscribe(ctx, websub.Request{
+			HubURL:       sub.HubURL,
+			TopicURL:     sub.TopicURL,
+			CallbackURL:  ws.CallbackURL(sub.FeedID),
+			Secret:       sub.Secret,
+			LeaseSeconds: websub.DefaultLeaseSeconds,
+		})
+		if err != nil {
+			slog.Error("WebSub renewal error", "err", err.Error(), "feedID", sub.FeedID, "hub", sub.HubURL)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
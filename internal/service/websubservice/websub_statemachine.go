@@ -0,0 +1,174 @@
+package websubservice
+
+import (
+	"context"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/statemachine"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/websub"
+)
+
+type WebSubArgs struct {
+	store        WebSubStore
+	hub          Hub
+	callbackURL  func(feedID int64) string
+	sub          *rf.WebSubSubscription
+	verification *rf.WebSubVerification
+}
+
+func (ws WebSubArgs) validateSubscribeFeed() error {
+	if ws.store == nil || ws.hub == nil || ws.callbackURL == nil {
+		return errors.InternalErrorf("store, hub and callback url cannot be nil")
+	}
+
+	if ws.sub.FeedID == 0 {
+		return errors.InvalidDataf(errors.ErrFeedRequired)
+	}
+
+	if ws.sub.HubURL == "" || ws.sub.TopicURL == "" {
+		return errors.InvalidDataf(errors.ErrHubNotAdvertised)
+	}
+
+	return nil
+}
+
+func (ws WebSubArgs) validateVerifyIntent() error {
+	if ws.store == nil {
+		return errors.InternalErrorf("store cannot be nil")
+	}
+
+	v := ws.verification
+	if v == nil || v.FeedID == 0 || v.Topic == "" {
+		return errors.InvalidDataf(errors.ErrFeedRequired)
+	}
+
+	switch v.Mode {
+	case websub.ModeSubscribe, websub.ModeUnsubscribe, websub.ModeDenied:
+		return nil
+	}
+
+	return errors.InvalidDataf("unsupported hub.mode %q", v.Mode)
+}
+
+func findSubscriptionState(ctx context.Context, args WebSubArgs) (WebSubArgs, statemachine.StateFn[WebSubArgs], error) {
+	existing, err := args.store.FindSubscriptionByFeedID(ctx, args.sub.FeedID)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if existing == nil {
+		return args, requestSubscriptionState, nil
+	}
+
+	unchanged := existing.HubURL == args.sub.HubURL && existing.TopicURL == args.sub.TopicURL
+	if unchanged && existing.State == rf.WebSubStateActive && time.Until(existing.ExpiresAt) > RenewBefore {
+		return args, nil, nil
+	}
+
+	args.sub.ID = existing.ID
+	return args, requestSubscriptionState, nil
+}
+
+func requestSubscriptionState(ctx context.Context, args WebSubArgs) (WebSubArgs, statemachine.StateFn[WebSubArgs], error) {
+	secret, err := websub.GenerateSecret()
+	if err != nil {
+		return args, nil, err
+	}
+
+	args.sub.Secret = secret
+	args.sub.State = rf.WebSubStatePending
+	args.sub.LeaseSeconds = websub.DefaultLeaseSeconds
+
+	// The subscription is saved before asking the hub, as hubs may verify
+	// the intent before the subscribe request has returned.
+	if err := args.store.SaveSubscription(ctx, args.sub); err != nil {
+		return args, nil, err
+	}
+
+	err = args.hub.Subscribe(ctx, websub.Request{
+		HubURL:       args.sub.HubURL,
+		TopicURL:     args.sub.TopicURL,
+		CallbackURL:  args.callbackURL(args.sub.FeedID),
+		Secret:       args.sub.Secret,
+		LeaseSeconds: args.sub.LeaseSeconds,
+	})
+	if err != nil {
+		return args, nil, err
+	}
+
+	return args, nil, nil
+}
+
+func verifyIntentState(ctx context.Context, args WebSubArgs) (WebSubArgs, statemachine.StateFn[WebSubArgs], error) {
+	sub, err := args.store.FindSubscriptionByFeedID(ctx, args.verification.FeedID)
+	if err != nil {
+		return args, nil, err
+	}
+
+	if sub == nil {
+		return args, nil, errors.NotFoundf(errors.ErrHubSubscriptionNotFound)
+	}
+
+	if sub.TopicURL != args.verification.Topic {
+		return args, nil, errors.NotFoundf(errors.ErrHubTopicMismatch)
+	}
+
+	args.sub = sub
+
+	switch args.verification.Mode {
+	case websub.ModeSubscribe:
+		return args, activateSubscriptionState, nil
+	case websub.ModeUnsubscribe:
+		return args, confirmUnsubscribeState, nil
+	default:
+		return args, denySubscriptionState, nil
+	}
+}
+
+func activateSubscriptionState(ctx context.Context, args WebSubArgs) (WebSubArgs, statemachine.StateFn[WebSubArgs], error) {
+	if args.sub.State == rf.WebSubStateUnsubscribed {
+		return args, nil, errors.NotFoundf(errors.ErrHubSubscriptionNotFound)
+	}
+
+	if args.verification.LeaseSeconds > 0 {
+		args.sub.LeaseSeconds = args.verification.LeaseSeconds
+	}
+	args.sub.State = rf.WebSubStateActive
+	args.sub.ExpiresAt = time.Now().UTC().Add(time.Duration(args.sub.LeaseSeconds) * time.Second)
+
+	if err := args.store.SaveSubscription(ctx, args.sub); err != nil {
+		return args, nil, err
+	}
+
+	if err := args.store.SetFeedPollInterval(ctx, args.sub.FeedID, SafetyNetPollInterval); err != nil {
+		return args, nil, err
+	}
+
+	return args, nil, nil
+}
+
+// confirmUnsubscribeState only confirms unsubscribe requests this service
+// made itself, so a third party cannot silently cut off push updates.
+func confirmUnsubscribeState(ctx context.Context, args WebSubArgs) (WebSubArgs, statemachine.StateFn[WebSubArgs], error) {
+	if args.sub.State != rf.WebSubStateUnsubscribed {
+		return args, nil, errors.NotFoundf(errors.ErrHubSubscriptionNotFound)
+	}
+
+	return args, nil, nil
+}
+
+func denySubscriptionState(ctx context.Context, args WebSubArgs) (WebSubArgs, statemachine.StateFn[WebSubArgs], error) {
+	args.sub.State = rf.WebSubStateDenied
+
+	if err := args.store.SaveSubscription(ctx, args.sub); err != nil {
+		return args, nil, err
+	}
+
+	if err := args.store.SetFeedPollInterval(ctx, args.sub.FeedID, DefaultPollInterval); err != nil {
+		return args, nil, err
+	}
+
+	return args, nil, nil
+}
@@ -0,0 +1,41 @@
+package signedurl_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/signedurl"
+	"github.com/matryer/is"
+)
+
+func TestSigner(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	signer := signedurl.NewSigner([]byte("secret"))
+
+	query := func(link string) url.Values {
+		u, err := url.Parse(link)
+		is.NoErr(err) // should be a url
+		return u.Query()
+	}
+
+	link := signer.Sign("/api/v1/exports/1/download", time.Now().Add(time.Minute))
+
+	is.True(strings.HasPrefix(link, "/api/v1/exports/1/download?"))  // should keep the path
+	is.True(signer.Valid("/api/v1/exports/1/download", query(link))) // should accept its own link
+
+	is.True(!signer.Valid("/api/v1/exports/2/download", query(link)))                               // should be bound to the path
+	is.True(!signedurl.NewSigner([]byte("other")).Valid("/api/v1/exports/1/download", query(link))) // should be bound to the secret
+
+	tampered := query(link)
+	tampered.Set("expires", "9999999999")
+	is.True(!signer.Valid("/api/v1/exports/1/download", tampered)) // should not extend the expiry
+
+	expired := signer.Sign("/api/v1/exports/1/download", time.Now().Add(-time.Second))
+	is.True(!signer.Valid("/api/v1/exports/1/download", query(expired))) // should reject expired links
+
+	is.True(!signer.Valid("/api/v1/exports/1/download", url.Values{})) // should reject unsigned links
+}
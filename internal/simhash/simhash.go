@@ -0,0 +1,74 @@
+package simhash
+
+import (
+	"hash/fnv"
+	"html"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is the number of consecutive words hashed together as one
+// feature, which keeps word order significant without being brittle.
+const shingleSize = 2
+
+// minWords is the least amount of words needed to produce a fingerprint,
+// shorter texts return 0 as they are too small to compare meaningfully.
+const minWords = 8
+
+func Fingerprint(text string) uint64 {
+	words := Normalize(text)
+	if len(words) < minWords {
+		return 0
+	}
+
+	var weights [64]int
+	for i := 0; i+shingleSize <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+shingleSize], " ")))
+		sum := h.Sum64()
+
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+
+	return fingerprint
+}
+
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Normalize strips markup and entities from text and returns its lower cased
+// words.
+func Normalize(text string) []string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range text {
+		switch {
+		case r == '<':
+			inTag = true
+			b.WriteRune(' ')
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.FieldsFunc(strings.ToLower(html.UnescapeString(b.String())), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
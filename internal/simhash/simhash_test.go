@@ -0,0 +1,33 @@
+package simhash_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/simhash"
+	"github.com/matryer/is"
+)
+
+func TestSimHash(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	release := "Gopher Corp today announced the general availability of its new " +
+		"concurrent garbage collector, cutting pause times for large heaps in half. " +
+		"The collector ships with the next point release and requires no changes to " +
+		"existing programs. Early adopters report lower tail latencies across web " +
+		"services, batch pipelines and command line tools, while memory overhead " +
+		"remains within a few percent of the previous implementation."
+	republished := "<p>" + strings.ReplaceAll(release, ".", "!") + "</p>"
+	unrelated := "The weather this weekend will be sunny with light winds along the coast " +
+		"and a small chance of showers inland on Sunday evening."
+
+	is.Equal(simhash.Fingerprint(release), simhash.Fingerprint(republished)) // markup and punctuation should be ignored
+
+	edited := release + " Pricing starts next month."
+	is.True(simhash.Distance(simhash.Fingerprint(release), simhash.Fingerprint(edited)) <= 8)   // small edits should stay close
+	is.True(simhash.Distance(simhash.Fingerprint(release), simhash.Fingerprint(unrelated)) > 8) // different stories should be far apart
+	is.Equal(simhash.Fingerprint("too short"), uint64(0))                                       // short texts have no fingerprint
+	is.Equal(simhash.Normalize("Go &amp; <b>Gophers</b>"), []string{"go", "gophers"})           // should strip tags and entities
+}
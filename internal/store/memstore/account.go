@@ -0,0 +1,48 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// DeleteAccount marks the account of the user deleted until purgeAt and
+// revokes every session. Api keys are refused while the account is deleted
+// and work again when it is restored.
+func (as *AuthStore) DeleteAccount(ctx context.Context, userID int64, purgeAt time.Time) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	auth, ok := as.db.auths[userID]
+	if !ok || auth.Deleted {
+		return errors.NotFoundf(errors.ErrUserNotFound)
+	}
+
+	auth.Deleted = true
+	auth.DeletedAt = now
+	auth.PurgeAt = purgeAt.UTC()
+	auth.ModifiedAt = now
+
+	as.db.revokeSessions(userID, 0, now)
+
+	return nil
+}
+
+// PurgeDeletedAccounts removes users whose deleted accounts are past their
+// purge time along with everything of theirs. It returns how many users
+// were removed.
+func (as *AuthStore) PurgeDeletedAccounts(ctx context.Context) (int64, error) {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	var purged int64
+	for userID, auth := range as.db.auths {
+		if auth.Deleted && !auth.PurgeAt.After(now) {
+			as.db.deleteUser(userID)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
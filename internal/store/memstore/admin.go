@@ -0,0 +1,169 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type AdminStore struct {
+	db *DB
+}
+
+func NewAdminStore(db *DB) *AdminStore {
+	return &AdminStore{
+		db: db,
+	}
+}
+
+// SearchUsers returns the users whose email or name contains the query,
+// ignoring case.
+func (as *AdminStore) SearchUsers(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	query := strings.ToLower(strings.TrimSpace(req.Query))
+
+	users := []rf.AdminUser{}
+	for userID, auth := range as.db.auths {
+		user := as.db.users[userID]
+
+		matches := query == "" ||
+			strings.Contains(strings.ToLower(auth.BasicAuth.Email), query) ||
+			strings.Contains(strings.ToLower(user.Name), query)
+		if !matches {
+			continue
+		}
+
+		users = append(users, rf.AdminUser{
+			ID:              user.ID,
+			Name:            user.Name,
+			Email:           auth.BasicAuth.Email,
+			Role:            user.Role,
+			Enabled:         auth.Enabled,
+			Deleted:         auth.Deleted,
+			EmailVerifiedAt: auth.EmailVerifiedAt,
+			LastSignedInAt:  auth.LastSignedInAt,
+			CreatedAt:       user.CreatedAt,
+		})
+	}
+
+	slices.SortFunc(users, func(a, b rf.AdminUser) int { return cmp.Compare(a.ID, b.ID) })
+
+	return page(users, req.Offset, req.Limit), nil
+}
+
+// SetAuthEnabled enables or disables the account of the user, disabling
+// also revokes every session.
+func (as *AdminStore) SetAuthEnabled(ctx context.Context, userID int64, enabled bool) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	auth, ok := as.db.auths[userID]
+	if !ok {
+		return errors.NotFoundf(errors.ErrUserNotFound)
+	}
+
+	auth.Enabled = enabled
+	auth.ModifiedAt = now
+
+	if !enabled {
+		as.db.revokeSessions(userID, 0, now)
+	}
+
+	return nil
+}
+
+// SetUserRole gives the user signed up with email the role and returns
+// their id.
+func (as *AdminStore) SetUserRole(ctx context.Context, email string, role rf.Role) (int64, error) {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	for userID, auth := range as.db.auths {
+		if auth.BasicAuth.Email != email || auth.Deleted {
+			continue
+		}
+
+		user := as.db.users[userID]
+		user.Role = role
+		user.ModifiedAt = now
+		return userID, nil
+	}
+
+	return 0, errors.NotFoundf(errors.ErrUserNotFound)
+}
+
+// RequestFeedResync marks the feed to be fetched on the next sync whatever
+// its poll interval.
+func (as *AdminStore) RequestFeedResync(ctx context.Context, feedID int64) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	f, ok := as.db.feeds[feedID]
+	if !ok || f.deleted {
+		return errors.NotFoundf(errors.ErrFeedNotFound)
+	}
+
+	f.resyncRequestedAt = now
+	f.modifiedAt = now
+
+	return nil
+}
+
+// SetFeedEnabled enables or disables fetching the feed for every
+// subscriber.
+func (as *AdminStore) SetFeedEnabled(ctx context.Context, feedID int64, enabled bool) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	f, ok := as.db.feeds[feedID]
+	if !ok || f.deleted {
+		return errors.NotFoundf(errors.ErrFeedNotFound)
+	}
+
+	f.enabled = enabled
+	f.modifiedAt = now
+
+	return nil
+}
+
+// ListFeedFetchErrors returns the feeds whose last fetch failed, most
+// recent failure first.
+func (as *AdminStore) ListFeedFetchErrors(ctx context.Context, limit int) ([]rf.FeedFetchError, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	fetchErrs := []rf.FeedFetchError{}
+	for _, f := range as.db.feeds {
+		if f.lastFetchError == nil || f.deleted {
+			continue
+		}
+
+		fetchErrs = append(fetchErrs, rf.FeedFetchError{
+			FeedID:   f.id,
+			URL:      f.url,
+			Enabled:  f.enabled,
+			Error:    *f.lastFetchError,
+			FailedAt: f.lastFetchErrorAt,
+			Failures: f.fetchFailures,
+		})
+	}
+
+	slices.SortFunc(fetchErrs, func(a, b rf.FeedFetchError) int {
+		return cmp.Or(b.FailedAt.Compare(a.FailedAt), cmp.Compare(a.FeedID, b.FeedID))
+	})
+
+	return page(fetchErrs, 0, limit), nil
+}
+
+// page returns the rows a LIMIT and OFFSET would.
+func page[T any](rows []T, offset, limit int) []T {
+	offset = min(max(offset, 0), len(rows))
+	rows = rows[offset:]
+	return rows[:min(max(limit, 0), len(rows))]
+}
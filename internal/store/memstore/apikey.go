@@ -0,0 +1,97 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateAPIKey(ctx context.Context, key *rf.APIKey) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	key.ID = as.db.nextID("api_keys")
+	key.CreatedAt = now
+
+	stored := copyAPIKey(key)
+	stored.Key = ""
+	as.db.apiKeys[key.ID] = &stored
+
+	return nil
+}
+
+func (as *AuthStore) ListUserAPIKeys(ctx context.Context, userID int64) ([]rf.APIKey, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	keys := []rf.APIKey{}
+	for _, key := range as.db.apiKeys {
+		if key.UserID == userID && key.RevokedAt == nil {
+			keys = append(keys, copyAPIKey(key))
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b rf.APIKey) int { return cmp.Compare(a.ID, b.ID) })
+
+	return keys, nil
+}
+
+func (as *AuthStore) FindAPIKeyByHash(ctx context.Context, keyHash string) (*rf.APIKey, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	for _, found := range as.db.apiKeys {
+		if found.KeyHash == keyHash {
+			key := copyAPIKey(found)
+			return &key, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (as *AuthStore) TouchAPIKey(ctx context.Context, keyID int64) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	if key, ok := as.db.apiKeys[keyID]; ok {
+		key.LastUsedAt = &now
+	}
+
+	return nil
+}
+
+func (as *AuthStore) RevokeAPIKey(ctx context.Context, userID, keyID int64) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	key, ok := as.db.apiKeys[keyID]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return errors.NotFoundf(errors.ErrAPIKeyNotFound)
+	}
+
+	key.RevokedAt = &now
+
+	return nil
+}
+
+// copyAPIKey copies key along with the times it points to.
+func copyAPIKey(key *rf.APIKey) rf.APIKey {
+	c := *key
+	c.LastUsedAt = copyTime(key.LastUsedAt)
+	c.ExpiresAt = copyTime(key.ExpiresAt)
+	c.RevokedAt = copyTime(key.RevokedAt)
+	return c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -0,0 +1,71 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+)
+
+// AuditStore keeps audit events in an append only list, it is an
+// audit.Recorder.
+type AuditStore struct {
+	db *DB
+}
+
+func NewAuditStore(db *DB) *AuditStore {
+	return &AuditStore{
+		db: db,
+	}
+}
+
+func (as *AuditStore) Record(ctx context.Context, event audit.Event) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	event.ID = as.db.nextID("audit_events")
+	event.CreatedAt = now
+
+	as.db.auditEvents = append(as.db.auditEvents, event)
+
+	return nil
+}
+
+// ListAuditEvents returns the events matching query, newest first.
+func (as *AuditStore) ListAuditEvents(ctx context.Context, query *audit.Query) ([]audit.Event, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	events := []audit.Event{}
+	for i := len(as.db.auditEvents) - 1; i >= 0 && len(events) < query.Limit; i-- {
+		event := as.db.auditEvents[i]
+		if matches(query, event) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func matches(query *audit.Query, event audit.Event) bool {
+	switch {
+	case len(query.Types) > 0 && !slices.Contains(query.Types, event.Type):
+		return false
+	case query.ActorID != 0 && event.ActorID != query.ActorID:
+		return false
+	case query.TargetType != "" && event.TargetType != query.TargetType:
+		return false
+	case query.TargetID != 0 && event.TargetID != query.TargetID:
+		return false
+	case query.UserID != 0 && event.ActorID != query.UserID &&
+		(event.TargetType != audit.TargetUser || event.TargetID != query.UserID):
+		return false
+	case !query.Since.IsZero() && event.CreatedAt.Before(query.Since):
+		return false
+	case !query.Until.IsZero() && !event.CreatedAt.Before(query.Until):
+		return false
+	case query.BeforeID != 0 && event.ID >= query.BeforeID:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type AuthStore struct {
+	db *DB
+}
+
+func NewAuthStore(db *DB) *AuthStore {
+	return &AuthStore{
+		db: db,
+	}
+}
+
+func (as *AuthStore) CreateAuthAndUser(ctx context.Context, auth *rf.Auth) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	return as.db.createAuthAndUser(auth, now)
+}
+
+func (db *DB) createAuthAndUser(auth *rf.Auth, now time.Time) error {
+	if db.findAuth(func(a *rf.Auth) bool { return a.BasicAuth.Email == auth.BasicAuth.Email }) != nil {
+		return errors.InvalidDataf(errors.ErrCouldNotProcess)
+	}
+
+	user := &rf.User{
+		ID:          db.nextID("users"),
+		Name:        auth.User.Name,
+		Role:        rf.RoleUser,
+		Timezone:    "UTC",
+		Preferences: map[string]any{},
+		CreatedAt:   now,
+		ModifiedAt:  now,
+	}
+	db.users[user.ID] = user
+
+	auth.ID = db.nextID("auths")
+	auth.UserID = user.ID
+	auth.Enabled = true
+	auth.CreatedAt = now
+	auth.ModifiedAt = now
+	auth.LastSignedInAt = now
+	if !auth.EmailVerifiedAt.IsZero() {
+		auth.EmailVerifiedAt = now
+	}
+
+	db.auths[user.ID] = &rf.Auth{
+		ID:              auth.ID,
+		UserID:          auth.UserID,
+		Enabled:         true,
+		CreatedAt:       auth.CreatedAt,
+		ModifiedAt:      auth.ModifiedAt,
+		LastSignedInAt:  auth.LastSignedInAt,
+		EmailVerifiedAt: auth.EmailVerifiedAt,
+		BasicAuth: &rf.BasicAuth{
+			Email:    auth.BasicAuth.Email,
+			Password: auth.BasicAuth.Password,
+		},
+	}
+
+	return nil
+}
+
+func (as *AuthStore) FindByEmail(ctx context.Context, email string) (*rf.Auth, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	return as.db.findAuth(func(a *rf.Auth) bool { return a.BasicAuth.Email == email }), nil
+}
+
+func (as *AuthStore) FindByUserID(ctx context.Context, userID int64) (*rf.Auth, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	return as.db.findAuth(func(a *rf.Auth) bool { return a.UserID == userID }), nil
+}
+
+// findAuth returns a copy of the auth matching match joined with its user
+// and two-factor enrolment, or nil when there is none.
+func (db *DB) findAuth(match func(*rf.Auth) bool) *rf.Auth {
+	for _, found := range db.auths {
+		if !match(found) {
+			continue
+		}
+
+		auth := *found
+		auth.BasicAuth = &rf.BasicAuth{
+			Email:    found.BasicAuth.Email,
+			Password: found.BasicAuth.Password,
+		}
+
+		user := db.users[auth.UserID]
+		auth.Role = user.Role
+		auth.User = &rf.User{
+			ID:   user.ID,
+			Name: user.Name,
+		}
+
+		if totp, ok := db.totps[auth.UserID]; ok {
+			auth.TOTPEnabledAt = totp.EnabledAt
+		}
+
+		return &auth
+	}
+
+	return nil
+}
+
+// RecordSignInAttempt stores the outcome of a password check. A success
+// re-checks the account is still enabled, restores it when it was deleted
+// but not yet purged, records the sign in and clears the failed attempts,
+// a failure counts it.
+func (as *AuthStore) RecordSignInAttempt(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	found, ok := as.db.auths[auth.UserID]
+
+	if !succeeded {
+		if ok {
+			found.FailedSignInAttempts++
+			found.LastFailedSignInAt = now
+			auth.FailedSignInAttempts = found.FailedSignInAttempts
+		}
+		auth.LastFailedSignInAt = now
+
+		return nil
+	}
+
+	if !ok {
+		return errors.Unauthorizedf(errors.ErrInvalidCredentials)
+	}
+
+	auth.Enabled = found.Enabled
+	auth.Deleted = found.Deleted
+
+	if found.Deleted && !found.PurgeAt.After(now) {
+		return errors.AccountDisabledf(errors.ErrAccountDeleted)
+	}
+	if !found.Enabled {
+		return errors.AccountDisabledf(errors.ErrAccountDisabled)
+	}
+
+	found.LastSignedInAt = now
+	found.FailedSignInAttempts = 0
+	found.Deleted = false
+	found.DeletedAt = time.Time{}
+	found.PurgeAt = time.Time{}
+
+	auth.LastSignedInAt = now
+	auth.FailedSignInAttempts = 0
+	auth.Deleted = false
+	auth.DeletedAt = time.Time{}
+	auth.PurgeAt = time.Time{}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// ListItemsWantingContent returns up to limit items, newest first, whose
+// full article a subscriber asked for and is not cached yet, or failed to
+// download before retryBefore.
+func (is *ItemStore) ListItemsWantingContent(ctx context.Context, retryBefore time.Time, limit int) ([]rf.Item, error) {
+	is.db.lock()
+	defer is.db.unlock()
+
+	wanted := map[int64]bool{}
+	for key, uf := range is.db.userFeeds {
+		if uf.fetchFullContent {
+			wanted[key.feedID] = true
+		}
+	}
+
+	items := []rf.Item{}
+	for _, item := range is.db.items {
+		if item.Link == "" || !wanted[is.db.channels[item.ChannelID].FeedID] {
+			continue
+		}
+
+		if content, ok := is.db.contents[item.ID]; ok && (content.Error == "" || !content.FetchedAt.Before(retryBefore)) {
+			continue
+		}
+
+		items = append(items, rf.Item{ID: item.ID, ChannelID: item.ChannelID, Link: item.Link})
+	}
+
+	slices.SortFunc(items, func(a, b rf.Item) int { return cmp.Compare(b.ID, a.ID) })
+
+	return items[:min(limit, len(items))], nil
+}
+
+func (is *ItemStore) FindItemContent(ctx context.Context, itemID int64) (*rf.ItemContent, error) {
+	is.db.lock()
+	defer is.db.unlock()
+
+	found, ok := is.db.contents[itemID]
+	if !ok {
+		return nil, nil
+	}
+
+	content := *found
+	return &content, nil
+}
+
+func (is *ItemStore) SaveItemContent(ctx context.Context, content *rf.ItemContent) error {
+	now := is.db.lock()
+	defer is.db.unlock()
+
+	if _, ok := is.db.items[content.ItemID]; !ok {
+		return errors.InternalErrorf("item %d does not exist", content.ItemID)
+	}
+
+	content.FetchedAt = now
+
+	stored := *content
+	is.db.contents[content.ItemID] = &stored
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package memstore
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateEmailVerificationToken(ctx context.Context, t *rf.EmailVerificationToken) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	t.CreatedAt = now
+
+	as.db.verificationTokens[t.TokenHash] = &token{
+		userID:    t.UserID,
+		email:     t.Email,
+		createdAt: t.CreatedAt,
+		expiresAt: t.ExpiresAt,
+	}
+
+	return nil
+}
+
+// ConfirmEmail spends a verification token and makes its address the
+// verified email of the account, replacing the old one on a change. It
+// returns the user id of the account.
+func (as *AuthStore) ConfirmEmail(ctx context.Context, tokenHash string) (int64, error) {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	t, ok := as.db.verificationTokens[tokenHash]
+	if !ok || !t.usable(now) {
+		return 0, errors.InvalidDataf(errors.ErrVerifyTokenInvalid)
+	}
+
+	taken := as.db.findAuth(func(a *rf.Auth) bool {
+		return a.BasicAuth.Email == t.email && a.UserID != t.userID
+	})
+	if taken != nil {
+		return 0, errors.InvalidDataf(errors.ErrCouldNotProcess)
+	}
+
+	if auth, ok := as.db.auths[t.userID]; ok {
+		auth.BasicAuth.Email = t.email
+		auth.EmailVerifiedAt = now
+		auth.ModifiedAt = now
+	}
+
+	// Links sent for other addresses must not undo this change.
+	spendTokens(as.db.verificationTokens, t.userID, now)
+
+	return t.userID, nil
+}
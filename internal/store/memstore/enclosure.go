@@ -0,0 +1,101 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (is *ItemStore) SavePlaybackPosition(ctx context.Context, position *rf.PlaybackPosition) error {
+	now := is.db.lock()
+	defer is.db.unlock()
+
+	// Positions are only kept for audio and video enclosures of items in
+	// feeds the user subscribes to.
+	enc, ok := is.db.enclosures[position.EnclosureID]
+	if !ok || enc.ItemID != position.ItemID || (enc.Medium != "audio" && enc.Medium != "video") {
+		return errors.NotFoundf(errors.ErrEnclosureNotFound)
+	}
+
+	feedID := is.db.channels[is.db.items[enc.ItemID].ChannelID].FeedID
+	if _, ok := is.db.userFeeds[userFeedKey{userID: position.UserID, feedID: feedID}]; !ok {
+		return errors.NotFoundf(errors.ErrEnclosureNotFound)
+	}
+
+	position.ModifiedAt = now
+
+	stored := *position
+	is.db.positions[userItemKey{userID: position.UserID, id: position.EnclosureID}] = &stored
+
+	return nil
+}
+
+func (db *DB) upsertEnclosures(item *rf.Item, now time.Time) {
+	// Enclosures the feed no longer lists are removed along with any
+	// playback positions in them.
+	for id, stored := range db.enclosures {
+		if stored.ItemID != item.ID || slices.ContainsFunc(item.Enclosures, func(enc rf.Enclosure) bool { return enc.URL == stored.URL }) {
+			continue
+		}
+
+		delete(db.enclosures, id)
+		for key := range db.positions {
+			if key.id == id {
+				delete(db.positions, key)
+			}
+		}
+	}
+
+	for i := range item.Enclosures {
+		enc := &item.Enclosures[i]
+		enc.ItemID = item.ID
+
+		var found *rf.Enclosure
+		for _, stored := range db.enclosures {
+			if stored.ItemID == enc.ItemID && stored.URL == enc.URL {
+				found = stored
+				break
+			}
+		}
+
+		if found == nil {
+			enc.ID = db.nextID("item_enclosures")
+			enc.CreatedAt = now
+		} else {
+			enc.ID = found.ID
+			enc.CreatedAt = found.CreatedAt
+		}
+		enc.ModifiedAt = now
+
+		stored := *enc
+		stored.Playback = nil
+		db.enclosures[enc.ID] = &stored
+	}
+}
+
+// listItemEnclosures returns the enclosures of the item with the playback
+// position of the user in each.
+func (db *DB) listItemEnclosures(userID, itemID int64) []rf.Enclosure {
+	enclosures := []rf.Enclosure{}
+	for _, stored := range db.enclosures {
+		if stored.ItemID != itemID {
+			continue
+		}
+
+		enc := *stored
+		if position, ok := db.positions[userItemKey{userID: userID, id: enc.ID}]; ok {
+			playback := *position
+			enc.Playback = &playback
+		}
+
+		enclosures = append(enclosures, enc)
+	}
+
+	slices.SortFunc(enclosures, func(a, b rf.Enclosure) int { return cmp.Compare(a.ID, b.ID) })
+
+	return enclosures
+}
@@ -0,0 +1,427 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// exportClaimTimeout is how long a running export may take before it is
+// taken to have died with its worker and is claimed again.
+const exportClaimTimeout = time.Hour
+
+type ExportStore struct {
+	db *DB
+}
+
+func NewExportStore(db *DB) *ExportStore {
+	return &ExportStore{
+		db: db,
+	}
+}
+
+// CreateExport queues an export, when the user already has one queued or
+// running export is set to it instead.
+func (es *ExportStore) CreateExport(ctx context.Context, e *rf.DataExport) error {
+	now := es.db.lock()
+	defer es.db.unlock()
+
+	if _, ok := es.db.users[e.UserID]; !ok {
+		return errors.InternalErrorf("user %d does not exist", e.UserID)
+	}
+
+	found := es.db.findExport(func(found *export) bool {
+		return found.UserID == e.UserID && found.ExpiresAt.After(now) &&
+			(found.State == rf.DataExportStatePending || found.State == rf.DataExportStateRunning)
+	})
+	if found != nil {
+		*e = *found
+		return nil
+	}
+
+	e.ID = es.db.nextID("data_exports")
+	e.State = rf.DataExportStatePending
+	e.CreatedAt = now
+
+	es.db.exports[e.ID] = &export{
+		DataExport: rf.DataExport{
+			ID:        e.ID,
+			UserID:    e.UserID,
+			State:     e.State,
+			CreatedAt: e.CreatedAt,
+			ExpiresAt: e.ExpiresAt.UTC(),
+		},
+	}
+
+	return nil
+}
+
+// FindExport returns an unexpired export, or nil when there is none.
+func (es *ExportStore) FindExport(ctx context.Context, exportID int64) (*rf.DataExport, error) {
+	now := es.db.lock()
+	defer es.db.unlock()
+
+	return es.db.findExport(func(found *export) bool {
+		return found.ID == exportID && found.ExpiresAt.After(now)
+	}), nil
+}
+
+func (es *ExportStore) ListUserExports(ctx context.Context, userID int64) ([]rf.DataExport, error) {
+	now := es.db.lock()
+	defer es.db.unlock()
+
+	exports := []rf.DataExport{}
+	for _, found := range es.db.exports {
+		if found.UserID == userID && found.ExpiresAt.After(now) {
+			exports = append(exports, found.DataExport)
+		}
+	}
+
+	slices.SortFunc(exports, newestExportFirst)
+
+	return exports, nil
+}
+
+// ClaimPendingExport marks the oldest queued export running and returns
+// it, or nil when none is queued. An export left running by a worker that
+// died is claimed again.
+func (es *ExportStore) ClaimPendingExport(ctx context.Context) (*rf.DataExport, error) {
+	now := es.db.lock()
+	defer es.db.unlock()
+
+	staleBefore := now.Add(-exportClaimTimeout)
+
+	var claim *export
+	for _, found := range es.db.exports {
+		claimable := found.State == rf.DataExportStatePending ||
+			(found.State == rf.DataExportStateRunning && found.startedAt.Before(staleBefore))
+		if !claimable || !found.ExpiresAt.After(now) {
+			continue
+		}
+
+		if claim == nil || newestExportFirst(found.DataExport, claim.DataExport) > 0 {
+			claim = found
+		}
+	}
+
+	if claim == nil {
+		return nil, nil
+	}
+
+	claim.State = rf.DataExportStateRunning
+	claim.startedAt = now
+
+	e := claim.DataExport
+	return &e, nil
+}
+
+// FinishExport records the outcome of a running export, size is the zip
+// size when it is ready.
+func (es *ExportStore) FinishExport(ctx context.Context, exportID int64, state rf.DataExportState, size int64) error {
+	now := es.db.lock()
+	defer es.db.unlock()
+
+	found, ok := es.db.exports[exportID]
+	if !ok || found.State != rf.DataExportStateRunning {
+		return errors.NotFoundf(errors.ErrExportNotFound)
+	}
+
+	found.State = state
+	found.Size = size
+	found.CompletedAt = now
+
+	return nil
+}
+
+// DeleteExpiredExports removes expired exports and returns their ids so
+// their files can be removed too.
+func (es *ExportStore) DeleteExpiredExports(ctx context.Context) ([]int64, error) {
+	now := es.db.lock()
+	defer es.db.unlock()
+
+	ids := []int64{}
+	for id, found := range es.db.exports {
+		if !found.ExpiresAt.After(now) {
+			delete(es.db.exports, id)
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+
+	return ids, nil
+}
+
+// findExport returns the newest export matching match, or nil when there
+// is none.
+func (db *DB) findExport(match func(*export) bool) *rf.DataExport {
+	var newest *rf.DataExport
+	for _, found := range db.exports {
+		if !match(found) {
+			continue
+		}
+
+		if newest == nil || newestExportFirst(found.DataExport, *newest) < 0 {
+			e := found.DataExport
+			newest = &e
+		}
+	}
+
+	return newest
+}
+
+func newestExportFirst(a, b rf.DataExport) int {
+	return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+}
+
+// FindExportProfile returns the account part of an export, or nil when the
+// user does not exist.
+func (es *ExportStore) FindExportProfile(ctx context.Context, userID int64) (*rf.ExportProfile, error) {
+	es.db.lock()
+	defer es.db.unlock()
+
+	user, ok := es.db.users[userID]
+	auth, hasAuth := es.db.auths[userID]
+	if !ok || !hasAuth {
+		return nil, nil
+	}
+
+	profile, err := copyUser(user)
+	if err != nil {
+		return nil, err
+	}
+	profile.Role = ""
+
+	return &rf.ExportProfile{
+		User:            profile,
+		Email:           auth.BasicAuth.Email,
+		EmailVerifiedAt: auth.EmailVerifiedAt,
+		CreatedAt:       auth.CreatedAt,
+		LastSignedInAt:  auth.LastSignedInAt,
+	}, nil
+}
+
+// EachSubscription calls fn with each feed of the user.
+func (es *ExportStore) EachSubscription(ctx context.Context, userID int64, fn func(rf.Feed) error) error {
+	es.db.lock()
+
+	feeds := []rf.Feed{}
+	for key, uf := range es.db.userFeeds {
+		if key.userID != userID || uf.deleted {
+			continue
+		}
+
+		feeds = append(feeds, rf.Feed{
+			ID:        key.feedID,
+			Name:      uf.name,
+			URL:       es.db.feeds[key.feedID].url,
+			CreatedAt: uf.createdAt,
+		})
+	}
+
+	es.db.unlock()
+
+	slices.SortFunc(feeds, func(a, b rf.Feed) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+
+	return each(feeds, fn)
+}
+
+// EachItemState calls fn with every item the user read, starred or saved.
+func (es *ExportStore) EachItemState(ctx context.Context, userID int64, fn func(rf.ExportItemState) error) error {
+	es.db.lock()
+
+	type row struct {
+		rf.ExportItemState
+		createdAt time.Time
+	}
+
+	rows := []row{}
+	for key, state := range es.db.itemStates {
+		if key.userID != userID || (state.readAt.IsZero() && state.starredAt.IsZero() && state.savedAt.IsZero()) {
+			continue
+		}
+
+		item := es.db.items[key.id]
+		rows = append(rows, row{
+			ExportItemState: rf.ExportItemState{
+				ItemID:    item.ID,
+				FeedID:    es.db.channels[item.ChannelID].FeedID,
+				Title:     item.Title,
+				Link:      item.Link,
+				ReadAt:    state.readAt,
+				StarredAt: state.starredAt,
+				SavedAt:   state.savedAt,
+			},
+			createdAt: state.createdAt,
+		})
+	}
+
+	es.db.unlock()
+
+	slices.SortFunc(rows, func(a, b row) int {
+		return cmp.Or(a.createdAt.Compare(b.createdAt), cmp.Compare(a.ItemID, b.ItemID))
+	})
+
+	return each(rows, func(r row) error { return fn(r.ExportItemState) })
+}
+
+func (es *ExportStore) EachPlaybackPosition(ctx context.Context, userID int64, fn func(rf.ExportPlaybackPosition) error) error {
+	es.db.lock()
+
+	type row struct {
+		rf.ExportPlaybackPosition
+		enclosureID int64
+	}
+
+	rows := []row{}
+	for key, position := range es.db.positions {
+		if key.userID != userID {
+			continue
+		}
+
+		enc := es.db.enclosures[key.id]
+		rows = append(rows, row{
+			ExportPlaybackPosition: rf.ExportPlaybackPosition{
+				ItemID:          enc.ItemID,
+				EnclosureURL:    enc.URL,
+				PositionSeconds: position.PositionSeconds,
+				Completed:       position.Completed,
+				ModifiedAt:      position.ModifiedAt,
+			},
+			enclosureID: enc.ID,
+		})
+	}
+
+	es.db.unlock()
+
+	slices.SortFunc(rows, func(a, b row) int {
+		return cmp.Or(a.ModifiedAt.Compare(b.ModifiedAt), cmp.Compare(a.enclosureID, b.enclosureID))
+	})
+
+	return each(rows, func(r row) error { return fn(r.ExportPlaybackPosition) })
+}
+
+// EachSession calls fn with every session of the user, including revoked
+// and expired ones.
+func (es *ExportStore) EachSession(ctx context.Context, userID int64, fn func(rf.ExportSession) error) error {
+	es.db.lock()
+
+	sessions := []rf.ExportSession{}
+	for _, session := range es.db.sessions {
+		if session.UserID != userID {
+			continue
+		}
+
+		sessions = append(sessions, rf.ExportSession{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+
+	es.db.unlock()
+
+	slices.SortFunc(sessions, func(a, b rf.ExportSession) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return each(sessions, fn)
+}
+
+// EachAPIKey calls fn with every api key of the user, including revoked
+// and expired ones.
+func (es *ExportStore) EachAPIKey(ctx context.Context, userID int64, fn func(rf.ExportAPIKey) error) error {
+	es.db.lock()
+
+	keys := []rf.ExportAPIKey{}
+	for _, key := range es.db.apiKeys {
+		if key.UserID != userID {
+			continue
+		}
+
+		keys = append(keys, rf.ExportAPIKey{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scope:      key.Scope,
+			CreatedAt:  key.CreatedAt,
+			LastUsedAt: timeOrZero(key.LastUsedAt),
+			ExpiresAt:  timeOrZero(key.ExpiresAt),
+			RevokedAt:  timeOrZero(key.RevokedAt),
+		})
+	}
+
+	es.db.unlock()
+
+	slices.SortFunc(keys, func(a, b rf.ExportAPIKey) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return each(keys, fn)
+}
+
+// EachIdentity calls fn with every OpenID Connect identity linked to the
+// user.
+func (es *ExportStore) EachIdentity(ctx context.Context, userID int64, fn func(rf.ExportIdentity) error) error {
+	es.db.lock()
+
+	type row struct {
+		rf.ExportIdentity
+		id int64
+	}
+
+	rows := []row{}
+	for _, identity := range es.db.identities {
+		if identity.UserID != userID {
+			continue
+		}
+
+		rows = append(rows, row{
+			ExportIdentity: rf.ExportIdentity{
+				Issuer:    identity.Issuer,
+				Subject:   identity.Subject,
+				Email:     identity.Email,
+				CreatedAt: identity.CreatedAt,
+			},
+			id: identity.ID,
+		})
+	}
+
+	es.db.unlock()
+
+	slices.SortFunc(rows, func(a, b row) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.id, b.id))
+	})
+
+	return each(rows, func(r row) error { return fn(r.ExportIdentity) })
+}
+
+// timeOrZero returns the time t points to, or the zero time when it is nil.
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// each calls fn with each of rows, they are read under the lock and fn is
+// called without it so fn may use the stores.
+func each[T any](rows []T, fn func(T) error) error {
+	for _, row := range rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// defaultPollInterval is the poll interval a feed starts with, the default
+// of the schema.
+const defaultPollInterval = 30 * time.Minute
+
+type FeedStore struct {
+	db *DB
+}
+
+func NewFeedStore(db *DB) *FeedStore {
+	return &FeedStore{
+		db: db,
+	}
+}
+
+// BeginTx begins a unit of work for CreateFeed and CreateUserFeed.
+func (fs *FeedStore) BeginTx(ctx context.Context) (rf.Tx, error) {
+	return fs.db.beginTx(), nil
+}
+
+// CreateFeed creates the feed in tx, when its url is taken the feed is the
+// existing one instead. A new feed is due to be fetched at once. A feed an
+// administrator disabled or deleted is not subscribed to again.
+func (fs *FeedStore) CreateFeed(ctx context.Context, tx rf.Tx, f *rf.Feed) error {
+	memTx, err := txFrom(tx)
+	if err != nil {
+		return err
+	}
+
+	if found := fs.db.findFeed(func(found *feed) bool { return found.url == f.URL }); found != nil {
+		if !found.Enabled || found.Deleted {
+			return errors.InvalidDataf(errors.ErrFeedDisabled)
+		}
+
+		f.ID = found.ID
+		f.CreatedAt = found.CreatedAt
+		f.ModifiedAt = found.ModifiedAt
+		f.LastSyncedAt = found.LastSyncedAt
+		return nil
+	}
+
+	f.ID = fs.db.nextID("feeds")
+	f.CreatedAt = memTx.now
+	f.ModifiedAt = memTx.now
+	f.LastSyncedAt = memTx.now
+
+	fs.db.feeds[f.ID] = &feed{
+		id:                f.ID,
+		url:               f.URL,
+		enabled:           true,
+		createdAt:         f.CreatedAt,
+		modifiedAt:        f.ModifiedAt,
+		lastSyncedAt:      f.LastSyncedAt,
+		itemIdentity:      rf.ItemIdentityGUID,
+		pollInterval:      defaultPollInterval,
+		resyncRequestedAt: memTx.now,
+	}
+
+	feedID := f.ID
+	memTx.undo = append(memTx.undo, func() { delete(fs.db.feeds, feedID) })
+
+	return nil
+}
+
+// CreateUserFeed subscribes the user to the feed in tx.
+func (fs *FeedStore) CreateUserFeed(ctx context.Context, tx rf.Tx, f *rf.Feed) error {
+	memTx, err := txFrom(tx)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := fs.db.users[f.UserID]; !ok {
+		return errors.InternalErrorf("user %d does not exist", f.UserID)
+	}
+	if _, ok := fs.db.feeds[f.ID]; !ok {
+		return errors.InternalErrorf("feed %d does not exist", f.ID)
+	}
+
+	key := userFeedKey{userID: f.UserID, feedID: f.ID}
+	if _, ok := fs.db.userFeeds[key]; ok {
+		return errors.InvalidDataf(errors.ErrCouldNotProcess)
+	}
+
+	fs.db.userFeeds[key] = &userFeed{
+		name:       f.Name,
+		createdAt:  memTx.now,
+		modifiedAt: memTx.now,
+	}
+
+	memTx.undo = append(memTx.undo, func() { delete(fs.db.userFeeds, key) })
+
+	return nil
+}
+
+func (fs *FeedStore) ListUserFeeds(ctx context.Context, userID int64) ([]rf.Feed, error) {
+	fs.db.lock()
+	defer fs.db.unlock()
+
+	feeds := []rf.Feed{}
+	for key, uf := range fs.db.userFeeds {
+		if key.userID != userID {
+			continue
+		}
+
+		feeds = append(feeds, rf.Feed{
+			ID:               key.feedID,
+			UserID:           key.userID,
+			Name:             uf.name,
+			URL:              fs.db.feeds[key.feedID].url,
+			FetchFullContent: uf.fetchFullContent,
+		})
+	}
+
+	slices.SortFunc(feeds, func(a, b rf.Feed) int { return cmp.Compare(a.ID, b.ID) })
+
+	return feeds, nil
+}
+
+func (fs *FeedStore) FindUserFeedByID(ctx context.Context, userID, feedID int64) (*rf.Feed, error) {
+	fs.db.lock()
+	defer fs.db.unlock()
+
+	uf, ok := fs.db.userFeeds[userFeedKey{userID: userID, feedID: feedID}]
+	if !ok {
+		return nil, nil
+	}
+
+	return &rf.Feed{
+		ID:               feedID,
+		UserID:           userID,
+		Name:             uf.name,
+		FetchFullContent: uf.fetchFullContent,
+	}, nil
+}
+
+func (fs *FeedStore) UpdateUserFeed(ctx context.Context, f *rf.Feed) error {
+	now := fs.db.lock()
+	defer fs.db.unlock()
+
+	uf, ok := fs.db.userFeeds[userFeedKey{userID: f.UserID, feedID: f.ID}]
+	if !ok {
+		return errors.NotFoundf(errors.ErrFeedNotFound)
+	}
+
+	f.ModifiedAt = now
+
+	uf.name = f.Name
+	uf.fetchFullContent = f.FetchFullContent
+	uf.modifiedAt = f.ModifiedAt
+
+	return nil
+}
+
+// FindByURL returns the feed of url whoever subscribes to it, or nil when
+// there is none.
+func (fs *FeedStore) FindByURL(ctx context.Context, url string) (*rf.Feed, error) {
+	fs.db.lock()
+	defer fs.db.unlock()
+
+	return fs.db.findFeed(func(found *feed) bool { return found.url == url }), nil
+}
+
+// DeleteFeed unsubscribes the user from the feed, the feed itself is kept
+// for its other subscribers.
+func (fs *FeedStore) DeleteFeed(ctx context.Context, userID, feedID int64) error {
+	fs.db.lock()
+	defer fs.db.unlock()
+
+	key := userFeedKey{userID: userID, feedID: feedID}
+	if _, ok := fs.db.userFeeds[key]; !ok {
+		return errors.NotFoundf(errors.ErrFeedNotFound)
+	}
+
+	delete(fs.db.userFeeds, key)
+
+	return nil
+}
+
+// findFeed returns the feed matching match, or nil when there is none.
+func (db *DB) findFeed(match func(*feed) bool) *rf.Feed {
+	for _, found := range db.feeds {
+		if !match(found) {
+			continue
+		}
+
+		return &rf.Feed{
+			ID:           found.id,
+			URL:          found.url,
+			Enabled:      found.enabled,
+			Deleted:      found.deleted,
+			CreatedAt:    found.createdAt,
+			ModifiedAt:   found.modifiedAt,
+			LastSyncedAt: found.lastSyncedAt,
+			ItemIdentity: found.itemIdentity,
+			PollInterval: found.pollInterval,
+		}
+	}
+
+	return nil
+}
+
+// RecordFeedFetch stores the outcome of fetching a feed. A failure keeps
+// its message and counts it, a success clears the last error and any
+// pending resync request.
+func (fs *FeedStore) RecordFeedFetch(ctx context.Context, feedID int64, fetchErr error) error {
+	now := fs.db.lock()
+	defer fs.db.unlock()
+
+	f, ok := fs.db.feeds[feedID]
+	if !ok {
+		return errors.NotFoundf(errors.ErrFeedNotFound)
+	}
+
+	f.resyncRequestedAt = time.Time{}
+
+	if fetchErr != nil {
+		message := fetchErr.Error()
+		f.lastFetchError = &message
+		f.lastFetchErrorAt = now
+		f.fetchFailures++
+		return nil
+	}
+
+	f.lastSyncedAt = now
+	f.lastFetchError = nil
+	f.fetchFailures = 0
+
+	return nil
+}
+
+// ListDueFeeds returns up to limit enabled feeds with subscribers that are
+// due to be fetched, resyncs first and then the longest since synced. A
+// feed is due when a resync is requested or its poll interval has passed
+// since both its last sync and its last failed fetch.
+func (fs *FeedStore) ListDueFeeds(ctx context.Context, limit int) ([]rf.Feed, error) {
+	now := fs.db.lock()
+	defer fs.db.unlock()
+
+	subscribed := map[int64]bool{}
+	for key := range fs.db.userFeeds {
+		subscribed[key.feedID] = true
+	}
+
+	var due []*feed
+	for _, f := range fs.db.feeds {
+		if !f.enabled || f.deleted || !subscribed[f.id] {
+			continue
+		}
+
+		pollDue := !f.lastSyncedAt.Add(f.pollInterval).After(now) &&
+			(f.lastFetchErrorAt.IsZero() || !f.lastFetchErrorAt.Add(f.pollInterval).After(now))
+		if f.resyncRequestedAt.IsZero() && !pollDue {
+			continue
+		}
+
+		due = append(due, f)
+	}
+
+	slices.SortFunc(due, func(a, b *feed) int {
+		if c := compareBool(a.resyncRequestedAt.IsZero(), b.resyncRequestedAt.IsZero()); c != 0 {
+			return c
+		}
+		if c := a.lastSyncedAt.Compare(b.lastSyncedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+
+	feeds := []rf.Feed{}
+	for _, f := range due[:min(limit, len(due))] {
+		feeds = append(feeds, *fs.db.findFeed(func(found *feed) bool { return found.id == f.id }))
+	}
+
+	return feeds, nil
+}
+
+// CountFeedSubscribers returns how many users subscribe to the feed.
+func (fs *FeedStore) CountFeedSubscribers(ctx context.Context, feedID int64) (int, error) {
+	fs.db.lock()
+	defer fs.db.unlock()
+
+	count := 0
+	for key := range fs.db.userFeeds {
+		if key.feedID == feedID {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// compareBool orders false before true, like Postgres does.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,447 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"math/bits"
+	"slices"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// storyWindow bounds how far back other feeds are searched for the same
+// story.
+const storyWindow = 72 * time.Hour
+
+type ItemStore struct {
+	db *DB
+}
+
+func NewItemStore(db *DB) *ItemStore {
+	return &ItemStore{
+		db: db,
+	}
+}
+
+func (is *ItemStore) FindFeedByID(ctx context.Context, feedID int64) (*rf.Feed, error) {
+	is.db.lock()
+	defer is.db.unlock()
+
+	return is.db.findFeed(func(found *feed) bool { return found.id == feedID }), nil
+}
+
+func (is *ItemStore) FindUserItemByID(ctx context.Context, userID, itemID int64) (*rf.Item, error) {
+	is.db.lock()
+	defer is.db.unlock()
+
+	found, ok := is.db.items[itemID]
+	if !ok {
+		return nil, nil
+	}
+
+	uf, ok := is.db.userFeeds[userFeedKey{userID: userID, feedID: is.db.channels[found.ChannelID].FeedID}]
+	if !ok {
+		return nil, nil
+	}
+
+	item := *found
+	item.IdentityKey = ""
+	item.CanonicalLink = ""
+	item.SimHash = 0
+	item.FetchFullContent = uf.fetchFullContent
+
+	if content, ok := is.db.contents[itemID]; ok && uf.fetchFullContent {
+		item.FullContent = content.Content
+	}
+
+	item.Enclosures = is.db.listItemEnclosures(userID, itemID)
+
+	return &item, nil
+}
+
+func (is *ItemStore) UpsertChannel(ctx context.Context, channel *rf.Channel) error {
+	now := is.db.lock()
+	defer is.db.unlock()
+
+	if _, ok := is.db.feeds[channel.FeedID]; !ok {
+		return errors.InternalErrorf("feed %d does not exist", channel.FeedID)
+	}
+
+	for _, found := range is.db.channels {
+		if found.FeedID != channel.FeedID {
+			continue
+		}
+
+		found.Title = channel.Title
+		found.Description = channel.Description
+		found.Link = channel.Link
+		found.ModifiedAt = now
+
+		channel.ID = found.ID
+		channel.CreatedAt = found.CreatedAt
+		channel.ModifiedAt = found.ModifiedAt
+
+		return nil
+	}
+
+	channel.ID = is.db.nextID("feed_channels")
+	channel.CreatedAt = now
+	channel.ModifiedAt = now
+
+	stored := *channel
+	is.db.channels[channel.ID] = &stored
+
+	return nil
+}
+
+func (is *ItemStore) ListChannelItems(ctx context.Context, channelID int64, limit int) ([]rf.Item, error) {
+	is.db.lock()
+	defer is.db.unlock()
+
+	items := []rf.Item{}
+	for _, item := range is.db.items {
+		if item.ChannelID == channelID {
+			items = append(items, *item)
+		}
+	}
+
+	slices.SortFunc(items, func(a, b rf.Item) int {
+		return cmp.Or(b.PublishedAt.Compare(a.PublishedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	return page(items, 0, limit), nil
+}
+
+func (is *ItemStore) UpsertItems(ctx context.Context, items []*rf.Item) error {
+	now := is.db.lock()
+	defer is.db.unlock()
+
+	// Items of a channel that does not exist fail the batch before any of
+	// it is stored.
+	for _, item := range items {
+		if _, ok := is.db.channels[item.ChannelID]; !ok {
+			return errors.InternalErrorf("feed channel %d does not exist", item.ChannelID)
+		}
+	}
+
+	for _, item := range items {
+		is.db.upsertItem(item, now)
+		is.db.upsertEnclosures(item, now)
+	}
+
+	return nil
+}
+
+func (is *ItemStore) SetFeedItemIdentity(ctx context.Context, feedID, channelID int64, identity rf.ItemIdentity) error {
+	now := is.db.lock()
+	defer is.db.unlock()
+
+	if f, ok := is.db.feeds[feedID]; ok {
+		f.itemIdentity = identity
+		f.modifiedAt = now
+	}
+
+	if identity != rf.ItemIdentityLink {
+		return nil
+	}
+
+	// Re-key stored items by link so the next sync updates them in place
+	// instead of inserting duplicates, keeping the oldest item per link.
+	keys := map[string]bool{}
+	oldest := map[string]*rf.Item{}
+	for _, item := range is.db.items {
+		if item.ChannelID != channelID {
+			continue
+		}
+
+		keys[item.IdentityKey] = true
+
+		if item.Link == "" {
+			continue
+		}
+		if kept, ok := oldest[item.Link]; !ok || item.ID < kept.ID {
+			oldest[item.Link] = item
+		}
+	}
+
+	for link, item := range oldest {
+		if !keys["link:"+link] {
+			item.IdentityKey = "link:" + link
+		}
+	}
+
+	return nil
+}
+
+func (is *ItemStore) GroupItemStories(ctx context.Context, items []*rf.Item, maxDistance int) error {
+	is.db.lock()
+	defer is.db.unlock()
+
+	for _, item := range items {
+		is.db.groupItemStory(item, maxDistance)
+	}
+
+	return nil
+}
+
+func (is *ItemStore) ListTimeline(ctx context.Context, req *rf.TimelineRequest) ([]rf.TimelineEntry, error) {
+	now := is.db.lock()
+	defer is.db.unlock()
+
+	before := req.Before
+	if before.IsZero() {
+		before = now.Add(time.Hour)
+	}
+
+	stories := map[int64][]rf.TimelineSource{}
+	for _, item := range is.db.items {
+		if item.StoryID == 0 {
+			continue
+		}
+
+		feedID := is.db.channels[item.ChannelID].FeedID
+		uf, ok := is.db.userFeeds[userFeedKey{userID: req.UserID, feedID: feedID}]
+		if !ok {
+			continue
+		}
+
+		state, ok := is.db.itemStates[userItemKey{userID: req.UserID, id: item.ID}]
+
+		stories[item.StoryID] = append(stories[item.StoryID], rf.TimelineSource{
+			FeedID:      feedID,
+			FeedName:    uf.name,
+			ItemID:      item.ID,
+			Title:       item.Title,
+			Link:        item.Link,
+			PublishedAt: item.PublishedAt,
+			Read:        ok && !state.readAt.IsZero(),
+		})
+	}
+
+	entries := []rf.TimelineEntry{}
+	for storyID, sources := range stories {
+		slices.SortFunc(sources, func(a, b rf.TimelineSource) int {
+			return cmp.Or(a.PublishedAt.Compare(b.PublishedAt), cmp.Compare(a.ItemID, b.ItemID))
+		})
+
+		entry := rf.TimelineEntry{
+			StoryID: storyID,
+			Title:   sources[0].Title,
+			Link:    sources[0].Link,
+			Read:    true,
+			Sources: sources,
+		}
+		for _, source := range sources {
+			if source.PublishedAt.After(entry.PublishedAt) {
+				entry.PublishedAt = source.PublishedAt
+			}
+			entry.Read = entry.Read && source.Read
+		}
+
+		if entry.PublishedAt.Before(before) {
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b rf.TimelineEntry) int {
+		return cmp.Or(b.PublishedAt.Compare(a.PublishedAt), cmp.Compare(b.StoryID, a.StoryID))
+	})
+
+	return page(entries, 0, req.Limit), nil
+}
+
+func (is *ItemStore) MarkStoryRead(ctx context.Context, userID, storyID int64) error {
+	now := is.db.lock()
+	defer is.db.unlock()
+
+	// Every member of the story is marked read, including items of feeds the
+	// user subscribes to later, as long as the story is visible to the user.
+	var members []int64
+	visible := false
+	for _, item := range is.db.items {
+		if item.StoryID != storyID {
+			continue
+		}
+
+		members = append(members, item.ID)
+
+		feedID := is.db.channels[item.ChannelID].FeedID
+		if _, ok := is.db.userFeeds[userFeedKey{userID: userID, feedID: feedID}]; ok {
+			visible = true
+		}
+	}
+
+	if !visible {
+		return errors.NotFoundf(errors.ErrStoryNotFound)
+	}
+
+	for _, itemID := range members {
+		key := userItemKey{userID: userID, id: itemID}
+
+		state, ok := is.db.itemStates[key]
+		if !ok {
+			state = &itemState{
+				createdAt: now,
+			}
+			is.db.itemStates[key] = state
+		}
+		if state.readAt.IsZero() {
+			state.readAt = now
+		}
+		state.modifiedAt = now
+	}
+
+	return nil
+}
+
+// StarItem stars or unstars an item of a feed the user subscribes to.
+func (is *ItemStore) StarItem(ctx context.Context, userID, itemID int64, starred bool) error {
+	return is.setItemState(userID, itemID, starred, func(state *itemState) *time.Time { return &state.starredAt })
+}
+
+// SaveItem saves an item of a feed the user subscribes to for later, or
+// stops saving it.
+func (is *ItemStore) SaveItem(ctx context.Context, userID, itemID int64, saved bool) error {
+	return is.setItemState(userID, itemID, saved, func(state *itemState) *time.Time { return &state.savedAt })
+}
+
+// setItemState sets the time field of the user's state of an item to now
+// when on, keeping the time it was first set, and clears it otherwise.
+func (is *ItemStore) setItemState(userID, itemID int64, on bool, field func(*itemState) *time.Time) error {
+	now := is.db.lock()
+	defer is.db.unlock()
+
+	item, ok := is.db.items[itemID]
+	if !ok {
+		return errors.NotFoundf(errors.ErrItemNotFound)
+	}
+
+	feedID := is.db.channels[item.ChannelID].FeedID
+	if _, ok := is.db.userFeeds[userFeedKey{userID: userID, feedID: feedID}]; !ok {
+		return errors.NotFoundf(errors.ErrItemNotFound)
+	}
+
+	key := userItemKey{userID: userID, id: itemID}
+
+	state, ok := is.db.itemStates[key]
+	if !ok {
+		state = &itemState{
+			createdAt: now,
+		}
+		is.db.itemStates[key] = state
+	}
+
+	at := field(state)
+	switch {
+	case !on:
+		*at = time.Time{}
+	case at.IsZero():
+		*at = now
+	}
+	state.modifiedAt = now
+
+	return nil
+}
+
+func (db *DB) upsertItem(item *rf.Item, now time.Time) {
+	// Only items whose content actually changed are updated, so modified_at
+	// is a reliable signal and the item id (and any per-user state keyed on
+	// it) survives every sync.
+	for _, found := range db.items {
+		if found.ChannelID != item.ChannelID || found.IdentityKey != item.IdentityKey {
+			continue
+		}
+
+		// Undated items keep the date they were first stored with.
+		if item.PublishedAt.IsZero() {
+			item.PublishedAt = found.PublishedAt
+		}
+
+		changed := found.GUID != item.GUID || found.Title != item.Title ||
+			found.Description != item.Description || found.Content != item.Content ||
+			found.Link != item.Link || !found.PublishedAt.Equal(item.PublishedAt)
+		if changed {
+			found.GUID = item.GUID
+			found.Title = item.Title
+			found.Description = item.Description
+			found.Content = item.Content
+			found.Link = item.Link
+			found.CanonicalLink = item.CanonicalLink
+			found.SimHash = item.SimHash
+			found.PublishedAt = item.PublishedAt
+			found.ModifiedAt = now
+		}
+
+		item.ID = found.ID
+		item.StoryID = found.StoryID
+		item.CreatedAt = found.CreatedAt
+		item.ModifiedAt = found.ModifiedAt
+
+		return
+	}
+
+	item.ID = db.nextID("feed_channel_items")
+	item.StoryID = 0
+	if item.PublishedAt.IsZero() {
+		item.PublishedAt = now
+	}
+	item.CreatedAt = now
+	item.ModifiedAt = now
+
+	db.items[item.ID] = &rf.Item{
+		ID:            item.ID,
+		ChannelID:     item.ChannelID,
+		GUID:          item.GUID,
+		IdentityKey:   item.IdentityKey,
+		CanonicalLink: item.CanonicalLink,
+		SimHash:       item.SimHash,
+		Title:         item.Title,
+		Description:   item.Description,
+		Content:       item.Content,
+		Link:          item.Link,
+		PublishedAt:   item.PublishedAt,
+		CreatedAt:     item.CreatedAt,
+		ModifiedAt:    item.ModifiedAt,
+	}
+}
+
+func (db *DB) groupItemStory(item *rf.Item, maxDistance int) {
+	feedID := db.channels[item.ChannelID].FeedID
+	since := item.PublishedAt.Add(-storyWindow)
+
+	var match *rf.Item
+	var matchByLink bool
+	for _, other := range db.items {
+		if other.StoryID == 0 || other.PublishedAt.Before(since) || db.channels[other.ChannelID].FeedID == feedID {
+			continue
+		}
+
+		byLink := item.CanonicalLink != "" && other.CanonicalLink == item.CanonicalLink
+		bySimHash := item.SimHash != 0 && other.SimHash != 0 &&
+			bits.OnesCount64(other.SimHash^item.SimHash) <= maxDistance
+		if !byLink && !bySimHash {
+			continue
+		}
+
+		// Items sharing the canonical link come first, then the oldest.
+		sameLink := other.CanonicalLink == item.CanonicalLink
+		if match == nil || (sameLink && !matchByLink) || (sameLink == matchByLink && other.ID < match.ID) {
+			match = other
+			matchByLink = sameLink
+		}
+	}
+
+	if match != nil {
+		item.StoryID = match.StoryID
+	}
+
+	if item.StoryID == 0 {
+		item.StoryID = db.nextID("stories")
+	}
+
+	if stored, ok := db.items[item.ID]; ok {
+		stored.StoryID = item.StoryID
+	}
+}
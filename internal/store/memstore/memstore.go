@@ -0,0 +1,323 @@
+// Package memstore keeps everything in memory with the same semantics as
+// postgresstore, including unique emails and feed urls and the cascading
+// deletes of the schema. It is used by tests that want a real store
+// without Docker and by the API when it runs with --store=memory, which
+// starts empty and forgets everything when it stops.
+package memstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// DB holds the tables every store of a DB shares. Each store method holds
+// the lock throughout, so it sees and leaves the tables consistent the way
+// a transaction does.
+type DB struct {
+	mu  sync.Mutex
+	ids map[string]int64
+
+	users              map[int64]*rf.User
+	auths              map[int64]*rf.Auth
+	sessions           map[int64]*rf.Session
+	refreshTokens      map[string]*rf.RefreshToken
+	apiKeys            map[int64]*rf.APIKey
+	resetTokens        map[string]*token
+	verificationTokens map[string]*token
+	unlockTokens       map[string]*token
+	totps              map[int64]*rf.TOTP
+	recoveryCodes      map[string]*recoveryCode
+	oidcStates         map[string]*oidcState
+	identities         map[int64]*rf.AuthIdentity
+	exports            map[int64]*export
+	auditEvents        []audit.Event
+
+	feeds         map[int64]*feed
+	userFeeds     map[userFeedKey]*userFeed
+	channels      map[int64]*rf.Channel
+	items         map[int64]*rf.Item
+	itemStates    map[userItemKey]*itemState
+	enclosures    map[int64]*rf.Enclosure
+	positions     map[userItemKey]*rf.PlaybackPosition
+	contents      map[int64]*rf.ItemContent
+	subscriptions map[int64]*rf.WebSubSubscription
+
+	Now func() time.Time
+}
+
+func NewDB() *DB {
+	return &DB{
+		ids: map[string]int64{},
+
+		users:              map[int64]*rf.User{},
+		auths:              map[int64]*rf.Auth{},
+		sessions:           map[int64]*rf.Session{},
+		refreshTokens:      map[string]*rf.RefreshToken{},
+		apiKeys:            map[int64]*rf.APIKey{},
+		resetTokens:        map[string]*token{},
+		verificationTokens: map[string]*token{},
+		unlockTokens:       map[string]*token{},
+		totps:              map[int64]*rf.TOTP{},
+		recoveryCodes:      map[string]*recoveryCode{},
+		oidcStates:         map[string]*oidcState{},
+		identities:         map[int64]*rf.AuthIdentity{},
+		exports:            map[int64]*export{},
+
+		feeds:         map[int64]*feed{},
+		userFeeds:     map[userFeedKey]*userFeed{},
+		channels:      map[int64]*rf.Channel{},
+		items:         map[int64]*rf.Item{},
+		itemStates:    map[userItemKey]*itemState{},
+		enclosures:    map[int64]*rf.Enclosure{},
+		positions:     map[userItemKey]*rf.PlaybackPosition{},
+		contents:      map[int64]*rf.ItemContent{},
+		subscriptions: map[int64]*rf.WebSubSubscription{},
+
+		Now: time.Now,
+	}
+}
+
+func (db *DB) Open() error {
+	return nil
+}
+
+func (db *DB) Close() error {
+	return nil
+}
+
+// lock takes the lock and returns the time of what is done under it, it is
+// truncated like the timestamps Postgres keeps.
+func (db *DB) lock() time.Time {
+	db.mu.Lock()
+	return db.Now().UTC().Truncate(time.Second)
+}
+
+func (db *DB) unlock() {
+	db.mu.Unlock()
+}
+
+// Tx is a unit of work, it holds the lock from when it begins until it is
+// committed or rolled back, so only the methods that take it may be called
+// meanwhile. Rolling back undoes its changes.
+type Tx struct {
+	db   *DB
+	now  time.Time
+	undo []func()
+	done bool
+}
+
+func (db *DB) beginTx() *Tx {
+	return &Tx{
+		db:  db,
+		now: db.lock(),
+	}
+}
+
+func (tx *Tx) Commit(ctx context.Context) error {
+	if tx.done {
+		return errors.InternalErrorf("transaction is already done")
+	}
+
+	tx.done = true
+	tx.db.unlock()
+	return nil
+}
+
+// Rollback undoes the changes of the transaction, after Commit it does
+// nothing.
+func (tx *Tx) Rollback(ctx context.Context) error {
+	if tx.done {
+		return nil
+	}
+
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+
+	tx.done = true
+	tx.db.unlock()
+	return nil
+}
+
+// txFrom returns the transaction behind tx, which any store of this package
+// may have begun.
+func txFrom(tx rf.Tx) (*Tx, error) {
+	memTx, ok := tx.(*Tx)
+	if !ok {
+		return nil, errors.InternalErrorf("%T is not a memory transaction", tx)
+	}
+	if memTx.done {
+		return nil, errors.InternalErrorf("transaction is already done")
+	}
+	return memTx, nil
+}
+
+// nextID returns the next identity of table, ids start at 1 and are never
+// reused.
+func (db *DB) nextID(table string) int64 {
+	db.ids[table]++
+	return db.ids[table]
+}
+
+// deleteUser removes the user and every row that references it, as the ON
+// DELETE CASCADE foreign keys of the schema do.
+func (db *DB) deleteUser(userID int64) {
+	delete(db.users, userID)
+	delete(db.auths, userID)
+	delete(db.totps, userID)
+
+	for id, session := range db.sessions {
+		if session.UserID == userID {
+			db.deleteSession(id)
+		}
+	}
+	for id, key := range db.apiKeys {
+		if key.UserID == userID {
+			delete(db.apiKeys, id)
+		}
+	}
+	for _, tokens := range []map[string]*token{db.resetTokens, db.verificationTokens, db.unlockTokens} {
+		for hash, t := range tokens {
+			if t.userID == userID {
+				delete(tokens, hash)
+			}
+		}
+	}
+	for hash, code := range db.recoveryCodes {
+		if code.userID == userID {
+			delete(db.recoveryCodes, hash)
+		}
+	}
+	for id, identity := range db.identities {
+		if identity.UserID == userID {
+			delete(db.identities, id)
+		}
+	}
+	for id, e := range db.exports {
+		if e.UserID == userID {
+			delete(db.exports, id)
+		}
+	}
+	for key := range db.userFeeds {
+		if key.userID == userID {
+			delete(db.userFeeds, key)
+		}
+	}
+	for key := range db.itemStates {
+		if key.userID == userID {
+			delete(db.itemStates, key)
+		}
+	}
+	for key := range db.positions {
+		if key.userID == userID {
+			delete(db.positions, key)
+		}
+	}
+}
+
+func (db *DB) deleteSession(sessionID int64) {
+	delete(db.sessions, sessionID)
+
+	for hash, t := range db.refreshTokens {
+		if t.SessionID == sessionID {
+			delete(db.refreshTokens, hash)
+		}
+	}
+}
+
+// revokeSessions revokes the unrevoked sessions of the user but
+// keepSessionID.
+func (db *DB) revokeSessions(userID, keepSessionID int64, now time.Time) {
+	for _, session := range db.sessions {
+		if session.UserID == userID && session.ID != keepSessionID && session.RevokedAt.IsZero() {
+			session.RevokedAt = now
+		}
+	}
+}
+
+// token is a single use token mailed to a user, email is only set on email
+// verification tokens.
+type token struct {
+	userID    int64
+	email     string
+	createdAt time.Time
+	expiresAt time.Time
+	usedAt    time.Time
+}
+
+// usable reports whether the token can still be spent.
+func (t *token) usable(now time.Time) bool {
+	return t.usedAt.IsZero() && t.expiresAt.After(now)
+}
+
+// spendTokens spends every unused token of the user in tokens.
+func spendTokens(tokens map[string]*token, userID int64, now time.Time) {
+	for _, t := range tokens {
+		if t.userID == userID && t.usedAt.IsZero() {
+			t.usedAt = now
+		}
+	}
+}
+
+type recoveryCode struct {
+	userID int64
+	usedAt time.Time
+}
+
+type oidcState struct {
+	rf.OIDCLoginState
+	usedAt time.Time
+}
+
+type export struct {
+	rf.DataExport
+	startedAt time.Time
+}
+
+type feed struct {
+	id                int64
+	url               string
+	enabled           bool
+	deleted           bool
+	createdAt         time.Time
+	modifiedAt        time.Time
+	lastSyncedAt      time.Time
+	itemIdentity      rf.ItemIdentity
+	pollInterval      time.Duration
+	resyncRequestedAt time.Time
+	lastFetchError    *string
+	lastFetchErrorAt  time.Time
+	fetchFailures     int
+}
+
+type userFeedKey struct {
+	userID int64
+	feedID int64
+}
+
+type userFeed struct {
+	name             string
+	fetchFullContent bool
+	deleted          bool
+	createdAt        time.Time
+	modifiedAt       time.Time
+}
+
+// userItemKey keys state a user keeps about an item or enclosure.
+type userItemKey struct {
+	userID int64
+	id     int64
+}
+
+type itemState struct {
+	readAt     time.Time
+	starredAt  time.Time
+	savedAt    time.Time
+	createdAt  time.Time
+	modifiedAt time.Time
+}
@@ -0,0 +1,29 @@
+package memstore_test
+
+import (
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/limiter"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/memstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/storetest"
+)
+
+func TestMemoryDBConformance(t *testing.T) {
+	t.Parallel()
+
+	db := memstore.NewDB()
+
+	storetest.Run(t, storetest.Stores{
+		Auth:    memstore.NewAuthStore(db),
+		User:    memstore.NewUserStore(db),
+		Feed:    memstore.NewFeedStore(db),
+		Item:    memstore.NewItemStore(db),
+		Sync:    memstore.NewFeedStore(db),
+		WebSub:  memstore.NewWebSubStore(db),
+		Export:  memstore.NewExportStore(db),
+		Admin:   memstore.NewAdminStore(db),
+		Audit:   memstore.NewAuditStore(db),
+		Limiter: limiter.NewMemoryLimiter(),
+		Now:     &db.Now,
+	})
+}
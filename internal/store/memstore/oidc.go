@@ -0,0 +1,106 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateOIDCLoginState(ctx context.Context, state *rf.OIDCLoginState) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	state.CreatedAt = now
+
+	as.db.oidcStates[state.StateHash] = &oidcState{
+		OIDCLoginState: *state,
+	}
+
+	return nil
+}
+
+// UseOIDCLoginState spends the state of a sign in started with provider.
+func (as *AuthStore) UseOIDCLoginState(ctx context.Context, provider, stateHash string) (*rf.OIDCLoginState, error) {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	found, ok := as.db.oidcStates[stateHash]
+	if !ok || found.Provider != provider || !found.usedAt.IsZero() || !found.ExpiresAt.After(now) {
+		return nil, errors.InvalidDataf(errors.ErrOIDCStateInvalid)
+	}
+
+	found.usedAt = now
+
+	state := found.OIDCLoginState
+	return &state, nil
+}
+
+// FindIdentity returns the identity of subject at issuer, or nil when it
+// is not linked to an account.
+func (as *AuthStore) FindIdentity(ctx context.Context, issuer, subject string) (*rf.AuthIdentity, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	for _, found := range as.db.identities {
+		if found.Issuer == issuer && found.Subject == subject {
+			identity := *found
+			return &identity, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (as *AuthStore) CreateIdentity(ctx context.Context, identity *rf.AuthIdentity) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	return as.db.createIdentity(identity, now)
+}
+
+// CreateAuthAndUserWithIdentity signs up a user of an OpenID Connect
+// provider, the account has no password until one is reset.
+func (as *AuthStore) CreateAuthAndUserWithIdentity(ctx context.Context, auth *rf.Auth, identity *rf.AuthIdentity) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	if as.db.identityLinked(identity) {
+		return errors.InvalidDataf(errors.ErrOIDCIdentityLinked)
+	}
+
+	err := as.db.createAuthAndUser(auth, now)
+	if err != nil {
+		return err
+	}
+
+	identity.UserID = auth.UserID
+
+	return as.db.createIdentity(identity, now)
+}
+
+func (db *DB) createIdentity(identity *rf.AuthIdentity, now time.Time) error {
+	if db.identityLinked(identity) {
+		return errors.InvalidDataf(errors.ErrOIDCIdentityLinked)
+	}
+
+	identity.ID = db.nextID("auth_identities")
+	identity.CreatedAt = now
+
+	stored := *identity
+	db.identities[identity.ID] = &stored
+
+	return nil
+}
+
+// identityLinked reports whether the subject of identity at its issuer is
+// already linked to an account.
+func (db *DB) identityLinked(identity *rf.AuthIdentity) bool {
+	for _, found := range db.identities {
+		if found.Issuer == identity.Issuer && found.Subject == identity.Subject {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreatePasswordResetToken(ctx context.Context, t *rf.PasswordResetToken) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	t.CreatedAt = now
+
+	as.db.resetTokens[t.TokenHash] = &token{
+		userID:    t.UserID,
+		createdAt: t.CreatedAt,
+		expiresAt: t.ExpiresAt,
+	}
+
+	return nil
+}
+
+// ResetPassword spends a reset token, sets the new password and revokes
+// every session of the user. It returns the id of the user.
+func (as *AuthStore) ResetPassword(ctx context.Context, tokenHash, hashedPassword string) (int64, error) {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	t, ok := as.db.resetTokens[tokenHash]
+	if !ok || !t.usable(now) {
+		return 0, errors.InvalidDataf(errors.ErrResetTokenInvalid)
+	}
+
+	as.db.changePassword(t.userID, 0, hashedPassword, now)
+
+	return t.userID, nil
+}
+
+// ChangePassword sets a new password, spends outstanding reset tokens and
+// revokes every session of the user but keepSessionID.
+func (as *AuthStore) ChangePassword(ctx context.Context, userID, keepSessionID int64, hashedPassword string) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	as.db.changePassword(userID, keepSessionID, hashedPassword, now)
+
+	return nil
+}
+
+func (db *DB) changePassword(userID, keepSessionID int64, hashedPassword string, now time.Time) {
+	if auth, ok := db.auths[userID]; ok {
+		auth.BasicAuth.Password = hashedPassword
+		auth.ModifiedAt = now
+	}
+
+	spendTokens(db.resetTokens, userID, now)
+	db.revokeSessions(userID, keepSessionID, now)
+}
+
+// RehashPassword replaces a password hash with one of the same password
+// made with stronger parameters, unless the password changed since
+// oldHash was read. Sessions are kept as the password is the same.
+func (as *AuthStore) RehashPassword(ctx context.Context, userID int64, oldHash, newHash string) error {
+	as.db.lock()
+	defer as.db.unlock()
+
+	if auth, ok := as.db.auths[userID]; ok && auth.BasicAuth.Password == oldHash {
+		auth.BasicAuth.Password = newHash
+	}
+
+	return nil
+}
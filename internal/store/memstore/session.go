@@ -0,0 +1,132 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateSession(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	session.ID = as.db.nextID("sessions")
+	session.CreatedAt = now
+	session.LastUsedAt = now
+
+	stored := *session
+	stored.Current = false
+	as.db.sessions[session.ID] = &stored
+
+	token.SessionID = session.ID
+	as.db.createRefreshToken(token, now)
+
+	return nil
+}
+
+func (as *AuthStore) FindRefreshToken(ctx context.Context, tokenHash string) (*rf.RefreshToken, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	found, ok := as.db.refreshTokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+
+	token := *found
+	return &token, nil
+}
+
+func (as *AuthStore) FindSessionByID(ctx context.Context, sessionID int64) (*rf.Session, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	found, ok := as.db.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+
+	session := *found
+	return &session, nil
+}
+
+func (as *AuthStore) RotateRefreshToken(ctx context.Context, session *rf.Session, used, next *rf.RefreshToken) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	// Only one of two concurrent refreshes with the same token may win, the
+	// loser is treated as a reuse.
+	found, ok := as.db.refreshTokens[used.TokenHash]
+	if !ok || !found.UsedAt.IsZero() {
+		return errors.Unauthorizedf(errors.ErrRefreshTokenReused)
+	}
+	found.UsedAt = now
+
+	next.SessionID = session.ID
+	as.db.createRefreshToken(next, now)
+
+	session.LastUsedAt = now
+
+	if stored, ok := as.db.sessions[session.ID]; ok {
+		stored.IP = session.IP
+		stored.UserAgent = session.UserAgent
+		stored.LastUsedAt = session.LastUsedAt
+		stored.ExpiresAt = session.ExpiresAt
+	}
+
+	return nil
+}
+
+func (as *AuthStore) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	session, ok := as.db.sessions[sessionID]
+	if !ok || session.UserID != userID {
+		return errors.NotFoundf(errors.ErrSessionNotFound)
+	}
+
+	if session.RevokedAt.IsZero() {
+		session.RevokedAt = now
+	}
+
+	return nil
+}
+
+func (as *AuthStore) ListUserSessions(ctx context.Context, userID int64) ([]rf.Session, error) {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	sessions := []rf.Session{}
+	for _, session := range as.db.sessions {
+		if session.UserID == userID && session.RevokedAt.IsZero() && session.ExpiresAt.After(now) {
+			sessions = append(sessions, *session)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b rf.Session) int {
+		return cmp.Or(b.LastUsedAt.Compare(a.LastUsedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	return sessions, nil
+}
+
+func (as *AuthStore) RevokeOtherSessions(ctx context.Context, userID, keepSessionID int64) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	as.db.revokeSessions(userID, keepSessionID, now)
+
+	return nil
+}
+
+func (db *DB) createRefreshToken(token *rf.RefreshToken, now time.Time) {
+	token.CreatedAt = now
+
+	stored := *token
+	db.refreshTokens[token.TokenHash] = &stored
+}
@@ -0,0 +1,122 @@
+package memstore
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// SaveTOTPSecret starts enrolment with a new secret, replacing the secret
+// of an enrolment that was never confirmed.
+func (as *AuthStore) SaveTOTPSecret(ctx context.Context, totp *rf.TOTP) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	if found, ok := as.db.totps[totp.UserID]; ok && !found.EnabledAt.IsZero() {
+		return errors.InvalidDataf(errors.ErrTOTPAlreadyEnabled)
+	}
+
+	totp.CreatedAt = now
+
+	as.db.totps[totp.UserID] = &rf.TOTP{
+		UserID:    totp.UserID,
+		Secret:    totp.Secret,
+		CreatedAt: totp.CreatedAt,
+	}
+
+	return nil
+}
+
+func (as *AuthStore) FindTOTP(ctx context.Context, userID int64) (*rf.TOTP, error) {
+	as.db.lock()
+	defer as.db.unlock()
+
+	found, ok := as.db.totps[userID]
+	if !ok {
+		return nil, nil
+	}
+
+	totp := *found
+	return &totp, nil
+}
+
+// EnableTOTP confirms enrolment with the step of the first code and
+// replaces the recovery codes of the user.
+func (as *AuthStore) EnableTOTP(ctx context.Context, userID, step int64, recoveryCodeHashes []string) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	totp, ok := as.db.totps[userID]
+	if !ok || !totp.EnabledAt.IsZero() || totp.LastUsedStep >= step {
+		return errors.InvalidDataf(errors.ErrTOTPCodeInvalid)
+	}
+
+	totp.EnabledAt = now
+	totp.LastUsedStep = step
+
+	as.db.replaceRecoveryCodes(userID, recoveryCodeHashes)
+
+	return nil
+}
+
+// UseTOTPStep spends the step of a code, a step at or before the last one
+// used is a replayed code.
+func (as *AuthStore) UseTOTPStep(ctx context.Context, userID, step int64) error {
+	as.db.lock()
+	defer as.db.unlock()
+
+	totp, ok := as.db.totps[userID]
+	if !ok || totp.EnabledAt.IsZero() || totp.LastUsedStep >= step {
+		return errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)
+	}
+
+	totp.LastUsedStep = step
+
+	return nil
+}
+
+func (as *AuthStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	code, ok := as.db.recoveryCodes[codeHash]
+	if !ok || code.userID != userID || !code.usedAt.IsZero() {
+		return errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)
+	}
+
+	code.usedAt = now
+
+	return nil
+}
+
+// DisableTOTP removes the secret and recovery codes of the user, step is
+// of the code that confirmed it so a replayed code cannot.
+func (as *AuthStore) DisableTOTP(ctx context.Context, userID, step int64) error {
+	as.db.lock()
+	defer as.db.unlock()
+
+	totp, ok := as.db.totps[userID]
+	if !ok || totp.EnabledAt.IsZero() || totp.LastUsedStep >= step {
+		return errors.InvalidDataf(errors.ErrTOTPCodeInvalid)
+	}
+
+	delete(as.db.totps, userID)
+	as.db.replaceRecoveryCodes(userID, nil)
+
+	return nil
+}
+
+func (db *DB) replaceRecoveryCodes(userID int64, codeHashes []string) {
+	for hash, code := range db.recoveryCodes {
+		if code.userID == userID {
+			delete(db.recoveryCodes, hash)
+		}
+	}
+
+	for _, hash := range codeHashes {
+		db.recoveryCodes[hash] = &recoveryCode{
+			userID: userID,
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package memstore
+
+import (
+	"context"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateUnlockToken(ctx context.Context, t *rf.UnlockToken) error {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	t.CreatedAt = now
+
+	as.db.unlockTokens[t.TokenHash] = &token{
+		userID:    t.UserID,
+		createdAt: t.CreatedAt,
+		expiresAt: t.ExpiresAt,
+	}
+
+	return nil
+}
+
+// UseUnlockToken spends an unlock token and returns the auth it unlocks.
+func (as *AuthStore) UseUnlockToken(ctx context.Context, tokenHash string) (*rf.Auth, error) {
+	now := as.db.lock()
+	defer as.db.unlock()
+
+	t, ok := as.db.unlockTokens[tokenHash]
+	if !ok || !t.usable(now) {
+		return nil, errors.InvalidDataf(errors.ErrUnlockTokenInvalid)
+	}
+
+	auth := as.db.findAuth(func(a *rf.Auth) bool { return a.UserID == t.userID })
+	if auth == nil {
+		return nil, errors.InvalidDataf(errors.ErrUnlockTokenInvalid)
+	}
+
+	t.usedAt = now
+
+	return auth, nil
+}
@@ -0,0 +1,88 @@
+package memstore
+
+import (
+	"context"
+	"encoding/json"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type UserStore struct {
+	db *DB
+}
+
+func NewUserStore(db *DB) *UserStore {
+	return &UserStore{
+		db: db,
+	}
+}
+
+// FindUser returns the user, or nil when there is none.
+func (us *UserStore) FindUser(ctx context.Context, userID int64) (*rf.User, error) {
+	us.db.lock()
+	defer us.db.unlock()
+
+	found, ok := us.db.users[userID]
+	if !ok {
+		return nil, nil
+	}
+
+	return copyUser(found)
+}
+
+func (us *UserStore) UpdateUser(ctx context.Context, user *rf.User) error {
+	now := us.db.lock()
+	defer us.db.unlock()
+
+	found, ok := us.db.users[user.ID]
+	if !ok {
+		return errors.NotFoundf(errors.ErrUserNotFound)
+	}
+
+	preferences, err := copyPreferences(user.Preferences)
+	if err != nil {
+		return err
+	}
+
+	user.ModifiedAt = now
+
+	found.Name = user.Name
+	found.Timezone = user.Timezone
+	found.Preferences = preferences
+	found.ModifiedAt = user.ModifiedAt
+
+	return nil
+}
+
+// copyUser copies user so callers cannot change the stored preferences.
+func copyUser(user *rf.User) (*rf.User, error) {
+	preferences, err := copyPreferences(user.Preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	c := *user
+	c.Preferences = preferences
+	return &c, nil
+}
+
+// copyPreferences deep copies preferences by a round trip through JSON,
+// which also leaves them as a jsonb column would.
+func copyPreferences(preferences map[string]any) (map[string]any, error) {
+	if preferences == nil {
+		return map[string]any{}, nil
+	}
+
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	c := map[string]any{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
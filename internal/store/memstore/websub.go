@@ -0,0 +1,84 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type WebSubStore struct {
+	db *DB
+}
+
+func NewWebSubStore(db *DB) *WebSubStore {
+	return &WebSubStore{
+		db: db,
+	}
+}
+
+func (ws *WebSubStore) FindSubscriptionByFeedID(ctx context.Context, feedID int64) (*rf.WebSubSubscription, error) {
+	ws.db.lock()
+	defer ws.db.unlock()
+
+	found, ok := ws.db.subscriptions[feedID]
+	if !ok {
+		return nil, nil
+	}
+
+	sub := *found
+	return &sub, nil
+}
+
+func (ws *WebSubStore) SaveSubscription(ctx context.Context, sub *rf.WebSubSubscription) error {
+	now := ws.db.lock()
+	defer ws.db.unlock()
+
+	if _, ok := ws.db.feeds[sub.FeedID]; !ok {
+		return errors.InternalErrorf("feed %d does not exist", sub.FeedID)
+	}
+
+	if found, ok := ws.db.subscriptions[sub.FeedID]; ok {
+		sub.ID = found.ID
+		sub.CreatedAt = found.CreatedAt
+	} else {
+		sub.ID = ws.db.nextID("websub_subscriptions")
+		sub.CreatedAt = now
+	}
+	sub.ModifiedAt = now
+
+	stored := *sub
+	ws.db.subscriptions[sub.FeedID] = &stored
+
+	return nil
+}
+
+func (ws *WebSubStore) ListExpiringSubscriptions(ctx context.Context, before time.Time) ([]rf.WebSubSubscription, error) {
+	ws.db.lock()
+	defer ws.db.unlock()
+
+	subs := []rf.WebSubSubscription{}
+	for _, sub := range ws.db.subscriptions {
+		if sub.State == rf.WebSubStateActive && !sub.ExpiresAt.IsZero() && sub.ExpiresAt.Before(before) {
+			subs = append(subs, *sub)
+		}
+	}
+
+	slices.SortFunc(subs, func(a, b rf.WebSubSubscription) int { return a.ExpiresAt.Compare(b.ExpiresAt) })
+
+	return subs, nil
+}
+
+func (ws *WebSubStore) SetFeedPollInterval(ctx context.Context, feedID int64, interval time.Duration) error {
+	now := ws.db.lock()
+	defer ws.db.unlock()
+
+	if f, ok := ws.db.feeds[feedID]; ok {
+		f.pollInterval = interval.Truncate(time.Second)
+		f.modifiedAt = now
+	}
+
+	return nil
+}
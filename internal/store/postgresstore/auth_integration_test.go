@@ -7,6 +7,7 @@ import (
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/authservice"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/postgresstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlstore"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/testcontainers"
 	"github.com/matryer/is"
 )
@@ -36,7 +37,7 @@ func TestPostgresDBAuthServiceIntegration(t *testing.T) {
 		is.NoErr(err) // failed to terminate pgContainer
 	})
 
-	authStore := postgresstore.NewAuthStore(container.DB)
+	authStore := sqlstore.NewAuthStore(container.DB)
 	authService := authservice.NewAuthService(authStore)
 
 	signUpSuccess := builder.NewSignUpRequestBuilder().
@@ -45,20 +46,20 @@ func TestPostgresDBAuthServiceIntegration(t *testing.T) {
 		WithPassword("gogopher1").
 		Build()
 
-	token, err := authService.SignUp(ctx, signUpSuccess)
+	tokens, err := authService.SignUp(ctx, signUpSuccess)
 
-	is.NoErr(err)           // should sign up
-	is.True(len(token) > 0) // should receive token
+	is.NoErr(err)                        // should sign up
+	is.True(len(tokens.AccessToken) > 0) // should receive token
 
 	signInSuccess := builder.NewSignInRequestBuilder().
 		WithEmail("gopher1@go.com").
 		WithPassword("gogopher1").
 		Build()
 
-	token, err = authService.SignIn(ctx, signInSuccess)
+	tokens, err = authService.SignIn(ctx, signInSuccess)
 
-	is.NoErr(err)           // should sign in
-	is.True(len(token) > 0) // should receive token
+	is.NoErr(err)                        // should sign in
+	is.True(len(tokens.AccessToken) > 0) // should receive token
 
 	signUpFailure := builder.NewSignUpRequestBuilder().
 		WithName("Gopher").
@@ -66,28 +67,28 @@ func TestPostgresDBAuthServiceIntegration(t *testing.T) {
 		WithPassword("gogopher1").
 		Build()
 
-	token, err = authService.SignUp(ctx, signUpFailure)
+	tokens, err = authService.SignUp(ctx, signUpFailure)
 
-	is.True(err != nil)      // should fail to sign up with duplicate email
-	is.True(len(token) == 0) // should receive no token
+	is.True(err != nil)    // should fail to sign up with duplicate email
+	is.True(tokens == nil) // should receive no token
 
 	signInEmailFailure := builder.NewSignInRequestBuilder().
 		WithEmail("gopher2@go.com").
 		WithPassword("gogopher1").
 		Build()
 
-	token, err = authService.SignIn(ctx, signInEmailFailure)
+	tokens, err = authService.SignIn(ctx, signInEmailFailure)
 
-	is.True(err != nil)      // should fail to sign in with incorrect email
-	is.True(len(token) == 0) // should receive no token
+	is.True(err != nil)    // should fail to sign in with incorrect email
+	is.True(tokens == nil) // should receive no token
 
 	signInPasswordFailure := builder.NewSignInRequestBuilder().
 		WithEmail("gopher1@go.com").
 		WithPassword("gogophe2").
 		Build()
 
-	token, err = authService.SignIn(ctx, signInPasswordFailure)
+	tokens, err = authService.SignIn(ctx, signInPasswordFailure)
 
-	is.True(err != nil)      // should fail to sign in with incorrect email
-	is.True(len(token) == 0) // should receive no token
+	is.True(err != nil)    // should fail to sign in with incorrect email
+	is.True(tokens == nil) // should receive no token
 }
@@ -0,0 +1,52 @@
+package postgresstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/postgresstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/storetest"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/testcontainers"
+	"github.com/matryer/is"
+)
+
+func TestPostgresDBConformanceIntegration(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	ctx := context.Background()
+
+	container, err := testcontainers.NewPostgres(ctx)
+	is.NoErr(err)
+
+	migration, err := postgresstore.NewPostgresMigration(container.DB, "migrations")
+	is.NoErr(err)
+
+	migration.Up()
+	is.NoErr(err)
+
+	t.Cleanup(func() {
+		err := migration.Reset()
+		is.NoErr(err)
+		err = migration.Close()
+		is.NoErr(err)
+		err = container.Cleanup(ctx)
+		is.NoErr(err) // failed to terminate pgContainer
+	})
+
+	storetest.Run(t, storetest.Stores{
+		Auth:    sqlstore.NewAuthStore(container.DB),
+		User:    sqlstore.NewUserStore(container.DB),
+		Feed:    sqlstore.NewFeedStore(container.DB),
+		Item:    sqlstore.NewItemStore(container.DB),
+		Sync:    sqlstore.NewFeedStore(container.DB),
+		WebSub:  sqlstore.NewWebSubStore(container.DB),
+		Export:  sqlstore.NewExportStore(container.DB),
+		Admin:   sqlstore.NewAdminStore(container.DB),
+		Audit:   sqlstore.NewAuditStore(container.DB),
+		Limiter: sqlstore.NewLimiter(container.DB),
+		Now:     &container.DB.Now,
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/authservice"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/feedservice"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/postgresstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlstore"
 	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/testcontainers"
 	"github.com/matryer/is"
 )
@@ -38,10 +39,10 @@ func TestPostgresDBFeedServiceIntegration(t *testing.T) {
 		is.NoErr(err) // failed to terminate pgContainer
 	})
 
-	authStore := postgresstore.NewAuthStore(container.DB)
+	authStore := sqlstore.NewAuthStore(container.DB)
 	authService := authservice.NewAuthService(authStore)
 
-	feedStore := postgresstore.NewFeedStore(container.DB)
+	feedStore := sqlstore.NewFeedStore(container.DB)
 	feedService := feedservice.NewFeedService(feedStore)
 
 	signUpReq := builder.NewSignUpRequestBuilder().
@@ -50,9 +51,9 @@ func TestPostgresDBFeedServiceIntegration(t *testing.T) {
 		WithPassword("gogopher1").
 		Build()
 
-	token, err := authService.SignUp(ctx, signUpReq)
-	is.NoErr(err)           // should sign up
-	is.True(len(token) > 0) // should receive token
+	tokens, err := authService.SignUp(ctx, signUpReq)
+	is.NoErr(err)                        // should sign up
+	is.True(len(tokens.AccessToken) > 0) // should receive token
 
 	userID := int64(1)
 
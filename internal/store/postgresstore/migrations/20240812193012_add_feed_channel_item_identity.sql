@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE feeds
+  ADD COLUMN item_identity text NOT NULL DEFAULT 'guid',
+  ADD CONSTRAINT check_item_identity CHECK (item_identity IN ('guid', 'link', 'hash'));
+
+-- A feed could end up with more than one channel, the items of the others
+-- move to its oldest channel before they are dropped.
+UPDATE feed_channel_items
+  SET feed_channel_id = kept.id
+  FROM feed_channels AS duplicate
+  JOIN (SELECT feed_id, min(id) AS id FROM feed_channels GROUP BY feed_id) AS kept
+    ON kept.feed_id = duplicate.feed_id
+  WHERE feed_channel_items.feed_channel_id = duplicate.id AND duplicate.id <> kept.id;
+
+DELETE FROM feed_channels
+  WHERE id NOT IN (SELECT min(id) FROM feed_channels GROUP BY feed_id);
+
+ALTER TABLE feed_channels
+  ADD CONSTRAINT unique_feed_channel UNIQUE (feed_id);
+
+ALTER TABLE feed_channel_items
+  ADD COLUMN guid text NOT NULL DEFAULT '',
+  ADD COLUMN content text NOT NULL DEFAULT '',
+  ADD COLUMN identity_key text,
+  ADD COLUMN published_at timestamp;
+
+UPDATE feed_channel_items SET identity_key = 'id:' || id, published_at = created_at;
+
+ALTER TABLE feed_channel_items
+  ALTER COLUMN identity_key SET NOT NULL,
+  ALTER COLUMN published_at SET NOT NULL;
+
+CREATE UNIQUE INDEX unique_feed_channel_item_identity ON feed_channel_items (feed_channel_id, identity_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS unique_feed_channel_item_identity;
+
+ALTER TABLE feed_channel_items
+  DROP COLUMN IF EXISTS published_at,
+  DROP COLUMN IF EXISTS identity_key,
+  DROP COLUMN IF EXISTS content,
+  DROP COLUMN IF EXISTS guid;
+
+ALTER TABLE feed_channels
+  DROP CONSTRAINT IF EXISTS unique_feed_channel;
+
+ALTER TABLE feeds
+  DROP CONSTRAINT IF EXISTS check_item_identity,
+  DROP COLUMN IF EXISTS item_identity;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS stories (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  created_at timestamp NOT NULL,
+  CONSTRAINT pk_stories PRIMARY KEY (id)
+);
+
+ALTER TABLE feed_channel_items
+  ADD COLUMN canonical_link text NOT NULL DEFAULT '',
+  ADD COLUMN simhash bigint NOT NULL DEFAULT 0,
+  ADD COLUMN story_id bigint,
+  ADD CONSTRAINT fk_story FOREIGN KEY (story_id) REFERENCES stories (id) ON DELETE SET NULL;
+
+CREATE INDEX index_feed_channel_items_story_id ON feed_channel_items (story_id);
+CREATE INDEX index_feed_channel_items_canonical_link ON feed_channel_items (canonical_link);
+CREATE INDEX index_feed_channel_items_published_at ON feed_channel_items (published_at);
+
+CREATE TABLE IF NOT EXISTS user_item_states (
+  user_id bigint NOT NULL,
+  item_id bigint NOT NULL,
+  read_at timestamp,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT pk_user_item_states PRIMARY KEY (user_id, item_id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_item FOREIGN KEY (item_id) REFERENCES feed_channel_items (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_item_states;
+
+DROP INDEX IF EXISTS index_feed_channel_items_published_at;
+DROP INDEX IF EXISTS index_feed_channel_items_canonical_link;
+DROP INDEX IF EXISTS index_feed_channel_items_story_id;
+
+ALTER TABLE feed_channel_items
+  DROP CONSTRAINT IF EXISTS fk_story,
+  DROP COLUMN IF EXISTS story_id,
+  DROP COLUMN IF EXISTS simhash,
+  DROP COLUMN IF EXISTS canonical_link;
+
+DROP TABLE IF EXISTS stories;
+-- +goose StatementEnd
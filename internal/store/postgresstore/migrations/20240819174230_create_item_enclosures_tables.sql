@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS item_enclosures (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  item_id bigint NOT NULL,
+  url text NOT NULL,
+  mime_type text NOT NULL,
+  length bigint NOT NULL DEFAULT 0,
+  medium text NOT NULL,
+  width integer NOT NULL DEFAULT 0,
+  height integer NOT NULL DEFAULT 0,
+  duration_seconds integer NOT NULL DEFAULT 0,
+  thumbnail_url text NOT NULL DEFAULT '',
+  image_url text NOT NULL DEFAULT '',
+  episode integer NOT NULL DEFAULT 0,
+  explicit boolean NOT NULL DEFAULT FALSE,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT pk_item_enclosures PRIMARY KEY (id),
+  CONSTRAINT fk_item FOREIGN KEY (item_id) REFERENCES feed_channel_items (id) ON DELETE CASCADE,
+  CONSTRAINT unique_item_enclosure_url UNIQUE (item_id, url)
+);
+
+CREATE TABLE IF NOT EXISTS user_playback_positions (
+  user_id bigint NOT NULL,
+  enclosure_id bigint NOT NULL,
+  position_seconds integer NOT NULL,
+  completed boolean NOT NULL DEFAULT FALSE,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT pk_user_playback_positions PRIMARY KEY (user_id, enclosure_id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_enclosure FOREIGN KEY (enclosure_id) REFERENCES item_enclosures (id) ON DELETE CASCADE,
+  CONSTRAINT check_position_seconds CHECK (position_seconds >= 0)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_playback_positions;
+
+DROP TABLE IF EXISTS item_enclosures;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE feeds
+  ADD COLUMN poll_interval_seconds integer NOT NULL DEFAULT 1800;
+
+CREATE TABLE IF NOT EXISTS websub_subscriptions (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  feed_id bigint NOT NULL,
+  hub_url text NOT NULL,
+  topic_url text NOT NULL,
+  secret text NOT NULL,
+  state text NOT NULL,
+  lease_seconds integer NOT NULL,
+  expires_at timestamp,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT pk_websub_subscriptions PRIMARY KEY (id),
+  CONSTRAINT fk_feed FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE,
+  CONSTRAINT unique_websub_subscription_feed UNIQUE (feed_id),
+  CONSTRAINT check_state CHECK (state IN ('pending', 'active', 'denied', 'unsubscribed'))
+);
+
+CREATE INDEX index_websub_subscriptions_expires_at ON websub_subscriptions (expires_at) WHERE state = 'active';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS websub_subscriptions;
+
+ALTER TABLE feeds
+  DROP COLUMN IF EXISTS poll_interval_seconds;
+-- +goose StatementEnd
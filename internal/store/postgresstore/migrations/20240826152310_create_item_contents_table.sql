@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_feeds ADD COLUMN IF NOT EXISTS fetch_full_content boolean NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS item_contents (
+  item_id bigint NOT NULL,
+  content text NOT NULL DEFAULT '',
+  error text NOT NULL DEFAULT '',
+  fetched_at timestamp NOT NULL,
+  CONSTRAINT pk_item_contents PRIMARY KEY (item_id),
+  CONSTRAINT fk_item FOREIGN KEY (item_id) REFERENCES feed_channel_items (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS item_contents;
+
+ALTER TABLE user_feeds DROP COLUMN IF EXISTS fetch_full_content;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sessions (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  user_id bigint NOT NULL,
+  device_name text NOT NULL DEFAULT '',
+  ip text NOT NULL DEFAULT '',
+  user_agent text NOT NULL DEFAULT '',
+  created_at timestamp NOT NULL,
+  last_used_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  revoked_at timestamp,
+  CONSTRAINT pk_sessions PRIMARY KEY (id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT check_device_name_length CHECK (char_length(device_name)<=100)
+);
+
+CREATE INDEX IF NOT EXISTS index_sessions_user_id ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS session_refresh_tokens (
+  token_hash text NOT NULL,
+  session_id bigint NOT NULL,
+  created_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_session_refresh_tokens PRIMARY KEY (token_hash),
+  CONSTRAINT fk_session FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS index_session_refresh_tokens_session_id ON session_refresh_tokens (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS session_refresh_tokens;
+
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
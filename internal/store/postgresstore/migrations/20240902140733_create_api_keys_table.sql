@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_keys (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  user_id bigint NOT NULL,
+  name text NOT NULL,
+  prefix text NOT NULL,
+  key_hash text NOT NULL,
+  scope text NOT NULL,
+  created_at timestamp NOT NULL,
+  last_used_at timestamp,
+  expires_at timestamp,
+  revoked_at timestamp,
+  CONSTRAINT pk_api_keys PRIMARY KEY (id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT unique_api_key_hash UNIQUE (key_hash),
+  CONSTRAINT check_api_key_scope CHECK (scope IN ('read', 'read_write')),
+  CONSTRAINT check_api_key_name_length CHECK (char_length(name)<=100)
+);
+
+CREATE INDEX IF NOT EXISTS index_api_keys_user_id ON api_keys (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
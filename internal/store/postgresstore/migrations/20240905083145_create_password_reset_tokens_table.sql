@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+  token_hash text NOT NULL,
+  user_id bigint NOT NULL,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_password_reset_tokens PRIMARY KEY (token_hash),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS index_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS password_reset_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS email_verified_at timestamp;
+
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+  token_hash text NOT NULL,
+  user_id bigint NOT NULL,
+  email text NOT NULL,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_email_verification_tokens PRIMARY KEY (token_hash),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT check_email_length CHECK (char_length(email)<=256)
+);
+
+CREATE INDEX IF NOT EXISTS index_email_verification_tokens_user_id ON email_verification_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS email_verification_tokens;
+
+ALTER TABLE auths DROP COLUMN IF EXISTS email_verified_at;
+-- +goose StatementEnd
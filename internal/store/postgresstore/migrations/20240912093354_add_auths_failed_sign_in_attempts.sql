@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS failed_sign_in_attempts int NOT NULL DEFAULT 0;
+
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS last_failed_sign_in_at timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE auths DROP COLUMN IF EXISTS last_failed_sign_in_at;
+
+ALTER TABLE auths DROP COLUMN IF EXISTS failed_sign_in_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS limiter_failures (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  key text NOT NULL,
+  failed_at timestamp NOT NULL,
+  CONSTRAINT pk_limiter_failures PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS index_limiter_failures_key_failed_at ON limiter_failures (key, failed_at);
+
+CREATE TABLE IF NOT EXISTS account_unlock_tokens (
+  token_hash text NOT NULL,
+  user_id bigint NOT NULL,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_account_unlock_tokens PRIMARY KEY (token_hash),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS index_account_unlock_tokens_user_id ON account_unlock_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_unlock_tokens;
+
+DROP TABLE IF EXISTS limiter_failures;
+-- +goose StatementEnd
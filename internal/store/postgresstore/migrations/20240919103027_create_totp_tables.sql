@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS auth_totps (
+  user_id bigint NOT NULL,
+  secret text NOT NULL,
+  created_at timestamp NOT NULL,
+  enabled_at timestamp,
+  last_used_step bigint NOT NULL DEFAULT 0,
+  CONSTRAINT pk_auth_totps PRIMARY KEY (user_id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+  code_hash text NOT NULL,
+  user_id bigint NOT NULL,
+  created_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_totp_recovery_codes PRIMARY KEY (code_hash),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS index_totp_recovery_codes_user_id ON totp_recovery_codes (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS totp_recovery_codes;
+
+DROP TABLE IF EXISTS auth_totps;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+  state_hash text NOT NULL,
+  provider text NOT NULL,
+  nonce text NOT NULL,
+  code_verifier text NOT NULL,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_oidc_login_states PRIMARY KEY (state_hash)
+);
+
+CREATE TABLE IF NOT EXISTS auth_identities (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  user_id bigint NOT NULL,
+  issuer text NOT NULL,
+  subject text NOT NULL,
+  email text NOT NULL,
+  created_at timestamp NOT NULL,
+  CONSTRAINT pk_auth_identities PRIMARY KEY (id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT unique_issuer_subject UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS index_auth_identities_user_id ON auth_identities (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS auth_identities;
+
+DROP TABLE IF EXISTS oidc_login_states;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone text NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferences jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS purge_at timestamp;
+
+CREATE INDEX IF NOT EXISTS index_auths_purge_at ON auths (purge_at) WHERE purge_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS index_auths_purge_at;
+
+ALTER TABLE auths DROP COLUMN IF EXISTS purge_at;
+
+ALTER TABLE auths DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS preferences;
+
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+-- +goose StatementEnd
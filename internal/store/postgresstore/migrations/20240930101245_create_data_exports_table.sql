@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS data_exports (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  user_id bigint NOT NULL,
+  state text NOT NULL DEFAULT 'pending',
+  size bigint NOT NULL DEFAULT 0,
+  created_at timestamp NOT NULL,
+  started_at timestamp,
+  completed_at timestamp,
+  expires_at timestamp NOT NULL,
+  CONSTRAINT pk_data_exports PRIMARY KEY (id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT check_data_export_state CHECK (state IN ('pending', 'running', 'ready', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS index_data_exports_user_id ON data_exports (user_id);
+
+CREATE INDEX IF NOT EXISTS index_data_exports_state ON data_exports (state, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS data_exports;
+-- +goose StatementEnd
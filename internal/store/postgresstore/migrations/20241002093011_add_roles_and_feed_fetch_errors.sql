@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'user'
+  CHECK (role IN ('user', 'admin'));
+
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS resync_requested_at timestamp;
+
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_fetch_error text;
+
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_fetch_error_at timestamp;
+
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS fetch_failures integer NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS index_feeds_last_fetch_error_at ON feeds (last_fetch_error_at DESC)
+  WHERE last_fetch_error IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS index_feeds_last_fetch_error_at;
+
+ALTER TABLE feeds DROP COLUMN IF EXISTS fetch_failures;
+
+ALTER TABLE feeds DROP COLUMN IF EXISTS last_fetch_error_at;
+
+ALTER TABLE feeds DROP COLUMN IF EXISTS last_fetch_error;
+
+ALTER TABLE feeds DROP COLUMN IF EXISTS resync_requested_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_events (
+  id bigint GENERATED ALWAYS AS IDENTITY,
+  type text NOT NULL,
+  actor_id bigint,
+  target_type text,
+  target_id bigint,
+  ip text NOT NULL DEFAULT '',
+  user_agent text NOT NULL DEFAULT '',
+  request_id text NOT NULL DEFAULT '',
+  detail text NOT NULL DEFAULT '',
+  created_at timestamp NOT NULL,
+  CONSTRAINT pk_audit_events PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS index_audit_events_actor_id ON audit_events (actor_id, id DESC);
+
+CREATE INDEX IF NOT EXISTS index_audit_events_target ON audit_events (target_type, target_id, id DESC);
+
+CREATE INDEX IF NOT EXISTS index_audit_events_type ON audit_events (type, id DESC);
+
+CREATE INDEX IF NOT EXISTS index_audit_events_created_at ON audit_events (created_at);
+
+-- Events outlive the users and feeds they name, so there are no foreign
+-- keys, and once written they are never changed.
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+  BEFORE UPDATE OR DELETE ON audit_events
+  FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+
+DROP FUNCTION IF EXISTS audit_events_append_only();
+
+DROP TABLE IF EXISTS audit_events;
+-- +goose StatementEnd
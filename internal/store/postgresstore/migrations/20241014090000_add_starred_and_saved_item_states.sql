@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_item_states ADD COLUMN IF NOT EXISTS starred_at timestamp;
+
+ALTER TABLE user_item_states ADD COLUMN IF NOT EXISTS saved_at timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_item_states DROP COLUMN IF EXISTS saved_at;
+
+ALTER TABLE user_item_states DROP COLUMN IF EXISTS starred_at;
+-- +goose StatementEnd
@@ -1,14 +1,38 @@
+// Package postgresstore opens a Postgres database for the stores of
+// sqlstore.
 package postgresstore
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
-	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlstore"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// dialect is the SQL of Postgres where it differs from the shared SQL.
+var dialect = &sqlstore.Dialect{
+	ForUpdate:  " FOR UPDATE",
+	SkipLocked: " FOR UPDATE SKIP LOCKED",
+	ILike:      "ILIKE",
+	AddSeconds: func(column, seconds string) string {
+		return fmt.Sprintf("%s + %s * interval '1 second'", column, seconds)
+	},
+	SimHashDistance: func(a, b string) string {
+		return fmt.Sprintf("bit_count((%s # %s)::bit(64))", a, b)
+	},
+	IsUniqueViolation: func(err error) bool {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.Code == "23505"
+	},
+}
+
 type DB struct {
 	db     *pgxpool.Pool
 	ctx    context.Context
@@ -29,7 +53,7 @@ func NewDB(dbURL string) *DB {
 
 func (db *DB) Open() (err error) {
 	if db.DBURL == "" {
-		return errors.InternalErrorf("db url required")
+		return rferrors.InternalErrorf("db url required")
 	}
 
 	if db.db, err = pgxpool.New(db.ctx, db.DBURL); err != nil {
@@ -49,21 +73,63 @@ func (db *DB) Close() error {
 	return nil
 }
 
-func (db *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (*Tx, error) {
+// BeginTx begins a transaction, one that is readOnly cannot write.
+func (db *DB) BeginTx(ctx context.Context, readOnly bool) (*sqlstore.Tx, error) {
+	opts := pgx.TxOptions{}
+	if readOnly {
+		opts.AccessMode = pgx.ReadOnly
+	}
+
 	tx, err := db.db.BeginTx(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Tx{
-		Tx:  tx,
-		db:  db,
-		now: db.Now().UTC().Truncate(time.Second),
-	}, nil
+	return sqlstore.NewTx(conn{tx: tx}, dialect, db.Now()), nil
+}
+
+// conn is a pgx transaction as a sqlstore.Conn.
+type conn struct {
+	tx pgx.Tx
+}
+
+func (c conn) Exec(ctx context.Context, query string, args sqlstore.NamedArgs) (int64, error) {
+	tag, err := c.tx.Exec(ctx, query, pgx.NamedArgs(args))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (c conn) Query(ctx context.Context, query string, args sqlstore.NamedArgs) (sqlstore.Rows, error) {
+	return c.tx.Query(ctx, query, pgx.NamedArgs(args))
+}
+
+func (c conn) QueryRow(ctx context.Context, query string, args sqlstore.NamedArgs) sqlstore.Row {
+	return row{c.tx.QueryRow(ctx, query, pgx.NamedArgs(args))}
 }
 
-type Tx struct {
-	pgx.Tx
-	db  *DB
-	now time.Time
+func (c conn) Commit(ctx context.Context) error {
+	return c.tx.Commit(ctx)
+}
+
+func (c conn) Rollback(ctx context.Context) error {
+	err := c.tx.Rollback(ctx)
+	if errors.Is(err, pgx.ErrTxClosed) {
+		return nil
+	}
+	return err
+}
+
+// row returns sql.ErrNoRows in place of pgx.ErrNoRows.
+type row struct {
+	pgx.Row
+}
+
+func (r row) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+	return err
 }
@@ -0,0 +1,31 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	"github.com/pressly/goose/v3"
+)
+
+//go:embed migrations/*.sql
+var embedMigrations embed.FS
+
+// migrate brings the database up to the latest schema. The migrations are
+// part of the binary as a self hosted install has no migrations directory
+// or goose to run them with.
+func migrate(ctx context.Context, db *sql.DB) error {
+	migrations, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		return err
+	}
+
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, migrations)
+	if err != nil {
+		return err
+	}
+
+	_, err = provider.Up(ctx)
+	return err
+}
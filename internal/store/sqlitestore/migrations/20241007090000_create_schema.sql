@@ -0,0 +1,399 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name text NOT NULL,
+  role text NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+  timezone text NOT NULL DEFAULT 'UTC',
+  preferences text NOT NULL DEFAULT '{}',
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT check_name_length CHECK (length(name)<=50)
+);
+
+CREATE TABLE auths (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer NOT NULL,
+  email text NOT NULL,
+  password text NOT NULL,
+  enabled boolean NOT NULL DEFAULT TRUE,
+  deleted boolean NOT NULL DEFAULT FALSE,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  last_signed_in_at timestamp NOT NULL,
+  email_verified_at timestamp,
+  failed_sign_in_attempts integer NOT NULL DEFAULT 0,
+  last_failed_sign_in_at timestamp,
+  deleted_at timestamp,
+  purge_at timestamp,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT unique_email_address UNIQUE (email),
+  CONSTRAINT check_email_length CHECK (length(email)<=256)
+);
+
+CREATE INDEX index_auths_purge_at ON auths (purge_at) WHERE purge_at IS NOT NULL;
+
+CREATE TABLE feeds (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  url text NOT NULL,
+  enabled boolean NOT NULL DEFAULT TRUE,
+  deleted boolean NOT NULL DEFAULT FALSE,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  last_synced_at timestamp NOT NULL,
+  item_identity text NOT NULL DEFAULT 'guid',
+  poll_interval_seconds integer NOT NULL DEFAULT 1800,
+  resync_requested_at timestamp,
+  last_fetch_error text,
+  last_fetch_error_at timestamp,
+  fetch_failures integer NOT NULL DEFAULT 0,
+  CONSTRAINT unique_url_address UNIQUE (url),
+  CONSTRAINT check_item_identity CHECK (item_identity IN ('guid', 'link', 'hash'))
+);
+
+CREATE INDEX index_feeds_last_fetch_error_at ON feeds (last_fetch_error_at DESC)
+  WHERE last_fetch_error IS NOT NULL;
+
+CREATE TABLE user_feeds (
+  user_id integer NOT NULL,
+  feed_id integer NOT NULL,
+  name text NOT NULL,
+  enabled boolean NOT NULL DEFAULT TRUE,
+  deleted boolean NOT NULL DEFAULT FALSE,
+  fetch_full_content boolean NOT NULL DEFAULT FALSE,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT pk_user_feed PRIMARY KEY (user_id, feed_id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_feed FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE,
+  CONSTRAINT check_name_length CHECK (length(name)<=50)
+);
+
+CREATE TABLE feed_channels (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  feed_id integer NOT NULL,
+  title text NOT NULL,
+  desciption text NOT NULL,
+  link text NOT NULL,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT fk_feed FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE,
+  CONSTRAINT unique_feed_channel UNIQUE (feed_id)
+);
+
+CREATE TABLE stories (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  created_at timestamp NOT NULL
+);
+
+CREATE TABLE feed_channel_items (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  feed_channel_id integer NOT NULL,
+  guid text NOT NULL DEFAULT '',
+  identity_key text NOT NULL,
+  canonical_link text NOT NULL DEFAULT '',
+  simhash integer NOT NULL DEFAULT 0,
+  story_id integer,
+  title text NOT NULL,
+  desciption text NOT NULL,
+  content text NOT NULL DEFAULT '',
+  link text NOT NULL,
+  published_at timestamp NOT NULL,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT fk_feed_channel FOREIGN KEY (feed_channel_id) REFERENCES feed_channels (id) ON DELETE CASCADE,
+  CONSTRAINT fk_story FOREIGN KEY (story_id) REFERENCES stories (id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX unique_feed_channel_item_identity ON feed_channel_items (feed_channel_id, identity_key);
+CREATE INDEX index_feed_channel_items_story_id ON feed_channel_items (story_id);
+CREATE INDEX index_feed_channel_items_canonical_link ON feed_channel_items (canonical_link);
+CREATE INDEX index_feed_channel_items_published_at ON feed_channel_items (published_at);
+
+CREATE TABLE user_item_states (
+  user_id integer NOT NULL,
+  item_id integer NOT NULL,
+  read_at timestamp,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT pk_user_item_states PRIMARY KEY (user_id, item_id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_item FOREIGN KEY (item_id) REFERENCES feed_channel_items (id) ON DELETE CASCADE
+);
+
+CREATE TABLE item_enclosures (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  item_id integer NOT NULL,
+  url text NOT NULL,
+  mime_type text NOT NULL,
+  length integer NOT NULL DEFAULT 0,
+  medium text NOT NULL,
+  width integer NOT NULL DEFAULT 0,
+  height integer NOT NULL DEFAULT 0,
+  duration_seconds integer NOT NULL DEFAULT 0,
+  thumbnail_url text NOT NULL DEFAULT '',
+  image_url text NOT NULL DEFAULT '',
+  episode integer NOT NULL DEFAULT 0,
+  explicit boolean NOT NULL DEFAULT FALSE,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT fk_item FOREIGN KEY (item_id) REFERENCES feed_channel_items (id) ON DELETE CASCADE,
+  CONSTRAINT unique_item_enclosure_url UNIQUE (item_id, url)
+);
+
+CREATE TABLE user_playback_positions (
+  user_id integer NOT NULL,
+  enclosure_id integer NOT NULL,
+  position_seconds integer NOT NULL,
+  completed boolean NOT NULL DEFAULT FALSE,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT pk_user_playback_positions PRIMARY KEY (user_id, enclosure_id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_enclosure FOREIGN KEY (enclosure_id) REFERENCES item_enclosures (id) ON DELETE CASCADE,
+  CONSTRAINT check_position_seconds CHECK (position_seconds >= 0)
+);
+
+CREATE TABLE item_contents (
+  item_id integer NOT NULL,
+  content text NOT NULL DEFAULT '',
+  error text NOT NULL DEFAULT '',
+  fetched_at timestamp NOT NULL,
+  CONSTRAINT pk_item_contents PRIMARY KEY (item_id),
+  CONSTRAINT fk_item FOREIGN KEY (item_id) REFERENCES feed_channel_items (id) ON DELETE CASCADE
+);
+
+CREATE TABLE websub_subscriptions (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  feed_id integer NOT NULL,
+  hub_url text NOT NULL,
+  topic_url text NOT NULL,
+  secret text NOT NULL,
+  state text NOT NULL,
+  lease_seconds integer NOT NULL,
+  expires_at timestamp,
+  created_at timestamp NOT NULL,
+  modified_at timestamp NOT NULL,
+  CONSTRAINT fk_feed FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE,
+  CONSTRAINT unique_websub_subscription_feed UNIQUE (feed_id),
+  CONSTRAINT check_state CHECK (state IN ('pending', 'active', 'denied', 'unsubscribed'))
+);
+
+CREATE INDEX index_websub_subscriptions_expires_at ON websub_subscriptions (expires_at) WHERE state = 'active';
+
+CREATE TABLE sessions (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer NOT NULL,
+  device_name text NOT NULL DEFAULT '',
+  ip text NOT NULL DEFAULT '',
+  user_agent text NOT NULL DEFAULT '',
+  created_at timestamp NOT NULL,
+  last_used_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  revoked_at timestamp,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT check_device_name_length CHECK (length(device_name)<=100)
+);
+
+CREATE INDEX index_sessions_user_id ON sessions (user_id);
+
+CREATE TABLE session_refresh_tokens (
+  token_hash text NOT NULL,
+  session_id integer NOT NULL,
+  created_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_session_refresh_tokens PRIMARY KEY (token_hash),
+  CONSTRAINT fk_session FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_session_refresh_tokens_session_id ON session_refresh_tokens (session_id);
+
+CREATE TABLE api_keys (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer NOT NULL,
+  name text NOT NULL,
+  prefix text NOT NULL,
+  key_hash text NOT NULL,
+  scope text NOT NULL,
+  created_at timestamp NOT NULL,
+  last_used_at timestamp,
+  expires_at timestamp,
+  revoked_at timestamp,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT unique_api_key_hash UNIQUE (key_hash),
+  CONSTRAINT check_api_key_scope CHECK (scope IN ('read', 'read_write')),
+  CONSTRAINT check_api_key_name_length CHECK (length(name)<=100)
+);
+
+CREATE INDEX index_api_keys_user_id ON api_keys (user_id);
+
+CREATE TABLE password_reset_tokens (
+  token_hash text NOT NULL,
+  user_id integer NOT NULL,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_password_reset_tokens PRIMARY KEY (token_hash),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+CREATE TABLE email_verification_tokens (
+  token_hash text NOT NULL,
+  user_id integer NOT NULL,
+  email text NOT NULL,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_email_verification_tokens PRIMARY KEY (token_hash),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT check_email_length CHECK (length(email)<=256)
+);
+
+CREATE INDEX index_email_verification_tokens_user_id ON email_verification_tokens (user_id);
+
+CREATE TABLE limiter_failures (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  key text NOT NULL,
+  failed_at timestamp NOT NULL
+);
+
+CREATE INDEX index_limiter_failures_key_failed_at ON limiter_failures (key, failed_at);
+
+CREATE TABLE account_unlock_tokens (
+  token_hash text NOT NULL,
+  user_id integer NOT NULL,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_account_unlock_tokens PRIMARY KEY (token_hash),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_account_unlock_tokens_user_id ON account_unlock_tokens (user_id);
+
+CREATE TABLE auth_totps (
+  user_id integer NOT NULL,
+  secret text NOT NULL,
+  created_at timestamp NOT NULL,
+  enabled_at timestamp,
+  last_used_step integer NOT NULL DEFAULT 0,
+  CONSTRAINT pk_auth_totps PRIMARY KEY (user_id),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE totp_recovery_codes (
+  code_hash text NOT NULL,
+  user_id integer NOT NULL,
+  created_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_totp_recovery_codes PRIMARY KEY (code_hash),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_totp_recovery_codes_user_id ON totp_recovery_codes (user_id);
+
+CREATE TABLE oidc_login_states (
+  state_hash text NOT NULL,
+  provider text NOT NULL,
+  nonce text NOT NULL,
+  code_verifier text NOT NULL,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  CONSTRAINT pk_oidc_login_states PRIMARY KEY (state_hash)
+);
+
+CREATE TABLE auth_identities (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer NOT NULL,
+  issuer text NOT NULL,
+  subject text NOT NULL,
+  email text NOT NULL,
+  created_at timestamp NOT NULL,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT unique_issuer_subject UNIQUE (issuer, subject)
+);
+
+CREATE INDEX index_auth_identities_user_id ON auth_identities (user_id);
+
+CREATE TABLE data_exports (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer NOT NULL,
+  state text NOT NULL DEFAULT 'pending',
+  size integer NOT NULL DEFAULT 0,
+  created_at timestamp NOT NULL,
+  started_at timestamp,
+  completed_at timestamp,
+  expires_at timestamp NOT NULL,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT check_data_export_state CHECK (state IN ('pending', 'running', 'ready', 'failed'))
+);
+
+CREATE INDEX index_data_exports_user_id ON data_exports (user_id);
+
+CREATE INDEX index_data_exports_state ON data_exports (state, created_at);
+
+CREATE TABLE audit_events (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  type text NOT NULL,
+  actor_id integer,
+  target_type text,
+  target_id integer,
+  ip text NOT NULL DEFAULT '',
+  user_agent text NOT NULL DEFAULT '',
+  request_id text NOT NULL DEFAULT '',
+  detail text NOT NULL DEFAULT '',
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX index_audit_events_actor_id ON audit_events (actor_id, id DESC);
+
+CREATE INDEX index_audit_events_target ON audit_events (target_type, target_id, id DESC);
+
+CREATE INDEX index_audit_events_type ON audit_events (type, id DESC);
+
+CREATE INDEX index_audit_events_created_at ON audit_events (created_at);
+
+-- Events outlive the users and feeds they name, so there are no foreign
+-- keys, and once written they are never changed.
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS data_exports;
+DROP TABLE IF EXISTS auth_identities;
+DROP TABLE IF EXISTS oidc_login_states;
+DROP TABLE IF EXISTS totp_recovery_codes;
+DROP TABLE IF EXISTS auth_totps;
+DROP TABLE IF EXISTS account_unlock_tokens;
+DROP TABLE IF EXISTS limiter_failures;
+DROP TABLE IF EXISTS email_verification_tokens;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS session_refresh_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS websub_subscriptions;
+DROP TABLE IF EXISTS item_contents;
+DROP TABLE IF EXISTS user_playback_positions;
+DROP TABLE IF EXISTS item_enclosures;
+DROP TABLE IF EXISTS user_item_states;
+DROP TABLE IF EXISTS feed_channel_items;
+DROP TABLE IF EXISTS stories;
+DROP TABLE IF EXISTS feed_channels;
+DROP TABLE IF EXISTS user_feeds;
+DROP TABLE IF EXISTS feeds;
+DROP TABLE IF EXISTS auths;
+DROP TABLE IF EXISTS users;
+-- +goose StatementEnd
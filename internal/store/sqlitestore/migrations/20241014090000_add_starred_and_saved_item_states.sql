@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_item_states ADD COLUMN starred_at timestamp;
+
+ALTER TABLE user_item_states ADD COLUMN saved_at timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_item_states DROP COLUMN saved_at;
+
+ALTER TABLE user_item_states DROP COLUMN starred_at;
+-- +goose StatementEnd
@@ -0,0 +1,189 @@
+// Package sqlitestore opens a single SQLite file for the stores of
+// sqlstore, for personal installs that would rather not run Postgres.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/bits"
+	"time"
+
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlstore"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// dialect is the SQL of SQLite where it differs from the shared SQL.
+var dialect = &sqlstore.Dialect{
+	// Every transaction that writes holds the one write lock, so there are
+	// no rows to lock.
+	ForUpdate:  "",
+	SkipLocked: "",
+	// LIKE only folds the case of ASCII letters where Postgres ILIKE folds
+	// every letter.
+	ILike: "LIKE",
+	// datetime drops the fraction of a second, which times never have.
+	AddSeconds: func(column, seconds string) string {
+		return fmt.Sprintf("datetime(%s, '+' || %s || ' seconds')", column, seconds)
+	},
+	SimHashDistance: func(a, b string) string {
+		return fmt.Sprintf("simhash_distance(%s, %s)", a, b)
+	},
+	IsUniqueViolation: isUniqueViolation,
+}
+
+// SQLite has no bit_count, simhash_distance is the number of bits two
+// SimHashes differ in.
+func init() {
+	err := sqlite.RegisterDeterministicScalarFunction("simhash_distance", 2,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			a, _ := args[0].(int64)
+			b, _ := args[1].(int64)
+			return int64(bits.OnesCount64(uint64(a ^ b))), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type DB struct {
+	db     *sql.DB
+	ctx    context.Context
+	cancel func()
+
+	Path string
+	Now  func() time.Time
+}
+
+func NewDB(path string) *DB {
+	db := &DB{
+		Path: path,
+		Now:  time.Now,
+	}
+	db.ctx, db.cancel = context.WithCancel(context.Background())
+	return db
+}
+
+// Open opens the database file, creating it when missing, and migrates it
+// to the latest schema.
+func (db *DB) Open() (err error) {
+	if db.Path == "" {
+		return rferrors.InternalErrorf("db path required")
+	}
+
+	// Transactions that write take the write lock when they begin instead
+	// of on their first write, so two of them never deadlock upgrading
+	// their locks and wait on each other for up to busy_timeout instead.
+	dsn := db.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	if db.db, err = sql.Open("sqlite", dsn); err != nil {
+		return err
+	}
+
+	if err := db.db.PingContext(db.ctx); err != nil {
+		return err
+	}
+
+	return migrate(db.ctx, db.db)
+}
+
+func (db *DB) Close() error {
+	db.cancel()
+	if db.db == nil {
+		return nil
+	}
+	return db.db.Close()
+}
+
+// BeginTx begins a transaction, one that is readOnly does not wait for or
+// hold the write lock.
+func (db *DB) BeginTx(ctx context.Context, readOnly bool) (*sqlstore.Tx, error) {
+	tx, err := db.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlstore.NewTx(conn{tx: tx}, dialect, db.Now()), nil
+}
+
+// conn is a database/sql transaction as a sqlstore.Conn.
+type conn struct {
+	tx *sql.Tx
+}
+
+func (c conn) Exec(ctx context.Context, query string, args sqlstore.NamedArgs) (int64, error) {
+	result, err := c.tx.ExecContext(ctx, query, named(args)...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (c conn) Query(ctx context.Context, query string, args sqlstore.NamedArgs) (sqlstore.Rows, error) {
+	result, err := c.tx.QueryContext(ctx, query, named(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return rows{result}, nil
+}
+
+func (c conn) QueryRow(ctx context.Context, query string, args sqlstore.NamedArgs) sqlstore.Row {
+	return c.tx.QueryRowContext(ctx, query, named(args)...)
+}
+
+func (c conn) Commit(ctx context.Context) error {
+	return c.tx.Commit()
+}
+
+func (c conn) Rollback(ctx context.Context) error {
+	err := c.tx.Rollback()
+	if errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+	return err
+}
+
+// rows closes without an error, as pgx rows do.
+type rows struct {
+	*sql.Rows
+}
+
+func (r rows) Close() {
+	r.Rows.Close()
+}
+
+// named turns args into the arguments of a database/sql query, writing
+// times as text. They are always UTC and without a zone, so they compare as
+// text and are read back as UTC like Postgres timestamps.
+func named(args sqlstore.NamedArgs) []any {
+	values := make([]any, 0, len(args))
+	for name, value := range args {
+		switch v := value.(type) {
+		case time.Time:
+			value = formatTime(v)
+		case *time.Time:
+			value = nil
+			if v != nil {
+				value = formatTime(*v)
+			}
+		}
+		values = append(values, sql.Named(name, value))
+	}
+	return values
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqlstore.TimeFormat)
+}
+
+// isUniqueViolation reports whether err is a unique constraint failing.
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
@@ -0,0 +1,185 @@
+package sqlitestore_test
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlitestore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/sqlstore"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/store/storetest"
+	"github.com/matryer/is"
+)
+
+func TestSQLiteDBConformance(t *testing.T) {
+	t.Parallel()
+
+	db := newDB(t)
+
+	storetest.Run(t, storetest.Stores{
+		Auth:    sqlstore.NewAuthStore(db),
+		User:    sqlstore.NewUserStore(db),
+		Feed:    sqlstore.NewFeedStore(db),
+		Item:    sqlstore.NewItemStore(db),
+		Sync:    sqlstore.NewFeedStore(db),
+		WebSub:  sqlstore.NewWebSubStore(db),
+		Export:  sqlstore.NewExportStore(db),
+		Admin:   sqlstore.NewAdminStore(db),
+		Audit:   sqlstore.NewAuditStore(db),
+		Limiter: sqlstore.NewLimiter(db),
+		Now:     &db.Now,
+	})
+}
+
+func TestSQLiteLimiterFailures(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	ctx := context.Background()
+	now := time.Date(2024, 10, 7, 9, 0, 0, 0, time.UTC)
+
+	db := newDB(t)
+	db.Now = func() time.Time { return now }
+
+	limiter := sqlstore.NewLimiter(db)
+
+	count, lastFailedAt, err := limiter.Failures(ctx, "sign-in:someone@test.com", now.Add(-time.Hour))
+	is.NoErr(err)
+	is.Equal(count, 0)             // no failures recorded yet
+	is.True(lastFailedAt.IsZero()) // no last failure yet
+
+	err = limiter.Fail(ctx, "sign-in:someone@test.com")
+	is.NoErr(err)
+
+	count, lastFailedAt, err = limiter.Failures(ctx, "sign-in:someone@test.com", now.Add(-time.Hour))
+	is.NoErr(err)
+	is.Equal(count, 1)                          // the failure is counted
+	is.True(lastFailedAt.Equal(now))            // the last failure is read back as a time
+	is.Equal(lastFailedAt.Location(), time.UTC) // the last failure is in UTC
+}
+
+func TestSQLiteSimHashDistance(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	ctx := context.Background()
+	db := newDB(t)
+
+	tx, err := db.BeginTx(ctx, false)
+	is.NoErr(err)
+	defer tx.Rollback(ctx)
+
+	distance := func(a, b uint64) int {
+		t.Helper()
+
+		var d int
+		err := tx.QueryRow(ctx, "SELECT simhash_distance(@a, @b)", sqlstore.NamedArgs{
+			"a": int64(a),
+			"b": int64(b),
+		}).Scan(&d)
+		is.NoErr(err)
+		return d
+	}
+
+	is.Equal(distance(0b1111, 0b1111), 0)     // equal hashes do not differ
+	is.Equal(distance(0b1111, 0b1110), 1)     // one bit differs
+	is.Equal(distance(0, math.MaxUint64), 64) // every bit differs
+	is.Equal(distance(1<<63, 1<<63|0b11), 2)  // hashes stored as negative integers compare like the rest
+	is.Equal(distance(1<<63, 0), 1)           // the sign bit counts once
+}
+
+func TestSQLiteTimesAreUTCText(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	ctx := context.Background()
+	now := time.Date(2024, 10, 7, 9, 0, 0, 0, time.FixedZone("UTC+5", 5*60*60))
+
+	db := newDB(t)
+	db.Now = func() time.Time { return now }
+
+	audits := sqlstore.NewAuditStore(db)
+
+	err := audits.Record(ctx, audit.Event{Type: audit.EventSignUp, ActorID: 1})
+	is.NoErr(err)
+
+	tx, err := db.BeginTx(ctx, false)
+	is.NoErr(err)
+	defer tx.Rollback(ctx)
+
+	var kind, text string
+	err = tx.QueryRow(ctx, "SELECT typeof(created_at), CAST(created_at AS text) FROM audit_events").Scan(&kind, &text)
+	is.NoErr(err)
+	is.Equal(kind, "text")                // times are stored as text
+	is.Equal(text, "2024-10-07 04:00:00") // times are stored in UTC without a zone
+
+	var count int
+	err = tx.QueryRow(ctx, "SELECT count(*) FROM audit_events WHERE created_at >= @since", sqlstore.NamedArgs{
+		"since": now.Add(-time.Second),
+	}).Scan(&count)
+	is.NoErr(err)
+	is.Equal(count, 1) // times in another zone compare as UTC text
+
+	is.NoErr(tx.Rollback(ctx))
+
+	events, err := audits.ListAuditEvents(ctx, &audit.Query{UserID: 1, Limit: 10})
+	is.NoErr(err)
+	is.Equal(len(events), 1)
+	is.True(events[0].CreatedAt.Equal(now))            // the time is read back
+	is.Equal(events[0].CreatedAt.Location(), time.UTC) // the time is read back in UTC
+}
+
+func TestSQLiteAuditEventsAppendOnly(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	ctx := context.Background()
+	db := newDB(t)
+
+	err := sqlstore.NewAuditStore(db).Record(ctx, audit.Event{Type: audit.EventSignUp, ActorID: 1, Detail: "first"})
+	is.NoErr(err)
+
+	for _, query := range []string{
+		"UPDATE audit_events SET detail = 'changed'",
+		"DELETE FROM audit_events",
+	} {
+		tx, err := db.BeginTx(ctx, false)
+		is.NoErr(err)
+
+		_, err = tx.Exec(ctx, query)
+		is.True(err != nil)                                                   // the trigger aborts the statement
+		is.True(strings.Contains(err.Error(), "audit_events is append-only")) // the trigger names the rule
+
+		is.NoErr(tx.Rollback(ctx))
+	}
+
+	events, err := sqlstore.NewAuditStore(db).ListAuditEvents(ctx, &audit.Query{UserID: 1, Limit: 10})
+	is.NoErr(err)
+	is.Equal(len(events), 1)            // the event was not deleted
+	is.Equal(events[0].Detail, "first") // the event was not changed
+}
+
+func newDB(t *testing.T) *sqlitestore.DB {
+	t.Helper()
+
+	db := sqlitestore.NewDB(filepath.Join(t.TempDir(), "rf.db"))
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return db
+}
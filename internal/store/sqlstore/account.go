@@ -0,0 +1,96 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// DeleteAccount marks the account of the user deleted until purgeAt and
+// revokes every session. Api keys are refused while the account is deleted
+// and work again when it is restored.
+func (as *AuthStore) DeleteAccount(ctx context.Context, userID int64, purgeAt time.Time) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := NamedArgs{
+		"userID":  userID,
+		"purgeAt": purgeAt,
+		"now":     tx.now,
+	}
+
+	query := `
+	UPDATE auths
+		SET deleted = TRUE,
+				deleted_at = @now,
+				purge_at = @purgeAt,
+				modified_at = @now
+		WHERE user_id = @userID AND deleted = FALSE
+	`
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrUserNotFound)
+	}
+
+	query = `
+	UPDATE sessions SET revoked_at = @now WHERE user_id = @userID AND revoked_at IS NULL
+	`
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// PurgeDeletedAccounts removes users whose deleted accounts are past their
+// purge time along with their feeds, everything else of theirs goes with
+// the user row. It returns how many users were removed.
+func (as *AuthStore) PurgeDeletedAccounts(ctx context.Context) (int64, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := NamedArgs{
+		"now": tx.now,
+	}
+
+	query := `
+	DELETE FROM user_feeds
+		WHERE user_id IN (SELECT user_id FROM auths WHERE deleted AND purge_at <= @now)
+	`
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `
+	DELETE FROM users
+		WHERE id IN (SELECT user_id FROM auths WHERE deleted AND purge_at <= @now)
+	`
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,243 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type AdminStore struct {
+	db DB
+}
+
+func NewAdminStore(db DB) *AdminStore {
+	return &AdminStore{
+		db: db,
+	}
+}
+
+// SearchUsers returns the users whose email or name contains the query,
+// ignoring case.
+func (as *AdminStore) SearchUsers(ctx context.Context, req *rf.UserSearchRequest) ([]rf.AdminUser, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT users.id, users.name, auths.email, users.role, auths.enabled, auths.deleted,
+				 auths.email_verified_at, auths.last_signed_in_at, users.created_at
+		FROM users
+		JOIN auths
+			ON auths.user_id = users.id
+		WHERE @pattern = '' OR auths.email ` + tx.dialect.ILike + ` @pattern ESCAPE '\'
+			OR users.name ` + tx.dialect.ILike + ` @pattern ESCAPE '\'
+		ORDER BY users.id
+		LIMIT @limit OFFSET @offset
+	`
+	args := NamedArgs{
+		"pattern": likePattern(req.Query),
+		"limit":   req.Limit,
+		"offset":  req.Offset,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(row Row) (rf.AdminUser, error) {
+		var user rf.AdminUser
+		var emailVerifiedAt *time.Time
+		err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Role, &user.Enabled, &user.Deleted,
+			&emailVerifiedAt, &user.LastSignedInAt, &user.CreatedAt)
+		if emailVerifiedAt != nil {
+			user.EmailVerifiedAt = *emailVerifiedAt
+		}
+		return user, err
+	})
+}
+
+// SetAuthEnabled enables or disables the account of the user, disabling
+// also revokes every session.
+func (as *AdminStore) SetAuthEnabled(ctx context.Context, userID int64, enabled bool) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := NamedArgs{
+		"userID":  userID,
+		"enabled": enabled,
+		"now":     tx.now,
+	}
+
+	query := `
+	UPDATE auths SET enabled = @enabled, modified_at = @now WHERE user_id = @userID
+	`
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrUserNotFound)
+	}
+
+	if !enabled {
+		query = `
+		UPDATE sessions SET revoked_at = @now WHERE user_id = @userID AND revoked_at IS NULL
+		`
+
+		_, err = tx.Exec(ctx, query, args)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetUserRole gives the user signed up with email the role and returns
+// their id.
+func (as *AdminStore) SetUserRole(ctx context.Context, email string, role rf.Role) (int64, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE users SET role = @role, modified_at = @now
+		WHERE id = (SELECT user_id FROM auths WHERE email = @email AND NOT deleted)
+		RETURNING id
+	`
+	args := NamedArgs{
+		"email": email,
+		"role":  string(role),
+		"now":   tx.now,
+	}
+
+	var userID int64
+	err = tx.QueryRow(ctx, query, args).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, rferrors.NotFoundf(rferrors.ErrUserNotFound)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit(ctx)
+}
+
+// RequestFeedResync marks the feed to be fetched on the next sync whatever
+// its poll interval.
+func (as *AdminStore) RequestFeedResync(ctx context.Context, feedID int64) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE feeds SET resync_requested_at = @now, modified_at = @now WHERE id = @feedID AND deleted = FALSE
+	`
+	args := NamedArgs{
+		"feedID": feedID,
+		"now":    tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrFeedNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetFeedEnabled enables or disables fetching the feed for every
+// subscriber.
+func (as *AdminStore) SetFeedEnabled(ctx context.Context, feedID int64, enabled bool) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE feeds SET enabled = @enabled, modified_at = @now WHERE id = @feedID AND deleted = FALSE
+	`
+	args := NamedArgs{
+		"feedID":  feedID,
+		"enabled": enabled,
+		"now":     tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrFeedNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListFeedFetchErrors returns the feeds whose last fetch failed, most
+// recent failure first.
+func (as *AdminStore) ListFeedFetchErrors(ctx context.Context, limit int) ([]rf.FeedFetchError, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, url, enabled, last_fetch_error, last_fetch_error_at, fetch_failures
+		FROM feeds
+		WHERE last_fetch_error IS NOT NULL AND deleted = FALSE
+		ORDER BY last_fetch_error_at DESC
+		LIMIT @limit
+	`
+	args := NamedArgs{
+		"limit": limit,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(row Row) (rf.FeedFetchError, error) {
+		var fetchErr rf.FeedFetchError
+		err := row.Scan(&fetchErr.FeedID, &fetchErr.URL, &fetchErr.Enabled, &fetchErr.Error,
+			&fetchErr.FailedAt, &fetchErr.Failures)
+		return fetchErr, err
+	})
+}
+
+// likePattern matches query anywhere in a value, with the wildcards of
+// query taken literally. An empty query gives an empty pattern.
+func likePattern(query string) string {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return ""
+	}
+
+	query = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+	return "%" + query + "%"
+}
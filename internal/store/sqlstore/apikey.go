@@ -0,0 +1,156 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateAPIKey(ctx context.Context, key *rf.APIKey) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	key.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO api_keys (user_id, name, prefix, key_hash, scope, created_at, expires_at)
+	VALUES (@userID, @name, @prefix, @keyHash, @scope, @createdAt, @expiresAt)
+	RETURNING id
+	`
+	args := NamedArgs{
+		"userID":    key.UserID,
+		"name":      key.Name,
+		"prefix":    key.Prefix,
+		"keyHash":   key.KeyHash,
+		"scope":     key.Scope,
+		"createdAt": key.CreatedAt,
+		"expiresAt": key.ExpiresAt,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&key.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthStore) ListUserAPIKeys(ctx context.Context, userID int64) ([]rf.APIKey, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, user_id, name, prefix, key_hash, scope, created_at, last_used_at, expires_at, revoked_at
+	FROM api_keys
+	WHERE user_id = @userID AND revoked_at IS NULL
+	ORDER BY id
+	`
+	args := NamedArgs{
+		"userID": userID,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, scanAPIKey)
+}
+
+func (as *AuthStore) FindAPIKeyByHash(ctx context.Context, keyHash string) (*rf.APIKey, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, user_id, name, prefix, key_hash, scope, created_at, last_used_at, expires_at, revoked_at
+	FROM api_keys
+	WHERE key_hash = @keyHash
+	`
+	args := NamedArgs{
+		"keyHash": keyHash,
+	}
+
+	key, err := scanAPIKey(tx.QueryRow(ctx, query, args))
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (as *AuthStore) TouchAPIKey(ctx context.Context, keyID int64) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE api_keys
+		SET last_used_at = @now
+		WHERE id = @keyID
+	`
+	args := NamedArgs{
+		"keyID": keyID,
+		"now":   tx.now,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthStore) RevokeAPIKey(ctx context.Context, userID, keyID int64) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE api_keys
+		SET revoked_at = @now
+		WHERE id = @keyID AND user_id = @userID AND revoked_at IS NULL
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"keyID":  keyID,
+		"now":    tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrAPIKeyNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func scanAPIKey(row Row) (rf.APIKey, error) {
+	var key rf.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &key.Scope,
+		&key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt)
+	return key, err
+}
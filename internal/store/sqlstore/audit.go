@@ -0,0 +1,145 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+)
+
+// AuditStore keeps audit events in the append only audit_events table, it
+// is an audit.Recorder.
+type AuditStore struct {
+	db DB
+}
+
+func NewAuditStore(db DB) *AuditStore {
+	return &AuditStore{
+		db: db,
+	}
+}
+
+func (as *AuditStore) Record(ctx context.Context, event audit.Event) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	INSERT INTO audit_events (type, actor_id, target_type, target_id, ip, user_agent, request_id, detail, created_at)
+	VALUES (@type, @actorID, @targetType, @targetID, @ip, @userAgent, @requestID, @detail, @now)
+	`
+	args := NamedArgs{
+		"type":       string(event.Type),
+		"actorID":    nullInt64(event.ActorID),
+		"targetType": nullString(string(event.TargetType)),
+		"targetID":   nullInt64(event.TargetID),
+		"ip":         event.IP,
+		"userAgent":  event.UserAgent,
+		"requestID":  event.RequestID,
+		"detail":     event.Detail,
+		"now":        tx.now,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListAuditEvents returns the events matching query, newest first.
+func (as *AuditStore) ListAuditEvents(ctx context.Context, query *audit.Query) ([]audit.Event, error) {
+	tx, err := as.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Only the filters that are set are added, so neither database has to
+	// guess the type of a parameter that is compared to nothing.
+	args := NamedArgs{"limit": query.Limit}
+	where := []string{"TRUE"}
+	if len(query.Types) > 0 {
+		types := make([]string, 0, len(query.Types))
+		for _, t := range query.Types {
+			types = append(types, string(t))
+		}
+		where = append(where, "type IN "+inList(args, "type", types))
+	}
+	if query.ActorID != 0 {
+		where = append(where, "actor_id = @actorID")
+		args["actorID"] = query.ActorID
+	}
+	if query.TargetType != "" {
+		where = append(where, "target_type = @targetType")
+		args["targetType"] = string(query.TargetType)
+	}
+	if query.TargetID != 0 {
+		where = append(where, "target_id = @targetID")
+		args["targetID"] = query.TargetID
+	}
+	if query.UserID != 0 {
+		where = append(where, "(actor_id = @userID OR (target_type = 'user' AND target_id = @userID))")
+		args["userID"] = query.UserID
+	}
+	if !query.Since.IsZero() {
+		where = append(where, "created_at >= @since")
+		args["since"] = query.Since.UTC()
+	}
+	if !query.Until.IsZero() {
+		where = append(where, "created_at < @until")
+		args["until"] = query.Until.UTC()
+	}
+	if query.BeforeID != 0 {
+		where = append(where, "id < @beforeID")
+		args["beforeID"] = query.BeforeID
+	}
+
+	sql := `
+	SELECT id, type, actor_id, target_type, target_id, ip, user_agent, request_id, detail, created_at
+		FROM audit_events
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY id DESC
+		LIMIT @limit
+	`
+
+	rows, err := tx.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(row Row) (audit.Event, error) {
+		var event audit.Event
+		var actorID, targetID *int64
+		var targetType *string
+		err := row.Scan(&event.ID, &event.Type, &actorID, &targetType, &targetID, &event.IP,
+			&event.UserAgent, &event.RequestID, &event.Detail, &event.CreatedAt)
+		if actorID != nil {
+			event.ActorID = *actorID
+		}
+		if targetType != nil {
+			event.TargetType = audit.TargetType(*targetType)
+		}
+		if targetID != nil {
+			event.TargetID = *targetID
+		}
+		return event, err
+	})
+}
+
+func nullInt64(n int64) *int64 {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,230 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type AuthStore struct {
+	db DB
+}
+
+func NewAuthStore(db DB) *AuthStore {
+	return &AuthStore{
+		db: db,
+	}
+}
+
+func (as *AuthStore) CreateAuthAndUser(ctx context.Context, auth *rf.Auth) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = createAuthAndUser(ctx, tx, auth)
+	if err != nil {
+		if tx.dialect.IsUniqueViolation(err) {
+			return rferrors.InvalidDataf(rferrors.ErrCouldNotProcess)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func createAuthAndUser(ctx context.Context, tx *Tx, auth *rf.Auth) error {
+	user := &rf.User{
+		Name: auth.User.Name,
+	}
+	err := createUser(ctx, tx, user)
+	if err != nil {
+		return err
+	}
+
+	auth.UserID = user.ID
+	auth.CreatedAt = tx.now
+	auth.ModifiedAt = auth.CreatedAt
+	auth.LastSignedInAt = auth.CreatedAt
+
+	var emailVerifiedAt *time.Time
+	if !auth.EmailVerifiedAt.IsZero() {
+		auth.EmailVerifiedAt = tx.now
+		emailVerifiedAt = &auth.EmailVerifiedAt
+	}
+
+	query := `
+	INSERT INTO auths (user_id, email, password, created_at, modified_at, last_signed_in_at, email_verified_at)
+	VALUES (@userID, @email, @password, @createdAt, @modifiedAt, @lastSignedInAt, @emailVerifiedAt)
+	RETURNING id
+	`
+	args := NamedArgs{
+		"userID":          auth.UserID,
+		"email":           auth.BasicAuth.Email,
+		"password":        auth.BasicAuth.Password,
+		"createdAt":       auth.CreatedAt,
+		"modifiedAt":      auth.ModifiedAt,
+		"lastSignedInAt":  auth.LastSignedInAt,
+		"emailVerifiedAt": emailVerifiedAt,
+	}
+
+	return tx.QueryRow(ctx, query, args).Scan(&auth.ID)
+}
+
+func (as *AuthStore) FindByEmail(ctx context.Context, email string) (*rf.Auth, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	return findByEmail(ctx, tx, email)
+}
+
+func (as *AuthStore) FindByUserID(ctx context.Context, userID int64) (*rf.Auth, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	return findAuth(ctx, tx, "auths.user_id = @userID", NamedArgs{"userID": userID})
+}
+
+func findByEmail(ctx context.Context, tx *Tx, email string) (*rf.Auth, error) {
+	return findAuth(ctx, tx, "auths.email = @email", NamedArgs{"email": email})
+}
+
+// findAuth returns the auth matching where, or nil when there is none.
+func findAuth(ctx context.Context, tx *Tx, where string, args NamedArgs) (*rf.Auth, error) {
+	auth := &rf.Auth{
+		BasicAuth: &rf.BasicAuth{},
+		User:      &rf.User{},
+	}
+
+	query := `
+	SELECT auths.id, auths.user_id, auths.email, auths.password, auths.enabled, auths.deleted,
+				 auths.created_at, auths.modified_at, auths.last_signed_in_at, auths.email_verified_at,
+				 auths.failed_sign_in_attempts, auths.last_failed_sign_in_at, auth_totps.enabled_at,
+				 auths.deleted_at, auths.purge_at, users.role, users.name
+		FROM auths
+		JOIN users
+			ON users.id = auths.user_id
+		LEFT JOIN auth_totps
+			ON auth_totps.user_id = auths.user_id
+		WHERE ` + where
+
+	var emailVerifiedAt, lastFailedSignInAt, totpEnabledAt, deletedAt, purgeAt *time.Time
+	err := tx.QueryRow(ctx, query, args).Scan(&auth.ID, &auth.UserID, &auth.BasicAuth.Email,
+		&auth.BasicAuth.Password, &auth.Enabled, &auth.Deleted, &auth.CreatedAt, &auth.ModifiedAt,
+		&auth.LastSignedInAt, &emailVerifiedAt, &auth.FailedSignInAttempts, &lastFailedSignInAt, &totpEnabledAt,
+		&deletedAt, &purgeAt, &auth.Role, &auth.User.Name)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if emailVerifiedAt != nil {
+		auth.EmailVerifiedAt = *emailVerifiedAt
+	}
+	if lastFailedSignInAt != nil {
+		auth.LastFailedSignInAt = *lastFailedSignInAt
+	}
+	if totpEnabledAt != nil {
+		auth.TOTPEnabledAt = *totpEnabledAt
+	}
+	if deletedAt != nil {
+		auth.DeletedAt = *deletedAt
+	}
+	if purgeAt != nil {
+		auth.PurgeAt = *purgeAt
+	}
+	auth.User.ID = auth.UserID
+
+	return auth, nil
+}
+
+// RecordSignInAttempt stores the outcome of a password check. A success
+// re-checks the account is still enabled, restores it when it was deleted
+// but not yet purged, records last_signed_in_at and clears the failed
+// attempts in one transaction, a failure counts it.
+func (as *AuthStore) RecordSignInAttempt(ctx context.Context, auth *rf.Auth, succeeded bool) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := NamedArgs{
+		"userID": auth.UserID,
+		"now":    tx.now,
+	}
+
+	if !succeeded {
+		query := `
+		UPDATE auths
+			SET failed_sign_in_attempts = failed_sign_in_attempts + 1,
+					last_failed_sign_in_at = @now
+			WHERE user_id = @userID
+			RETURNING failed_sign_in_attempts
+		`
+
+		err = tx.QueryRow(ctx, query, args).Scan(&auth.FailedSignInAttempts)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		auth.LastFailedSignInAt = tx.now
+
+		return tx.Commit(ctx)
+	}
+
+	query := `
+	SELECT enabled, deleted, purge_at FROM auths WHERE user_id = @userID` + tx.dialect.ForUpdate + `
+	`
+
+	var purgeAt *time.Time
+	err = tx.QueryRow(ctx, query, args).Scan(&auth.Enabled, &auth.Deleted, &purgeAt)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return rferrors.Unauthorizedf(rferrors.ErrInvalidCredentials)
+		}
+		return err
+	}
+
+	if auth.Deleted && (purgeAt == nil || !purgeAt.After(tx.now)) {
+		return rferrors.AccountDisabledf(rferrors.ErrAccountDeleted)
+	}
+	if !auth.Enabled {
+		return rferrors.AccountDisabledf(rferrors.ErrAccountDisabled)
+	}
+
+	query = `
+	UPDATE auths
+		SET last_signed_in_at = @now,
+				failed_sign_in_attempts = 0,
+				deleted = FALSE,
+				deleted_at = NULL,
+				purge_at = NULL
+		WHERE user_id = @userID
+	`
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	auth.LastSignedInAt = tx.now
+	auth.FailedSignInAttempts = 0
+	auth.Deleted = false
+	auth.DeletedAt = time.Time{}
+	auth.PurgeAt = time.Time{}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,117 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+// ListItemsWantingContent returns up to limit items, newest first, whose
+// full article a subscriber asked for and is not cached yet, or failed to
+// download before retryBefore.
+func (is *ItemStore) ListItemsWantingContent(ctx context.Context, retryBefore time.Time, limit int) ([]rf.Item, error) {
+	tx, err := is.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT items.id, items.feed_channel_id, items.link
+		FROM feed_channel_items AS items
+		JOIN feed_channels AS channels
+			ON channels.id = items.feed_channel_id
+		LEFT JOIN item_contents AS contents
+			ON contents.item_id = items.id
+		WHERE items.link <> ''
+			AND EXISTS (
+				SELECT 1
+					FROM user_feeds
+					WHERE user_feeds.feed_id = channels.feed_id AND user_feeds.fetch_full_content
+			)
+			AND (contents.item_id IS NULL OR (contents.error <> '' AND contents.fetched_at < @retryBefore))
+		ORDER BY items.id DESC
+		LIMIT @limit
+	`
+	args := NamedArgs{
+		"retryBefore": retryBefore.UTC(),
+		"limit":       limit,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(row Row) (rf.Item, error) {
+		var item rf.Item
+		err := row.Scan(&item.ID, &item.ChannelID, &item.Link)
+		return item, err
+	})
+}
+
+func (is *ItemStore) FindItemContent(ctx context.Context, itemID int64) (*rf.ItemContent, error) {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	content := &rf.ItemContent{
+		ItemID: itemID,
+	}
+
+	query := `
+	SELECT content, error, fetched_at
+	FROM item_contents
+	WHERE item_id = @itemID
+	`
+	args := NamedArgs{
+		"itemID": itemID,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&content.Content, &content.Error, &content.FetchedAt)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (is *ItemStore) SaveItemContent(ctx context.Context, content *rf.ItemContent) error {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	content.FetchedAt = tx.now
+
+	query := `
+	INSERT INTO item_contents (item_id, content, error, fetched_at)
+	VALUES (@itemID, @content, @error, @fetchedAt)
+	ON CONFLICT (item_id) DO UPDATE
+		SET content = EXCLUDED.content,
+				error = EXCLUDED.error,
+				fetched_at = EXCLUDED.fetched_at
+	`
+	args := NamedArgs{
+		"itemID":    content.ItemID,
+		"content":   content.Content,
+		"error":     content.Error,
+		"fetchedAt": content.FetchedAt,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
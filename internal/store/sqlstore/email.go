@@ -0,0 +1,105 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateEmailVerificationToken(ctx context.Context, token *rf.EmailVerificationToken) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	token.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO email_verification_tokens (token_hash, user_id, email, created_at, expires_at)
+	VALUES (@tokenHash, @userID, @email, @createdAt, @expiresAt)
+	`
+	args := NamedArgs{
+		"tokenHash": token.TokenHash,
+		"userID":    token.UserID,
+		"email":     token.Email,
+		"createdAt": token.CreatedAt,
+		"expiresAt": token.ExpiresAt,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ConfirmEmail spends a verification token and makes its address the
+// verified email of the account, replacing the old one on a change. It
+// returns the user id of the account.
+func (as *AuthStore) ConfirmEmail(ctx context.Context, tokenHash string) (int64, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE email_verification_tokens
+		SET used_at = @now
+		WHERE token_hash = @tokenHash AND used_at IS NULL AND expires_at > @now
+		RETURNING user_id, email
+	`
+	args := NamedArgs{
+		"tokenHash": tokenHash,
+		"now":       tx.now,
+	}
+
+	var userID int64
+	var email string
+	err = tx.QueryRow(ctx, query, args).Scan(&userID, &email)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return 0, rferrors.InvalidDataf(rferrors.ErrVerifyTokenInvalid)
+		}
+		return 0, err
+	}
+
+	query = `
+	UPDATE auths
+		SET email = @email,
+				email_verified_at = @now,
+				modified_at = @now
+		WHERE user_id = @userID
+	`
+	args = NamedArgs{
+		"userID": userID,
+		"email":  email,
+		"now":    tx.now,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		if tx.dialect.IsUniqueViolation(err) {
+			return 0, rferrors.InvalidDataf(rferrors.ErrCouldNotProcess)
+		}
+		return 0, err
+	}
+
+	// Links sent for other addresses must not undo this change.
+	query = `
+	UPDATE email_verification_tokens
+		SET used_at = @now
+		WHERE user_id = @userID AND used_at IS NULL
+	`
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit(ctx)
+}
@@ -0,0 +1,182 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (is *ItemStore) SavePlaybackPosition(ctx context.Context, position *rf.PlaybackPosition) error {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	position.ModifiedAt = tx.now
+
+	// Positions are only kept for audio and video enclosures of items in
+	// feeds the user subscribes to.
+	query := `
+	INSERT INTO user_playback_positions (user_id, enclosure_id, position_seconds, completed, created_at, modified_at)
+	SELECT @userID, enclosures.id, @positionSeconds, @completed, @now, @now
+		FROM item_enclosures AS enclosures
+		JOIN feed_channel_items AS items
+			ON items.id = enclosures.item_id
+		JOIN feed_channels AS channels
+			ON channels.id = items.feed_channel_id
+		JOIN user_feeds
+			ON user_feeds.feed_id = channels.feed_id AND user_feeds.user_id = @userID
+		WHERE enclosures.id = @enclosureID
+			AND enclosures.item_id = @itemID
+			AND enclosures.medium IN ('audio', 'video')
+	ON CONFLICT (user_id, enclosure_id) DO UPDATE
+		SET position_seconds = EXCLUDED.position_seconds,
+				completed = EXCLUDED.completed,
+				modified_at = EXCLUDED.modified_at
+	`
+	args := NamedArgs{
+		"userID":          position.UserID,
+		"itemID":          position.ItemID,
+		"enclosureID":     position.EnclosureID,
+		"positionSeconds": position.PositionSeconds,
+		"completed":       position.Completed,
+		"now":             tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrEnclosureNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func upsertEnclosures(ctx context.Context, tx *Tx, item *rf.Item) error {
+	// Enclosures the feed no longer lists are removed along with any
+	// playback positions in them.
+	urls := make([]string, len(item.Enclosures))
+	for i := range item.Enclosures {
+		urls[i] = item.Enclosures[i].URL
+	}
+
+	args := NamedArgs{
+		"itemID": item.ID,
+	}
+	query := `
+	DELETE FROM item_enclosures WHERE item_id = @itemID
+	`
+	if len(urls) > 0 {
+		query += ` AND url NOT IN ` + inList(args, "url", urls)
+	}
+
+	_, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	for i := range item.Enclosures {
+		enc := &item.Enclosures[i]
+		enc.ItemID = item.ID
+
+		query := `
+		INSERT INTO item_enclosures (item_id, url, mime_type, length, medium, width, height, duration_seconds,
+																 thumbnail_url, image_url, episode, explicit, created_at, modified_at)
+		VALUES (@itemID, @url, @mimeType, @length, @medium, @width, @height, @durationSeconds,
+						@thumbnailURL, @imageURL, @episode, @explicit, @now, @now)
+		ON CONFLICT (item_id, url) DO UPDATE
+			SET mime_type = EXCLUDED.mime_type,
+					length = EXCLUDED.length,
+					medium = EXCLUDED.medium,
+					width = EXCLUDED.width,
+					height = EXCLUDED.height,
+					duration_seconds = EXCLUDED.duration_seconds,
+					thumbnail_url = EXCLUDED.thumbnail_url,
+					image_url = EXCLUDED.image_url,
+					episode = EXCLUDED.episode,
+					explicit = EXCLUDED.explicit,
+					modified_at = EXCLUDED.modified_at
+		RETURNING id, created_at, modified_at
+		`
+		args := NamedArgs{
+			"itemID":          enc.ItemID,
+			"url":             enc.URL,
+			"mimeType":        enc.MIMEType,
+			"length":          enc.Length,
+			"medium":          enc.Medium,
+			"width":           enc.Width,
+			"height":          enc.Height,
+			"durationSeconds": enc.DurationSeconds,
+			"thumbnailURL":    enc.ThumbnailURL,
+			"imageURL":        enc.ImageURL,
+			"episode":         enc.Episode,
+			"explicit":        enc.Explicit,
+			"now":             tx.now,
+		}
+
+		err := tx.QueryRow(ctx, query, args).Scan(&enc.ID, &enc.CreatedAt, &enc.ModifiedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func listItemEnclosures(ctx context.Context, tx *Tx, userID, itemID int64) ([]rf.Enclosure, error) {
+	query := `
+	SELECT enclosures.id, enclosures.item_id, enclosures.url, enclosures.mime_type, enclosures.length,
+				 enclosures.medium, enclosures.width, enclosures.height, enclosures.duration_seconds,
+				 enclosures.thumbnail_url, enclosures.image_url, enclosures.episode, enclosures.explicit,
+				 enclosures.created_at, enclosures.modified_at,
+				 positions.position_seconds, positions.completed, positions.modified_at
+		FROM item_enclosures AS enclosures
+		LEFT JOIN user_playback_positions AS positions
+			ON positions.enclosure_id = enclosures.id AND positions.user_id = @userID
+		WHERE enclosures.item_id = @itemID
+		ORDER BY enclosures.id
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"itemID": itemID,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(row Row) (rf.Enclosure, error) {
+		var enc rf.Enclosure
+		var positionSeconds *int
+		var completed *bool
+		var positionModifiedAt *time.Time
+
+		err := row.Scan(&enc.ID, &enc.ItemID, &enc.URL, &enc.MIMEType, &enc.Length, &enc.Medium,
+			&enc.Width, &enc.Height, &enc.DurationSeconds, &enc.ThumbnailURL, &enc.ImageURL,
+			&enc.Episode, &enc.Explicit, &enc.CreatedAt, &enc.ModifiedAt,
+			&positionSeconds, &completed, &positionModifiedAt)
+		if err != nil {
+			return enc, err
+		}
+
+		if positionSeconds != nil {
+			enc.Playback = &rf.PlaybackPosition{
+				UserID:          userID,
+				ItemID:          enc.ItemID,
+				EnclosureID:     enc.ID,
+				PositionSeconds: *positionSeconds,
+				Completed:       *completed,
+				ModifiedAt:      *positionModifiedAt,
+			}
+		}
+
+		return enc, nil
+	})
+}
@@ -0,0 +1,493 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// exportClaimTimeout is how long a running export may take before it is
+// taken to have died with its worker and is claimed again.
+const exportClaimTimeout = time.Hour
+
+type ExportStore struct {
+	db DB
+}
+
+func NewExportStore(db DB) *ExportStore {
+	return &ExportStore{
+		db: db,
+	}
+}
+
+// CreateExport queues an export, when the user already has one queued or
+// running export is set to it instead.
+func (es *ExportStore) CreateExport(ctx context.Context, export *rf.DataExport) error {
+	tx, err := es.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the user serialises concurrent requests of the same user.
+	_, err = tx.Exec(ctx, `SELECT id FROM users WHERE id = @userID`+tx.dialect.ForUpdate, NamedArgs{"userID": export.UserID})
+	if err != nil {
+		return err
+	}
+
+	found, err := findExport(ctx, tx, "user_id = @userID AND state IN ('pending', 'running') AND expires_at > @now",
+		NamedArgs{"userID": export.UserID, "now": tx.now})
+	if err != nil {
+		return err
+	}
+
+	if found != nil {
+		*export = *found
+		return nil
+	}
+
+	export.State = rf.DataExportStatePending
+	export.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO data_exports (user_id, state, created_at, expires_at)
+	VALUES (@userID, @state, @createdAt, @expiresAt)
+	RETURNING id
+	`
+	args := NamedArgs{
+		"userID":    export.UserID,
+		"state":     export.State,
+		"createdAt": export.CreatedAt,
+		"expiresAt": export.ExpiresAt,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&export.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FindExport returns an unexpired export, or nil when there is none.
+func (es *ExportStore) FindExport(ctx context.Context, exportID int64) (*rf.DataExport, error) {
+	tx, err := es.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	return findExport(ctx, tx, "id = @exportID AND expires_at > @now", NamedArgs{"exportID": exportID, "now": tx.now})
+}
+
+func (es *ExportStore) ListUserExports(ctx context.Context, userID int64) ([]rf.DataExport, error) {
+	tx, err := es.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, user_id, state, size, created_at, completed_at, expires_at
+		FROM data_exports
+		WHERE user_id = @userID AND expires_at > @now
+		ORDER BY created_at DESC, id DESC
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"now":    tx.now,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(row Row) (rf.DataExport, error) {
+		var export rf.DataExport
+		err := scanExport(row, &export)
+		return export, err
+	})
+}
+
+// ClaimPendingExport marks the oldest queued export running and returns
+// it, or nil when none is queued. Concurrent workers claim different ones,
+// an export left running by a worker that died is claimed again.
+func (es *ExportStore) ClaimPendingExport(ctx context.Context) (*rf.DataExport, error) {
+	tx, err := es.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE data_exports
+		SET state = 'running',
+				started_at = @now
+		WHERE id = (
+			SELECT id FROM data_exports
+				WHERE (state = 'pending' OR (state = 'running' AND started_at < @staleBefore)) AND expires_at > @now
+				ORDER BY created_at, id
+				LIMIT 1` + tx.dialect.SkipLocked + `
+		)
+		RETURNING id, user_id, state, size, created_at, completed_at, expires_at
+	`
+	args := NamedArgs{
+		"now":         tx.now,
+		"staleBefore": tx.now.Add(-exportClaimTimeout),
+	}
+
+	export := &rf.DataExport{}
+	err = scanExport(tx.QueryRow(ctx, query, args), export)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// FinishExport records the outcome of a running export, size is the zip
+// size when it is ready.
+func (es *ExportStore) FinishExport(ctx context.Context, exportID int64, state rf.DataExportState, size int64) error {
+	tx, err := es.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE data_exports
+		SET state = @state,
+				size = @size,
+				completed_at = @now
+		WHERE id = @exportID AND state = 'running'
+	`
+	args := NamedArgs{
+		"exportID": exportID,
+		"state":    state,
+		"size":     size,
+		"now":      tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrExportNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteExpiredExports removes expired exports and returns their ids so
+// their files can be removed too.
+func (es *ExportStore) DeleteExpiredExports(ctx context.Context) ([]int64, error) {
+	tx, err := es.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	DELETE FROM data_exports WHERE expires_at <= @now RETURNING id
+	`
+	args := NamedArgs{
+		"now": tx.now,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := collectRows(rows, rowTo[int64])
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func findExport(ctx context.Context, tx *Tx, where string, args NamedArgs) (*rf.DataExport, error) {
+	query := `
+	SELECT id, user_id, state, size, created_at, completed_at, expires_at
+		FROM data_exports
+		WHERE ` + where + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	export := &rf.DataExport{}
+	err := scanExport(tx.QueryRow(ctx, query, args), export)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func scanExport(row Row, export *rf.DataExport) error {
+	var completedAt *time.Time
+	err := row.Scan(&export.ID, &export.UserID, &export.State, &export.Size, &export.CreatedAt,
+		&completedAt, &export.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	if completedAt != nil {
+		export.CompletedAt = *completedAt
+	}
+
+	return nil
+}
+
+// FindExportProfile returns the account part of an export, or nil when the
+// user does not exist.
+func (es *ExportStore) FindExportProfile(ctx context.Context, userID int64) (*rf.ExportProfile, error) {
+	tx, err := es.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	profile := &rf.ExportProfile{
+		User: &rf.User{},
+	}
+
+	query := `
+	SELECT users.id, users.name, users.timezone, users.preferences, users.created_at, users.modified_at,
+				 auths.email, auths.email_verified_at, auths.created_at, auths.last_signed_in_at
+		FROM users
+		JOIN auths
+			ON auths.user_id = users.id
+		WHERE users.id = @userID
+	`
+	args := NamedArgs{
+		"userID": userID,
+	}
+
+	var emailVerifiedAt *time.Time
+	var preferences string
+	err = tx.QueryRow(ctx, query, args).Scan(&profile.User.ID, &profile.User.Name, &profile.User.Timezone,
+		&preferences, &profile.User.CreatedAt, &profile.User.ModifiedAt, &profile.Email,
+		&emailVerifiedAt, &profile.CreatedAt, &profile.LastSignedInAt)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(preferences), &profile.User.Preferences); err != nil {
+		return nil, err
+	}
+
+	if emailVerifiedAt != nil {
+		profile.EmailVerifiedAt = *emailVerifiedAt
+	}
+
+	return profile, nil
+}
+
+// EachSubscription calls fn with each feed of the user as it is read, the
+// rows are never all held in memory.
+func (es *ExportStore) EachSubscription(ctx context.Context, userID int64, fn func(rf.Feed) error) error {
+	tx, err := es.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT user_feeds.feed_id, user_feeds.name, feeds.url, user_feeds.created_at
+		FROM user_feeds
+		JOIN feeds
+			ON feeds.id = user_feeds.feed_id
+		WHERE user_feeds.user_id = @userID AND NOT user_feeds.deleted
+		ORDER BY user_feeds.name, user_feeds.feed_id
+	`
+
+	var feed rf.Feed
+	return eachRow(ctx, tx, query, NamedArgs{"userID": userID},
+		[]any{&feed.ID, &feed.Name, &feed.URL, &feed.CreatedAt},
+		func() error { return fn(feed) })
+}
+
+// EachItemState calls fn with every item the user read, starred or saved.
+func (es *ExportStore) EachItemState(ctx context.Context, userID int64, fn func(rf.ExportItemState) error) error {
+	tx, err := es.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT feed_channel_items.id, feed_channels.feed_id, feed_channel_items.title,
+				 feed_channel_items.link, user_item_states.read_at, user_item_states.starred_at,
+				 user_item_states.saved_at
+		FROM user_item_states
+		JOIN feed_channel_items
+			ON feed_channel_items.id = user_item_states.item_id
+		JOIN feed_channels
+			ON feed_channels.id = feed_channel_items.feed_channel_id
+		WHERE user_item_states.user_id = @userID
+			AND (user_item_states.read_at IS NOT NULL
+				OR user_item_states.starred_at IS NOT NULL
+				OR user_item_states.saved_at IS NOT NULL)
+		ORDER BY user_item_states.created_at, feed_channel_items.id
+	`
+
+	var state rf.ExportItemState
+	var readAt, starredAt, savedAt timeValue
+	return eachRow(ctx, tx, query, NamedArgs{"userID": userID},
+		[]any{&state.ItemID, &state.FeedID, &state.Title, &state.Link, &readAt, &starredAt, &savedAt},
+		func() error {
+			state.ReadAt, state.StarredAt, state.SavedAt = readAt.Time, starredAt.Time, savedAt.Time
+			return fn(state)
+		})
+}
+
+func (es *ExportStore) EachPlaybackPosition(ctx context.Context, userID int64, fn func(rf.ExportPlaybackPosition) error) error {
+	tx, err := es.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT item_enclosures.item_id, item_enclosures.url, user_playback_positions.position_seconds,
+				 user_playback_positions.completed, user_playback_positions.modified_at
+		FROM user_playback_positions
+		JOIN item_enclosures
+			ON item_enclosures.id = user_playback_positions.enclosure_id
+		WHERE user_playback_positions.user_id = @userID
+		ORDER BY user_playback_positions.modified_at, item_enclosures.id
+	`
+
+	var position rf.ExportPlaybackPosition
+	return eachRow(ctx, tx, query, NamedArgs{"userID": userID},
+		[]any{&position.ItemID, &position.EnclosureURL, &position.PositionSeconds, &position.Completed, &position.ModifiedAt},
+		func() error { return fn(position) })
+}
+
+// EachSession calls fn with every session of the user, including revoked
+// and expired ones.
+func (es *ExportStore) EachSession(ctx context.Context, userID int64, fn func(rf.ExportSession) error) error {
+	tx, err := es.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, device_name, ip, user_agent, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = @userID
+		ORDER BY created_at, id
+	`
+
+	var session rf.ExportSession
+	var revokedAt *time.Time
+	return eachRow(ctx, tx, query, NamedArgs{"userID": userID},
+		[]any{&session.ID, &session.DeviceName, &session.IP, &session.UserAgent, &session.CreatedAt,
+			&session.LastUsedAt, &session.ExpiresAt, &revokedAt},
+		func() error {
+			session.RevokedAt = time.Time{}
+			if revokedAt != nil {
+				session.RevokedAt = *revokedAt
+			}
+			return fn(session)
+		})
+}
+
+// EachAPIKey calls fn with every api key of the user, including revoked
+// and expired ones.
+func (es *ExportStore) EachAPIKey(ctx context.Context, userID int64, fn func(rf.ExportAPIKey) error) error {
+	tx, err := es.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, name, prefix, scope, created_at, last_used_at, expires_at, revoked_at
+		FROM api_keys
+		WHERE user_id = @userID
+		ORDER BY created_at, id
+	`
+
+	var key rf.ExportAPIKey
+	var lastUsedAt, expiresAt, revokedAt timeValue
+	return eachRow(ctx, tx, query, NamedArgs{"userID": userID},
+		[]any{&key.ID, &key.Name, &key.Prefix, &key.Scope, &key.CreatedAt, &lastUsedAt, &expiresAt, &revokedAt},
+		func() error {
+			key.LastUsedAt, key.ExpiresAt, key.RevokedAt = lastUsedAt.Time, expiresAt.Time, revokedAt.Time
+			return fn(key)
+		})
+}
+
+// EachIdentity calls fn with every OpenID Connect identity linked to the
+// user.
+func (es *ExportStore) EachIdentity(ctx context.Context, userID int64, fn func(rf.ExportIdentity) error) error {
+	tx, err := es.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT issuer, subject, email, created_at
+		FROM auth_identities
+		WHERE user_id = @userID
+		ORDER BY created_at, id
+	`
+
+	var identity rf.ExportIdentity
+	return eachRow(ctx, tx, query, NamedArgs{"userID": userID},
+		[]any{&identity.Issuer, &identity.Subject, &identity.Email, &identity.CreatedAt},
+		func() error { return fn(identity) })
+}
+
+// eachRow scans the rows of query into scans one at a time and calls fn
+// after each.
+func eachRow(ctx context.Context, tx *Tx, query string, args NamedArgs, scans []any, fn func() error) error {
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := rows.Scan(scans...); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,384 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type FeedStore struct {
+	db DB
+}
+
+func NewFeedStore(db DB) *FeedStore {
+	return &FeedStore{
+		db: db,
+	}
+}
+
+// BeginTx begins a unit of work for CreateFeed and CreateUserFeed.
+func (fs *FeedStore) BeginTx(ctx context.Context) (rf.Tx, error) {
+	return fs.db.BeginTx(ctx, false)
+}
+
+// CreateFeed creates the feed in tx, when its url is taken the feed is the
+// existing one instead. A new feed is due to be fetched at once. The
+// conflicting insert waits for a concurrent one of the same url to finish,
+// so only one feed is ever created per url. A feed an administrator
+// disabled or deleted is not subscribed to again.
+func (fs *FeedStore) CreateFeed(ctx context.Context, tx rf.Tx, feed *rf.Feed) error {
+	dbTx, err := txFrom(tx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO feeds (url, created_at, modified_at, last_synced_at, resync_requested_at)
+	VALUES (@url, @now, @now, @now, @now)
+	ON CONFLICT (url) DO UPDATE
+		SET url = EXCLUDED.url
+	RETURNING id, enabled, deleted, created_at, modified_at, last_synced_at
+	`
+	args := NamedArgs{
+		"url": feed.URL,
+		"now": dbTx.now,
+	}
+
+	err = dbTx.QueryRow(ctx, query, args).Scan(&feed.ID, &feed.Enabled, &feed.Deleted, &feed.CreatedAt,
+		&feed.ModifiedAt, &feed.LastSyncedAt)
+	if err != nil {
+		return err
+	}
+
+	if !feed.Enabled || feed.Deleted {
+		return rferrors.InvalidDataf(rferrors.ErrFeedDisabled)
+	}
+
+	return nil
+}
+
+// CreateUserFeed subscribes the user to the feed in tx.
+func (fs *FeedStore) CreateUserFeed(ctx context.Context, tx rf.Tx, feed *rf.Feed) error {
+	dbTx, err := txFrom(tx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO user_feeds (user_id, feed_id, name, created_at, modified_at)
+	VALUES (@userID, @feedID, @name, @now, @now)
+	`
+	args := NamedArgs{
+		"userID": feed.UserID,
+		"feedID": feed.ID,
+		"name":   feed.Name,
+		"now":    dbTx.now,
+	}
+
+	result, err := dbTx.Exec(ctx, query, args)
+	if err != nil {
+		if dbTx.dialect.IsUniqueViolation(err) {
+			return rferrors.InvalidDataf(rferrors.ErrCouldNotProcess)
+		}
+		return err
+	}
+
+	if result.RowsAffected() != 1 {
+		return rferrors.InternalErrorf("no rows were inserted into user_feeds: %s", result.String())
+	}
+
+	return nil
+}
+
+func (fs *FeedStore) ListUserFeeds(ctx context.Context, userID int64) ([]rf.Feed, error) {
+	tx, err := fs.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT user_feeds.user_id as user_id,
+				 user_feeds.feed_id as feed_id,
+				 user_feeds.name as name,
+				 user_feeds.fetch_full_content as fetch_full_content,
+				 feeds.url as url
+		FROM user_feeds
+		LEFT JOIN feeds
+			ON user_feeds.feed_id = feeds.id
+		WHERE user_id = @userID
+	`
+	args := NamedArgs{
+		"userID": userID,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	feeds, err := collectRows(rows, func(row Row) (rf.Feed, error) {
+		var feed rf.Feed
+		var url sql.NullString
+		err := row.Scan(&feed.UserID, &feed.ID, &feed.Name, &feed.FetchFullContent, &url)
+		feed.URL = url.String
+		return feed, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return feeds, nil
+}
+
+func (fs *FeedStore) FindUserFeedByID(ctx context.Context, userID, feedID int64) (*rf.Feed, error) {
+	tx, err := fs.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	feed := &rf.Feed{
+		ID:     feedID,
+		UserID: userID,
+	}
+
+	query := `
+	SELECT name, fetch_full_content
+	FROM user_feeds
+	WHERE user_id = @userID AND feed_id = @feedID
+	`
+	args := NamedArgs{
+		"userID": feed.UserID,
+		"feedID": feed.ID,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&feed.Name, &feed.FetchFullContent)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+func (fs *FeedStore) UpdateUserFeed(ctx context.Context, feed *rf.Feed) error {
+	tx, err := fs.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	feed.ModifiedAt = tx.now
+
+	query := `
+	UPDATE user_feeds
+		SET name = @name,
+				fetch_full_content = @fetchFullContent,
+				modified_at = @modifiedAt
+		WHERE user_id = @userID AND feed_id = @feedID
+	`
+	args := NamedArgs{
+		"userID":           feed.UserID,
+		"feedID":           feed.ID,
+		"name":             feed.Name,
+		"fetchFullContent": feed.FetchFullContent,
+		"modifiedAt":       feed.ModifiedAt,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrFeedNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FindByURL returns the feed of url whoever subscribes to it, or nil when
+// there is none.
+func (fs *FeedStore) FindByURL(ctx context.Context, url string) (*rf.Feed, error) {
+	tx, err := fs.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	return findFeed(ctx, tx, "url = @url", NamedArgs{"url": url})
+}
+
+// DeleteFeed unsubscribes the user from the feed, the feed itself is kept
+// for its other subscribers.
+func (fs *FeedStore) DeleteFeed(ctx context.Context, userID, feedID int64) error {
+	tx, err := fs.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	DELETE FROM user_feeds WHERE user_id = @userID AND feed_id = @feedID
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"feedID": feedID,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrFeedNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func findFeedByID(ctx context.Context, tx *Tx, feedID int64) (*rf.Feed, error) {
+	return findFeed(ctx, tx, "id = @feedID", NamedArgs{"feedID": feedID})
+}
+
+// feedColumns are the columns scanFeed reads.
+const feedColumns = `id, url, enabled, deleted, created_at, modified_at, last_synced_at, item_identity, poll_interval_seconds`
+
+// findFeed returns the feed matching where, or nil when there is none.
+func findFeed(ctx context.Context, tx *Tx, where string, args NamedArgs) (*rf.Feed, error) {
+	query := `SELECT ` + feedColumns + ` FROM feeds WHERE ` + where
+
+	feed, err := scanFeed(tx.QueryRow(ctx, query, args))
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &feed, nil
+}
+
+func scanFeed(row Row) (rf.Feed, error) {
+	var feed rf.Feed
+	var pollIntervalSeconds int
+	err := row.Scan(&feed.ID, &feed.URL, &feed.Enabled, &feed.Deleted,
+		&feed.CreatedAt, &feed.ModifiedAt, &feed.LastSyncedAt, &feed.ItemIdentity, &pollIntervalSeconds)
+	feed.PollInterval = time.Duration(pollIntervalSeconds) * time.Second
+	return feed, err
+}
+
+// RecordFeedFetch stores the outcome of fetching a feed. A failure keeps
+// its message and counts it, a success clears the last error and any
+// pending resync request.
+func (fs *FeedStore) RecordFeedFetch(ctx context.Context, feedID int64, fetchErr error) error {
+	tx, err := fs.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := NamedArgs{
+		"feedID": feedID,
+		"now":    tx.now,
+	}
+
+	query := `
+	UPDATE feeds
+		SET last_synced_at = @now,
+				last_fetch_error = NULL,
+				fetch_failures = 0,
+				resync_requested_at = NULL
+		WHERE id = @feedID
+	`
+
+	if fetchErr != nil {
+		args["error"] = fetchErr.Error()
+		query = `
+		UPDATE feeds
+			SET last_fetch_error = @error,
+					last_fetch_error_at = @now,
+					fetch_failures = fetch_failures + 1,
+					resync_requested_at = NULL
+			WHERE id = @feedID
+		`
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrFeedNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListDueFeeds returns up to limit enabled feeds with subscribers that are
+// due to be fetched, resyncs first and then the longest since synced. A
+// feed is due when a resync is requested or its poll interval has passed
+// since both its last sync and its last failed fetch.
+func (fs *FeedStore) ListDueFeeds(ctx context.Context, limit int) ([]rf.Feed, error) {
+	tx, err := fs.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT ` + feedColumns + `
+		FROM feeds
+		WHERE enabled AND NOT deleted
+			AND EXISTS (SELECT 1 FROM user_feeds WHERE user_feeds.feed_id = feeds.id)
+			AND (resync_requested_at IS NOT NULL
+				OR (` + tx.dialect.AddSeconds("last_synced_at", "poll_interval_seconds") + ` <= @now
+					AND (last_fetch_error_at IS NULL
+						OR ` + tx.dialect.AddSeconds("last_fetch_error_at", "poll_interval_seconds") + ` <= @now)))
+		ORDER BY resync_requested_at IS NULL, last_synced_at, id
+		LIMIT @limit
+	`
+	args := NamedArgs{
+		"now":   tx.now,
+		"limit": limit,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, scanFeed)
+}
+
+// CountFeedSubscribers returns how many users subscribe to the feed.
+func (fs *FeedStore) CountFeedSubscribers(ctx context.Context, feedID int64) (int, error) {
+	tx, err := fs.db.BeginTx(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT COUNT(*) FROM user_feeds WHERE feed_id = @feedID
+	`
+	args := NamedArgs{
+		"feedID": feedID,
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, query, args).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,536 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type ItemStore struct {
+	db DB
+}
+
+func NewItemStore(db DB) *ItemStore {
+	return &ItemStore{
+		db: db,
+	}
+}
+
+func (is *ItemStore) FindFeedByID(ctx context.Context, feedID int64) (*rf.Feed, error) {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	return findFeedByID(ctx, tx, feedID)
+}
+
+func (is *ItemStore) FindUserItemByID(ctx context.Context, userID, itemID int64) (*rf.Item, error) {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	item := &rf.Item{
+		ID: itemID,
+	}
+
+	query := `
+	SELECT items.feed_channel_id, items.guid, items.title, items.desciption, items.content, items.link,
+				 COALESCE(items.story_id, 0), items.published_at, items.created_at, items.modified_at,
+				 user_feeds.fetch_full_content, COALESCE(contents.content, '')
+		FROM feed_channel_items AS items
+		JOIN feed_channels AS channels
+			ON channels.id = items.feed_channel_id
+		JOIN user_feeds
+			ON user_feeds.feed_id = channels.feed_id AND user_feeds.user_id = @userID
+		LEFT JOIN item_contents AS contents
+			ON contents.item_id = items.id AND user_feeds.fetch_full_content
+		WHERE items.id = @itemID
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"itemID": itemID,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&item.ChannelID, &item.GUID, &item.Title, &item.Description,
+		&item.Content, &item.Link, &item.StoryID, &item.PublishedAt, &item.CreatedAt, &item.ModifiedAt,
+		&item.FetchFullContent, &item.FullContent)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	item.Enclosures, err = listItemEnclosures(ctx, tx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func (is *ItemStore) UpsertChannel(ctx context.Context, channel *rf.Channel) error {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	INSERT INTO feed_channels (feed_id, title, desciption, link, created_at, modified_at)
+	VALUES (@feedID, @title, @description, @link, @now, @now)
+	ON CONFLICT (feed_id) DO UPDATE
+		SET title = EXCLUDED.title,
+				desciption = EXCLUDED.desciption,
+				link = EXCLUDED.link,
+				modified_at = EXCLUDED.modified_at
+	RETURNING id, created_at, modified_at
+	`
+	args := NamedArgs{
+		"feedID":      channel.FeedID,
+		"title":       channel.Title,
+		"description": channel.Description,
+		"link":        channel.Link,
+		"now":         tx.now,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&channel.ID, &channel.CreatedAt, &channel.ModifiedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (is *ItemStore) ListChannelItems(ctx context.Context, channelID int64, limit int) ([]rf.Item, error) {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, feed_channel_id, guid, identity_key, title, desciption, content, link,
+				 canonical_link, simhash, COALESCE(story_id, 0), published_at, created_at, modified_at
+		FROM feed_channel_items
+		WHERE feed_channel_id = @channelID
+		ORDER BY published_at DESC, id DESC
+		LIMIT @limit
+	`
+	args := NamedArgs{
+		"channelID": channelID,
+		"limit":     limit,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(row Row) (rf.Item, error) {
+		var item rf.Item
+		var simhash int64
+		err := row.Scan(&item.ID, &item.ChannelID, &item.GUID, &item.IdentityKey, &item.Title,
+			&item.Description, &item.Content, &item.Link, &item.CanonicalLink, &simhash, &item.StoryID,
+			&item.PublishedAt, &item.CreatedAt, &item.ModifiedAt)
+		item.SimHash = uint64(simhash)
+		return item, err
+	})
+}
+
+func (is *ItemStore) UpsertItems(ctx context.Context, items []*rf.Item) error {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, item := range items {
+		if err := upsertItem(ctx, tx, item); err != nil {
+			return err
+		}
+		if err := upsertEnclosures(ctx, tx, item); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (is *ItemStore) SetFeedItemIdentity(ctx context.Context, feedID, channelID int64, identity rf.ItemIdentity) error {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE feeds SET item_identity = @identity, modified_at = @now WHERE id = @feedID
+	`
+	args := NamedArgs{
+		"feedID":   feedID,
+		"identity": identity,
+		"now":      tx.now,
+	}
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return err
+	}
+
+	if identity == rf.ItemIdentityLink {
+		// Re-key stored items by link so the next sync updates them in place
+		// instead of inserting duplicates, keeping the oldest row per link.
+		query = `
+		UPDATE feed_channel_items AS items
+			SET identity_key = 'link:' || items.link
+			FROM (
+				SELECT min(id) AS id
+					FROM feed_channel_items
+					WHERE feed_channel_id = @channelID AND link <> ''
+					GROUP BY link
+			) AS keep
+			WHERE items.id = keep.id
+				AND NOT EXISTS (
+					SELECT 1 FROM feed_channel_items AS other
+						WHERE other.feed_channel_id = @channelID
+							AND other.identity_key = 'link:' || items.link
+				)
+		`
+		args = NamedArgs{
+			"channelID": channelID,
+		}
+
+		if _, err := tx.Exec(ctx, query, args); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (is *ItemStore) GroupItemStories(ctx context.Context, items []*rf.Item, maxDistance int) error {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, item := range items {
+		if err := groupItemStory(ctx, tx, item, maxDistance); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (is *ItemStore) ListTimeline(ctx context.Context, req *rf.TimelineRequest) ([]rf.TimelineEntry, error) {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	before := req.Before
+	if before.IsZero() {
+		before = tx.now.Add(time.Hour)
+	}
+
+	query := `
+	WITH user_items AS (
+		SELECT items.id, items.story_id, items.title, items.link, items.published_at,
+					 channels.feed_id, user_feeds.name
+			FROM feed_channel_items AS items
+			JOIN feed_channels AS channels
+				ON channels.id = items.feed_channel_id
+			JOIN user_feeds
+				ON user_feeds.feed_id = channels.feed_id AND user_feeds.user_id = @userID
+			WHERE items.story_id IS NOT NULL
+	), timeline AS (
+		SELECT story_id, max(published_at) AS published_at
+			FROM user_items
+			GROUP BY story_id
+			HAVING max(published_at) < @before
+			ORDER BY published_at DESC, story_id DESC
+			LIMIT @limit
+	)
+	SELECT timeline.story_id, timeline.published_at, user_items.id, user_items.feed_id, user_items.name,
+				 user_items.title, user_items.link, user_items.published_at,
+				 (states.read_at IS NOT NULL) AS read
+		FROM timeline
+		JOIN user_items
+			ON user_items.story_id = timeline.story_id
+		LEFT JOIN user_item_states AS states
+			ON states.item_id = user_items.id AND states.user_id = @userID
+		ORDER BY timeline.published_at DESC, timeline.story_id DESC, user_items.published_at, user_items.id
+	`
+	args := NamedArgs{
+		"userID": req.UserID,
+		"before": before,
+		"limit":  req.Limit,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []rf.TimelineEntry{}
+	for rows.Next() {
+		var storyID int64
+		var publishedAt timeValue
+		var source rf.TimelineSource
+
+		err := rows.Scan(&storyID, &publishedAt, &source.ItemID, &source.FeedID, &source.FeedName,
+			&source.Title, &source.Link, &source.PublishedAt, &source.Read)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(entries) == 0 || entries[len(entries)-1].StoryID != storyID {
+			entries = append(entries, rf.TimelineEntry{
+				StoryID:     storyID,
+				Title:       source.Title,
+				Link:        source.Link,
+				PublishedAt: publishedAt.Time,
+				Read:        true,
+			})
+		}
+
+		entry := &entries[len(entries)-1]
+		entry.Read = entry.Read && source.Read
+		entry.Sources = append(entry.Sources, source)
+	}
+
+	return entries, rows.Err()
+}
+
+func (is *ItemStore) MarkStoryRead(ctx context.Context, userID, storyID int64) error {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Every member of the story is marked read, including items of feeds the
+	// user subscribes to later, as long as the story is visible to the user.
+	query := `
+	INSERT INTO user_item_states (user_id, item_id, read_at, created_at, modified_at)
+	SELECT @userID, items.id, @now, @now, @now
+		FROM feed_channel_items AS items
+		WHERE items.story_id = @storyID
+			AND EXISTS (
+				SELECT 1
+					FROM feed_channel_items AS visible
+					JOIN feed_channels AS channels
+						ON channels.id = visible.feed_channel_id
+					JOIN user_feeds
+						ON user_feeds.feed_id = channels.feed_id AND user_feeds.user_id = @userID
+					WHERE visible.story_id = @storyID
+			)
+	ON CONFLICT (user_id, item_id) DO UPDATE
+		SET read_at = COALESCE(user_item_states.read_at, EXCLUDED.read_at),
+				modified_at = EXCLUDED.modified_at
+	`
+	args := NamedArgs{
+		"userID":  userID,
+		"storyID": storyID,
+		"now":     tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrStoryNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// StarItem stars or unstars an item of a feed the user subscribes to.
+func (is *ItemStore) StarItem(ctx context.Context, userID, itemID int64, starred bool) error {
+	return is.setItemState(ctx, "starred_at", userID, itemID, starred)
+}
+
+// SaveItem saves an item of a feed the user subscribes to for later, or
+// stops saving it.
+func (is *ItemStore) SaveItem(ctx context.Context, userID, itemID int64, saved bool) error {
+	return is.setItemState(ctx, "saved_at", userID, itemID, saved)
+}
+
+// setItemState sets the time column of the user's state of an item to now
+// when on, keeping the time it was first set, and clears it otherwise.
+func (is *ItemStore) setItemState(ctx context.Context, column string, userID, itemID int64, on bool) error {
+	tx, err := is.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var at *time.Time
+	if on {
+		at = &tx.now
+	}
+
+	query := `
+	INSERT INTO user_item_states (user_id, item_id, ` + column + `, created_at, modified_at)
+	SELECT @userID, items.id, @at, @now, @now
+		FROM feed_channel_items AS items
+		JOIN feed_channels AS channels
+			ON channels.id = items.feed_channel_id
+		JOIN user_feeds
+			ON user_feeds.feed_id = channels.feed_id AND user_feeds.user_id = @userID
+		WHERE items.id = @itemID
+	ON CONFLICT (user_id, item_id) DO UPDATE
+		SET ` + column + ` = CASE WHEN EXCLUDED.` + column + ` IS NULL THEN NULL
+													 ELSE COALESCE(user_item_states.` + column + `, EXCLUDED.` + column + `) END,
+				modified_at = EXCLUDED.modified_at
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"itemID": itemID,
+		"at":     at,
+		"now":    tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrItemNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func upsertItem(ctx context.Context, tx *Tx, item *rf.Item) error {
+	// Only rows whose content actually changed are updated, so modified_at
+	// is a reliable signal and the item id (and any per-user state keyed on
+	// it) survives every sync. Undated items are dated when first stored and
+	// keep that date.
+	var publishedAt *time.Time
+	firstPublishedAt := tx.now
+	if !item.PublishedAt.IsZero() {
+		publishedAt = &item.PublishedAt
+		firstPublishedAt = item.PublishedAt
+	}
+
+	query := `
+	INSERT INTO feed_channel_items (feed_channel_id, identity_key, guid, title, desciption, content, link,
+																	canonical_link, simhash, published_at, created_at, modified_at)
+	VALUES (@channelID, @identityKey, @guid, @title, @description, @content, @link,
+					@canonicalLink, @simhash, @firstPublishedAt, @now, @now)
+	ON CONFLICT (feed_channel_id, identity_key) DO UPDATE
+		SET guid = EXCLUDED.guid,
+				title = EXCLUDED.title,
+				desciption = EXCLUDED.desciption,
+				content = EXCLUDED.content,
+				link = EXCLUDED.link,
+				canonical_link = EXCLUDED.canonical_link,
+				simhash = EXCLUDED.simhash,
+				published_at = COALESCE(@publishedAt, feed_channel_items.published_at),
+				modified_at = EXCLUDED.modified_at
+		WHERE (feed_channel_items.guid, feed_channel_items.title, feed_channel_items.desciption,
+					 feed_channel_items.content, feed_channel_items.link, feed_channel_items.published_at)
+			IS DISTINCT FROM (EXCLUDED.guid, EXCLUDED.title, EXCLUDED.desciption,
+												EXCLUDED.content, EXCLUDED.link, COALESCE(@publishedAt, feed_channel_items.published_at))
+	RETURNING id, COALESCE(story_id, 0), published_at, created_at, modified_at
+	`
+	args := NamedArgs{
+		"channelID":        item.ChannelID,
+		"identityKey":      item.IdentityKey,
+		"guid":             item.GUID,
+		"title":            item.Title,
+		"description":      item.Description,
+		"content":          item.Content,
+		"link":             item.Link,
+		"canonicalLink":    item.CanonicalLink,
+		"simhash":          int64(item.SimHash),
+		"publishedAt":      publishedAt,
+		"firstPublishedAt": firstPublishedAt,
+		"now":              tx.now,
+	}
+
+	err := tx.QueryRow(ctx, query, args).Scan(&item.ID, &item.StoryID, &item.PublishedAt, &item.CreatedAt, &item.ModifiedAt)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	query = `
+	SELECT id, COALESCE(story_id, 0), published_at, created_at, modified_at
+		FROM feed_channel_items
+		WHERE feed_channel_id = @channelID AND identity_key = @identityKey
+	`
+
+	return tx.QueryRow(ctx, query, args).Scan(&item.ID, &item.StoryID, &item.PublishedAt, &item.CreatedAt, &item.ModifiedAt)
+}
+
+// storyWindow bounds how far back other feeds are searched for the same
+// story, which keeps the SimHash comparison to a small set of rows.
+const storyWindow = 72 * time.Hour
+
+func groupItemStory(ctx context.Context, tx *Tx, item *rf.Item, maxDistance int) error {
+	query := `
+	SELECT other.story_id
+		FROM feed_channel_items AS other
+		JOIN feed_channels AS other_channel
+			ON other_channel.id = other.feed_channel_id
+		WHERE other.story_id IS NOT NULL
+			AND other.published_at >= @since
+			AND other_channel.feed_id <> (SELECT feed_id FROM feed_channels WHERE id = @channelID)
+			AND (
+				(@canonicalLink <> '' AND other.canonical_link = @canonicalLink)
+				OR (@simhash <> 0 AND other.simhash <> 0
+					AND ` + tx.dialect.SimHashDistance("other.simhash", "@simhash") + ` <= @maxDistance)
+			)
+		ORDER BY (other.canonical_link = @canonicalLink) DESC, other.id
+		LIMIT 1
+	`
+	args := NamedArgs{
+		"itemID":        item.ID,
+		"channelID":     item.ChannelID,
+		"canonicalLink": item.CanonicalLink,
+		"simhash":       int64(item.SimHash),
+		"maxDistance":   maxDistance,
+		"since":         item.PublishedAt.Add(-storyWindow),
+		"now":           tx.now,
+	}
+
+	err := tx.QueryRow(ctx, query, args).Scan(&item.StoryID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if item.StoryID == 0 {
+		query = `
+		INSERT INTO stories (created_at) VALUES (@now) RETURNING id
+		`
+		if err := tx.QueryRow(ctx, query, args).Scan(&item.StoryID); err != nil {
+			return err
+		}
+	}
+	args["storyID"] = item.StoryID
+
+	query = `
+	UPDATE feed_channel_items SET story_id = @storyID WHERE id = @itemID
+	`
+	_, err = tx.Exec(ctx, query, args)
+	return err
+}
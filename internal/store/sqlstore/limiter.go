@@ -0,0 +1,106 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/limiter"
+)
+
+// Limiter is a limiter.Limiter that keeps failures in the database so every
+// API instance sees the same counts.
+type Limiter struct {
+	db DB
+}
+
+func NewLimiter(db DB) *Limiter {
+	return &Limiter{
+		db: db,
+	}
+}
+
+func (l *Limiter) Fail(ctx context.Context, key string) error {
+	tx, err := l.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	INSERT INTO limiter_failures (key, failed_at)
+	VALUES (@key, @now)
+	`
+	args := NamedArgs{
+		"key": key,
+		"now": tx.now,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	// Failures are only ever counted over recent windows, older ones of the
+	// key are dropped as new ones come in.
+	query = `
+	DELETE FROM limiter_failures WHERE key = @key AND failed_at < @expiredAt
+	`
+	args = NamedArgs{
+		"key":       key,
+		"expiredAt": tx.now.Add(-limiter.Retention),
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (l *Limiter) Failures(ctx context.Context, key string, since time.Time) (int, time.Time, error) {
+	tx, err := l.db.BeginTx(ctx, false)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT count(*), max(failed_at) FROM limiter_failures WHERE key = @key AND failed_at >= @since
+	`
+	args := NamedArgs{
+		"key":   key,
+		"since": since.UTC(),
+	}
+
+	var count int
+	var lastFailedAt timeValue
+	err = tx.QueryRow(ctx, query, args).Scan(&count, &lastFailedAt)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return count, lastFailedAt.Time, nil
+}
+
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	tx, err := l.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	DELETE FROM limiter_failures WHERE key = @key
+	`
+	args := NamedArgs{
+		"key": key,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,175 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateOIDCLoginState(ctx context.Context, state *rf.OIDCLoginState) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	state.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO oidc_login_states (state_hash, provider, nonce, code_verifier, created_at, expires_at)
+	VALUES (@stateHash, @provider, @nonce, @codeVerifier, @createdAt, @expiresAt)
+	`
+	args := NamedArgs{
+		"stateHash":    state.StateHash,
+		"provider":     state.Provider,
+		"nonce":        state.Nonce,
+		"codeVerifier": state.CodeVerifier,
+		"createdAt":    state.CreatedAt,
+		"expiresAt":    state.ExpiresAt,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseOIDCLoginState spends the state of a sign in started with provider.
+func (as *AuthStore) UseOIDCLoginState(ctx context.Context, provider, stateHash string) (*rf.OIDCLoginState, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	state := &rf.OIDCLoginState{}
+
+	query := `
+	UPDATE oidc_login_states
+		SET used_at = @now
+		WHERE state_hash = @stateHash AND provider = @provider AND used_at IS NULL AND expires_at > @now
+		RETURNING state_hash, provider, nonce, code_verifier, created_at, expires_at
+	`
+	args := NamedArgs{
+		"stateHash": stateHash,
+		"provider":  provider,
+		"now":       tx.now,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&state.StateHash, &state.Provider, &state.Nonce,
+		&state.CodeVerifier, &state.CreatedAt, &state.ExpiresAt)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, rferrors.InvalidDataf(rferrors.ErrOIDCStateInvalid)
+		}
+		return nil, err
+	}
+
+	return state, tx.Commit(ctx)
+}
+
+// FindIdentity returns the identity of subject at issuer, or nil when it
+// is not linked to an account.
+func (as *AuthStore) FindIdentity(ctx context.Context, issuer, subject string) (*rf.AuthIdentity, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	identity := &rf.AuthIdentity{}
+
+	query := `
+	SELECT id, user_id, issuer, subject, email, created_at
+		FROM auth_identities
+		WHERE issuer = @issuer AND subject = @subject
+	`
+	args := NamedArgs{
+		"issuer":  issuer,
+		"subject": subject,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&identity.ID, &identity.UserID, &identity.Issuer,
+		&identity.Subject, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (as *AuthStore) CreateIdentity(ctx context.Context, identity *rf.AuthIdentity) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = createIdentity(ctx, tx, identity)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateAuthAndUserWithIdentity signs up a user of an OpenID Connect
+// provider, the account has no password until one is reset.
+func (as *AuthStore) CreateAuthAndUserWithIdentity(ctx context.Context, auth *rf.Auth, identity *rf.AuthIdentity) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = createAuthAndUser(ctx, tx, auth)
+	if err != nil {
+		if tx.dialect.IsUniqueViolation(err) {
+			return rferrors.InvalidDataf(rferrors.ErrCouldNotProcess)
+		}
+		return err
+	}
+
+	identity.UserID = auth.UserID
+	err = createIdentity(ctx, tx, identity)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func createIdentity(ctx context.Context, tx *Tx, identity *rf.AuthIdentity) error {
+	identity.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO auth_identities (user_id, issuer, subject, email, created_at)
+	VALUES (@userID, @issuer, @subject, @email, @createdAt)
+	RETURNING id
+	`
+	args := NamedArgs{
+		"userID":    identity.UserID,
+		"issuer":    identity.Issuer,
+		"subject":   identity.Subject,
+		"email":     identity.Email,
+		"createdAt": identity.CreatedAt,
+	}
+
+	err := tx.QueryRow(ctx, query, args).Scan(&identity.ID)
+	if err != nil {
+		if tx.dialect.IsUniqueViolation(err) {
+			return rferrors.InvalidDataf(rferrors.ErrOIDCIdentityLinked)
+		}
+		return err
+	}
+
+	return nil
+}
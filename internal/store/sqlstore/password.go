@@ -0,0 +1,147 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreatePasswordResetToken(ctx context.Context, token *rf.PasswordResetToken) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	token.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+	VALUES (@tokenHash, @userID, @createdAt, @expiresAt)
+	`
+	args := NamedArgs{
+		"tokenHash": token.TokenHash,
+		"userID":    token.UserID,
+		"createdAt": token.CreatedAt,
+		"expiresAt": token.ExpiresAt,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ResetPassword spends a reset token, sets the new password and revokes
+// every session of the user in a single transaction. It returns the id of
+// the user.
+func (as *AuthStore) ResetPassword(ctx context.Context, tokenHash, hashedPassword string) (int64, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE password_reset_tokens
+		SET used_at = @now
+		WHERE token_hash = @tokenHash AND used_at IS NULL AND expires_at > @now
+		RETURNING user_id
+	`
+	args := NamedArgs{
+		"tokenHash": tokenHash,
+		"now":       tx.now,
+	}
+
+	var userID int64
+	err = tx.QueryRow(ctx, query, args).Scan(&userID)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return 0, rferrors.InvalidDataf(rferrors.ErrResetTokenInvalid)
+		}
+		return 0, err
+	}
+
+	args = NamedArgs{
+		"userID":   userID,
+		"password": hashedPassword,
+		"now":      tx.now,
+	}
+
+	queries := []string{
+		`UPDATE auths SET password = @password, modified_at = @now WHERE user_id = @userID`,
+		`UPDATE password_reset_tokens SET used_at = @now WHERE user_id = @userID AND used_at IS NULL`,
+		`UPDATE sessions SET revoked_at = @now WHERE user_id = @userID AND revoked_at IS NULL`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, args); err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// ChangePassword sets a new password, spends outstanding reset tokens and
+// revokes every session of the user but keepSessionID.
+func (as *AuthStore) ChangePassword(ctx context.Context, userID, keepSessionID int64, hashedPassword string) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := NamedArgs{
+		"userID":        userID,
+		"keepSessionID": keepSessionID,
+		"password":      hashedPassword,
+		"now":           tx.now,
+	}
+
+	queries := []string{
+		`UPDATE auths SET password = @password, modified_at = @now WHERE user_id = @userID`,
+		`UPDATE password_reset_tokens SET used_at = @now WHERE user_id = @userID AND used_at IS NULL`,
+		`UPDATE sessions SET revoked_at = @now WHERE user_id = @userID AND id <> @keepSessionID AND revoked_at IS NULL`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, args); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RehashPassword replaces a password hash with one of the same password
+// made with stronger parameters, unless the password changed since
+// oldHash was read. Sessions are kept as the password is the same.
+func (as *AuthStore) RehashPassword(ctx context.Context, userID int64, oldHash, newHash string) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE auths SET password = @newHash WHERE user_id = @userID AND password = @oldHash`
+	args := NamedArgs{
+		"userID":  userID,
+		"oldHash": oldHash,
+		"newHash": newHash,
+	}
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
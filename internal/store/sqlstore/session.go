@@ -0,0 +1,287 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateSession(ctx context.Context, session *rf.Session, token *rf.RefreshToken) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	session.CreatedAt = tx.now
+	session.LastUsedAt = session.CreatedAt
+
+	query := `
+	INSERT INTO sessions (user_id, device_name, ip, user_agent, created_at, last_used_at, expires_at)
+	VALUES (@userID, @deviceName, @ip, @userAgent, @createdAt, @lastUsedAt, @expiresAt)
+	RETURNING id
+	`
+	args := NamedArgs{
+		"userID":     session.UserID,
+		"deviceName": session.DeviceName,
+		"ip":         session.IP,
+		"userAgent":  session.UserAgent,
+		"createdAt":  session.CreatedAt,
+		"lastUsedAt": session.LastUsedAt,
+		"expiresAt":  session.ExpiresAt,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&session.ID)
+	if err != nil {
+		return err
+	}
+
+	token.SessionID = session.ID
+	err = createRefreshToken(ctx, tx, token)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthStore) FindRefreshToken(ctx context.Context, tokenHash string) (*rf.RefreshToken, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	token := &rf.RefreshToken{
+		TokenHash: tokenHash,
+	}
+
+	query := `
+	SELECT session_id, created_at, used_at
+	FROM session_refresh_tokens
+	WHERE token_hash = @tokenHash
+	`
+	args := NamedArgs{
+		"tokenHash": tokenHash,
+	}
+
+	var usedAt *time.Time
+	err = tx.QueryRow(ctx, query, args).Scan(&token.SessionID, &token.CreatedAt, &usedAt)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if usedAt != nil {
+		token.UsedAt = *usedAt
+	}
+
+	return token, nil
+}
+
+func (as *AuthStore) FindSessionByID(ctx context.Context, sessionID int64) (*rf.Session, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	session := &rf.Session{
+		ID: sessionID,
+	}
+
+	query := `
+	SELECT user_id, device_name, ip, user_agent, created_at, last_used_at, expires_at, revoked_at
+	FROM sessions
+	WHERE id = @sessionID
+	`
+	args := NamedArgs{
+		"sessionID": sessionID,
+	}
+
+	var revokedAt *time.Time
+	err = tx.QueryRow(ctx, query, args).Scan(&session.UserID, &session.DeviceName, &session.IP, &session.UserAgent,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &revokedAt)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if revokedAt != nil {
+		session.RevokedAt = *revokedAt
+	}
+
+	return session, nil
+}
+
+func (as *AuthStore) RotateRefreshToken(ctx context.Context, session *rf.Session, used, next *rf.RefreshToken) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Only one of two concurrent refreshes with the same token may win, the
+	// loser is treated as a reuse.
+	query := `
+	UPDATE session_refresh_tokens
+		SET used_at = @now
+		WHERE token_hash = @tokenHash AND used_at IS NULL
+	`
+	args := NamedArgs{
+		"tokenHash": used.TokenHash,
+		"now":       tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.Unauthorizedf(rferrors.ErrRefreshTokenReused)
+	}
+
+	next.SessionID = session.ID
+	err = createRefreshToken(ctx, tx, next)
+	if err != nil {
+		return err
+	}
+
+	session.LastUsedAt = tx.now
+
+	query = `
+	UPDATE sessions
+		SET ip = @ip,
+				user_agent = @userAgent,
+				last_used_at = @lastUsedAt,
+				expires_at = @expiresAt
+		WHERE id = @sessionID
+	`
+	args = NamedArgs{
+		"sessionID":  session.ID,
+		"ip":         session.IP,
+		"userAgent":  session.UserAgent,
+		"lastUsedAt": session.LastUsedAt,
+		"expiresAt":  session.ExpiresAt,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthStore) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE sessions
+		SET revoked_at = COALESCE(revoked_at, @now)
+		WHERE id = @sessionID AND user_id = @userID
+	`
+	args := NamedArgs{
+		"userID":    userID,
+		"sessionID": sessionID,
+		"now":       tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrSessionNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthStore) ListUserSessions(ctx context.Context, userID int64) ([]rf.Session, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, user_id, device_name, ip, user_agent, created_at, last_used_at, expires_at
+	FROM sessions
+	WHERE user_id = @userID AND revoked_at IS NULL AND expires_at > @now
+	ORDER BY last_used_at DESC, id DESC
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"now":    tx.now,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(row Row) (rf.Session, error) {
+		var session rf.Session
+		err := row.Scan(&session.ID, &session.UserID, &session.DeviceName, &session.IP, &session.UserAgent,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt)
+		return session, err
+	})
+}
+
+func (as *AuthStore) RevokeOtherSessions(ctx context.Context, userID, keepSessionID int64) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE sessions
+		SET revoked_at = @now
+		WHERE user_id = @userID AND id <> @keepSessionID AND revoked_at IS NULL
+	`
+	args := NamedArgs{
+		"userID":        userID,
+		"keepSessionID": keepSessionID,
+		"now":           tx.now,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func createRefreshToken(ctx context.Context, tx *Tx, token *rf.RefreshToken) error {
+	token.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO session_refresh_tokens (token_hash, session_id, created_at)
+	VALUES (@tokenHash, @sessionID, @createdAt)
+	`
+	args := NamedArgs{
+		"tokenHash": token.TokenHash,
+		"sessionID": token.SessionID,
+		"createdAt": token.CreatedAt,
+	}
+
+	_, err := tx.Exec(ctx, query, args)
+	return err
+}
@@ -0,0 +1,209 @@
+// Package sqlstore keeps everything in a SQL database. The stores are
+// written once against DB, postgresstore and sqlitestore open the database
+// and say how its SQL differs through Dialect.
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// DB is the database the stores keep their data in.
+type DB interface {
+	// BeginTx begins a transaction, one that is readOnly does not wait for
+	// or hold the write lock.
+	BeginTx(ctx context.Context, readOnly bool) (*Tx, error)
+}
+
+// Conn is a transaction of the database driver. QueryRow returns
+// sql.ErrNoRows when there is no row, whatever the driver calls it.
+type Conn interface {
+	Exec(ctx context.Context, query string, args NamedArgs) (int64, error)
+	Query(ctx context.Context, query string, args NamedArgs) (Rows, error)
+	QueryRow(ctx context.Context, query string, args NamedArgs) Row
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close()
+}
+
+type Row interface {
+	Scan(dest ...any) error
+}
+
+// Dialect is where the SQL of a database differs from the SQL the stores
+// share.
+type Dialect struct {
+	// ForUpdate is appended to a SELECT to lock the rows it reads until the
+	// transaction ends, SkipLocked does too but skips rows another
+	// transaction locked. Both are empty where a transaction that writes
+	// holds the only write lock.
+	ForUpdate  string
+	SkipLocked string
+	// ILike matches a LIKE pattern ignoring case.
+	ILike string
+	// AddSeconds returns the time column moved by the seconds column.
+	AddSeconds func(column, seconds string) string
+	// SimHashDistance returns the number of bits two SimHashes differ in.
+	SimHashDistance func(a, b string) string
+	// IsUniqueViolation reports whether err is a unique constraint failing.
+	IsUniqueViolation func(err error) bool
+}
+
+// NamedArgs are the @name parameters of a query.
+type NamedArgs map[string]any
+
+// Tx is a transaction of a DB. Everything done in it happens at now, so
+// the times it writes agree with each other and with the clock of the DB.
+type Tx struct {
+	conn    Conn
+	dialect *Dialect
+	now     time.Time
+}
+
+func NewTx(conn Conn, dialect *Dialect, now time.Time) *Tx {
+	return &Tx{
+		conn:    conn,
+		dialect: dialect,
+		now:     now.UTC().Truncate(time.Second),
+	}
+}
+
+func (tx *Tx) Exec(ctx context.Context, query string, args ...NamedArgs) (Result, error) {
+	rowsAffected, err := tx.conn.Exec(ctx, query, merge(args))
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{rowsAffected: rowsAffected}, nil
+}
+
+func (tx *Tx) Query(ctx context.Context, query string, args ...NamedArgs) (Rows, error) {
+	return tx.conn.Query(ctx, query, merge(args))
+}
+
+func (tx *Tx) QueryRow(ctx context.Context, query string, args ...NamedArgs) Row {
+	return tx.conn.QueryRow(ctx, query, merge(args))
+}
+
+func (tx *Tx) Commit(ctx context.Context) error {
+	return tx.conn.Commit(ctx)
+}
+
+// Rollback rolls back the transaction, after Commit it does nothing.
+func (tx *Tx) Rollback(ctx context.Context) error {
+	return tx.conn.Rollback(ctx)
+}
+
+// txFrom returns the transaction behind tx, which a FeedStore began.
+func txFrom(tx rf.Tx) (*Tx, error) {
+	sqlTx, ok := tx.(*Tx)
+	if !ok {
+		return nil, rferrors.InternalErrorf("%T is not a sql transaction", tx)
+	}
+	return sqlTx, nil
+}
+
+func merge(args []NamedArgs) NamedArgs {
+	if len(args) == 1 {
+		return args[0]
+	}
+
+	merged := NamedArgs{}
+	for _, a := range args {
+		for name, value := range a {
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+// Result is the outcome of Exec.
+type Result struct {
+	rowsAffected int64
+}
+
+func (r Result) RowsAffected() int64 {
+	return r.rowsAffected
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d rows affected", r.rowsAffected)
+}
+
+// collectRows scans every row with fn and closes rows, it returns an empty
+// slice when there are none.
+func collectRows[T any](rows Rows, fn func(row Row) (T, error)) ([]T, error) {
+	defer rows.Close()
+
+	collected := []T{}
+	for rows.Next() {
+		value, err := fn(rows)
+		if err != nil {
+			return nil, err
+		}
+		collected = append(collected, value)
+	}
+
+	return collected, rows.Err()
+}
+
+// rowTo scans a row of a single column.
+func rowTo[T any](row Row) (T, error) {
+	var value T
+	err := row.Scan(&value)
+	return value, err
+}
+
+// inList returns the parameters of an IN list of values named name0,
+// name1 and so on, and adds them to args.
+func inList[T any](args NamedArgs, name string, values []T) string {
+	params := make([]string, 0, len(values))
+	for i, value := range values {
+		param := fmt.Sprintf("%s%d", name, i)
+		args[param] = value
+		params = append(params, "@"+param)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// timeValue scans a timestamp that may be null or went through an
+// expression, such as max(), which some drivers no longer know is a time
+// and hand over as text.
+type timeValue struct {
+	Time  time.Time
+	Valid bool
+}
+
+// TimeFormat is how a driver that keeps times as text writes them. They
+// are always UTC and without a zone, so they compare as text.
+const TimeFormat = "2006-01-02 15:04:05.999999999"
+
+func (tv *timeValue) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		tv.Time, tv.Valid = time.Time{}, false
+		return nil
+	case time.Time:
+		tv.Time, tv.Valid = v.UTC(), true
+		return nil
+	case string:
+		t, err := time.Parse(TimeFormat, v)
+		if err != nil {
+			return err
+		}
+		tv.Time, tv.Valid = t, true
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into a time", src)
+	}
+}
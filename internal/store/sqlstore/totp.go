@@ -0,0 +1,247 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+// SaveTOTPSecret starts enrolment with a new secret, replacing the secret
+// of an enrolment that was never confirmed.
+func (as *AuthStore) SaveTOTPSecret(ctx context.Context, totp *rf.TOTP) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	totp.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO auth_totps (user_id, secret, created_at)
+	VALUES (@userID, @secret, @createdAt)
+	ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret,
+				created_at = EXCLUDED.created_at,
+				last_used_step = 0
+		WHERE auth_totps.enabled_at IS NULL
+	`
+	args := NamedArgs{
+		"userID":    totp.UserID,
+		"secret":    totp.Secret,
+		"createdAt": totp.CreatedAt,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.InvalidDataf(rferrors.ErrTOTPAlreadyEnabled)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthStore) FindTOTP(ctx context.Context, userID int64) (*rf.TOTP, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT user_id, secret, created_at, enabled_at, last_used_step FROM auth_totps WHERE user_id = @userID
+	`
+	args := NamedArgs{
+		"userID": userID,
+	}
+
+	totp := &rf.TOTP{}
+	var enabledAt *time.Time
+	err = tx.QueryRow(ctx, query, args).Scan(&totp.UserID, &totp.Secret, &totp.CreatedAt, &enabledAt, &totp.LastUsedStep)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if enabledAt != nil {
+		totp.EnabledAt = *enabledAt
+	}
+
+	return totp, nil
+}
+
+// EnableTOTP confirms enrolment with the step of the first code and
+// replaces the recovery codes of the user.
+func (as *AuthStore) EnableTOTP(ctx context.Context, userID, step int64, recoveryCodeHashes []string) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE auth_totps
+		SET enabled_at = @now,
+				last_used_step = @step
+		WHERE user_id = @userID AND enabled_at IS NULL AND last_used_step < @step
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"step":   step,
+		"now":    tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.InvalidDataf(rferrors.ErrTOTPCodeInvalid)
+	}
+
+	err = replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseTOTPStep spends the step of a code, a step at or before the last one
+// used is a replayed code.
+func (as *AuthStore) UseTOTPStep(ctx context.Context, userID, step int64) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE auth_totps
+		SET last_used_step = @step
+		WHERE user_id = @userID AND enabled_at IS NOT NULL AND last_used_step < @step
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"step":   step,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.Unauthorizedf(rferrors.ErrTOTPCodeInvalid)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE totp_recovery_codes
+		SET used_at = @now
+		WHERE code_hash = @codeHash AND user_id = @userID AND used_at IS NULL
+	`
+	args := NamedArgs{
+		"userID":   userID,
+		"codeHash": codeHash,
+		"now":      tx.now,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.Unauthorizedf(rferrors.ErrTOTPCodeInvalid)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DisableTOTP removes the secret and recovery codes of the user, step is
+// of the code that confirmed it so a replayed code cannot.
+func (as *AuthStore) DisableTOTP(ctx context.Context, userID, step int64) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	DELETE FROM auth_totps
+		WHERE user_id = @userID AND enabled_at IS NOT NULL AND last_used_step < @step
+	`
+	args := NamedArgs{
+		"userID": userID,
+		"step":   step,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.InvalidDataf(rferrors.ErrTOTPCodeInvalid)
+	}
+
+	err = replaceRecoveryCodes(ctx, tx, userID, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *Tx, userID int64, codeHashes []string) error {
+	query := `
+	DELETE FROM totp_recovery_codes WHERE user_id = @userID
+	`
+	args := NamedArgs{
+		"userID": userID,
+	}
+
+	_, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		query := `
+		INSERT INTO totp_recovery_codes (code_hash, user_id, created_at)
+		VALUES (@codeHash, @userID, @now)
+		`
+		args := NamedArgs{
+			"codeHash": codeHash,
+			"userID":   userID,
+			"now":      tx.now,
+		}
+
+		_, err := tx.Exec(ctx, query, args)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
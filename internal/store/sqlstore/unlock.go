@@ -0,0 +1,78 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+func (as *AuthStore) CreateUnlockToken(ctx context.Context, token *rf.UnlockToken) error {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	token.CreatedAt = tx.now
+
+	query := `
+	INSERT INTO account_unlock_tokens (token_hash, user_id, created_at, expires_at)
+	VALUES (@tokenHash, @userID, @createdAt, @expiresAt)
+	`
+	args := NamedArgs{
+		"tokenHash": token.TokenHash,
+		"userID":    token.UserID,
+		"createdAt": token.CreatedAt,
+		"expiresAt": token.ExpiresAt,
+	}
+
+	_, err = tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseUnlockToken spends an unlock token and returns the auth it unlocks.
+func (as *AuthStore) UseUnlockToken(ctx context.Context, tokenHash string) (*rf.Auth, error) {
+	tx, err := as.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE account_unlock_tokens
+		SET used_at = @now
+		WHERE token_hash = @tokenHash AND used_at IS NULL AND expires_at > @now
+		RETURNING user_id
+	`
+	args := NamedArgs{
+		"tokenHash": tokenHash,
+		"now":       tx.now,
+	}
+
+	var userID int64
+	err = tx.QueryRow(ctx, query, args).Scan(&userID)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, rferrors.InvalidDataf(rferrors.ErrUnlockTokenInvalid)
+		}
+		return nil, err
+	}
+
+	auth, err := findAuth(ctx, tx, "auths.user_id = @userID", NamedArgs{"userID": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	if auth == nil {
+		return nil, rferrors.InvalidDataf(rferrors.ErrUnlockTokenInvalid)
+	}
+
+	return auth, tx.Commit(ctx)
+}
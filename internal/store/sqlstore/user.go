@@ -0,0 +1,128 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	rferrors "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+type UserStore struct {
+	db DB
+}
+
+func NewUserStore(db DB) *UserStore {
+	return &UserStore{
+		db: db,
+	}
+}
+
+func createUser(ctx context.Context, tx *Tx, user *rf.User) error {
+	user.CreatedAt = tx.now
+	user.ModifiedAt = user.CreatedAt
+
+	query := `
+  INSERT INTO users (name, created_at, modified_at)
+  VALUES (@name, @createdAt, @modifiedAt)
+  RETURNING id
+	`
+	args := NamedArgs{
+		"name":       user.Name,
+		"createdAt":  user.CreatedAt,
+		"modifiedAt": user.ModifiedAt,
+	}
+
+	err := tx.QueryRow(ctx, query, args).Scan(&user.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindUser returns the user, or nil when there is none.
+func (us *UserStore) FindUser(ctx context.Context, userID int64) (*rf.User, error) {
+	tx, err := us.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	user := &rf.User{}
+
+	query := `
+	SELECT id, name, role, timezone, preferences, created_at, modified_at
+		FROM users
+		WHERE id = @userID
+	`
+	args := NamedArgs{
+		"userID": userID,
+	}
+
+	var preferences string
+	err = tx.QueryRow(ctx, query, args).Scan(&user.ID, &user.Name, &user.Role, &user.Timezone,
+		&preferences, &user.CreatedAt, &user.ModifiedAt)
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(preferences), &user.Preferences); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (us *UserStore) UpdateUser(ctx context.Context, user *rf.User) error {
+	tx, err := us.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	user.ModifiedAt = tx.now
+
+	preferences := user.Preferences
+	if preferences == nil {
+		preferences = map[string]any{}
+	}
+
+	// Preferences are passed as JSON text, which either database keeps.
+	preferencesJSON, err := json.Marshal(preferences)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE users
+		SET name = @name,
+				timezone = @timezone,
+				preferences = @preferences,
+				modified_at = @modifiedAt
+		WHERE id = @userID
+	`
+	args := NamedArgs{
+		"userID":      user.ID,
+		"name":        user.Name,
+		"timezone":    user.Timezone,
+		"preferences": string(preferencesJSON),
+		"modifiedAt":  user.ModifiedAt,
+	}
+
+	result, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return rferrors.NotFoundf(rferrors.ErrUserNotFound)
+	}
+
+	return tx.Commit(ctx)
+}
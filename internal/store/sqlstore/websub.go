@@ -0,0 +1,151 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+)
+
+type WebSubStore struct {
+	db DB
+}
+
+func NewWebSubStore(db DB) *WebSubStore {
+	return &WebSubStore{
+		db: db,
+	}
+}
+
+func (ws *WebSubStore) FindSubscriptionByFeedID(ctx context.Context, feedID int64) (*rf.WebSubSubscription, error) {
+	tx, err := ws.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, feed_id, hub_url, topic_url, secret, state, lease_seconds,
+				 expires_at, created_at, modified_at
+		FROM websub_subscriptions
+		WHERE feed_id = @feedID
+	`
+	args := NamedArgs{
+		"feedID": feedID,
+	}
+
+	sub, err := scanSubscription(tx.QueryRow(ctx, query, args))
+	if err != nil {
+		if ok := errors.Is(err, sql.ErrNoRows); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+func (ws *WebSubStore) SaveSubscription(ctx context.Context, sub *rf.WebSubSubscription) error {
+	tx, err := ws.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var expiresAt *time.Time
+	if !sub.ExpiresAt.IsZero() {
+		expiresAt = &sub.ExpiresAt
+	}
+
+	query := `
+	INSERT INTO websub_subscriptions (feed_id, hub_url, topic_url, secret, state, lease_seconds, expires_at,
+																		created_at, modified_at)
+	VALUES (@feedID, @hubURL, @topicURL, @secret, @state, @leaseSeconds, @expiresAt, @now, @now)
+	ON CONFLICT (feed_id) DO UPDATE
+		SET hub_url = EXCLUDED.hub_url,
+				topic_url = EXCLUDED.topic_url,
+				secret = EXCLUDED.secret,
+				state = EXCLUDED.state,
+				lease_seconds = EXCLUDED.lease_seconds,
+				expires_at = EXCLUDED.expires_at,
+				modified_at = EXCLUDED.modified_at
+	RETURNING id, created_at, modified_at
+	`
+	args := NamedArgs{
+		"feedID":       sub.FeedID,
+		"hubURL":       sub.HubURL,
+		"topicURL":     sub.TopicURL,
+		"secret":       sub.Secret,
+		"state":        sub.State,
+		"leaseSeconds": sub.LeaseSeconds,
+		"expiresAt":    expiresAt,
+		"now":          tx.now,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&sub.ID, &sub.CreatedAt, &sub.ModifiedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (ws *WebSubStore) ListExpiringSubscriptions(ctx context.Context, before time.Time) ([]rf.WebSubSubscription, error) {
+	tx, err := ws.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, feed_id, hub_url, topic_url, secret, state, lease_seconds,
+				 expires_at, created_at, modified_at
+		FROM websub_subscriptions
+		WHERE state = 'active' AND expires_at < @before
+		ORDER BY expires_at
+	`
+	args := NamedArgs{
+		"before": before.UTC(),
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, scanSubscription)
+}
+
+func (ws *WebSubStore) SetFeedPollInterval(ctx context.Context, feedID int64, interval time.Duration) error {
+	tx, err := ws.db.BeginTx(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE feeds SET poll_interval_seconds = @seconds, modified_at = @now WHERE id = @feedID
+	`
+	args := NamedArgs{
+		"feedID":  feedID,
+		"seconds": int(interval.Seconds()),
+		"now":     tx.now,
+	}
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func scanSubscription(row Row) (rf.WebSubSubscription, error) {
+	var sub rf.WebSubSubscription
+	var expiresAt timeValue
+	err := row.Scan(&sub.ID, &sub.FeedID, &sub.HubURL, &sub.TopicURL, &sub.Secret, &sub.State,
+		&sub.LeaseSeconds, &expiresAt, &sub.CreatedAt, &sub.ModifiedAt)
+	sub.ExpiresAt = expiresAt.Time
+	return sub, err
+}
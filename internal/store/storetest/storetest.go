@@ -0,0 +1,1398 @@
+// Package storetest is a conformance suite for the store implementations,
+// each of them runs it so they keep the same semantics.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	rf "github.com/dwaynedwards/rss-feed-aggregator-in-go/internal"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/audit"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/builder"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/limiter"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/adminservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/auditservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/authservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/exportservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/feedservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/itemservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/syncservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/userservice"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/service/websubservice"
+	"github.com/matryer/is"
+)
+
+// Stores are the stores under test, they share one database. Every test
+// creates its own users and feeds so the database only needs to be empty
+// when Run starts.
+type Stores struct {
+	Auth    authservice.AuthStore
+	User    userservice.UserStore
+	Feed    feedservice.FeedStore
+	Item    itemservice.ItemStore
+	Sync    syncservice.SyncStore
+	WebSub  websubservice.WebSubStore
+	Export  exportservice.ExportStore
+	Admin   adminservice.AdminStore
+	Audit   AuditStore
+	Limiter limiter.Limiter
+
+	// Now is the clock of the database, tests that need time to pass move
+	// it and put it back. The suite runs one test at a time so this is safe.
+	Now *func() time.Time
+}
+
+// AuditStore records audit events and lists them.
+type AuditStore interface {
+	audit.Recorder
+	auditservice.AuditStore
+}
+
+// Run runs the conformance suite against stores.
+func Run(t *testing.T, stores Stores) {
+	t.Run("UniqueEmail", func(t *testing.T) { testUniqueEmail(t, stores) })
+	t.Run("ConcurrentUniqueEmail", func(t *testing.T) { testConcurrentUniqueEmail(t, stores) })
+	t.Run("UniqueFeedURL", func(t *testing.T) { testUniqueFeedURL(t, stores) })
+	t.Run("UserFeed", func(t *testing.T) { testUserFeed(t, stores) })
+	t.Run("FeedTxRollback", func(t *testing.T) { testFeedTxRollback(t, stores) })
+	t.Run("ConcurrentAddFeed", func(t *testing.T) { testConcurrentAddFeed(t, stores) })
+	t.Run("PasswordResetTokenSingleUse", func(t *testing.T) { testPasswordResetTokenSingleUse(t, stores) })
+	t.Run("RefreshTokenReuse", func(t *testing.T) { testRefreshTokenReuse(t, stores) })
+	t.Run("PurgeCascade", func(t *testing.T) { testPurgeCascade(t, stores) })
+	t.Run("StaleEnclosures", func(t *testing.T) { testStaleEnclosures(t, stores) })
+	t.Run("UndatedItem", func(t *testing.T) { testUndatedItem(t, stores) })
+	t.Run("SyncItemIdentity", func(t *testing.T) { testSyncItemIdentity(t, stores) })
+	t.Run("DueFeeds", func(t *testing.T) { testDueFeeds(t, stores) })
+	t.Run("ItemsWantingContent", func(t *testing.T) { testItemsWantingContent(t, stores) })
+	t.Run("UserProfile", func(t *testing.T) { testUserProfile(t, stores) })
+	t.Run("UserRole", func(t *testing.T) { testUserRole(t, stores) })
+	t.Run("ItemUpsert", func(t *testing.T) { testItemUpsert(t, stores) })
+	t.Run("StoryGrouping", func(t *testing.T) { testStoryGrouping(t, stores) })
+	t.Run("WebSubSubscription", func(t *testing.T) { testWebSubSubscription(t, stores) })
+	t.Run("SessionListing", func(t *testing.T) { testSessionListing(t, stores) })
+	t.Run("TOTPStepReuse", func(t *testing.T) { testTOTPStepReuse(t, stores) })
+	t.Run("OIDCStateSingleUse", func(t *testing.T) { testOIDCStateSingleUse(t, stores) })
+	t.Run("LimiterWindows", func(t *testing.T) { testLimiterWindows(t, stores) })
+	t.Run("ExportStreaming", func(t *testing.T) { testExportStreaming(t, stores) })
+	t.Run("AuditAppendOnly", func(t *testing.T) { testAuditAppendOnly(t, stores) })
+}
+
+func testUniqueEmail(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "unique@go.com")
+
+	err := stores.Auth.CreateAuthAndUser(ctx, newAuth("unique@go.com"))
+	is.Equal(err, errors.InvalidDataf(errors.ErrCouldNotProcess)) // should refuse a taken email
+
+	found, err := stores.Auth.FindByEmail(ctx, "unique@go.com")
+	is.NoErr(err)                       // should find the auth
+	is.Equal(found.UserID, auth.UserID) // should keep the first user
+	is.Equal(found.User.Name, "Gopher") // should join the user
+	is.Equal(found.Role, rf.RoleUser)   // should default the role
+
+	found, err = stores.Auth.FindByEmail(ctx, "missing@go.com")
+	is.NoErr(err)         // should not error when no auth is found
+	is.True(found == nil) // should not find an auth
+}
+
+func testConcurrentUniqueEmail(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	const signUps = 8
+
+	errs := make(chan error, signUps)
+	for range signUps {
+		go func() {
+			errs <- stores.Auth.CreateAuthAndUser(ctx, newAuth("concurrent@go.com"))
+		}()
+	}
+
+	created := 0
+	for range signUps {
+		err := <-errs
+		if err == nil {
+			created++
+			continue
+		}
+		is.Equal(err, errors.InvalidDataf(errors.ErrCouldNotProcess)) // should refuse a taken email
+	}
+	is.Equal(created, 1) // should create the user once
+}
+
+func testUniqueFeedURL(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	feed := createFeed(t, stores, "http://unique.com/rss")
+
+	again := builder.NewFeedBuilder().WithURL("http://unique.com/rss").Build()
+	err := inTx(ctx, stores, func(tx rf.Tx) error { return stores.Feed.CreateFeed(ctx, tx, again) })
+	is.NoErr(err)                      // should not refuse a taken url
+	is.Equal(again.ID, feed.ID)        // should be the existing feed
+	is.True(!again.CreatedAt.IsZero()) // should have the existing feed's creation time
+
+	found, err := stores.Feed.FindByURL(ctx, "http://unique.com/rss")
+	is.NoErr(err)                                // should find the feed
+	is.Equal(found.ID, feed.ID)                  // should find the first feed
+	is.Equal(found.URL, "http://unique.com/rss") // should have the url
+	is.True(found.Enabled)                       // should be enabled
+
+	found, err = stores.Feed.FindByURL(ctx, "http://missing.com/rss")
+	is.NoErr(err)         // should not error when no feed is found
+	is.True(found == nil) // should not find a feed
+
+	err = stores.Admin.SetFeedEnabled(ctx, feed.ID, false)
+	is.NoErr(err) // should disable the feed
+
+	disabled := builder.NewFeedBuilder().WithURL("http://unique.com/rss").Build()
+	err = inTx(ctx, stores, func(tx rf.Tx) error { return stores.Feed.CreateFeed(ctx, tx, disabled) })
+	is.Equal(err, errors.InvalidDataf(errors.ErrFeedDisabled)) // should not subscribe to a disabled feed
+}
+
+func testUserFeed(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "userfeed@go.com")
+	feed := createFeed(t, stores, "http://userfeed.com/rss")
+
+	userFeed := builder.NewFeedBuilder().
+		WithID(feed.ID).
+		WithUserID(auth.UserID).
+		WithName("The Gopher Podcast").
+		Build()
+
+	err := inTx(ctx, stores, func(tx rf.Tx) error { return stores.Feed.CreateUserFeed(ctx, tx, userFeed) })
+	is.NoErr(err) // should subscribe the user
+
+	err = inTx(ctx, stores, func(tx rf.Tx) error { return stores.Feed.CreateUserFeed(ctx, tx, userFeed) })
+	is.Equal(err, errors.InvalidDataf(errors.ErrCouldNotProcess)) // should refuse a second subscription
+
+	found, err := stores.Feed.FindUserFeedByID(ctx, auth.UserID, feed.ID)
+	is.NoErr(err)                              // should find the subscription
+	is.Equal(found.Name, "The Gopher Podcast") // should have the name
+
+	feeds, err := stores.Feed.ListUserFeeds(ctx, auth.UserID)
+	is.NoErr(err)           // should list the subscriptions
+	is.Equal(len(feeds), 1) // should list 1 feed
+
+	err = stores.Feed.DeleteFeed(ctx, auth.UserID, feed.ID)
+	is.NoErr(err) // should unsubscribe the user
+
+	err = stores.Feed.DeleteFeed(ctx, auth.UserID, feed.ID)
+	is.Equal(err, errors.NotFoundf(errors.ErrFeedNotFound)) // should not find the subscription again
+
+	found, err = stores.Feed.FindUserFeedByID(ctx, auth.UserID, feed.ID)
+	is.NoErr(err)         // should not error when no subscription is found
+	is.True(found == nil) // should not find the subscription
+
+	found, err = stores.Feed.FindByURL(ctx, "http://userfeed.com/rss")
+	is.NoErr(err)         // should find the feed
+	is.True(found != nil) // should keep the feed for other subscribers
+}
+
+func testFeedTxRollback(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "rollback@go.com")
+
+	tx, err := stores.Feed.BeginTx(ctx)
+	is.NoErr(err) // should begin a transaction
+
+	feed := builder.NewFeedBuilder().
+		WithURL("http://rollback.com/rss").
+		WithUserID(auth.UserID).
+		WithName("Rolled Back").
+		Build()
+
+	err = stores.Feed.CreateFeed(ctx, tx, feed)
+	is.NoErr(err) // should create the feed
+
+	err = stores.Feed.CreateUserFeed(ctx, tx, feed)
+	is.NoErr(err) // should subscribe the user
+
+	err = tx.Rollback(ctx)
+	is.NoErr(err) // should roll back
+
+	err = tx.Rollback(ctx)
+	is.NoErr(err) // should do nothing when rolled back again
+
+	found, err := stores.Feed.FindByURL(ctx, "http://rollback.com/rss")
+	is.NoErr(err)         // should not error when no feed is found
+	is.True(found == nil) // should not keep the feed
+
+	found, err = stores.Feed.FindUserFeedByID(ctx, auth.UserID, feed.ID)
+	is.NoErr(err)         // should not error when no subscription is found
+	is.True(found == nil) // should not keep the subscription
+}
+
+func testConcurrentAddFeed(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	const adds = 8
+
+	auths := make([]*rf.Auth, adds)
+	for i := range adds {
+		auths[i] = createAuth(t, stores, fmt.Sprintf("concurrent-feed-%d@go.com", i))
+	}
+
+	feedIDs := make(chan int64, adds)
+	errs := make(chan error, adds)
+	for _, auth := range auths {
+		go func() {
+			feed := builder.NewFeedBuilder().
+				WithURL("http://concurrent.com/rss").
+				WithUserID(auth.UserID).
+				WithName("Concurrent").
+				Build()
+
+			errs <- inTx(ctx, stores, func(tx rf.Tx) error {
+				if err := stores.Feed.CreateFeed(ctx, tx, feed); err != nil {
+					return err
+				}
+				return stores.Feed.CreateUserFeed(ctx, tx, feed)
+			})
+			feedIDs <- feed.ID
+		}()
+	}
+
+	var feedID int64
+	for range adds {
+		is.NoErr(<-errs) // should subscribe every user
+
+		id := <-feedIDs
+		if feedID == 0 {
+			feedID = id
+		}
+		is.Equal(id, feedID) // should share one feed
+	}
+
+	for _, auth := range auths {
+		found, err := stores.Feed.FindUserFeedByID(ctx, auth.UserID, feedID)
+		is.NoErr(err)         // should find the subscription
+		is.True(found != nil) // should keep every subscription
+	}
+}
+
+func testPasswordResetTokenSingleUse(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "reset@go.com")
+
+	err := stores.Auth.CreatePasswordResetToken(ctx, &rf.PasswordResetToken{
+		TokenHash: "reset-token-hash",
+		UserID:    auth.UserID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	is.NoErr(err) // should create the token
+
+	userID, err := stores.Auth.ResetPassword(ctx, "reset-token-hash", "new-password-hash")
+	is.NoErr(err)                 // should reset the password
+	is.Equal(userID, auth.UserID) // should return the user of the token
+
+	found, err := stores.Auth.FindByUserID(ctx, auth.UserID)
+	is.NoErr(err)                                           // should find the auth
+	is.Equal(found.BasicAuth.Password, "new-password-hash") // should have the new password
+
+	_, err = stores.Auth.ResetPassword(ctx, "reset-token-hash", "other-password-hash")
+	is.Equal(err, errors.InvalidDataf(errors.ErrResetTokenInvalid)) // should refuse a spent token
+
+	err = stores.Auth.CreatePasswordResetToken(ctx, &rf.PasswordResetToken{
+		TokenHash: "expired-token-hash",
+		UserID:    auth.UserID,
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+	is.NoErr(err) // should create the token
+
+	_, err = stores.Auth.ResetPassword(ctx, "expired-token-hash", "other-password-hash")
+	is.Equal(err, errors.InvalidDataf(errors.ErrResetTokenInvalid)) // should refuse an expired token
+}
+
+func testRefreshTokenReuse(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "refresh@go.com")
+	session, token := createSession(t, stores, auth, "refresh-token-hash")
+
+	expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	session.ExpiresAt = expiresAt
+
+	next := &rf.RefreshToken{TokenHash: "next-refresh-token-hash"}
+	err := stores.Auth.RotateRefreshToken(ctx, session, token, next)
+	is.NoErr(err)                        // should rotate the token
+	is.Equal(next.SessionID, session.ID) // should tie the next token to the session
+
+	rotated, err := stores.Auth.FindSessionByID(ctx, session.ID)
+	is.NoErr(err)                               // should find the session
+	is.True(rotated.ExpiresAt.Equal(expiresAt)) // should extend the session
+
+	used, err := stores.Auth.FindRefreshToken(ctx, "refresh-token-hash")
+	is.NoErr(err)                  // should find the used token
+	is.True(!used.UsedAt.IsZero()) // should mark the token used
+
+	err = stores.Auth.RotateRefreshToken(ctx, session, token, &rf.RefreshToken{TokenHash: "reused-refresh-token-hash"})
+	is.Equal(err, errors.Unauthorizedf(errors.ErrRefreshTokenReused)) // should refuse a used token
+
+	found, err := stores.Auth.FindRefreshToken(ctx, "reused-refresh-token-hash")
+	is.NoErr(err)         // should not error when no token is found
+	is.True(found == nil) // should not store the token of a refused rotation
+}
+
+func testPurgeCascade(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "purge@go.com")
+	session, _ := createSession(t, stores, auth, "purge-refresh-token-hash")
+	feed := createFeed(t, stores, "http://purge.com/rss")
+
+	err := inTx(ctx, stores, func(tx rf.Tx) error {
+		return stores.Feed.CreateUserFeed(ctx, tx, builder.NewFeedBuilder().
+			WithID(feed.ID).
+			WithUserID(auth.UserID).
+			WithName("Purged").
+			Build())
+	})
+	is.NoErr(err) // should subscribe the user
+
+	purged, err := stores.Auth.PurgeDeletedAccounts(ctx)
+	is.NoErr(err)              // should purge nothing
+	is.Equal(purged, int64(0)) // should keep accounts that are not deleted
+
+	err = stores.Auth.DeleteAccount(ctx, auth.UserID, time.Now().Add(-time.Minute))
+	is.NoErr(err) // should delete the account
+
+	err = stores.Auth.DeleteAccount(ctx, auth.UserID, time.Now())
+	is.Equal(err, errors.NotFoundf(errors.ErrUserNotFound)) // should not delete the account twice
+
+	revoked, err := stores.Auth.FindSessionByID(ctx, session.ID)
+	is.NoErr(err)                        // should find the session
+	is.True(!revoked.RevokedAt.IsZero()) // should revoke the sessions of the account
+
+	purged, err = stores.Auth.PurgeDeletedAccounts(ctx)
+	is.NoErr(err)              // should purge the account
+	is.Equal(purged, int64(1)) // should purge 1 account
+
+	found, err := stores.Auth.FindByUserID(ctx, auth.UserID)
+	is.NoErr(err)         // should not error when no auth is found
+	is.True(found == nil) // should remove the auth
+
+	foundSession, err := stores.Auth.FindSessionByID(ctx, session.ID)
+	is.NoErr(err)                // should not error when no session is found
+	is.True(foundSession == nil) // should remove the sessions
+
+	foundToken, err := stores.Auth.FindRefreshToken(ctx, "purge-refresh-token-hash")
+	is.NoErr(err)              // should not error when no token is found
+	is.True(foundToken == nil) // should remove the refresh tokens
+
+	foundFeed, err := stores.Feed.FindUserFeedByID(ctx, auth.UserID, feed.ID)
+	is.NoErr(err)             // should not error when no subscription is found
+	is.True(foundFeed == nil) // should remove the subscriptions
+
+	foundFeed, err = stores.Feed.FindByURL(ctx, "http://purge.com/rss")
+	is.NoErr(err)             // should find the feed
+	is.True(foundFeed != nil) // should keep the feed itself
+}
+
+func testStaleEnclosures(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "enclosures@go.com")
+	feed := createFeed(t, stores, "http://enclosures.com/rss")
+	subscribe(t, stores, auth, feed)
+	channel := createChannel(t, stores, feed)
+
+	item := &rf.Item{
+		ChannelID:   channel.ID,
+		GUID:        "episode-1",
+		IdentityKey: "guid:episode-1",
+		Title:       "Episode 1",
+		PublishedAt: time.Now().UTC().Truncate(time.Second),
+		Enclosures: []rf.Enclosure{
+			{URL: "http://enclosures.com/episode-1.mp3", MIMEType: "audio/mpeg", Medium: "audio"},
+			{URL: "http://enclosures.com/episode-1-old.mp3", MIMEType: "audio/mpeg", Medium: "audio"},
+		},
+	}
+	err := stores.Item.UpsertItems(ctx, []*rf.Item{item})
+	is.NoErr(err) // should store the item and its enclosures
+
+	kept, removed := item.Enclosures[0], item.Enclosures[1]
+
+	err = stores.Item.SavePlaybackPosition(ctx, &rf.PlaybackPosition{
+		UserID:          auth.UserID,
+		ItemID:          item.ID,
+		EnclosureID:     removed.ID,
+		PositionSeconds: 30,
+	})
+	is.NoErr(err) // should save a position in the enclosure about to be removed
+
+	item.Enclosures = item.Enclosures[:1]
+	err = stores.Item.UpsertItems(ctx, []*rf.Item{item})
+	is.NoErr(err) // should store the item again
+
+	found, err := stores.Item.FindUserItemByID(ctx, auth.UserID, item.ID)
+	is.NoErr(err)                             // should find the item
+	is.Equal(len(found.Enclosures), 1)        // should drop the enclosure the feed no longer lists
+	is.Equal(found.Enclosures[0].ID, kept.ID) // should keep the listed enclosure
+
+	err = stores.Item.SavePlaybackPosition(ctx, &rf.PlaybackPosition{
+		UserID:          auth.UserID,
+		ItemID:          item.ID,
+		EnclosureID:     removed.ID,
+		PositionSeconds: 60,
+	})
+	is.Equal(err, errors.NotFoundf(errors.ErrEnclosureNotFound)) // should not save a position in a removed enclosure
+
+	item.Enclosures = nil
+	err = stores.Item.UpsertItems(ctx, []*rf.Item{item})
+	is.NoErr(err) // should store the item without enclosures
+
+	found, err = stores.Item.FindUserItemByID(ctx, auth.UserID, item.ID)
+	is.NoErr(err)                      // should find the item
+	is.Equal(len(found.Enclosures), 0) // should drop every enclosure
+}
+
+func testUndatedItem(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	feed := createFeed(t, stores, "http://undated.com/rss")
+	channel := createChannel(t, stores, feed)
+
+	firstSeen := time.Date(2024, 10, 7, 9, 0, 0, 0, time.UTC)
+	setNow(t, stores, firstSeen)
+
+	undated := func() *rf.Item {
+		return &rf.Item{ChannelID: channel.ID, GUID: "undated", IdentityKey: "guid:undated", Title: "Undated"}
+	}
+
+	item := undated()
+	err := stores.Item.UpsertItems(ctx, []*rf.Item{item})
+	is.NoErr(err)                              // should store the item
+	is.True(item.PublishedAt.Equal(firstSeen)) // should date the item when first seen
+	is.True(item.ModifiedAt.Equal(firstSeen))  // should be modified when stored
+
+	setNow(t, stores, firstSeen.Add(time.Hour))
+
+	item = undated()
+	err = stores.Item.UpsertItems(ctx, []*rf.Item{item})
+	is.NoErr(err)                              // should store the item again
+	is.True(item.PublishedAt.Equal(firstSeen)) // should keep the date the item was first seen
+	is.True(item.ModifiedAt.Equal(firstSeen))  // should not modify an unchanged item
+
+	item = undated()
+	item.Title = "Dated at last"
+	err = stores.Item.UpsertItems(ctx, []*rf.Item{item})
+	is.NoErr(err)                                            // should store the changed item
+	is.True(item.PublishedAt.Equal(firstSeen))               // should still keep the date the item was first seen
+	is.True(item.ModifiedAt.Equal(firstSeen.Add(time.Hour))) // should modify a changed item
+}
+
+func testSyncItemIdentity(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	feed := createFeed(t, stores, "http://identity.com/rss")
+	service := itemservice.NewItemService(stores.Item)
+
+	sync := func(items ...*rf.Item) *rf.Channel {
+		channel := &rf.Channel{FeedID: feed.ID, Title: "Identity"}
+		if err := service.SyncItems(ctx, channel, items); err != nil {
+			t.Fatal(err)
+		}
+		return channel
+	}
+
+	a := &rf.Item{GUID: "a", Title: "A", Link: "http://identity.com/a"}
+	b := &rf.Item{GUID: "b", Title: "B", Link: "http://identity.com/b"}
+	sync(a, b)
+
+	againA := &rf.Item{GUID: "a", Title: "A", Link: "http://identity.com/a"}
+	againB := &rf.Item{GUID: "b", Title: "B", Link: "http://identity.com/b"}
+	noGUID := &rf.Item{Title: "C", Link: "http://identity.com/c"}
+	channel := sync(againA, againB, noGUID)
+
+	is.Equal(againA.ID, a.ID)                         // should update the same item
+	is.Equal(againB.ID, b.ID)                         // should update the same item
+	is.Equal(againA.IdentityKey, "guid:a")            // should keep keying by guid
+	is.Equal(noGUID.IdentityKey, "link:"+noGUID.Link) // should key an item without a guid by its link
+
+	stored, err := stores.Item.ListChannelItems(ctx, channel.ID, 10)
+	is.NoErr(err)            // should list the items
+	is.Equal(len(stored), 3) // should not duplicate items
+
+	found, err := stores.Item.FindFeedByID(ctx, feed.ID)
+	is.NoErr(err)                                     // should find the feed
+	is.Equal(found.ItemIdentity, rf.ItemIdentityGUID) // should not change the identity of the feed
+
+	dupA := &rf.Item{GUID: "dup", Title: "A", Link: "http://identity.com/a"}
+	dupB := &rf.Item{GUID: "dup", Title: "B", Link: "http://identity.com/b"}
+	sync(dupA, dupB)
+
+	is.Equal(dupA.ID, a.ID) // should re-key stored items by link
+	is.Equal(dupB.ID, b.ID) // should re-key stored items by link
+
+	found, err = stores.Item.FindFeedByID(ctx, feed.ID)
+	is.NoErr(err)                                     // should find the feed
+	is.Equal(found.ItemIdentity, rf.ItemIdentityLink) // should switch a feed repeating guids to links
+
+	stored, err = stores.Item.ListChannelItems(ctx, channel.ID, 10)
+	is.NoErr(err)            // should list the items
+	is.Equal(len(stored), 3) // should not duplicate items
+}
+
+func testDueFeeds(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	setNow(t, stores, now)
+
+	auth := createAuth(t, stores, "due@go.com")
+	feed := createFeed(t, stores, "http://due.com/rss")
+	unsubscribed := createFeed(t, stores, "http://due-unsubscribed.com/rss")
+	subscribe(t, stores, auth, feed)
+
+	isDue := func(feedID int64) bool {
+		t.Helper()
+
+		due, err := stores.Sync.ListDueFeeds(ctx, 1000)
+		is.NoErr(err) // should list due feeds
+		for _, f := range due {
+			if f.ID == feedID {
+				return true
+			}
+		}
+		return false
+	}
+
+	is.True(isDue(feed.ID))          // should fetch a new feed at once
+	is.True(!isDue(unsubscribed.ID)) // should not fetch a feed nobody subscribes to
+
+	err := stores.Sync.RecordFeedFetch(ctx, feed.ID, nil)
+	is.NoErr(err)            // should record the fetch
+	is.True(!isDue(feed.ID)) // should wait for the poll interval after a fetch
+
+	count, err := stores.Feed.CountFeedSubscribers(ctx, feed.ID)
+	is.NoErr(err)      // should count subscribers
+	is.Equal(count, 1) // should count the subscriber
+
+	setNow(t, stores, now.Add(31*time.Minute))
+	is.True(isDue(feed.ID)) // should fetch again once the poll interval passed
+
+	err = stores.Sync.RecordFeedFetch(ctx, feed.ID, errors.InternalErrorf(errors.ErrFetchFailed))
+	is.NoErr(err)            // should record the failed fetch
+	is.True(!isDue(feed.ID)) // should wait for the poll interval after a failed fetch
+
+	fetchErrors, err := stores.Admin.ListFeedFetchErrors(ctx, 1000)
+	is.NoErr(err) // should list fetch errors
+	found := false
+	for _, fetchErr := range fetchErrors {
+		if fetchErr.FeedID == feed.ID {
+			found = true
+			is.Equal(fetchErr.Failures, 1) // should count the failed fetch
+		}
+	}
+	is.True(found) // should list the failed fetch
+
+	setNow(t, stores, now.Add(62*time.Minute))
+	is.True(isDue(feed.ID)) // should retry once the poll interval passed
+
+	err = stores.Sync.RecordFeedFetch(ctx, feed.ID, nil)
+	is.NoErr(err) // should record the fetch
+
+	err = stores.Admin.RequestFeedResync(ctx, feed.ID)
+	is.NoErr(err)           // should request a resync
+	is.True(isDue(feed.ID)) // should fetch a feed an admin asked to resync
+
+	err = stores.Admin.SetFeedEnabled(ctx, feed.ID, false)
+	is.NoErr(err)            // should disable the feed
+	is.True(!isDue(feed.ID)) // should not fetch a disabled feed
+
+	err = stores.Admin.SetFeedEnabled(ctx, feed.ID, true)
+	is.NoErr(err) // should enable the feed
+
+	err = stores.Feed.DeleteFeed(ctx, auth.UserID, feed.ID)
+	is.NoErr(err)            // should unsubscribe
+	is.True(!isDue(feed.ID)) // should stop fetching a feed without subscribers
+
+	count, err = stores.Feed.CountFeedSubscribers(ctx, feed.ID)
+	is.NoErr(err)      // should count subscribers
+	is.Equal(count, 0) // should have no subscribers left
+}
+
+func testItemsWantingContent(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "content@go.com")
+	feed := createFeed(t, stores, "http://content.com/rss")
+	subscribe(t, stores, auth, feed)
+	channel := createChannel(t, stores, feed)
+
+	linked := &rf.Item{ChannelID: channel.ID, GUID: "linked", IdentityKey: "guid:linked", Link: "http://content.com/linked"}
+	unlinked := &rf.Item{ChannelID: channel.ID, GUID: "unlinked", IdentityKey: "guid:unlinked"}
+	err := stores.Item.UpsertItems(ctx, []*rf.Item{linked, unlinked})
+	is.NoErr(err) // should store the items
+
+	wanting := func(retryBefore time.Time) []int64 {
+		t.Helper()
+
+		items, err := stores.Item.ListItemsWantingContent(ctx, retryBefore, 1000)
+		is.NoErr(err) // should list items wanting content
+		var ids []int64
+		for _, item := range items {
+			if item.ChannelID == channel.ID {
+				ids = append(ids, item.ID)
+			}
+		}
+		return ids
+	}
+
+	is.Equal(len(wanting(time.Now())), 0) // should not download articles nobody asked for
+
+	userFeed := builder.NewFeedBuilder().WithID(feed.ID).WithUserID(auth.UserID).WithName("Gopher").Build()
+	userFeed.FetchFullContent = true
+	err = stores.Feed.UpdateUserFeed(ctx, userFeed)
+	is.NoErr(err) // should ask for full content
+
+	is.Equal(wanting(time.Now()), []int64{linked.ID}) // should list items with a link
+
+	err = stores.Item.SaveItemContent(ctx, &rf.ItemContent{ItemID: linked.ID, Error: "boom"})
+	is.NoErr(err) // should cache the failure
+
+	is.Equal(len(wanting(time.Now().Add(-time.Hour))), 0)            // should wait before retrying a failure
+	is.Equal(wanting(time.Now().Add(time.Hour)), []int64{linked.ID}) // should retry an old failure
+
+	err = stores.Item.SaveItemContent(ctx, &rf.ItemContent{ItemID: linked.ID, Content: "<p>article</p>"})
+	is.NoErr(err) // should cache the article
+
+	is.Equal(len(wanting(time.Now().Add(time.Hour))), 0) // should not download a cached article again
+
+	found, err := stores.Item.FindUserItemByID(ctx, auth.UserID, linked.ID)
+	is.NoErr(err)                                 // should find the item
+	is.Equal(found.FullContent, "<p>article</p>") // should serve the cached article
+}
+
+func testUserProfile(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "profile@go.com")
+
+	user, err := stores.User.FindUser(ctx, auth.UserID)
+	is.NoErr(err)                 // should find the user
+	is.Equal(user.Name, "Gopher") // should have the name signed up with
+
+	user.Name = "Renamed"
+	user.Timezone = "Europe/London"
+	user.Preferences = map[string]any{"theme": "dark"}
+	err = stores.User.UpdateUser(ctx, user)
+	is.NoErr(err) // should update the user
+
+	found, err := stores.User.FindUser(ctx, auth.UserID)
+	is.NoErr(err)                                // should find the user
+	is.Equal(found.Name, "Renamed")              // should keep the new name
+	is.Equal(found.Timezone, "Europe/London")    // should keep the timezone
+	is.Equal(found.Preferences["theme"], "dark") // should keep the preferences
+
+	found, err = stores.User.FindUser(ctx, 0)
+	is.NoErr(err)         // should not error when no user is found
+	is.True(found == nil) // should not find a user
+}
+
+func testUserRole(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "role@go.com")
+
+	userID, err := stores.Admin.SetUserRole(ctx, "role@go.com", rf.RoleAdmin)
+	is.NoErr(err)                 // should set the role
+	is.Equal(userID, auth.UserID) // should return the user
+
+	found, err := stores.Auth.FindByUserID(ctx, auth.UserID)
+	is.NoErr(err)                      // should find the auth
+	is.Equal(found.Role, rf.RoleAdmin) // should keep the role
+
+	_, err = stores.Admin.SetUserRole(ctx, "nobody@go.com", rf.RoleAdmin)
+	is.Equal(err, errors.NotFoundf(errors.ErrUserNotFound)) // should not find a user without an account
+}
+
+func testItemUpsert(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "upsert@go.com")
+	feed := createFeed(t, stores, "http://upsert.com/rss")
+	other := createFeed(t, stores, "http://upsert-other.com/rss")
+	subscribe(t, stores, auth, feed)
+	channel := createChannel(t, stores, feed)
+	otherChannel := createChannel(t, stores, other)
+
+	newItem := func(channelID int64, title string) *rf.Item {
+		return &rf.Item{
+			ChannelID:   channelID,
+			GUID:        "upsert",
+			IdentityKey: "guid:upsert",
+			Title:       title,
+			Link:        "http://upsert.com/item",
+			PublishedAt: time.Now().UTC().Truncate(time.Second),
+		}
+	}
+
+	item := newItem(channel.ID, "First")
+	err := stores.Item.UpsertItems(ctx, []*rf.Item{item})
+	is.NoErr(err)         // should store the item
+	is.True(item.ID != 0) // should set the id
+
+	again := newItem(channel.ID, "Second")
+	err = stores.Item.UpsertItems(ctx, []*rf.Item{again})
+	is.NoErr(err)               // should store the item again
+	is.Equal(again.ID, item.ID) // should update the item with the same identity
+
+	elsewhere := newItem(otherChannel.ID, "Elsewhere")
+	err = stores.Item.UpsertItems(ctx, []*rf.Item{elsewhere})
+	is.NoErr(err)                    // should store the item of the other feed
+	is.True(elsewhere.ID != item.ID) // should key items by channel
+
+	stored, err := stores.Item.ListChannelItems(ctx, channel.ID, 10)
+	is.NoErr(err)                       // should list the items
+	is.Equal(len(stored), 1)            // should not duplicate the item
+	is.Equal(stored[0].Title, "Second") // should keep the latest title
+
+	found, err := stores.Item.FindUserItemByID(ctx, auth.UserID, item.ID)
+	is.NoErr(err)                   // should find the item
+	is.Equal(found.Title, "Second") // should find the subscribed item
+
+	found, err = stores.Item.FindUserItemByID(ctx, auth.UserID, elsewhere.ID)
+	is.NoErr(err)         // should not error when the item is not visible
+	is.True(found == nil) // should hide items of feeds the user does not subscribe to
+}
+
+func testStoryGrouping(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "stories@go.com")
+	first := createFeed(t, stores, "http://stories-first.com/rss")
+	second := createFeed(t, stores, "http://stories-second.com/rss")
+	subscribe(t, stores, auth, first)
+	subscribe(t, stores, auth, second)
+	firstChannel := createChannel(t, stores, first)
+	secondChannel := createChannel(t, stores, second)
+
+	publishedAt := time.Now().UTC().Truncate(time.Second)
+	group := func(channel *rf.Channel, guid string, simHash uint64, publishedAt time.Time) *rf.Item {
+		t.Helper()
+
+		item := &rf.Item{
+			ChannelID:   channel.ID,
+			GUID:        guid,
+			IdentityKey: "guid:" + guid,
+			Title:       guid,
+			SimHash:     simHash,
+			PublishedAt: publishedAt,
+		}
+		if err := stores.Item.UpsertItems(ctx, []*rf.Item{item}); err != nil {
+			t.Fatal(err)
+		}
+		if err := stores.Item.GroupItemStories(ctx, []*rf.Item{item}, 3); err != nil {
+			t.Fatal(err)
+		}
+		return item
+	}
+
+	original := group(firstChannel, "original", 0b1111, publishedAt)
+	sameFeed := group(firstChannel, "same-feed", 0b1111, publishedAt)
+	similar := group(secondChannel, "similar", 0b1110, publishedAt)
+	different := group(secondChannel, "different", 0xFFFF0000, publishedAt)
+	later := group(secondChannel, "later", 0b1111, publishedAt.Add(30*24*time.Hour))
+
+	is.True(original.StoryID != 0)                 // should start a story
+	is.Equal(similar.StoryID, original.StoryID)    // should group a similar item of another feed
+	is.True(different.StoryID != original.StoryID) // should not group a different item
+	is.True(sameFeed.StoryID != original.StoryID)  // should not group items of the same feed
+	is.True(later.StoryID != original.StoryID)     // should not group items far apart in time
+
+	timeline, err := stores.Item.ListTimeline(ctx, &rf.TimelineRequest{UserID: auth.UserID, Limit: 10})
+	is.NoErr(err) // should list the timeline
+	for _, entry := range timeline {
+		if entry.StoryID == original.StoryID {
+			is.Equal(len(entry.Sources), 2) // should show both feeds of the story
+		}
+	}
+
+	err = stores.Item.MarkStoryRead(ctx, auth.UserID, original.StoryID)
+	is.NoErr(err) // should mark the story read
+
+	timeline, err = stores.Item.ListTimeline(ctx, &rf.TimelineRequest{UserID: auth.UserID, Limit: 10})
+	is.NoErr(err) // should list the timeline
+	for _, entry := range timeline {
+		is.Equal(entry.Read, entry.StoryID == original.StoryID) // should only mark the story read
+	}
+}
+
+func testWebSubSubscription(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	feed := createFeed(t, stores, "http://websub.com/rss")
+
+	found, err := stores.WebSub.FindSubscriptionByFeedID(ctx, feed.ID)
+	is.NoErr(err)         // should not error when there is no subscription
+	is.True(found == nil) // should not find a subscription
+
+	sub := &rf.WebSubSubscription{
+		FeedID:   feed.ID,
+		HubURL:   "http://hub.com/",
+		TopicURL: "http://websub.com/rss",
+		Secret:   "secret",
+		State:    rf.WebSubStatePending,
+	}
+	err = stores.WebSub.SaveSubscription(ctx, sub)
+	is.NoErr(err)        // should save the subscription
+	is.True(sub.ID != 0) // should set the id
+
+	active := *sub
+	active.ID = 0
+	active.State = rf.WebSubStateActive
+	active.LeaseSeconds = 3600
+	active.ExpiresAt = now.Add(time.Hour)
+	err = stores.WebSub.SaveSubscription(ctx, &active)
+	is.NoErr(err)               // should save the subscription again
+	is.Equal(active.ID, sub.ID) // should keep one subscription per feed
+
+	found, err = stores.WebSub.FindSubscriptionByFeedID(ctx, feed.ID)
+	is.NoErr(err)                                      // should find the subscription
+	is.Equal(found.State, rf.WebSubStateActive)        // should keep the latest state
+	is.Equal(found.Secret, "secret")                   // should keep the secret
+	is.Equal(found.LeaseSeconds, 3600)                 // should keep the lease
+	is.True(found.ExpiresAt.Equal(now.Add(time.Hour))) // should keep when the lease expires
+
+	expiring := func(before time.Time) bool {
+		t.Helper()
+
+		subs, err := stores.WebSub.ListExpiringSubscriptions(ctx, before)
+		is.NoErr(err) // should list expiring subscriptions
+		for _, s := range subs {
+			if s.FeedID == feed.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	is.True(!expiring(now.Add(30 * time.Minute))) // should not renew a lease far from expiring
+	is.True(expiring(now.Add(2 * time.Hour)))     // should renew a lease about to expire
+
+	err = stores.WebSub.SetFeedPollInterval(ctx, feed.ID, 24*time.Hour)
+	is.NoErr(err) // should set the poll interval
+
+	polled, err := stores.Feed.FindByURL(ctx, feed.URL)
+	is.NoErr(err)                               // should find the feed
+	is.Equal(polled.PollInterval, 24*time.Hour) // should keep the poll interval
+}
+
+func testSessionListing(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "sessions@go.com")
+	other := createAuth(t, stores, "sessions-other@go.com")
+
+	current, _ := createSession(t, stores, auth, "sessions-current-hash")
+	revoked, _ := createSession(t, stores, auth, "sessions-revoked-hash")
+	createSession(t, stores, other, "sessions-other-hash")
+
+	expired := &rf.Session{UserID: auth.UserID, ExpiresAt: time.Now().Add(-time.Hour)}
+	err := stores.Auth.CreateSession(ctx, expired, &rf.RefreshToken{TokenHash: "sessions-expired-hash"})
+	is.NoErr(err) // should create an expired session
+
+	err = stores.Auth.RevokeSession(ctx, auth.UserID, revoked.ID)
+	is.NoErr(err) // should revoke the session
+
+	err = stores.Auth.RevokeSession(ctx, other.UserID, current.ID)
+	is.Equal(err, errors.NotFoundf(errors.ErrSessionNotFound)) // should not revoke a session of another user
+
+	sessions, err := stores.Auth.ListUserSessions(ctx, auth.UserID)
+	is.NoErr(err)                        // should list the sessions
+	is.Equal(len(sessions), 1)           // should only list live sessions of the user
+	is.Equal(sessions[0].ID, current.ID) // should list the live session
+
+	kept, _ := createSession(t, stores, auth, "sessions-kept-hash")
+	err = stores.Auth.RevokeOtherSessions(ctx, auth.UserID, kept.ID)
+	is.NoErr(err) // should revoke the other sessions
+
+	sessions, err = stores.Auth.ListUserSessions(ctx, auth.UserID)
+	is.NoErr(err)                     // should list the sessions
+	is.Equal(len(sessions), 1)        // should keep one session
+	is.Equal(sessions[0].ID, kept.ID) // should keep the session asked for
+
+	sessions, err = stores.Auth.ListUserSessions(ctx, other.UserID)
+	is.NoErr(err)              // should list the sessions of the other user
+	is.Equal(len(sessions), 1) // should not revoke sessions of other users
+}
+
+func testTOTPStepReuse(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "totp@go.com")
+
+	err := stores.Auth.SaveTOTPSecret(ctx, &rf.TOTP{UserID: auth.UserID, Secret: "secret"})
+	is.NoErr(err) // should start enrolment
+
+	err = stores.Auth.UseTOTPStep(ctx, auth.UserID, 10)
+	is.Equal(err, errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)) // should not use a code before enrolment is confirmed
+
+	err = stores.Auth.EnableTOTP(ctx, auth.UserID, 10, []string{"totp-recovery-hash"})
+	is.NoErr(err) // should confirm enrolment
+
+	err = stores.Auth.SaveTOTPSecret(ctx, &rf.TOTP{UserID: auth.UserID, Secret: "other"})
+	is.Equal(err, errors.InvalidDataf(errors.ErrTOTPAlreadyEnabled)) // should not replace a confirmed secret
+
+	err = stores.Auth.UseTOTPStep(ctx, auth.UserID, 10)
+	is.Equal(err, errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)) // should refuse the code that confirmed enrolment
+
+	err = stores.Auth.UseTOTPStep(ctx, auth.UserID, 11)
+	is.NoErr(err) // should use a later code
+
+	err = stores.Auth.UseTOTPStep(ctx, auth.UserID, 11)
+	is.Equal(err, errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)) // should refuse a replayed code
+
+	err = stores.Auth.UseTOTPStep(ctx, auth.UserID, 9)
+	is.Equal(err, errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)) // should refuse an earlier code
+
+	err = stores.Auth.UseRecoveryCode(ctx, auth.UserID, "totp-recovery-hash")
+	is.NoErr(err) // should use a recovery code
+
+	err = stores.Auth.UseRecoveryCode(ctx, auth.UserID, "totp-recovery-hash")
+	is.Equal(err, errors.Unauthorizedf(errors.ErrTOTPCodeInvalid)) // should use a recovery code once
+
+	err = stores.Auth.DisableTOTP(ctx, auth.UserID, 11)
+	is.Equal(err, errors.InvalidDataf(errors.ErrTOTPCodeInvalid)) // should not disable with a used code
+
+	err = stores.Auth.DisableTOTP(ctx, auth.UserID, 12)
+	is.NoErr(err) // should disable with a new code
+
+	totp, err := stores.Auth.FindTOTP(ctx, auth.UserID)
+	is.NoErr(err)        // should not error when there is no secret
+	is.True(totp == nil) // should remove the secret
+}
+
+func testOIDCStateSingleUse(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	state := &rf.OIDCLoginState{
+		StateHash:    "oidc-state-hash",
+		Provider:     "gopher",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().UTC().Add(10 * time.Minute).Truncate(time.Second),
+	}
+	err := stores.Auth.CreateOIDCLoginState(ctx, state)
+	is.NoErr(err) // should create the state
+
+	_, err = stores.Auth.UseOIDCLoginState(ctx, "other", state.StateHash)
+	is.Equal(err, errors.InvalidDataf(errors.ErrOIDCStateInvalid)) // should refuse a state of another provider
+
+	used, err := stores.Auth.UseOIDCLoginState(ctx, "gopher", state.StateHash)
+	is.NoErr(err)                           // should use the state
+	is.Equal(used.Nonce, "nonce")           // should return the nonce
+	is.Equal(used.CodeVerifier, "verifier") // should return the code verifier
+
+	_, err = stores.Auth.UseOIDCLoginState(ctx, "gopher", state.StateHash)
+	is.Equal(err, errors.InvalidDataf(errors.ErrOIDCStateInvalid)) // should use a state once
+
+	expired := &rf.OIDCLoginState{
+		StateHash: "oidc-expired-hash",
+		Provider:  "gopher",
+		ExpiresAt: time.Now().UTC().Add(-time.Minute).Truncate(time.Second),
+	}
+	err = stores.Auth.CreateOIDCLoginState(ctx, expired)
+	is.NoErr(err) // should create the expired state
+
+	_, err = stores.Auth.UseOIDCLoginState(ctx, "gopher", expired.StateHash)
+	is.Equal(err, errors.InvalidDataf(errors.ErrOIDCStateInvalid)) // should refuse an expired state
+}
+
+func testLimiterWindows(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	const key = "storetest:limiter"
+	now := time.Now()
+
+	count, lastFailedAt, err := stores.Limiter.Failures(ctx, key, now.Add(-time.Hour))
+	is.NoErr(err)                  // should count failures
+	is.Equal(count, 0)             // should have no failures yet
+	is.True(lastFailedAt.IsZero()) // should have no last failure yet
+
+	for range 2 {
+		err = stores.Limiter.Fail(ctx, key)
+		is.NoErr(err) // should record the failure
+	}
+	err = stores.Limiter.Fail(ctx, key+":other")
+	is.NoErr(err) // should record a failure of another key
+
+	count, lastFailedAt, err = stores.Limiter.Failures(ctx, key, now.Add(-time.Hour))
+	is.NoErr(err)                   // should count failures
+	is.Equal(count, 2)              // should count the failures of the key in the window
+	is.True(!lastFailedAt.IsZero()) // should have the last failure
+
+	count, _, err = stores.Limiter.Failures(ctx, key, now.Add(time.Hour))
+	is.NoErr(err)      // should count failures
+	is.Equal(count, 0) // should not count failures before the window
+
+	err = stores.Limiter.Reset(ctx, key)
+	is.NoErr(err) // should reset the key
+
+	count, _, err = stores.Limiter.Failures(ctx, key, now.Add(-time.Hour))
+	is.NoErr(err)      // should count failures
+	is.Equal(count, 0) // should forget the failures of the key
+
+	count, _, err = stores.Limiter.Failures(ctx, key+":other", now.Add(-time.Hour))
+	is.NoErr(err)      // should count failures
+	is.Equal(count, 1) // should keep the failures of other keys
+}
+
+func testExportStreaming(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "export@go.com")
+	first := createFeed(t, stores, "http://export-first.com/rss")
+	second := createFeed(t, stores, "http://export-second.com/rss")
+	subscribe(t, stores, auth, first)
+	subscribe(t, stores, auth, second)
+	channel := createChannel(t, stores, first)
+	_, _ = createSession(t, stores, auth, "export-current-hash")
+	revoked, _ := createSession(t, stores, auth, "export-revoked-hash")
+
+	err := stores.Auth.RevokeSession(ctx, auth.UserID, revoked.ID)
+	is.NoErr(err) // should revoke the session
+
+	item := &rf.Item{
+		ChannelID:   channel.ID,
+		GUID:        "export",
+		IdentityKey: "guid:export",
+		Title:       "Exported",
+		PublishedAt: time.Now().UTC().Truncate(time.Second),
+		Enclosures:  []rf.Enclosure{{URL: "http://export-first.com/episode.mp3", MIMEType: "audio/mpeg", Medium: "audio"}},
+	}
+	err = stores.Item.UpsertItems(ctx, []*rf.Item{item})
+	is.NoErr(err) // should store the item
+	err = stores.Item.GroupItemStories(ctx, []*rf.Item{item}, 3)
+	is.NoErr(err) // should group the item
+	err = stores.Item.MarkStoryRead(ctx, auth.UserID, item.StoryID)
+	is.NoErr(err) // should read the item
+	err = stores.Item.SavePlaybackPosition(ctx, &rf.PlaybackPosition{
+		UserID:          auth.UserID,
+		ItemID:          item.ID,
+		EnclosureID:     item.Enclosures[0].ID,
+		PositionSeconds: 42,
+	})
+	is.NoErr(err) // should save the position
+
+	kept := &rf.Item{
+		ChannelID:   channel.ID,
+		GUID:        "export-kept",
+		IdentityKey: "guid:export-kept",
+		Title:       "Kept",
+		PublishedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	err = stores.Item.UpsertItems(ctx, []*rf.Item{kept})
+	is.NoErr(err) // should store the item
+	err = stores.Item.StarItem(ctx, auth.UserID, kept.ID, true)
+	is.NoErr(err) // should star the item
+	err = stores.Item.SaveItem(ctx, auth.UserID, kept.ID, true)
+	is.NoErr(err) // should save the item
+	err = stores.Item.StarItem(ctx, auth.UserID, item.ID, true)
+	is.NoErr(err) // should star the read item
+	err = stores.Item.StarItem(ctx, auth.UserID, item.ID, false)
+	is.NoErr(err) // should unstar the read item
+
+	other := createAuth(t, stores, "export-other@go.com")
+	err = stores.Item.StarItem(ctx, other.UserID, kept.ID, true)
+	is.Equal(err, errors.NotFoundf(errors.ErrItemNotFound)) // should not star an item of a feed the user does not subscribe to
+
+	err = stores.Auth.CreateAPIKey(ctx, &rf.APIKey{
+		UserID:  auth.UserID,
+		Name:    "Reader",
+		Prefix:  "rf_export",
+		KeyHash: "export-key-hash",
+		Scope:   rf.APIKeyScopeRead,
+	})
+	is.NoErr(err) // should create the api key
+	err = stores.Auth.CreateIdentity(ctx, &rf.AuthIdentity{
+		UserID:  auth.UserID,
+		Issuer:  "https://accounts.example.com",
+		Subject: "export-subject",
+		Email:   "export@go.com",
+	})
+	is.NoErr(err) // should link the identity
+
+	var feedIDs []int64
+	err = stores.Export.EachSubscription(ctx, auth.UserID, func(feed rf.Feed) error {
+		feedIDs = append(feedIDs, feed.ID)
+		return nil
+	})
+	is.NoErr(err)                                   // should stream the subscriptions
+	is.Equal(feedIDs, []int64{first.ID, second.ID}) // should stream every subscription
+
+	stop := errors.InternalErrorf("stop")
+	calls := 0
+	err = stores.Export.EachSubscription(ctx, auth.UserID, func(feed rf.Feed) error {
+		calls++
+		return stop
+	})
+	is.Equal(err, stop) // should return the error of fn
+	is.Equal(calls, 1)  // should stop streaming once fn fails
+
+	var states []rf.ExportItemState
+	err = stores.Export.EachItemState(ctx, auth.UserID, func(state rf.ExportItemState) error {
+		states = append(states, state)
+		return nil
+	})
+	is.NoErr(err)                          // should stream the item states
+	is.Equal(len(states), 2)               // should stream the read, starred and saved items
+	is.Equal(states[0].ItemID, item.ID)    // should stream the item
+	is.Equal(states[0].FeedID, first.ID)   // should stream the feed of the item
+	is.True(!states[0].ReadAt.IsZero())    // should stream when it was read
+	is.True(states[0].StarredAt.IsZero())  // should not stream an unstarred item as starred
+	is.Equal(states[1].ItemID, kept.ID)    // should stream the kept item
+	is.True(states[1].ReadAt.IsZero())     // should not stream an unread item as read
+	is.True(!states[1].StarredAt.IsZero()) // should stream when it was starred
+	is.True(!states[1].SavedAt.IsZero())   // should stream when it was saved
+
+	var positions []rf.ExportPlaybackPosition
+	err = stores.Export.EachPlaybackPosition(ctx, auth.UserID, func(position rf.ExportPlaybackPosition) error {
+		positions = append(positions, position)
+		return nil
+	})
+	is.NoErr(err)                                                              // should stream the positions
+	is.Equal(len(positions), 1)                                                // should stream the position
+	is.Equal(positions[0].EnclosureURL, "http://export-first.com/episode.mp3") // should stream the enclosure url
+	is.Equal(positions[0].PositionSeconds, 42)                                 // should stream the position
+
+	sessions := 0
+	err = stores.Export.EachSession(ctx, auth.UserID, func(session rf.ExportSession) error {
+		sessions++
+		return nil
+	})
+	is.NoErr(err)         // should stream the sessions
+	is.Equal(sessions, 2) // should stream revoked sessions too
+
+	var keys []rf.ExportAPIKey
+	err = stores.Export.EachAPIKey(ctx, auth.UserID, func(key rf.ExportAPIKey) error {
+		keys = append(keys, key)
+		return nil
+	})
+	is.NoErr(err)                               // should stream the api keys
+	is.Equal(len(keys), 1)                      // should stream the api key
+	is.Equal(keys[0].Name, "Reader")            // should stream the name
+	is.Equal(keys[0].Prefix, "rf_export")       // should stream the prefix
+	is.Equal(keys[0].Scope, rf.APIKeyScopeRead) // should stream the scope
+	is.True(!keys[0].CreatedAt.IsZero())        // should stream when it was created
+	is.True(keys[0].LastUsedAt.IsZero())        // should stream an unused key as never used
+
+	var identities []rf.ExportIdentity
+	err = stores.Export.EachIdentity(ctx, auth.UserID, func(identity rf.ExportIdentity) error {
+		identities = append(identities, identity)
+		return nil
+	})
+	is.NoErr(err)                                                  // should stream the identities
+	is.Equal(len(identities), 1)                                   // should stream the linked identity
+	is.Equal(identities[0].Issuer, "https://accounts.example.com") // should stream the issuer
+	is.Equal(identities[0].Subject, "export-subject")              // should stream the subject
+
+	export := &rf.DataExport{UserID: auth.UserID, ExpiresAt: time.Now().UTC().Add(time.Hour).Truncate(time.Second)}
+	err = stores.Export.CreateExport(ctx, export)
+	is.NoErr(err)                                     // should queue the export
+	is.Equal(export.State, rf.DataExportStatePending) // should be pending
+
+	claimed, err := stores.Export.ClaimPendingExport(ctx)
+	is.NoErr(err)                                      // should claim the export
+	is.Equal(claimed.ID, export.ID)                    // should claim the queued export
+	is.Equal(claimed.State, rf.DataExportStateRunning) // should run the export
+
+	claimed, err = stores.Export.ClaimPendingExport(ctx)
+	is.NoErr(err)           // should not error when nothing is queued
+	is.True(claimed == nil) // should claim an export once
+
+	err = stores.Export.FinishExport(ctx, export.ID, rf.DataExportStateReady, 10)
+	is.NoErr(err) // should finish the export
+
+	found, err := stores.Export.FindExport(ctx, export.ID)
+	is.NoErr(err)                                  // should find the export
+	is.Equal(found.State, rf.DataExportStateReady) // should be ready
+	is.Equal(found.Size, int64(10))                // should have the size
+}
+
+func testAuditAppendOnly(t *testing.T, stores Stores) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	auth := createAuth(t, stores, "audit@go.com")
+
+	record := func(eventType audit.EventType, detail string) {
+		t.Helper()
+
+		err := stores.Audit.Record(ctx, audit.Event{
+			Type:       eventType,
+			ActorID:    auth.UserID,
+			TargetType: audit.TargetUser,
+			TargetID:   auth.UserID,
+			Detail:     detail,
+		})
+		is.NoErr(err) // should record the event
+	}
+
+	list := func() []audit.Event {
+		t.Helper()
+
+		events, err := stores.Audit.ListAuditEvents(ctx, &audit.Query{UserID: auth.UserID, Limit: 10})
+		is.NoErr(err) // should list the events
+		return events
+	}
+
+	record(audit.EventSignUp, "first")
+	record(audit.EventPasswordChanged, "second")
+
+	events := list()
+	is.Equal(len(events), 2)             // should list the events of the user
+	is.Equal(events[0].Detail, "second") // should list the newest event first
+	is.True(events[0].ID > events[1].ID) // should give later events higher ids
+
+	record(audit.EventSessionRevoked, "third")
+
+	again := list()
+	is.Equal(len(again), 3)     // should append the event
+	is.Equal(again[1:], events) // should leave earlier events as they were
+
+	page, err := stores.Audit.ListAuditEvents(ctx, &audit.Query{UserID: auth.UserID, BeforeID: again[0].ID, Limit: 10})
+	is.NoErr(err)          // should list the next page
+	is.Equal(page, events) // should page past the newest event
+
+	err = stores.Auth.DeleteAccount(ctx, auth.UserID, time.Now().Add(-time.Minute))
+	is.NoErr(err) // should delete the account
+	_, err = stores.Auth.PurgeDeletedAccounts(ctx)
+	is.NoErr(err) // should purge the account
+
+	is.Equal(list(), again) // should keep the events of a purged account
+}
+
+func newAuth(email string) *rf.Auth {
+	return builder.NewAuthBuilder().
+		WithUser(builder.NewUserBuilder().WithName("Gopher")).
+		WithBasicAuth(builder.NewBasicAuthBuilder().WithEmail(email).WithPassword("password-hash")).
+		Build()
+}
+
+func createAuth(t *testing.T, stores Stores, email string) *rf.Auth {
+	t.Helper()
+
+	auth := newAuth(email)
+	if err := stores.Auth.CreateAuthAndUser(context.Background(), auth); err != nil {
+		t.Fatal(err)
+	}
+
+	return auth
+}
+
+func createFeed(t *testing.T, stores Stores, url string) *rf.Feed {
+	t.Helper()
+
+	ctx := context.Background()
+
+	feed := builder.NewFeedBuilder().WithURL(url).Build()
+	if err := inTx(ctx, stores, func(tx rf.Tx) error { return stores.Feed.CreateFeed(ctx, tx, feed) }); err != nil {
+		t.Fatal(err)
+	}
+
+	return feed
+}
+
+// inTx runs fn in a feed store transaction, committing it when fn succeeds.
+func inTx(ctx context.Context, stores Stores, fn func(tx rf.Tx) error) error {
+	tx, err := stores.Feed.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func createSession(t *testing.T, stores Stores, auth *rf.Auth, tokenHash string) (*rf.Session, *rf.RefreshToken) {
+	t.Helper()
+
+	session := &rf.Session{
+		UserID:    auth.UserID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	token := &rf.RefreshToken{TokenHash: tokenHash}
+	if err := stores.Auth.CreateSession(context.Background(), session, token); err != nil {
+		t.Fatal(err)
+	}
+
+	return session, token
+}
+
+// subscribe subscribes the user of auth to feed.
+func subscribe(t *testing.T, stores Stores, auth *rf.Auth, feed *rf.Feed) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	userFeed := builder.NewFeedBuilder().
+		WithID(feed.ID).
+		WithUserID(auth.UserID).
+		WithName("Gopher").
+		Build()
+	if err := inTx(ctx, stores, func(tx rf.Tx) error { return stores.Feed.CreateUserFeed(ctx, tx, userFeed) }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createChannel(t *testing.T, stores Stores, feed *rf.Feed) *rf.Channel {
+	t.Helper()
+
+	channel := &rf.Channel{FeedID: feed.ID, Title: "Gopher", Link: feed.URL}
+	if err := stores.Item.UpsertChannel(context.Background(), channel); err != nil {
+		t.Fatal(err)
+	}
+
+	return channel
+}
+
+// setNow fixes the clock of the stores at now until the test ends.
+func setNow(t *testing.T, stores Stores, now time.Time) {
+	t.Helper()
+
+	if stores.Now == nil {
+		t.Skip("the stores have no clock to move")
+	}
+
+	restore := *stores.Now
+	*stores.Now = func() time.Time { return now }
+	t.Cleanup(func() { *stores.Now = restore })
+}
@@ -0,0 +1,78 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// rfc6238Secret is the SHA1 key of the RFC 6238 test vectors.
+var rfc6238Secret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	// The RFC lists 8 digit codes, the last 6 digits are the 6 digit code.
+	for unix, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := Code(rfc6238Secret, Step(time.Unix(unix, 0)))
+
+		is.NoErr(err)        // should generate a code
+		is.Equal(code, want) // should match the RFC test vector
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	secret, err := GenerateSecret()
+	is.NoErr(err) // should generate a secret
+
+	now := time.Unix(1726131600, 0)
+	code, err := Code(secret, Step(now))
+	is.NoErr(err) // should generate a code
+
+	step, ok, err := Validate(secret, code, now)
+
+	is.NoErr(err)             // should validate
+	is.True(ok)               // should accept the current code
+	is.Equal(step, Step(now)) // should return the matched step
+
+	_, ok, err = Validate(secret, code, now.Add(Period))
+
+	is.NoErr(err) // should validate
+	is.True(ok)   // should allow a period of drift
+
+	_, ok, err = Validate(secret, code, now.Add(3*Period))
+
+	is.NoErr(err) // should validate
+	is.True(!ok)  // should reject old codes
+
+	_, ok, err = Validate(secret, "12345", now)
+
+	is.NoErr(err) // should validate
+	is.True(!ok)  // should reject codes of the wrong length
+}
+
+func TestURI(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	uri := URI("RSS Feed Aggregator", "gopher@go.com", "JBSWY3DPEHPK3PXP")
+
+	is.True(strings.HasPrefix(uri, "otpauth://totp/RSS%20Feed%20Aggregator:gopher@go.com?")) // should label with issuer and account
+	is.True(strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP"))                                // should carry the secret
+	is.True(strings.Contains(uri, "issuer=RSS+Feed+Aggregator"))                             // should carry the issuer
+}
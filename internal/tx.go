@@ -0,0 +1,12 @@
+package rf
+
+import "context"
+
+// Tx is a unit of work begun by a FeedStore, a feed and the subscription to
+// it are created in one so neither is kept without the other. Only the
+// feed store takes part in it. Nothing done through it is kept until
+// Commit, Rollback after Commit does nothing so it can always be deferred.
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
@@ -4,9 +4,31 @@ import (
 	"time"
 )
 
+// Role decides which endpoints a user may call beyond their own data.
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
 type User struct {
-	ID         int64     `json:"id"`
-	Name       string    `json:"name"`
-	CreatedAt  time.Time `json:"createdAt"`
-	ModifiedAt time.Time `json:"modifiedAt"`
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+	// Timezone is an IANA time zone name such as Europe/London.
+	Timezone string `json:"timezone"`
+	// Preferences are settings the clients keep for the user, the server
+	// does not interpret them.
+	Preferences map[string]any `json:"preferences"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	ModifiedAt  time.Time      `json:"modifiedAt"`
+}
+
+// UpdateProfileRequest changes the fields that are set. Preferences are
+// merged into the stored ones, a null value removes a preference.
+type UpdateProfileRequest struct {
+	Name        *string        `json:"name"`
+	Timezone    *string        `json:"timezone"`
+	Preferences map[string]any `json:"preferences"`
 }
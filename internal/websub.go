@@ -0,0 +1,35 @@
+package rf
+
+import (
+	"time"
+)
+
+type WebSubState string
+
+const (
+	WebSubStatePending      WebSubState = "pending"
+	WebSubStateActive       WebSubState = "active"
+	WebSubStateDenied       WebSubState = "denied"
+	WebSubStateUnsubscribed WebSubState = "unsubscribed"
+)
+
+type WebSubSubscription struct {
+	ID           int64       `json:"id"`
+	FeedID       int64       `json:"feedID"`
+	HubURL       string      `json:"hubURL"`
+	TopicURL     string      `json:"topicURL"`
+	Secret       string      `json:"-"`
+	State        WebSubState `json:"state"`
+	LeaseSeconds int         `json:"leaseSeconds"`
+	ExpiresAt    time.Time   `json:"expiresAt"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	ModifiedAt   time.Time   `json:"modifiedAt"`
+}
+
+type WebSubVerification struct {
+	FeedID       int64
+	Mode         string
+	Topic        string
+	Challenge    string
+	LeaseSeconds int
+}
@@ -0,0 +1,139 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/errors"
+)
+
+const (
+	ModeSubscribe   = "subscribe"
+	ModeUnsubscribe = "unsubscribe"
+	ModeDenied      = "denied"
+)
+
+// DefaultLeaseSeconds is the lease requested from hubs, which are free to
+// grant a different one during verification.
+const DefaultLeaseSeconds = 10 * 24 * 60 * 60
+
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client sending requests to hubs with httpClient,
+// which should be the guarded client of fetch so hubs named by remote
+// feeds cannot reach private networks.
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{
+		HTTPClient: httpClient,
+	}
+}
+
+type Request struct {
+	HubURL       string
+	TopicURL     string
+	CallbackURL  string
+	Secret       string
+	LeaseSeconds int
+}
+
+func (c *Client) Subscribe(ctx context.Context, req Request) error {
+	return c.send(ctx, ModeSubscribe, req)
+}
+
+func (c *Client) Unsubscribe(ctx context.Context, req Request) error {
+	return c.send(ctx, ModeUnsubscribe, req)
+}
+
+func (c *Client) send(ctx context.Context, mode string, req Request) error {
+	form := url.Values{
+		"hub.mode":     {mode},
+		"hub.topic":    {req.TopicURL},
+		"hub.callback": {req.CallbackURL},
+	}
+	if req.Secret != "" {
+		form.Set("hub.secret", req.Secret)
+	}
+	if req.LeaseSeconds > 0 {
+		form.Set("hub.lease_seconds", strconv.Itoa(req.LeaseSeconds))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.HubURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusAccepted && res.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return errors.InternalErrorf("%s: %d %s", errors.ErrHubRejected, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// GenerateSecret returns a random hex encoded secret for hub.secret.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// VerifySignature checks an X-Hub-Signature header of the form
+// method=signature against the HMAC of body keyed with secret.
+func VerifySignature(secret, header string, body []byte) bool {
+	method, signature, found := strings.Cut(header, "=")
+	if !found {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// Sign returns an X-Hub-Signature header value for body, as a hub would.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
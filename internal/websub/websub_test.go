@@ -0,0 +1,54 @@
+package websub_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/fetch"
+	"github.com/dwaynedwards/rss-feed-aggregator-in-go/internal/websub"
+	"github.com/matryer/is"
+)
+
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	secret, err := websub.GenerateSecret()
+	is.NoErr(err)
+	is.Equal(len(secret), 64) // should be a 32 byte hex secret
+
+	body := []byte("<rss></rss>")
+	signature := websub.Sign(secret, body)
+
+	is.True(websub.VerifySignature(secret, signature, body))                           // should verify its own signature
+	is.True(!websub.VerifySignature("other", signature, body))                         // should not verify with another secret
+	is.True(!websub.VerifySignature(secret, signature, []byte("<rss>tampered</rss>"))) // should not verify a tampered body
+	is.True(!websub.VerifySignature(secret, "md5=00", body))                           // should not verify unsupported methods
+}
+
+func TestClient_Subscribe_RefusesPrivateHubs(t *testing.T) {
+	t.Parallel()
+
+	is := is.New(t)
+
+	var called bool
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(hub.Close)
+
+	client := websub.NewClient(fetch.NewClient(fetch.Options{}).HTTPClient())
+
+	err := client.Subscribe(context.Background(), websub.Request{
+		HubURL:      hub.URL,
+		TopicURL:    "https://feed.com/rss",
+		CallbackURL: "https://reader.com/api/v1/websub/1",
+	})
+
+	is.True(err != nil) // should refuse a hub on a loopback address
+	is.True(!called)    // should not reach the hub
+}